@@ -0,0 +1,175 @@
+package viper
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// HostPort is a network address of the form host:port, as returned by
+// GetHostPort. Port ranges such as "8000-8010" set EndPort to the last port
+// of the range.
+type HostPort struct {
+	Host    string
+	Port    int
+	EndPort int
+}
+
+// IsRange tells whether the address has a range of ports.
+func (hp HostPort) IsRange() bool {
+	return hp.EndPort > hp.Port
+}
+
+// String returns the address as host:port, with IPv6 hosts in brackets, as
+// accepted by net.Dial unless it has a range of ports.
+func (hp HostPort) String() string {
+	port := strconv.Itoa(hp.Port)
+	if hp.IsRange() {
+		port += "-" + strconv.Itoa(hp.EndPort)
+	}
+	return net.JoinHostPort(hp.Host, port)
+}
+
+// AddressError denotes a value which isn't a valid network address.
+type AddressError struct {
+	Key   string
+	Value interface{}
+	err   error
+}
+
+// Error returns the formatted address error.
+func (e AddressError) Error() string {
+	return fmt.Sprintf("invalid address %v for key %q: %s", e.Value, e.Key, e.err.Error())
+}
+
+// GetHostPort returns the value associated with the key as a network address
+// with a host and a port, given as "host:port", "[::1]:port" for IPv6, a
+// port range "host:8000-8010", or a map with host and port keys. Host names
+// are lower-cased and IP addresses normalized, e.g. "[0:0::1]:80" becomes
+// "[::1]:80". GetHostPort returns a KeyNotSetError if the key has no value
+// and an AddressError if it isn't a valid address.
+func GetHostPort(key string) (HostPort, error) { return v.GetHostPort(key) }
+func (v *Viper) GetHostPort(key string) (HostPort, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return HostPort{}, err
+	}
+	hp, err := parseHostPort(val)
+	if err == nil && hp.Host == "" {
+		err = fmt.Errorf("missing host")
+	}
+	if err == nil && hp.Port == 0 {
+		err = fmt.Errorf("missing port")
+	}
+	if err != nil {
+		return HostPort{}, AddressError{Key: key, Value: val, err: err}
+	}
+	return hp, nil
+}
+
+// GetListenAddr returns the value associated with the key as an address to
+// listen on, as accepted by net.Listen: a port alone, e.g. 8080 or ":8080",
+// listens on all interfaces. The host and port are normalized like by
+// GetHostPort; port ranges aren't accepted. GetListenAddr returns a
+// KeyNotSetError if the key has no value and an AddressError if it isn't a
+// valid address.
+func GetListenAddr(key string) (string, error) { return v.GetListenAddr(key) }
+func (v *Viper) GetListenAddr(key string) (string, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return "", err
+	}
+	addr := val
+	if s, err := cast.ToStringE(val); err == nil {
+		if _, err := strconv.Atoi(s); err == nil {
+			addr = ":" + s
+		}
+	}
+	hp, err := parseHostPort(addr)
+	if err == nil && hp.IsRange() {
+		err = fmt.Errorf("port range can't be listened on")
+	}
+	if err != nil {
+		return "", AddressError{Key: key, Value: val, err: err}
+	}
+	return net.JoinHostPort(hp.Host, strconv.Itoa(hp.Port)), nil
+}
+
+// parseHostPort parses an address given as a string or a map with host and
+// port keys. The host may be empty.
+func parseHostPort(val interface{}) (HostPort, error) {
+	var host, port string
+	switch val := val.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := cast.ToStringMap(val)
+		host, port = cast.ToString(m["host"]), cast.ToString(m["port"])
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	default:
+		s, err := cast.ToStringE(val)
+		if err != nil {
+			return HostPort{}, err
+		}
+		s = strings.TrimSpace(s)
+		if ip := net.ParseIP(s); ip != nil && strings.Contains(s, ":") {
+			return HostPort{}, fmt.Errorf("missing port, IPv6 addresses with a port must be in brackets")
+		}
+		if host, port, err = net.SplitHostPort(s); err != nil {
+			return HostPort{}, err
+		}
+	}
+
+	hp := HostPort{}
+	var err error
+	if hp.Host, err = normalizeHost(host); err != nil {
+		return HostPort{}, err
+	}
+	if port == "" {
+		return hp, nil
+	}
+	first, last := port, port
+	if i := strings.Index(port, "-"); i > 0 {
+		first, last = port[:i], port[i+1:]
+	}
+	if hp.Port, err = parsePort(first); err != nil {
+		return HostPort{}, err
+	}
+	if hp.EndPort, err = parsePort(last); err != nil {
+		return HostPort{}, err
+	}
+	if hp.EndPort < hp.Port {
+		return HostPort{}, fmt.Errorf("invalid port range %q", port)
+	}
+	return hp, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// normalizeHost returns host lower-cased, or normalized if it's an IP
+// address, possibly with an IPv6 zone.
+func normalizeHost(host string) (string, error) {
+	if host == "" {
+		return "", nil
+	}
+	addr, zone := host, ""
+	if i := strings.LastIndex(host, "%"); i > 0 {
+		addr, zone = host[:i], host[i:]
+	}
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String() + zone, nil
+	}
+	for _, r := range host {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == '_') {
+			return "", fmt.Errorf("invalid host %q", host)
+		}
+	}
+	return strings.ToLower(host), nil
+}
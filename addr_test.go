@@ -0,0 +1,58 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetHostPort(t *testing.T) {
+	v := New()
+	for value, want := range map[interface{}]HostPort{
+		"DB.Example.com:5432": {Host: "db.example.com", Port: 5432, EndPort: 5432},
+		"[0:0::1]:80":         {Host: "::1", Port: 80, EndPort: 80},
+		"[fe80::1%eth0]:80":   {Host: "fe80::1%eth0", Port: 80, EndPort: 80},
+		"10.0.0.1:8000-8010":  {Host: "10.0.0.1", Port: 8000, EndPort: 8010},
+	} {
+		v.Set("addr", value)
+		hp, err := v.GetHostPort("addr")
+		require.NoError(t, err, value)
+		assert.Equal(t, want, hp, value)
+	}
+
+	v.Set("upstream", map[string]interface{}{"host": "::1", "port": 8080})
+	hp, err := v.GetHostPort("upstream")
+	require.NoError(t, err)
+	assert.Equal(t, "[::1]:8080", hp.String())
+	assert.False(t, hp.IsRange())
+
+	for _, value := range []string{"localhost", "::1", ":8080", "host:70000", "host:9-1", "ho st:80"} {
+		v.Set("addr", value)
+		_, err := v.GetHostPort("addr")
+		assert.IsType(t, AddressError{}, err, value)
+	}
+	_, err = v.GetHostPort("missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+}
+
+func TestGetListenAddr(t *testing.T) {
+	v := New()
+	for value, want := range map[interface{}]string{
+		8080:            ":8080",
+		"8080":          ":8080",
+		":8080":         ":8080",
+		"0.0.0.0:8080":  "0.0.0.0:8080",
+		"[::]:443":      "[::]:443",
+		"LocalHost:443": "localhost:443",
+	} {
+		v.Set("listen", value)
+		addr, err := v.GetListenAddr("listen")
+		require.NoError(t, err, value)
+		assert.Equal(t, want, addr, value)
+	}
+
+	v.Set("listen", ":8000-8010")
+	_, err := v.GetListenAddr("listen")
+	assert.EqualError(t, err, `invalid address :8000-8010 for key "listen": port range can't be listened on`)
+}
@@ -0,0 +1,241 @@
+// Package appconfig integrates AWS AppConfig with Viper.
+//
+// Importing the package registers the "appconfig" remote provider:
+//
+//	import _ "github.com/spf13/viper/appconfig"
+//
+//	viper.AddRemoteProvider("appconfig", "eu-west-1", "application/environment/profile")
+//	viper.SetConfigType("json")
+//	err := viper.ReadRemoteConfig()
+//
+// The endpoint is either an AWS region or the URL of an AppConfig Data API
+// endpoint. Credentials are read from the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+package appconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper/internal/sigv4"
+)
+
+// Credentials are the AWS credentials used to sign requests.
+type Credentials = sigv4.Credentials
+
+// Client is a minimal client for the AWS AppConfig Data API.
+type Client struct {
+	// Region of the AppConfig application, e.g. "eu-west-1".
+	Region string
+	// Credentials used to sign requests.
+	Credentials Credentials
+	// Endpoint of the API; defaults to the regional AWS endpoint.
+	Endpoint string
+	// HTTPClient performs the requests; a client timing out after 30
+	// seconds is used when nil.
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+// defaultHTTPClient performs the requests of clients without an HTTPClient.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// NewClient returns a client for the given region, using the credentials
+// found in the environment. An empty region falls back to the AWS_REGION and
+// AWS_DEFAULT_REGION environment variables.
+func NewClient(region string) *Client {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &Client{
+		Region: region,
+		Credentials: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		now: time.Now,
+	}
+}
+
+// Error denotes an error response from the AppConfig Data API.
+type Error struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+// Error returns the formatted AppConfig error.
+func (e Error) Error() string {
+	return fmt.Sprintf("AppConfig Error %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// StartSession starts a configuration session for the given application,
+// environment and configuration profile (names or IDs). minPollInterval is
+// the minimum interval the session may be polled at; zero keeps the service
+// default.
+func (c *Client) StartSession(application, environment, profile string, minPollInterval time.Duration) (*Session, error) {
+	req := map[string]interface{}{
+		"ApplicationIdentifier":          application,
+		"EnvironmentIdentifier":          environment,
+		"ConfigurationProfileIdentifier": profile,
+	}
+	if minPollInterval > 0 {
+		req["RequiredMinimumPollIntervalInSeconds"] = int(minPollInterval / time.Second)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(http.MethodPost, "/configurationsessions", nil, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		InitialConfigurationToken string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &Session{client: c, token: out.InitialConfigurationToken, expires: c.clock().Add(sessionLifetime)}, nil
+}
+
+// sessionLifetime is the time after which a session is restarted rather than
+// polled: the configuration tokens of a session expire after 24 hours.
+const sessionLifetime = 23 * time.Hour
+
+func (c *Client) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Client) endpoint() string {
+	if c.Endpoint != "" {
+		return strings.TrimSuffix(c.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://appconfigdata.%s.amazonaws.com", c.Region)
+}
+
+func (c *Client) do(method, path string, query url.Values, body []byte) (*http.Response, error) {
+	u := c.endpoint() + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	sigv4.Sign(req, body, c.Credentials, c.Region, "appconfig", c.clock())
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		aerr := Error{StatusCode: resp.StatusCode, Type: resp.Header.Get("X-Amzn-Errortype")}
+		var errBody struct {
+			Message string
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
+			aerr.Message = errBody.Message
+		}
+		return nil, aerr
+	}
+	return resp, nil
+}
+
+// Session is an AppConfig configuration session. It keeps track of the poll
+// token and of the latest configuration received, as the service only sends
+// the configuration again when it changed.
+type Session struct {
+	client *Client
+
+	mu           sync.Mutex
+	token        string
+	pollInterval time.Duration
+	contentType  string
+	data         []byte
+	expires      time.Time
+}
+
+// Latest returns the latest configuration, and whether it changed since the
+// previous call.
+func (s *Session) Latest() ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp, err := s.client.do(http.MethodGet, "/configuration",
+		url.Values{"configuration_token": {s.token}}, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if token := resp.Header.Get("Next-Poll-Configuration-Token"); token != "" {
+		s.token = token
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Next-Poll-Interval-In-Seconds")); err == nil {
+		s.pollInterval = time.Duration(secs) * time.Second
+	}
+	if len(data) == 0 {
+		// configuration unchanged
+		return s.data, false, nil
+	}
+	s.data = data
+	s.contentType = resp.Header.Get("Content-Type")
+	return data, true, nil
+}
+
+// Expired reports whether the session is about to expire, so that a new one
+// should be started.
+func (s *Session) Expired() bool {
+	return !s.client.clock().Before(s.expires)
+}
+
+// PollInterval returns the interval the service asked to be polled at.
+func (s *Session) PollInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pollInterval <= 0 {
+		return 60 * time.Second
+	}
+	return s.pollInterval
+}
+
+// ContentType returns the content type of the latest configuration,
+// e.g. "application/json".
+func (s *Session) ContentType() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.contentType
+}
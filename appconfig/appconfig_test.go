@@ -0,0 +1,153 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAppConfig(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	polls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/configurationsessions":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			assert.Equal(t, "app", req["ApplicationIdentifier"])
+			assert.Equal(t, "prod", req["EnvironmentIdentifier"])
+			assert.Equal(t, "flags", req["ConfigurationProfileIdentifier"])
+			json.NewEncoder(w).Encode(map[string]string{"InitialConfigurationToken": "token-0"})
+		case "/configuration":
+			token := r.URL.Query().Get("configuration_token")
+			polls++
+			w.Header().Set("Next-Poll-Configuration-Token", "token-"+strconv.Itoa(polls))
+			w.Header().Set("Next-Poll-Interval-In-Seconds", "1")
+			w.Header().Set("Content-Type", "application/json")
+			if token == "token-0" {
+				w.Write([]byte(`{"checkout": {"enabled": true}, "limit": 10}`))
+			}
+			// later polls: unchanged, empty body
+		default:
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"Message": "not found"}`))
+		}
+	}))
+}
+
+func TestSessionLatest(t *testing.T) {
+	srv := newTestAppConfig(t)
+	defer srv.Close()
+
+	c := NewClient("eu-west-1")
+	c.Credentials = Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	c.Endpoint = srv.URL
+
+	s, err := c.StartSession("app", "prod", "flags", 0)
+	require.NoError(t, err)
+
+	data, changed, err := s.Latest()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "application/json", s.ContentType())
+
+	again, changed, err := s.Latest()
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, data, again)
+	assert.Equal(t, "1s", s.PollInterval().String())
+}
+
+func TestRemoteProvider(t *testing.T) {
+	srv := newTestAppConfig(t)
+	defer srv.Close()
+
+	for k, val := range map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "secret"} {
+		prev, ok := os.LookupEnv(k)
+		os.Setenv(k, val)
+		if ok {
+			defer os.Setenv(k, prev)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+
+	v := viper.New()
+	require.NoError(t, v.AddRemoteProvider("appconfig", srv.URL, "app/prod/flags"))
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.True(t, v.GetBool("checkout.enabled"))
+	assert.Equal(t, 10, v.GetInt("limit"))
+}
+
+func TestClientError(t *testing.T) {
+	srv := newTestAppConfig(t)
+	defer srv.Close()
+
+	c := NewClient("eu-west-1")
+	c.Credentials = Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	c.Endpoint = srv.URL + "/missing"
+
+	_, err := c.StartSession("app", "prod", "flags", 0)
+	assert.Equal(t, Error{StatusCode: http.StatusNotFound, Type: "ResourceNotFoundException", Message: "not found"}, err)
+}
+
+type testProvider struct{ endpoint, path string }
+
+func (p testProvider) Provider() string      { return "appconfig" }
+func (p testProvider) Endpoint() string      { return p.endpoint }
+func (p testProvider) Path() string          { return p.path }
+func (p testProvider) SecretKeyring() string { return "" }
+
+func TestWatchChannelError(t *testing.T) {
+	rc := &remoteConfigProvider{sessions: make(map[string]*Session)}
+	resp, quit := rc.WatchChannel(testProvider{endpoint: "us-east-1", path: "app/prod"})
+	defer close(quit)
+
+	r := <-resp
+	require.NotNil(t, r)
+	assert.EqualError(t, r.Error, `appconfig path "app/prod" must be application/environment/profile`)
+}
+
+func TestSessionRestart(t *testing.T) {
+	srv := newTestAppConfig(t)
+	defer srv.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	rc := &remoteConfigProvider{sessions: make(map[string]*Session)}
+	rp := testProvider{endpoint: srv.URL, path: "app/prod/flags"}
+	s, err := rc.session(rp)
+	require.NoError(t, err)
+	again, err := rc.session(rp)
+	require.NoError(t, err)
+	assert.True(t, s == again)
+
+	// expired sessions are replaced
+	s.client.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	assert.True(t, s.Expired())
+	fresh, err := rc.session(rp)
+	require.NoError(t, err)
+	assert.False(t, s == fresh)
+
+	// sessions failing to poll are dropped
+	fresh.client.Endpoint = srv.URL + "/missing"
+	_, err = rc.Get(rp)
+	assert.Error(t, err)
+	assert.Empty(t, rc.sessions)
+}
@@ -0,0 +1,137 @@
+package appconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type remoteConfigProvider struct {
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+func (rc *remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	s, err := rc.session(rp)
+	if err != nil {
+		return nil, err
+	}
+	b, _, err := s.Latest()
+	if err != nil {
+		rc.drop(rp, s)
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (rc *remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return rc.Get(rp)
+}
+
+func (rc *remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	quit := make(chan bool)
+	viperResponsCh := make(chan *viper.RemoteResponse)
+	go func() {
+		s, err := rc.session(rp)
+		if err != nil {
+			select {
+			case <-quit:
+			case viperResponsCh <- &viper.RemoteResponse{Error: err}:
+			}
+			return
+		}
+		var last []byte
+		interval := s.PollInterval()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-time.After(interval):
+			}
+			var b []byte
+			changed := false
+			s, err = rc.session(rp)
+			if err == nil {
+				interval = s.PollInterval()
+				if b, changed, err = s.Latest(); err != nil {
+					rc.drop(rp, s)
+				}
+			}
+			// a new session sends the configuration again, changed or not
+			if err == nil && (!changed || bytes.Equal(b, last)) {
+				continue
+			}
+			if err == nil {
+				last = b
+			}
+			select {
+			case <-quit:
+				return
+			case viperResponsCh <- &viper.RemoteResponse{Value: b, Error: err}:
+			}
+		}
+	}()
+	return viperResponsCh, quit
+}
+
+// session returns the configuration session of rp, starting one if there is
+// none or it expired.
+func (rc *remoteConfigProvider) session(rp viper.RemoteProvider) (*Session, error) {
+	id := sessionID(rp)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if s, ok := rc.sessions[id]; ok && !s.Expired() {
+		return s, nil
+	}
+
+	parts := strings.Split(strings.Trim(rp.Path(), "/"), "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("appconfig path %q must be application/environment/profile", rp.Path())
+	}
+
+	var client *Client
+	if strings.Contains(rp.Endpoint(), "://") {
+		client = NewClient("")
+		client.Endpoint = rp.Endpoint()
+		if client.Region == "" {
+			client.Region = "us-east-1"
+		}
+	} else {
+		client = NewClient(rp.Endpoint())
+	}
+
+	s, err := client.StartSession(parts[0], parts[1], parts[2], 0)
+	if err != nil {
+		return nil, err
+	}
+	rc.sessions[id] = s
+	return s, nil
+}
+
+// drop forgets the session s of rp after a failed poll, so that the next one
+// starts a new session.
+func (rc *remoteConfigProvider) drop(rp viper.RemoteProvider, s *Session) {
+	id := sessionID(rp)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.sessions[id] == s {
+		delete(rc.sessions, id)
+	}
+}
+
+func sessionID(rp viper.RemoteProvider) string {
+	return rp.Endpoint() + "|" + rp.Path()
+}
+
+func init() {
+	viper.RegisterRemoteConfigProvider("appconfig", &remoteConfigProvider{
+		sessions: make(map[string]*Session),
+	})
+}
@@ -0,0 +1,224 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// AuditOperation is the kind of mutation an AuditRecord records.
+type AuditOperation string
+
+const (
+	// AuditSet records an override stored by Set, SetE or SetAs.
+	AuditSet AuditOperation = "set"
+	// AuditSetDefault records a default stored by SetDefault.
+	AuditSetDefault AuditOperation = "set_default"
+	// AuditRead records a config key changed by ReadInConfig, ReadConfig,
+	// ImportState or a reload of WatchConfig.
+	AuditRead AuditOperation = "read"
+	// AuditMerge records a config key changed by MergeInConfig, MergeConfig
+	// or MergeConfigMap.
+	AuditMerge AuditOperation = "merge"
+	// AuditRemote records a key/value store key changed by a read of remote
+	// configuration.
+	AuditRemote AuditOperation = "remote"
+)
+
+// AuditRedacted replaces the values of secret keys in AuditRecords, see
+// SetAuditRedactor. It is the placeholder of redacted output, SecretRedacted.
+const AuditRedacted = SecretRedacted
+
+// AuditRecord records a mutation of the configuration, see OnAudit.
+type AuditRecord struct {
+	Time      time.Time
+	Operation AuditOperation
+	// Key is the key whose value changed.
+	Key string
+	// OldValue and NewValue are the values of the key within the layer the
+	// operation changed, after redaction. OldValue is nil if the key had no
+	// value, NewValue is nil if the key was removed.
+	OldValue interface{}
+	NewValue interface{}
+	// Source is the config file or remote provider read, or the profile of
+	// a default set through SetDefaultFor, if any.
+	Source string
+	// Actor is the metadata of the actor given to SetAuditActor, merged
+	// with the one given to SetAs.
+	Actor map[string]string
+}
+
+// auditState holds the functions registered through OnAudit.
+type auditState struct {
+	mu     sync.Mutex
+	hooks  []func(AuditRecord)
+	actor  map[string]string
+	redact func(key string, value interface{}) interface{}
+	// serializes the delivery of records
+	deliver sync.Mutex
+}
+
+// OnAudit adds a function called with a record of every mutation of the
+// configuration: every Set and SetDefault call, and every key changed by a
+// read, merge or reload of config files and remote configuration. Functions
+// are called in order of registration, one record at a time, once the
+// mutation is in place. They can't be removed: the audit log is append-only.
+// The functions may read the configuration, but must not modify it.
+func OnAudit(hook func(AuditRecord)) { v.OnAudit(hook) }
+func (v *Viper) OnAudit(hook func(AuditRecord)) {
+	v.audit.mu.Lock()
+	v.audit.hooks = append(v.audit.hooks, hook)
+	v.audit.mu.Unlock()
+}
+
+// SetAuditActor sets the actor metadata recorded with every AuditRecord,
+// e.g. the service and host names, or the user of an admin session.
+func SetAuditActor(actor map[string]string) { v.SetAuditActor(actor) }
+func (v *Viper) SetAuditActor(actor map[string]string) {
+	v.audit.mu.Lock()
+	v.audit.actor = actor
+	v.audit.mu.Unlock()
+}
+
+// SetAuditRedactor sets the function returning the value recorded in an
+// AuditRecord for the value of key. By default, the values of keys whose
+// last path element contains "password", "passwd", "secret", "token",
+// "credential", "private" or "apikey", in any case, e.g. "database.password"
+// or "API_Key", are replaced by AuditRedacted. The values of secret keys
+// (see MarkSecret) are replaced by AuditRedacted before redact is called,
+// whichever redactor is set. Nested maps and slices are redacted per key,
+// like by AllSettingsRedacted.
+func SetAuditRedactor(redact func(key string, value interface{}) interface{}) {
+	v.SetAuditRedactor(redact)
+}
+func (v *Viper) SetAuditRedactor(redact func(key string, value interface{}) interface{}) {
+	v.audit.mu.Lock()
+	v.audit.redact = redact
+	v.audit.mu.Unlock()
+}
+
+// SetAs behaves like SetE, and records actor in the AuditRecord of the
+// override, merged with the actor given to SetAuditActor.
+func SetAs(actor map[string]string, key string, value interface{}) error {
+	return v.SetAs(actor, key, value)
+}
+func (v *Viper) SetAs(actor map[string]string, key string, value interface{}) error {
+	return v.setE(key, value, actor)
+}
+
+var auditSecretWords = []string{"password", "passwd", "secret", "token", "credential", "private", "apikey", "api_key"}
+
+// auditSecretKey tells whether the default redactor hides the value of key.
+func (v *Viper) auditSecretKey(key string) bool {
+	name := strings.ToLower(key)
+	if i := strings.LastIndex(name, v.keyDelim); i >= 0 {
+		name = name[i+len(v.keyDelim):]
+	}
+	for _, word := range auditSecretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditing tells whether mutations are recorded. It must not be called with
+// the lock held, as the hooks may read the configuration.
+func (v *Viper) auditing() bool {
+	v.audit.mu.Lock()
+	defer v.audit.mu.Unlock()
+	return len(v.audit.hooks) > 0
+}
+
+// auditValue returns the value of key redacted by redact, or by the default
+// redactor if nil, once the secret keys are.
+func (v *Viper) auditValue(redact func(string, interface{}) interface{}, key string, value interface{}) interface{} {
+	if redact != nil {
+		return v.redactValue(key, value, v.IsSecret, redact)
+	}
+	return v.redactValue(key, value, func(key string) bool {
+		return v.IsSecret(key) || v.auditSecretKey(key)
+	}, nil)
+}
+
+// recordAudit redacts records and hands them to the OnAudit functions.
+// actor is merged over the actor given to SetAuditActor.
+func (v *Viper) recordAudit(actor map[string]string, records ...AuditRecord) {
+	if len(records) == 0 {
+		return
+	}
+	v.audit.mu.Lock()
+	hooks, redact := v.audit.hooks, v.audit.redact
+	merged := make(map[string]string, len(v.audit.actor)+len(actor))
+	for k, val := range v.audit.actor {
+		merged[k] = val
+	}
+	v.audit.mu.Unlock()
+	for k, val := range actor {
+		merged[k] = val
+	}
+
+	now := time.Now()
+	v.audit.deliver.Lock()
+	defer v.audit.deliver.Unlock()
+	for _, r := range records {
+		r.Time, r.Actor = now, merged
+		r.OldValue = v.auditValue(redact, r.Key, r.OldValue)
+		r.NewValue = v.auditValue(redact, r.Key, r.NewValue)
+		for _, hook := range hooks {
+			hook(r)
+		}
+	}
+}
+
+// auditLayer records the keys whose value differs between the old and new
+// maps of a configuration layer, in key order. Nested maps are compared
+// per key.
+func (v *Viper) auditLayer(op AuditOperation, source string, old, new map[string]interface{}) {
+	before, after := map[string]interface{}{}, map[string]interface{}{}
+	v.flattenAuditMap(before, old, "")
+	v.flattenAuditMap(after, new, "")
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	var records []AuditRecord
+	for _, key := range sortedKeys(keys) {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			records = append(records, AuditRecord{
+				Operation: op,
+				Key:       key,
+				OldValue:  before[key],
+				NewValue:  after[key],
+				Source:    source,
+			})
+		}
+	}
+	v.recordAudit(nil, records...)
+}
+
+func (v *Viper) flattenAuditMap(out, m map[string]interface{}, prefix string) {
+	for k, val := range m {
+		switch val.(type) {
+		case map[string]interface{}, map[interface{}]interface{}:
+			v.flattenAuditMap(out, cast.ToStringMap(val), prefix+k+v.keyDelim)
+		default:
+			out[prefix+k] = val
+		}
+	}
+}
+
+// remoteAuditSource describes provider as the Source of AuditRecords.
+func remoteAuditSource(provider RemoteProvider) string {
+	if provider == nil {
+		return ""
+	}
+	return provider.Provider() + " " + provider.Endpoint() + " " + provider.Path()
+}
@@ -0,0 +1,102 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("db:\n  host: localhost\n  password: hunter2\n"), 0644))
+
+	var records []AuditRecord
+	v := New()
+	v.SetFs(fs)
+	v.SetAuditActor(map[string]string{"service": "api"})
+	v.OnAudit(func(r AuditRecord) {
+		assert.False(t, r.Time.IsZero())
+		r.Time = time.Time{}
+		records = append(records, r)
+	})
+
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.SetDefault("Workers", 4)
+	require.NoError(t, v.SetAs(map[string]string{"user": "alice"}, "workers", 8))
+	v.Set("db.password", "s3cret")
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"db": map[string]interface{}{"host": "db.internal"}}))
+
+	api := map[string]string{"service": "api"}
+	assert.Equal(t, []AuditRecord{
+		{Operation: AuditRead, Key: "db.host", NewValue: "localhost", Source: "/etc/app/config.yaml", Actor: api},
+		{Operation: AuditRead, Key: "db.password", NewValue: AuditRedacted, Source: "/etc/app/config.yaml", Actor: api},
+		{Operation: AuditSetDefault, Key: "workers", NewValue: 4, Actor: api},
+		{Operation: AuditSet, Key: "workers", NewValue: 8, Actor: map[string]string{"service": "api", "user": "alice"}},
+		{Operation: AuditSet, Key: "db.password", NewValue: AuditRedacted, Actor: api},
+		{Operation: AuditMerge, Key: "db.host", OldValue: "localhost", NewValue: "db.internal", Actor: api},
+	}, records)
+
+	// unchanged keys aren't recorded on a re-read
+	records = nil
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{"db": {"host": "db.internal", "password": "hunter2"}}`)))
+	assert.Empty(t, records)
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{"db": {"host": "db.internal"}}`)))
+	assert.Equal(t, []AuditRecord{
+		{Operation: AuditRead, Key: "db.password", OldValue: AuditRedacted, Actor: api},
+	}, records)
+}
+
+func TestAuditRedactor(t *testing.T) {
+	var records []AuditRecord
+	v := New()
+	v.OnAudit(func(r AuditRecord) { records = append(records, r) })
+	v.SetAuditRedactor(func(key string, value interface{}) interface{} {
+		if key == "db.dsn" {
+			return "***"
+		}
+		return value
+	})
+	v.Set("db", map[string]interface{}{"dsn": "postgres://user:pw@db", "password": "pw"})
+
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string]interface{}{"dsn": "***", "password": "pw"}, records[0].NewValue)
+
+	// secret keys are redacted whichever the redactor
+	v.MarkSecret("db.password")
+	v.Set("db.password", "s3cr3t")
+	require.Len(t, records, 2)
+	assert.Equal(t, AuditRedacted, records[1].NewValue)
+}
+
+func TestAuditRedactCaseSensitive(t *testing.T) {
+	var records []AuditRecord
+	v := NewWithOptions(KeysCaseSensitive())
+	v.OnAudit(func(r AuditRecord) { records = append(records, r) })
+	v.Set("DB_Password", "hunter2")
+
+	require.Len(t, records, 1)
+	assert.Equal(t, AuditRedacted, records[0].NewValue)
+}
+
+func TestAuditRemote(t *testing.T) {
+	registerTestProvider(t, "test-audit", &testRemoteConfigFactory{payload: remoteExample})
+
+	var records []AuditRecord
+	v := New()
+	v.OnAudit(func(r AuditRecord) { records = append(records, r) })
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("test-audit", "localhost", "/config"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	require.NotEmpty(t, records)
+	for _, r := range records {
+		assert.Equal(t, AuditRemote, r.Operation)
+		assert.Equal(t, "test-audit localhost /config", r.Source)
+	}
+}
@@ -0,0 +1,74 @@
+package viper
+
+import (
+	"fmt"
+)
+
+// Action is a mutation of the configuration checked by the Authorizer, see
+// SetAuthorizer.
+type Action string
+
+const (
+	// ActionSet stores an override through Set, SetE, SetAs or GetOrSet.
+	ActionSet Action = "set"
+	// ActionSetDefault stores a default through SetDefault,
+	// SetDefaultWithDoc or SetDefaultFor, or a default tag read by
+	// BindStruct.
+	ActionSetDefault Action = "set_default"
+	// ActionWriteConfig writes the configuration to a file through
+	// WriteConfig, SafeWriteConfig, WriteConfigAs or SafeWriteConfigAs.
+	ActionWriteConfig Action = "write_config"
+)
+
+// An Authorizer decides whether action may be performed on key, returning an
+// error describing why it is denied. key is the path of the file written for
+// ActionWriteConfig.
+type Authorizer func(action Action, key string) error
+
+// AuthorizationError denotes a mutation denied by the Authorizer set through
+// SetAuthorizer.
+type AuthorizationError struct {
+	Action Action
+	Key    string
+	err    error
+}
+
+// Error returns the formatted authorization error.
+func (e AuthorizationError) Error() string {
+	return fmt.Sprintf("%s %q not authorized: %s", e.Action, e.Key, e.err.Error())
+}
+
+// SetAuthorizer sets the function consulted before the runtime mutations of
+// the configuration listed by the Action constants, e.g. to restrict the
+// prefixes of the keys components or admin users may change. Denied
+// mutations are not performed: SetE, SetAs and the WriteConfig functions
+// return an AuthorizationError, Set and the SetDefault functions log it.
+// Loading configuration layers (ReadInConfig, ReadConfig, ReadSources, the
+// Merge functions, FromMapProvider, ImportState and remote reads) and
+// registering bindings (BindEnv, BindPFlag, RegisterAlias) aren't checked.
+// Admin handlers of the application can consult the same function through
+// Authorize.
+//
+//	viper.SetAuthorizer(func(action viper.Action, key string) error {
+//		if action == viper.ActionSet && !strings.HasPrefix(key, "features.") {
+//			return errors.New("only feature flags can be changed at runtime")
+//		}
+//		return nil
+//	})
+func SetAuthorizer(authorize Authorizer) { v.SetAuthorizer(authorize) }
+func (v *Viper) SetAuthorizer(authorize Authorizer) {
+	v.authorizer = authorize
+}
+
+// Authorize returns an AuthorizationError if the Authorizer denies action on
+// key, nil otherwise or if no Authorizer is set.
+func Authorize(action Action, key string) error { return v.Authorize(action, key) }
+func (v *Viper) Authorize(action Action, key string) error {
+	if v.authorizer == nil {
+		return nil
+	}
+	if err := v.authorizer(action, key); err != nil {
+		return AuthorizationError{Action: action, Key: key, err: err}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package viper
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	v := New()
+	v.SetFs(fs)
+	v.SetAuthorizer(func(action Action, key string) error {
+		switch {
+		case action == ActionSet && strings.HasPrefix(key, "features."):
+			return nil
+		case action == ActionWriteConfig && key == "/etc/app/config.yaml":
+			return nil
+		}
+		return errors.New("read-only")
+	})
+
+	require.NoError(t, v.SetE("Features.Beta", true))
+	assert.True(t, v.GetBool("features.beta"))
+
+	err := v.SetE("Database.Host", "db")
+	assert.Equal(t, AuthorizationError{Action: ActionSet, Key: "database.host", err: errors.New("read-only")}, err)
+	assert.EqualError(t, err, `set "database.host" not authorized: read-only`)
+	assert.False(t, v.IsSet("database.host"))
+
+	v.SetDefault("database.port", 5432)
+	assert.False(t, v.IsSet("database.port"))
+
+	require.NoError(t, v.WriteConfigAs("/etc/app/config.yaml"))
+	assert.Error(t, v.WriteConfigAs("/tmp/config.yaml"))
+	exists, _ := afero.Exists(fs, "/tmp/config.yaml")
+	assert.False(t, exists)
+
+	assert.NoError(t, v.Authorize(ActionSet, "features.alpha"))
+	assert.Error(t, v.Authorize(ActionSetDefault, "features.alpha"))
+	assert.NoError(t, New().Authorize(ActionSet, "any"))
+}
@@ -0,0 +1,181 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// BindStruct registers the keys of the struct pointed to by ptr, named as
+// Unmarshal decodes them, from the tags of its fields:
+//
+//	type Config struct {
+//		Port    int           `mapstructure:"port" default:"8080" env:"APP_PORT"`
+//		Host    string        `mapstructure:"host" required:"true"`
+//		Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+//		DB      struct {
+//			Hosts []string `mapstructure:"hosts" default:"db1,db2"`
+//		} `mapstructure:"db"`
+//	}
+//
+// A default tag sets the default of the key, converted to the type of the
+// field (slices are given as comma separated values), an env tag binds the
+// key to the environment variable, a doc tag sets the description of the key,
+// see SetDefaultWithDoc, and a required:"true" tag records the key as
+// required, see SetRequired. Every key is registered, see RegisterKeys.
+// Nested structs define nested keys, unless squashed with
+// `mapstructure:",squash"`; fields of recursive types, e.g. Next of
+// `type Node struct{ Next *Node }`, are skipped. A subsequent Unmarshal into
+// the struct then populates it following the usual precedence rules.
+func BindStruct(ptr interface{}) error { return v.BindStruct(ptr) }
+func (v *Viper) BindStruct(ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("BindStruct requires a pointer to a struct, got %T", ptr)
+	}
+	return v.bindStruct(rv.Elem().Type(), "", map[reflect.Type]bool{})
+}
+
+// bindStruct registers the keys of the fields of t below prefix. visiting
+// holds the struct types t is nested in, whose fields of the same type are
+// skipped rather than recursed into endlessly.
+func (v *Viper) bindStruct(t reflect.Type, prefix string, visiting map[reflect.Type]bool) error {
+	visiting[t] = true
+	defer delete(visiting, t)
+	var errs []error
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name, opts := field.Name, ""
+		if tag, ok := field.Tag.Lookup("mapstructure"); ok {
+			if tag == "-" {
+				continue
+			}
+			if j := strings.Index(tag, ","); j >= 0 {
+				tag, opts = tag[:j], tag[j+1:]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		key := prefix + name
+		if strings.Contains(","+opts+",", ",squash,") {
+			key = strings.TrimSuffix(prefix, v.keyDelim)
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}) {
+			if visiting[ft] {
+				// a recursive type, e.g. a linked list
+				continue
+			}
+			nested := key + v.keyDelim
+			if key == "" {
+				nested = ""
+			}
+			if err := v.bindStruct(ft, nested, visiting); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		v.RegisterKeys(key)
+		if def, ok := field.Tag.Lookup("default"); ok {
+			value, err := parseDefaultTag(def, ft)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid default %q for key %q: %s", def, key, err))
+			} else {
+				origin := defaultOrigin{kind: DefaultStructTag, detail: t.String() + "." + field.Name}
+				if err := v.setDefault(key, value, origin); err != nil {
+					jww.ERROR.Println(err)
+				}
+			}
+		}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			v.setDoc(key, doc)
+		}
+		if env := field.Tag.Get("env"); env != "" {
+			if err := v.BindEnv(key, env); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if field.Tag.Get("required") == "true" {
+			if v.required == nil {
+				v.required = make(map[string]bool)
+			}
+			v.required[v.realKey(v.normalizeKey(key))] = true
+		}
+	}
+	return errorList(errs)
+}
+
+// parseDefaultTag converts the default tag s to a value of type t.
+func parseDefaultTag(s string, t reflect.Type) (interface{}, error) {
+	if t == durationType {
+		return cast.ToDurationE(s)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return cast.ToBoolE(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := cast.ToInt64E(s)
+		return convertKind(n, t.Kind()), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := cast.ToUint64E(s)
+		return convertKind(n, t.Kind()), err
+	case reflect.Float32, reflect.Float64:
+		n, err := cast.ToFloat64E(s)
+		return convertKind(n, t.Kind()), err
+	case reflect.Slice:
+		parts := []string{}
+		if s != "" {
+			parts = strings.Split(s, ",")
+		}
+		elems := make([]interface{}, len(parts))
+		for i, part := range parts {
+			elem, err := parseDefaultTag(strings.TrimSpace(part), t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = elem
+		}
+		if t.Elem().Kind() == reflect.String {
+			return cast.ToStringSliceE(elems)
+		}
+		return elems, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// convertKind converts the number n to the builtin type of the given kind,
+// e.g. int64 to int, rather than to a named type the getters can't cast.
+func convertKind(n interface{}, kind reflect.Kind) interface{} {
+	return reflect.ValueOf(n).Convert(kindTypes[kind]).Interface()
+}
+
+// RequiredKeys returns the keys recorded as required by SetRequired and
+// BindStruct, sorted.
+func RequiredKeys() []string { return v.RequiredKeys() }
+func (v *Viper) RequiredKeys() []string {
+	keys := make([]string, 0, len(v.required))
+	for key := range v.required {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
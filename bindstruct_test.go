@@ -0,0 +1,100 @@
+package viper
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type BindStructBase struct {
+	Name string `mapstructure:"name" default:"app"`
+}
+
+type bindStructConfig struct {
+	BindStructBase `mapstructure:",squash"`
+
+	Port    int           `mapstructure:"port" default:"8080" env:"BINDSTRUCT_PORT"`
+	Host    string        `mapstructure:"host" required:"true"`
+	Debug   bool          `default:"true"`
+	Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+	Ignored string        `mapstructure:"-" default:"x"`
+	DB      struct {
+		Hosts  []string `mapstructure:"hosts" default:"db1, db2"`
+		Weight float32  `mapstructure:"weight" default:"0.5"`
+		User   string   `mapstructure:"user" required:"true"`
+	} `mapstructure:"db"`
+	TLS *struct {
+		Cert string `mapstructure:"cert" default:"/etc/tls.crt"`
+	} `mapstructure:"tls"`
+	internal string `default:"x"`
+}
+
+func TestBindStruct(t *testing.T) {
+	os.Setenv("BINDSTRUCT_PORT", "9090")
+	defer os.Unsetenv("BINDSTRUCT_PORT")
+
+	v := New()
+	var cfg bindStructConfig
+	require.NoError(t, v.BindStruct(&cfg))
+	v.Set("host", "example.com")
+
+	assert.Equal(t, 8080, v.defaults["port"])
+	assert.Equal(t, 9090, v.GetInt("port"))
+	assert.Equal(t, "app", v.GetString("name"))
+	assert.True(t, v.GetBool("debug"))
+	assert.Equal(t, 5*time.Second, v.GetDuration("timeout"))
+	assert.Equal(t, []string{"db1", "db2"}, v.GetStringSlice("db.hosts"))
+	assert.Equal(t, "/etc/tls.crt", v.GetString("tls.cert"))
+	assert.False(t, v.IsSet("ignored"))
+	assert.False(t, v.IsSet("internal"))
+	assert.Equal(t, []string{"db.user", "host"}, v.RequiredKeys())
+
+	require.NoError(t, v.Unmarshal(&cfg))
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, "example.com", cfg.Host)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, []string{"db1", "db2"}, cfg.DB.Hosts)
+	assert.Equal(t, float32(0.5), cfg.DB.Weight)
+	require.NotNil(t, cfg.TLS)
+	assert.Equal(t, "/etc/tls.crt", cfg.TLS.Cert)
+}
+
+func TestBindStructErrors(t *testing.T) {
+	v := New()
+	assert.Error(t, v.BindStruct(bindStructConfig{}))
+
+	var cfg struct {
+		Port  int          `default:"http"`
+		Ports map[int]bool `default:"80"`
+		Name  string       `default:"steve"`
+	}
+	err := v.BindStruct(&cfg)
+	require.Error(t, err)
+	assert.Len(t, err.(MultiError), 2)
+	assert.Equal(t, "steve", v.GetString("name"))
+}
+
+type bindStructNode struct {
+	Name string `default:"head"`
+	Next *bindStructNode
+}
+
+func TestBindStructRecursive(t *testing.T) {
+	type endpoint struct {
+		Host string `default:"localhost"`
+	}
+	var cfg struct {
+		List     bindStructNode
+		Primary  endpoint
+		Fallback endpoint
+	}
+	v := New()
+	require.NoError(t, v.BindStruct(&cfg))
+	assert.Equal(t, "head", v.GetString("list.name"))
+	assert.Equal(t, "localhost", v.GetString("primary.host"))
+	assert.Equal(t, "localhost", v.GetString("fallback.host"))
+}
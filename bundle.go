@@ -0,0 +1,166 @@
+package viper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var zipMagic = []byte("PK\x03\x04")
+
+// SetConfigBundle defines the path of a tar (optionally gzip compressed, e.g.
+// "config.tar.gz") or zip archive holding the configuration, e.g. a conf.d
+// tree shipped as a single release artifact. ReadInConfig then extracts the
+// archive in memory and merges all files with a supported extension in
+// lexical order of their path, e.g. "conf.d/00-base.yaml" before
+// "conf.d/10-prod.yaml"; other files are ignored. The format of each file is
+// given by its extension.
+// Viper will use this and not check any of the config paths.
+func SetConfigBundle(in string) { v.SetConfigBundle(in) }
+func (v *Viper) SetConfigBundle(in string) {
+	if in != "" {
+		v.configFile = in
+		v.configFiles = nil
+		v.configBundle = true
+	}
+}
+
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+// readConfigBundle reads the configuration from the archive at filename.
+func (v *Viper) readConfigBundle(filename string) error {
+	jww.DEBUG.Println("Reading bundle: ", filename)
+	file, err := v.readConfigFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var files []bundleFile
+	if bytes.HasPrefix(file, zipMagic) {
+		files, err = readZipBundle(file)
+	} else {
+		files, err = readTarBundle(file)
+	}
+	if err != nil {
+		return ConfigParseError{err}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	config := make(map[string]interface{})
+	origins := make(map[string]map[string]interface{})
+	names := []string{}
+	for _, f := range files {
+		configType := fileConfigType(f.name)
+		if !stringInSlice(configType, SupportedExts) {
+			jww.DEBUG.Println("Skipping bundle file: ", f.name)
+			continue
+		}
+		cfg := make(map[string]interface{})
+		if err := v.unmarshalReaderAs(bytes.NewReader(f.data), cfg, configType); err != nil {
+			return err
+		}
+		if v.readOptions().strict {
+			if err := v.checkStrict(cfg, filename+":"+f.name, f.data, configType); err != nil {
+				return err
+			}
+		}
+		v.mergeMaps(cfg, config)
+		origins[f.name] = cfg
+		names = append(names, f.name)
+	}
+	if len(names) == 0 {
+		return ConfigFileNotFoundError{"*", filename}
+	}
+	if err := v.applyLoadTransforms(config); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	old := v.config
+	v.config = config
+	v.configSource = nil
+	v.recordConfigFile(filename, "bundle", file, false)
+	v.recordConfigOrigins(nil, "", false)
+	for _, name := range names {
+		v.recordConfigOrigins(origins[name], filename+":"+name, true)
+	}
+	v.unlockChanged()
+	v.warnShadowedKeys()
+	if v.auditing() {
+		v.auditLayer(AuditRead, filename, old, config)
+	}
+	return nil
+}
+
+// fileConfigType returns the config type of a file given by its
+// extension, ignoring compression extensions.
+func fileConfigType(name string) string {
+	ext := path.Ext(name)
+	if len(ext) > 1 && isCompressionExt(ext[1:]) {
+		ext = path.Ext(strings.TrimSuffix(name, ext))
+	}
+	if len(ext) > 1 {
+		return strings.ToLower(ext[1:])
+	}
+	return ""
+}
+
+func readTarBundle(data []byte) ([]bundleFile, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	var files []bundleFile
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		contents, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, bundleFile{name: path.Clean(hdr.Name), data: contents})
+	}
+}
+
+func readZipBundle(data []byte) ([]bundleFile, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	var files []bundleFile
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, bundleFile{name: path.Clean(f.Name), data: contents})
+	}
+	return files, nil
+}
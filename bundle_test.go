@@ -0,0 +1,89 @@
+package viper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var bundleFiles = []struct {
+	name, contents string
+}{
+	{"conf.d/10-prod.json", `{"db": {"host": "db.prod"}}`},
+	{"conf.d/00-base.yaml", "name: steve\ndb:\n  host: localhost\n  port: 5432\n"},
+	{"conf.d/README", "not a config file"},
+	{"conf.d/20-local.toml", "name = \"bob\"\n"},
+}
+
+func tarBundle(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "conf.d/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, f := range bundleFiles {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: f.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.contents)),
+		}))
+		_, err := tw.Write([]byte(f.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func zipBundle(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range bundleFiles {
+		w, err := zw.Create(f.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(f.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestConfigBundle(t *testing.T) {
+	for name, bundle := range map[string][]byte{
+		"config.tar":    tarBundle(t),
+		"config.tar.gz": gzipped(t, tarBundle(t)),
+		"config.zip":    zipBundle(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/release/"+name, bundle, 0644))
+
+			v := New()
+			v.SetFs(fs)
+			v.SetConfigBundle("/release/" + name)
+			require.NoError(t, v.ReadInConfig())
+
+			assert.Equal(t, "bob", v.GetString("name"))
+			assert.Equal(t, "db.prod", v.GetString("db.host"))
+			assert.Equal(t, 5432, v.GetInt("db.port"))
+
+			source, detail := v.Origin("db.host")
+			assert.Equal(t, SourceConfig, source)
+			assert.Equal(t, "/release/"+name+":conf.d/10-prod.json", detail)
+			require.Len(t, v.ConfigFilesUsed(), 1)
+			assert.Equal(t, "/release/"+name, v.ConfigFilesUsed()[0].Path)
+		})
+	}
+}
+
+func TestConfigBundleEmpty(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	var buf bytes.Buffer
+	require.NoError(t, tar.NewWriter(&buf).Close())
+	require.NoError(t, afero.WriteFile(fs, "/release/config.tar", buf.Bytes(), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigBundle("/release/config.tar")
+	assert.IsType(t, ConfigFileNotFoundError{}, v.ReadInConfig())
+}
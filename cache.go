@@ -0,0 +1,91 @@
+package viper
+
+import (
+	"sync"
+	"time"
+)
+
+// keyCache holds resolved values of keys registered through CacheKey.
+type keyCache struct {
+	mu      sync.Mutex
+	ttls    map[string]time.Duration
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{
+		ttls:    make(map[string]time.Duration),
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// CacheKey enables a read-through cache for the given key: the first Get
+// resolves the value through the usual precedence chain and later calls
+// return that value until ttl has elapsed. This is meant for keys whose
+// resolution is expensive; a ttl <= 0 disables caching for the key.
+// Cached values are dropped whenever the configuration changes, i.e. every
+// time its Generation increases.
+// CacheKey is case-insensitive for a key.
+func CacheKey(key string, ttl time.Duration) { v.CacheKey(key, ttl) }
+func (v *Viper) CacheKey(key string, ttl time.Duration) {
+	key = v.normalizeKey(key)
+
+	v.cache.mu.Lock()
+	defer v.cache.mu.Unlock()
+	delete(v.cache.entries, key)
+	if ttl <= 0 {
+		delete(v.cache.ttls, key)
+		return
+	}
+	v.cache.ttls[key] = ttl
+}
+
+// InvalidateCache drops the cached values of the given keys, so that the
+// next Get resolves them again. Without keys, the whole cache is dropped.
+// The index of EnableKeyIndex is dropped either way.
+// InvalidateCache is case-insensitive for a key.
+func InvalidateCache(keys ...string) { v.InvalidateCache(keys...) }
+func (v *Viper) InvalidateCache(keys ...string) {
+	if v.keyIndex != nil {
+		v.keyIndex.reset()
+	}
+
+	v.cache.mu.Lock()
+	defer v.cache.mu.Unlock()
+	if len(keys) == 0 {
+		v.cache.entries = make(map[string]cacheEntry)
+		return
+	}
+	for _, key := range keys {
+		delete(v.cache.entries, v.normalizeKey(key))
+	}
+}
+
+// cachedFind behaves like find, serving keys registered through CacheKey
+// from the cache while their entry is fresh.
+// Note: this assumes a lower-cased key given.
+func (v *Viper) cachedFind(lcaseKey string) interface{} {
+	v.cache.mu.Lock()
+	ttl, cached := v.cache.ttls[lcaseKey]
+	entry, ok := v.cache.entries[lcaseKey]
+	v.cache.mu.Unlock()
+
+	if !cached {
+		return v.find(lcaseKey)
+	}
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value
+	}
+
+	val := v.find(lcaseKey)
+
+	v.cache.mu.Lock()
+	v.cache.entries[lcaseKey] = cacheEntry{value: val, expires: time.Now().Add(ttl)}
+	v.cache.mu.Unlock()
+	return val
+}
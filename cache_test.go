@@ -0,0 +1,44 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheKey(t *testing.T) {
+	v := New()
+	calls := 0
+	v.SetEnvKeyMapper(func(envName string) string {
+		if envName == "CACHED_SECRET" {
+			calls++
+			return "secret"
+		}
+		return ""
+	})
+	v.AutomaticEnv()
+	t.Setenv("CACHED_SECRET", "s3cr3t")
+
+	v.CacheKey("Secret", time.Hour)
+	assert.Equal(t, "s3cr3t", v.Get("secret"))
+	assert.Equal(t, "s3cr3t", v.Get("SECRET"))
+	assert.Equal(t, 1, calls)
+
+	t.Setenv("CACHED_SECRET", "rotated")
+	assert.Equal(t, "s3cr3t", v.Get("secret"))
+
+	v.InvalidateCache("secret")
+	assert.Equal(t, "rotated", v.Get("secret"))
+	assert.Equal(t, 2, calls)
+
+	v.CacheKey("secret", time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	v.Get("secret")
+	v.Get("secret")
+	assert.Equal(t, 4, calls)
+
+	v.Set("secret", "override")
+	v.CacheKey("secret", time.Hour)
+	assert.Equal(t, "override", v.Get("secret"))
+}
@@ -0,0 +1,52 @@
+package viper
+
+import (
+	"sort"
+	"strings"
+)
+
+// Codec encodes and decodes configuration maps in a given format, allowing
+// formats that are not built into Viper to be plugged in through
+// RegisterConfigType.
+type Codec interface {
+	// Encode returns the serialized form of the configuration map.
+	Encode(v map[string]interface{}) ([]byte, error)
+	// Decode parses b and stores the resulting values into v.
+	Decode(b []byte, v map[string]interface{}) error
+}
+
+// codecs holds the codecs registered through RegisterConfigType,
+// by lower-cased config type.
+var codecs = map[string]Codec{}
+
+// RegisterConfigType registers a codec for the given config type, which is
+// also the file extension (without the dot) it is associated with.
+// The type is added to SupportedExts, so that it can be used with
+// SetConfigType, ReadConfig, WriteConfigAs and in config file discovery.
+// Registering a codec for a built-in type replaces the built-in support.
+func RegisterConfigType(ext string, codec Codec) {
+	ext = strings.ToLower(ext)
+	codecs[ext] = codec
+	if !stringInSlice(ext, SupportedExts) {
+		SupportedExts = append(SupportedExts, ext)
+	}
+}
+
+// codecFor returns the codec registered for configType, if any.
+func codecFor(configType string) (Codec, bool) {
+	codec, ok := codecs[strings.ToLower(configType)]
+	return codec, ok
+}
+
+// registeredExts returns the registered config types that are not part of
+// the given list, sorted.
+func registeredExts(builtin []string) []string {
+	exts := make([]string, 0, len(codecs))
+	for ext := range codecs {
+		if !stringInSlice(ext, builtin) {
+			exts = append(exts, ext)
+		}
+	}
+	sort.Strings(exts)
+	return exts
+}
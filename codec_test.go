@@ -0,0 +1,74 @@
+package viper
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kvCodec handles a trivial "key = value" per line format.
+type kvCodec struct{}
+
+func (kvCodec) Encode(m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s = %v", k, m[k]))
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (kvCodec) Decode(b []byte, m map[string]interface{}) error {
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid line %q", line)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return nil
+}
+
+func TestRegisterConfigType(t *testing.T) {
+	RegisterConfigType("KV", kvCodec{})
+	defer func() {
+		delete(codecs, "kv")
+		Reset()
+	}()
+	assert.Contains(t, SupportedExts, "kv")
+
+	Reset()
+	assert.Contains(t, SupportedExts, "kv")
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.kv", []byte("Name = steve\nage = 35\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.AddConfigPath("/etc/app")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, filepath.FromSlash("/etc/app/config.kv"), v.ConfigFileUsed())
+	assert.Equal(t, "steve", v.Get("name"))
+
+	v.Set("age", 36)
+	require.NoError(t, v.WriteConfigAs("/out.kv"))
+	b, err := afero.ReadFile(fs, "/out.kv")
+	require.NoError(t, err)
+	assert.Equal(t, "age = 36\nname = steve\n", string(b))
+
+	err = v.ReadConfig(strings.NewReader("broken"))
+	assert.IsType(t, ConfigParseError{}, err)
+}
@@ -0,0 +1,71 @@
+package viper
+
+// keyAnnotation documents a key: its description, see SetDefaultWithDoc, and
+// its comment, see SetComment. Both are written as comments by WriteConfig
+// and RenderExample.
+type keyAnnotation struct {
+	doc     string
+	comment string
+}
+
+// text returns the comment written above the key: its comment, falling back
+// to its description.
+func (a keyAnnotation) text() string {
+	if a.comment != "" {
+		return a.comment
+	}
+	return a.doc
+}
+
+// annotate updates the annotation of key with fn, dropping it once empty.
+// It must be called without the lock held.
+func (v *Viper) annotate(key string, fn func(a *keyAnnotation)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key = v.realKey(v.normalizeKey(key))
+	a := v.annotations[key]
+	fn(&a)
+	if a == (keyAnnotation{}) {
+		delete(v.annotations, key)
+		return
+	}
+	if v.annotations == nil {
+		v.annotations = make(map[string]keyAnnotation)
+	}
+	v.annotations[key] = a
+}
+
+// SetComment sets the comment written above key by WriteConfig and
+// RenderExample, e.g. to make a generated config file self-documenting.
+// Comments are written to YAML, TOML and INI files, for keys holding a value
+// as well as for sections; keys without a comment get their description, see
+// SetDefaultWithDoc, and an empty comment removes the comment of the key.
+// Config files patched in place keep their own comments, see SetWriteOrder.
+// SetComment is case-insensitive for a key.
+//
+//	viper.SetComment("server.port", "Port the HTTP listener binds to")
+func SetComment(key, comment string) { v.SetComment(key, comment) }
+func (v *Viper) SetComment(key, comment string) {
+	v.annotate(key, func(a *keyAnnotation) { a.comment = comment })
+}
+
+// Comment returns the comment of key set through SetComment, if any.
+// Comment is case-insensitive for a key.
+func Comment(key string) string { return v.Comment(key) }
+func (v *Viper) Comment(key string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.annotations[v.realKey(v.normalizeKey(key))].comment
+}
+
+// writeComments returns the comments written by WriteConfig and
+// RenderExample, by key.
+func (v *Viper) writeComments() map[string]string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	comments := make(map[string]string, len(v.annotations))
+	for key, a := range v.annotations {
+		comments[key] = a.text()
+	}
+	return comments
+}
@@ -0,0 +1,92 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCommentedViper() *Viper {
+	v := New()
+	v.Set("name", "app")
+	v.Set("server.port", 8080)
+	v.Set("server.host", "localhost")
+	v.SetComment("Server.Port", "Port the HTTP listener binds to")
+	v.SetComment("server", "HTTP server\nsettings")
+	v.SetComment("name", "")
+	return v
+}
+
+func TestSetComment(t *testing.T) {
+	v := newCommentedViper()
+	assert.Equal(t, "Port the HTTP listener binds to", v.Comment("SERVER.PORT"))
+	assert.Equal(t, "", v.Comment("name"))
+
+	out, err := v.RenderConfig("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `name: app
+# HTTP server
+# settings
+server:
+  host: localhost
+  # Port the HTTP listener binds to
+  port: 8080
+`, string(out))
+
+	out, err = v.RenderConfig("toml")
+	require.NoError(t, err)
+	assert.Equal(t, `name = "app"
+
+# HTTP server
+# settings
+[server]
+host = "localhost"
+# Port the HTTP listener binds to
+port = 8080
+`, string(out))
+
+	out, err = v.RenderConfig("ini")
+	require.NoError(t, err)
+	assert.Equal(t, `name = app
+
+# HTTP server
+# settings
+[server]
+host = localhost
+# Port the HTTP listener binds to
+port = 8080
+`, string(out))
+
+	// the comments can be read back
+	for _, format := range []string{"yaml", "toml", "ini"} {
+		out, err := v.RenderConfig(format)
+		require.NoError(t, err)
+		read := New()
+		read.SetConfigType(format)
+		require.NoError(t, read.ReadConfig(bytes.NewReader(out)), format)
+		assert.Equal(t, 8080, read.GetInt("server.port"), format)
+	}
+}
+
+func TestSetCommentAndDoc(t *testing.T) {
+	v := New()
+	v.SetDefaultWithDoc("port", 8080, "Port the HTTP listener binds to")
+	v.SetDefaultWithDoc("host", "localhost", "Host of the listener")
+	v.SetComment("host", "Interface to bind")
+
+	// descriptions are written by WriteConfig, comments by RenderExample
+	for _, render := range []func(string) ([]byte, error){v.RenderConfig, v.RenderExample} {
+		out, err := render("yaml")
+		require.NoError(t, err)
+		assert.Equal(t, "# Interface to bind\nhost: localhost\n# Port the HTTP listener binds to\nport: 8080\n", string(out))
+	}
+	assert.Equal(t, "Host of the listener", v.KeyDoc("host"))
+
+	v.SetComment("host", "")
+	assert.Equal(t, "Host of the listener", v.KeyDoc("host"))
+	out, err := v.RenderExample("ini")
+	require.NoError(t, err)
+	assert.Equal(t, "# Host of the listener\nhost = localhost\n# Port the HTTP listener binds to\nport = 8080\n", string(out))
+}
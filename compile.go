@@ -0,0 +1,64 @@
+package viper
+
+// Compiled is a read-only struct compiled from the configuration, see
+// Compile. Load, Updates and Stop behave as for UnmarshalOnChange.
+type Compiled struct {
+	*Unmarshaled
+}
+
+// Compile resolves the whole configuration once into rawVal, a pointer to a
+// struct, like Unmarshal does, so that hot paths read plain struct fields
+// rather than looking keys up in Viper. Then after every change to the
+// configuration, on Set, on reads and merges, and on the reloads of
+// WatchConfig, a new value of the type of rawVal is compiled and replaces the
+// current one; the functions given to OnUpdate are called with it. Load never
+// returns rawVal itself, which stays the caller's, and values are never
+// modified once compiled, so they can be shared between goroutines without
+// locking. Use SuspendEvents to compile a batch of changes once.
+//
+// If the new configuration can't be decoded, the function given to OnError,
+// if any, is called with the error and the current value is kept. An error
+// is returned if the initial compile fails.
+//
+//	compiled, err := viper.Compile(&Config{})
+//	compiled.OnUpdate(func(cfg interface{}) {
+//		server.SetConfig(cfg.(*Config))
+//	})
+func Compile(rawVal interface{}, opts ...DecoderConfigOption) (*Compiled, error) {
+	return v.Compile(rawVal, opts...)
+}
+func (v *Viper) Compile(rawVal interface{}, opts ...DecoderConfigOption) (*Compiled, error) {
+	u, err := v.newUnmarshaled("Compile", rawVal, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	v.unmarshalers.mu.Lock()
+	v.unmarshalers.list = append(v.unmarshalers.list, u)
+	v.unmarshalers.mu.Unlock()
+	// the configuration may have changed before u was registered
+	u.reload()
+	return &Compiled{u}, nil
+}
+
+// OnUpdate adds a function called with every new value compiled. Values are
+// delivered one at a time and in order: if the configuration is changed
+// while the functions run, e.g. by several goroutines at once, they are
+// called next with the latest value only.
+func (c *Compiled) OnUpdate(fn func(value interface{})) {
+	c.mu.Lock()
+	c.onUpdate = append(c.onUpdate, fn)
+	c.mu.Unlock()
+}
+
+// OnError sets a function called when a new configuration can't be
+// compiled, in which case the current value is kept.
+func (c *Compiled) OnError(fn func(err error)) {
+	c.mu.Lock()
+	c.onError = fn
+	c.mu.Unlock()
+}
+
+// recompile updates the values returned by Compile.
+func (v *Viper) recompile() {
+	v.reloadUnmarshalers(true)
+}
@@ -0,0 +1,151 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type compiledConfig struct {
+	Server struct {
+		Port    int
+		Timeout time.Duration
+	}
+	Name string
+}
+
+func TestCompile(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("server:\n  port: 8080\n  timeout: 5s\n")))
+	v.SetDefault("name", "app")
+
+	var cfg compiledConfig
+	compiled, err := v.Compile(&cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	first := compiled.Load().(*compiledConfig)
+	assert.False(t, &cfg == first)
+	assert.Equal(t, cfg, *first)
+	// rawVal stays the caller's
+	cfg.Server.Port = 1
+	assert.Equal(t, 8080, compiled.Load().(*compiledConfig).Server.Port)
+	assert.Equal(t, 5*time.Second, first.Server.Timeout)
+	assert.Equal(t, "app", first.Name)
+
+	var updates []*compiledConfig
+	compiled.OnUpdate(func(cfg interface{}) {
+		updates = append(updates, cfg.(*compiledConfig))
+	})
+	var errs []error
+	compiled.OnError(func(err error) { errs = append(errs, err) })
+
+	v.Set("server.port", 9090)
+	require.Len(t, updates, 1)
+	assert.Equal(t, 9090, updates[0].Server.Port)
+	assert.Equal(t, updates[0], compiled.Load())
+	// values are never modified once compiled
+	assert.Equal(t, 8080, first.Server.Port)
+
+	require.NoError(t, v.MergeConfig(bytes.NewBufferString("name: merged\n")))
+	require.Len(t, updates, 2)
+	assert.Equal(t, "merged", compiled.Load().(*compiledConfig).Name)
+
+	v.SuspendEvents()
+	v.Set("server.port", 1)
+	v.Set("server.port", 2)
+	v.Set("name", "batch")
+	assert.Len(t, updates, 2)
+	v.ResumeEvents()
+	require.Len(t, updates, 3)
+	assert.Equal(t, 2, updates[2].Server.Port)
+	assert.Equal(t, "batch", updates[2].Name)
+
+	v.Set("server.timeout", "soon")
+	require.Len(t, errs, 1)
+	assert.Len(t, updates, 3)
+	assert.Equal(t, updates[2], compiled.Load())
+
+	compiled.Stop()
+	v.Set("server.timeout", "1s")
+	assert.Len(t, updates, 3)
+	assert.Empty(t, v.unmarshalers.list)
+}
+
+func TestCompileEnvAndUpdateCallbacks(t *testing.T) {
+	os.Setenv("COMPILE_NAME", "from-env")
+	defer os.Unsetenv("COMPILE_NAME")
+
+	v := New()
+	v.SetDefault("name", "app")
+	compiled, err := v.Compile(&compiledConfig{})
+	require.NoError(t, err)
+	compiled.OnUpdate(func(cfg interface{}) {
+		// callbacks may change the configuration
+		if cfg.(*compiledConfig).Server.Port == 0 {
+			v.Set("server.port", 80)
+		}
+	})
+
+	require.NoError(t, v.BindEnv("name", "COMPILE_NAME"))
+	cfg := compiled.Load().(*compiledConfig)
+	assert.Equal(t, "from-env", cfg.Name)
+	assert.Equal(t, 80, cfg.Server.Port)
+}
+
+func TestCompileDeliversLatestLast(t *testing.T) {
+	v := New()
+	compiled, err := v.Compile(&compiledConfig{})
+	require.NoError(t, err)
+	var mu sync.Mutex
+	var delivered []int
+	compiled.OnUpdate(func(cfg interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered = append(delivered, cfg.(*compiledConfig).Server.Port)
+	})
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			v.Set("server.port", port)
+		}(i)
+	}
+	wg.Wait()
+
+	require.NotEmpty(t, delivered)
+	assert.Equal(t, compiled.Load().(*compiledConfig).Server.Port, delivered[len(delivered)-1])
+	assert.Equal(t, v.GetInt("server.port"), delivered[len(delivered)-1])
+}
+
+func TestCompileErrors(t *testing.T) {
+	v := New()
+	_, err := v.Compile(compiledConfig{})
+	assert.EqualError(t, err, "Compile requires a non-nil pointer, got viper.compiledConfig")
+
+	v.Set("server.port", "eighty")
+	_, err = v.Compile(&compiledConfig{})
+	assert.Error(t, err)
+}
+
+func BenchmarkCompiledRead(b *testing.B) {
+	v := New()
+	v.Set("server.port", 8080)
+	compiled, err := v.Compile(&compiledConfig{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if compiled.Load().(*compiledConfig).Server.Port != 8080 {
+			b.Fatal("wrong port")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package viper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// A Decompressor returns a reader decompressing r.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// UnsupportedCompressionError denotes a compressed configuration for which
+// no Decompressor is registered.
+type UnsupportedCompressionError string
+
+// Error returns the formatted compression error.
+func (str UnsupportedCompressionError) Error() string {
+	return fmt.Sprintf("Unsupported Config Compression %q, register a decompressor with RegisterCompression", string(str))
+}
+
+type compression struct {
+	ext        string
+	magic      []byte
+	decompress Decompressor
+}
+
+// compressions are the known compression formats. zstd is recognized, but
+// needs a decompressor to be registered.
+var compressions = []compression{
+	{ext: "gz", magic: []byte{0x1f, 0x8b}, decompress: func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	}},
+	{ext: "zst", magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// RegisterCompression registers a decompressor for configuration compressed
+// in a format identified by the file extension ext, e.g. "zst" for
+// "config.json.zst", and by magic, the bytes compressed data starts with.
+// Compressed configuration is detected and decompressed transparently when
+// read. gzip is supported out of the box; zstd is recognized, but as viper
+// doesn't depend on a zstd implementation, one must be registered:
+//
+//	viper.RegisterCompression("zst", []byte{0x28, 0xb5, 0x2f, 0xfd},
+//		func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) })
+//
+// RegisterCompression is meant to be called from init functions; it isn't
+// safe for concurrent use.
+func RegisterCompression(ext string, magic []byte, decompress Decompressor) {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	for i, c := range compressions {
+		if c.ext == ext {
+			compressions[i] = compression{ext: ext, magic: magic, decompress: decompress}
+			return
+		}
+	}
+	compressions = append(compressions, compression{ext: ext, magic: magic, decompress: decompress})
+}
+
+// isCompressionExt reports whether ext, without leading dot, is the
+// extension of a known compression format.
+func isCompressionExt(ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, c := range compressions {
+		if c.ext == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// decompress returns the decompressed contents of data if it starts with the
+// magic bytes of a known compression format, and data itself otherwise.
+func decompress(data []byte) ([]byte, error) {
+	for _, c := range compressions {
+		if len(c.magic) == 0 || !bytes.HasPrefix(data, c.magic) {
+			continue
+		}
+		if c.decompress == nil {
+			return nil, UnsupportedCompressionError(c.ext)
+		}
+		r, err := c.decompress(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		res, err := ioutil.ReadAll(r)
+		if rc, ok := r.(io.Closer); ok {
+			rc.Close()
+		}
+		return res, err
+	}
+	return data, nil
+}
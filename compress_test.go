@@ -0,0 +1,59 @@
+package viper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadInConfigGzip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml.gz", gzipped(t, yamlExample), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.AddConfigPath("/etc/app")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "/etc/app/config.yaml.gz", v.ConfigFileUsed())
+	assert.Equal(t, "steve", v.GetString("name"))
+
+	// detected from the magic bytes, whatever the file name
+	v.SetConfigType("json")
+	require.NoError(t, v.MergeConfig(bytes.NewReader(gzipped(t, []byte(`{"name": "bob"}`)))))
+	assert.Equal(t, "bob", v.GetString("name"))
+}
+
+func TestReadInConfigZstd(t *testing.T) {
+	// not an actual zstd frame, the test decompressor strips the magic bytes
+	magic := []byte{0x28, 0xb5, 0x2f, 0xfd}
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.json.zst", append(magic, jsonExample...), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.json.zst")
+	err := v.ReadInConfig()
+	assert.Equal(t, ConfigParseError{UnsupportedCompressionError("zst")}, err)
+
+	defer RegisterCompression("zst", magic, nil)
+	RegisterCompression(".ZST", magic, func(r io.Reader) (io.Reader, error) {
+		_, err := r.Read(make([]byte, len(magic)))
+		return r, err
+	})
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "donut", v.GetString("type"))
+}
@@ -0,0 +1,20 @@
+package viper
+
+// The configuration layers of a Viper, its aliases, bindings and mounts are
+// guarded by v.mu: lookups hold the read lock while walking them, and
+// mutations hold the write lock while storing into them. Callbacks, load
+// transforms and constraints always run without the lock held, so that they
+// may use the Viper instance themselves.
+//
+// Values handed out by Get may be nested maps of the layers, read by the
+// caller without any lock. Nested maps are thus never modified once stored:
+// Set and SetDefault copy the maps and slices along the path of the key, see
+// setInLayer, and merges and remote reads work on a copy of the layer which
+// replaces it.
+
+// lockedRealKey is realKey for callers not holding v.mu.
+func (v *Viper) lockedRealKey(key string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.realKey(key)
+}
@@ -0,0 +1,164 @@
+package viper
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests below are meant to be run with the race detector.
+
+func newConcurrentViper(t *testing.T) *Viper {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("db:\n  host: localhost\n  port: 5432\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.SetDefault("db.pool", 10)
+	require.NoError(t, v.ReadInConfig())
+	return v
+}
+
+func TestConcurrentReadsAndWrites(t *testing.T) {
+	v := newConcurrentViper(t)
+
+	type dbConfig struct {
+		Host string
+		Port int
+		Pool int
+	}
+
+	var wg sync.WaitGroup
+	run := func(n int, fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				fn(i)
+			}
+		}()
+	}
+
+	// readers
+	for r := 0; r < 4; r++ {
+		run(200, func(int) {
+			v.Get("db")
+			v.GetString("db.host")
+			v.GetInt("db.port")
+			v.IsSet("db.pool")
+			v.InConfig("db")
+			v.AllKeys()
+			v.Origin("db.host")
+			var c dbConfig
+			assert.NoError(t, v.UnmarshalKey("db", &c))
+			// nested maps handed out are never modified afterwards
+			if m, ok := v.Get("db").(map[string]interface{}); ok {
+				for range m {
+				}
+			}
+		})
+	}
+	run(10, func(int) { v.Debug() })
+
+	// writers
+	run(200, func(i int) {
+		v.Set("db.port", 6000+i)
+		v.Set(fmt.Sprintf("db.replica%d", i%4), i)
+	})
+	run(200, func(i int) {
+		v.SetDefault("db.timeout", i)
+	})
+	run(100, func(i int) {
+		assert.NoError(t, v.MergeConfigMap(map[string]interface{}{
+			"db": map[string]interface{}{"user": fmt.Sprintf("user%d", i)},
+		}))
+	})
+	run(50, func(i int) {
+		assert.NoError(t, v.BindEnv(fmt.Sprintf("db.env%d", i%4)))
+		v.RegisterAlias(fmt.Sprintf("alias%d", i), "db.host")
+	})
+	// the reload path of WatchConfig
+	run(50, func(i int) {
+		content := fmt.Sprintf("db:\n  host: host%d\n  port: 5432\n", i)
+		assert.NoError(t, afero.WriteFile(v.fs, "/etc/app/config.yaml", []byte(content), 0644))
+		assert.NoError(t, v.readInConfig())
+	})
+
+	wg.Wait()
+
+	assert.Equal(t, 6199, v.GetInt("db.port"))
+	assert.Equal(t, "host49", v.GetString("db.host"))
+	assert.Equal(t, 10, v.GetInt("db.pool"))
+}
+
+func TestConcurrentWriteConfig(t *testing.T) {
+	v := newConcurrentViper(t)
+	require.NoError(t, afero.WriteFile(v.fs, "/etc/app/config.properties", []byte("# app\nname = app\n"), 0644))
+
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				fn(i)
+			}
+		}()
+	}
+	run(func(int) { assert.NoError(t, v.WriteConfigAs("/out/config.yaml")) })
+	run(func(int) { assert.NoError(t, v.WriteConfigAs("/out/config.properties")) })
+	run(func(int) { assert.NoError(t, v.ReadInConfig()) })
+	// the reload path of WatchConfig
+	run(func(int) { assert.NoError(t, v.readInConfig()) })
+	run(func(int) {
+		assert.NoError(t, v.ReadSources([]Source{FileSource("/etc/app/config.properties", "")}))
+	})
+	wg.Wait()
+}
+
+func TestConcurrentMount(t *testing.T) {
+	v := New()
+	plugin := New()
+	plugin.Set("timeout", "5s")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, v.Mount("plugins.auth", plugin))
+			assert.NoError(t, v.Mount("plugins.auth", nil))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			v.Get("plugins.auth.timeout")
+			v.AllSettings()
+			v.Generation()
+		}
+	}()
+	wg.Wait()
+
+	assert.Nil(t, v.Get("plugins.auth.timeout"))
+}
+
+func TestSetCopiesNestedMaps(t *testing.T) {
+	v := New()
+	v.Set("db.host", "localhost")
+	db := v.Get("db").(map[string]interface{})
+
+	v.Set("db.port", 5432)
+	assert.Equal(t, map[string]interface{}{"host": "localhost"}, db)
+	assert.Equal(t, map[string]interface{}{"host": "localhost", "port": 5432}, v.Get("db"))
+
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"cache": map[string]interface{}{"ttl": 1}}))
+	cache := v.Get("cache").(map[string]interface{})
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"cache": map[string]interface{}{"size": 2}}))
+	assert.Equal(t, map[string]interface{}{"ttl": 1}, cache)
+}
@@ -0,0 +1,353 @@
+package viper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// SetConfigFiles sets a list of config files, e.g. a base config followed by
+// environment specific overrides. ReadInConfig reads all of them and merges
+// them in the order given, later files taking precedence, and WatchConfig
+// re-reads them when any of them changes. Unlike the files of config
+// directories, the files given must exist: ReadInConfig returns a
+// ConfigFileNotFoundError for the first one missing. The format of each file
+// is given by its extension, unless set with SetConfigType.
+// Viper will use these and not check any of the config paths.
+func SetConfigFiles(paths ...string) { v.SetConfigFiles(paths...) }
+func (v *Viper) SetConfigFiles(paths ...string) {
+	v.configFiles = nil
+	for _, in := range paths {
+		if in != "" {
+			v.configFiles = append(v.configFiles, localConfigFile(in))
+		}
+	}
+	if len(v.configFiles) > 0 {
+		v.configBundle = false
+	}
+}
+
+// AddConfigDir adds a directory of drop-in config files, conf.d style.
+// ReadInConfig merges the files of the directory with a supported extension
+// in lexical order, e.g. "10-base.yaml" before "20-prod.yaml", on top of the
+// config files (see SetConfigFile, SetConfigFiles and AddConfigPathGroup).
+// Without config files or config paths, only the directories are read.
+// Directories are merged in the order they were added. dir may also be a
+// glob pattern, e.g. "/etc/app/conf.d/*.yaml". Missing directories and
+// directories without config files are skipped. WatchConfig re-reads the
+// configuration when a file of the directory is written, added or removed.
+//
+//	viper.SetConfigFile("/etc/app/config.yaml")
+//	viper.AddConfigDir("/etc/app/conf.d")
+//	err := viper.ReadInConfig()
+//
+// Config directories don't apply to config bundles.
+func AddConfigDir(dir string) { v.AddConfigDir(dir) }
+func (v *Viper) AddConfigDir(dir string) {
+	if dir != "" {
+		absdir := absPathify(dir)
+		jww.INFO.Println("adding", absdir, "to config directories")
+		if !stringInSlice(absdir, v.configDirs) {
+			v.configDirs = append(v.configDirs, absdir)
+		}
+	}
+}
+
+// layeredConfig reports whether the configuration is read from several
+// files, see readLayeredConfig.
+func (v *Viper) layeredConfig() bool {
+	if len(v.configFiles) > 0 {
+		return true
+	}
+	if v.configBundle {
+		return false
+	}
+	return len(v.configDirs) > 0 || (v.configFile == "" && len(v.configGroups) > 0)
+}
+
+// layeredConfigFiles returns the config files to merge, in merge order.
+func (v *Viper) layeredConfigFiles() ([]string, error) {
+	var files []string
+	switch {
+	case len(v.configFiles) > 0:
+		for _, file := range v.configFiles {
+			if configURL(file) == nil {
+				if ok, _ := exists(v.fs, file); !ok {
+					return nil, ConfigFileNotFoundError{filepath.Base(file), filepath.Dir(file)}
+				}
+			}
+			files = append(files, file)
+		}
+	case v.configFile == "" && len(v.configGroups) > 0:
+		found, err := v.findConfigGroupFiles()
+		if err != nil {
+			return nil, err
+		}
+		files = found
+	case v.configFile == "" && len(v.configPaths) == 0:
+		// no base config file, only the directories
+	default:
+		file, err := v.getConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		files = []string{file}
+	}
+
+	for _, dir := range v.configDirs {
+		found, err := v.configDirFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range found {
+			if !stringInSlice(file, files) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// configDirFiles returns the config files of the directory or glob pattern
+// dir, in lexical order.
+func (v *Viper) configDirFiles(dir string) ([]string, error) {
+	var names []string
+	if isGlobPattern(dir) {
+		matches, err := afero.Glob(v.fs, dir)
+		if err != nil {
+			return nil, err
+		}
+		names = matches
+	} else {
+		infos, err := afero.ReadDir(v.fs, dir)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range infos {
+			names = append(names, filepath.Join(dir, fi.Name()))
+		}
+	}
+
+	var files []string
+	for _, name := range names {
+		if !stringInSlice(fileConfigType(filepath.ToSlash(name)), SupportedExts) {
+			continue
+		}
+		if fi, err := v.fs.Stat(name); err != nil || fi.IsDir() {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// readLayeredConfig reads and merges the config files of layeredConfigFiles.
+func (v *Viper) readLayeredConfig() error {
+	filenames, err := v.layeredConfigFiles()
+	if err != nil {
+		return err
+	}
+
+	layers := make([]configLayer, len(filenames))
+	for i, filename := range filenames {
+		jww.DEBUG.Println("Reading file: ", filename)
+		data, err := v.readConfigFile(filename)
+		if err != nil {
+			return err
+		}
+		configType := v.configType
+		if configType == "" {
+			configType = fileConfigType(filepath.ToSlash(filename))
+		}
+		layers[i] = configLayer{name: filename, format: configType, data: data, file: true}
+	}
+	return v.readLayers(layers, strings.Join(filenames, ", "), v.readOptions().strict)
+}
+
+// configLayer is one of the layers merged into the config layer by
+// readLayers: a config file, or a source of ReadSources.
+type configLayer struct {
+	// name is the detail reported by Origin for the keys of the layer
+	name   string
+	format string
+	// data is parsed as format, unless cfg is given
+	data []byte
+	cfg  map[string]interface{}
+	// config files are recorded by ConfigFilesUsed
+	file bool
+}
+
+// readLayers parses the layers and replaces the config layer with their
+// merge, later layers taking precedence, checking them first if strict is
+// set, see StrictRead. source describes the layers in the audit log.
+// Nothing is read if a layer fails.
+func (v *Viper) readLayers(layers []configLayer, source string, strict bool) error {
+	config := make(map[string]interface{})
+	for i := range layers {
+		l := &layers[i]
+		if l.cfg == nil {
+			l.cfg = make(map[string]interface{})
+			if err := v.unmarshalBytesAs(l.data, l.cfg, l.format); err != nil {
+				return err
+			}
+		}
+		if strict {
+			if err := v.checkStrict(l.cfg, l.name, l.data, l.format); err != nil {
+				return err
+			}
+		}
+		v.mergeMaps(l.cfg, config)
+	}
+	if err := v.applyLoadTransforms(config); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	old := v.config
+	v.config = config
+	v.configSource = nil
+	v.configFilesUsed = nil
+	v.recordConfigOrigins(nil, "", false)
+	for _, l := range layers {
+		if l.file {
+			v.recordConfigFile(l.name, l.format, l.data, true)
+		}
+		v.recordConfigOrigins(l.cfg, l.name, true)
+	}
+	v.unlockChanged()
+	v.warnShadowedKeys()
+	if v.auditing() {
+		v.auditLayer(AuditRead, source, old, config)
+	}
+	return nil
+}
+
+// configWatch tracks the files and directories WatchConfig reacts to.
+type configWatch struct {
+	// the watched files, mapped to the file their symlinks resolve to
+	files map[string]string
+	// config directories, see AddConfigDir
+	dirs []string
+	// whether the configuration is layered, see layeredConfig
+	layered bool
+}
+
+// newConfigWatch returns the watch of the config files currently in use.
+func (v *Viper) newConfigWatch() (*configWatch, error) {
+	w := &configWatch{files: map[string]string{}, layered: v.layeredConfig()}
+	if !w.layered {
+		filename, err := v.getConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		if configURL(filename) != nil {
+			return nil, fmt.Errorf("can't watch remote config file %s", filename)
+		}
+		w.add(filename)
+		return w, nil
+	}
+
+	if err := w.refresh(v); err != nil {
+		return nil, err
+	}
+	for _, dir := range v.configDirs {
+		if isGlobPattern(dir) {
+			dir = filepath.Dir(dir)
+		}
+		w.dirs = append(w.dirs, filepath.Clean(dir))
+	}
+	return w, nil
+}
+
+func (w *configWatch) add(filename string) {
+	w.files[filepath.Clean(filename)], _ = filepath.EvalSymlinks(filename)
+}
+
+// refresh updates the watched files of a layered configuration, e.g. after
+// drop-in files were added.
+func (w *configWatch) refresh(v *Viper) error {
+	filenames, err := v.layeredConfigFiles()
+	if err != nil {
+		return err
+	}
+	files := w.files
+	w.files = map[string]string{}
+	for _, filename := range filenames {
+		if configURL(filename) != nil {
+			jww.WARN.Println("can't watch remote config file", filename)
+			continue
+		}
+		if real, ok := files[filepath.Clean(filename)]; ok {
+			w.files[filepath.Clean(filename)] = real
+		} else {
+			w.add(filename)
+		}
+	}
+	return nil
+}
+
+// watchDirs returns the directories to watch: watching entire directories
+// picks up renames and atomic saves in a cross-platform way.
+func (w *configWatch) watchDirs() []string {
+	var dirs []string
+	for file := range w.files {
+		if dir := filepath.Dir(file); !stringInSlice(dir, dirs) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range w.dirs {
+		if !stringInSlice(dir, dirs) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// changed reports whether event changes the configuration. We only care
+// about the config files with the following cases:
+// 1 - if a config file was modified or created
+// 2 - if the real path to a config file changed (eg: k8s ConfigMap replacement)
+// 3 - if a config file was added to or removed from a config directory
+func (w *configWatch) changed(event fsnotify.Event) bool {
+	const writeOrCreateMask = fsnotify.Write | fsnotify.Create
+	changed := false
+	name := filepath.Clean(event.Name)
+	for file, real := range w.files {
+		current, _ := filepath.EvalSymlinks(file)
+		if (name == file && event.Op&writeOrCreateMask != 0) ||
+			(current != "" && current != real) {
+			w.files[file] = current
+			changed = true
+		}
+	}
+	if !changed && stringInSlice(filepath.Dir(name), w.dirs) {
+		changed = stringInSlice(fileConfigType(filepath.ToSlash(name)), SupportedExts)
+	}
+	return changed
+}
+
+// removed reports whether event removes the config file, ending the watch.
+// Layered configurations are watched until the watcher fails instead, so
+// that files can be replaced.
+func (w *configWatch) removed(event fsnotify.Event) bool {
+	if w.layered {
+		return false
+	}
+	_, ok := w.files[filepath.Clean(event.Name)]
+	return ok && event.Op&fsnotify.Remove != 0
+}
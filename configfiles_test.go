@@ -0,0 +1,109 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfigFilesFs(t *testing.T) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for name, contents := range map[string]string{
+		"/etc/app/config.yaml":          "name: base\ndb:\n  host: db.prod\n  port: 5432\n",
+		"/etc/app/prod.json":            `{"name": "prod"}`,
+		"/etc/app/conf.d/20-cache.yaml": "cache:\n  size: 20\n",
+		"/etc/app/conf.d/10-db.toml":    "[db]\nhost = \"db.local\"\n",
+		"/etc/app/conf.d/README":        "not a config file",
+	} {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(contents), 0644))
+	}
+	return fs
+}
+
+func TestSetConfigFiles(t *testing.T) {
+	v := New()
+	v.SetFs(newConfigFilesFs(t))
+	v.SetConfigFiles("/etc/app/config.yaml", "/etc/app/prod.json")
+	require.NoError(t, v.ReadInConfig())
+
+	assert.Equal(t, "prod", v.GetString("name"))
+	assert.Equal(t, "db.prod", v.GetString("db.host"))
+	files := v.ConfigFilesUsed()
+	require.Len(t, files, 2)
+	assert.Equal(t, "/etc/app/prod.json", files[1].Path)
+	_, detail := v.Origin("name")
+	assert.Equal(t, "/etc/app/prod.json", detail)
+
+	v.SetConfigFiles("/etc/app/config.yaml", "/etc/app/missing.yaml")
+	err := v.ReadInConfig()
+	require.IsType(t, ConfigFileNotFoundError{}, err)
+	assert.EqualError(t, err, `Config File "missing.yaml" Not Found in "/etc/app"`)
+
+	// a single config file replaces the list
+	v.SetConfigFile("/etc/app/prod.json")
+	require.NoError(t, v.ReadInConfig())
+	assert.False(t, v.IsSet("db.host"))
+}
+
+func TestAddConfigDir(t *testing.T) {
+	v := New()
+	v.SetFs(newConfigFilesFs(t))
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.AddConfigDir("/etc/app/conf.d")
+	v.AddConfigDir("/etc/app/missing.d")
+	require.NoError(t, v.ReadInConfig())
+
+	assert.Equal(t, "base", v.GetString("name"))
+	assert.Equal(t, "db.local", v.GetString("db.host"))
+	assert.Equal(t, 5432, v.GetInt("db.port"))
+	assert.Equal(t, 20, v.GetInt("cache.size"))
+	var paths []string
+	for _, f := range v.ConfigFilesUsed() {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{
+		"/etc/app/config.yaml",
+		"/etc/app/conf.d/10-db.toml",
+		"/etc/app/conf.d/20-cache.yaml",
+	}, paths)
+
+	// glob patterns select some of the files
+	v = New()
+	v.SetFs(newConfigFilesFs(t))
+	v.SetConfigFiles("/etc/app/config.yaml")
+	v.AddConfigDir("/etc/app/conf.d/*.yaml")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "db.prod", v.GetString("db.host"))
+	assert.Equal(t, 20, v.GetInt("cache.size"))
+
+	// directories may be read without a base config file
+	v = New()
+	v.SetFs(newConfigFilesFs(t))
+	v.AddConfigDir("/etc/app/conf.d")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "db.local", v.GetString("db.host"))
+	assert.Len(t, v.ConfigFilesUsed(), 2)
+}
+
+func TestConfigWatchLayered(t *testing.T) {
+	v := New()
+	v.SetFs(newConfigFilesFs(t))
+	v.SetConfigFiles("/etc/app/config.yaml", "/etc/app/prod.json")
+	v.AddConfigDir("/etc/app/conf.d")
+
+	w, err := v.newConfigWatch()
+	require.NoError(t, err)
+	assert.True(t, w.layered)
+	assert.Equal(t, []string{"/etc/app", "/etc/app/conf.d"}, w.watchDirs())
+
+	assert.True(t, w.changed(fsnotify.Event{Name: "/etc/app/prod.json", Op: fsnotify.Write}))
+	assert.True(t, w.changed(fsnotify.Event{Name: "/etc/app/conf.d/10-db.toml", Op: fsnotify.Write}))
+	assert.True(t, w.changed(fsnotify.Event{Name: "/etc/app/conf.d/40-new.yaml", Op: fsnotify.Create}))
+	assert.True(t, w.changed(fsnotify.Event{Name: "/etc/app/conf.d/20-cache.yaml", Op: fsnotify.Remove}))
+	assert.False(t, w.changed(fsnotify.Event{Name: "/etc/app/other.yaml", Op: fsnotify.Write}))
+	assert.False(t, w.changed(fsnotify.Event{Name: "/etc/app/conf.d/README", Op: fsnotify.Write}))
+	assert.False(t, w.removed(fsnotify.Event{Name: "/etc/app/prod.json", Op: fsnotify.Remove}))
+}
@@ -0,0 +1,160 @@
+package viper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	jww "github.com/spf13/jwalterweatherman"
+
+	"github.com/spf13/viper/internal/sigv4"
+)
+
+// A ConfigURLFetcher returns the contents of the configuration file at u.
+type ConfigURLFetcher func(u *url.URL) ([]byte, error)
+
+// ConfigURLClient performs the requests fetching config files by http, https
+// and s3 URL. Its timeout bounds every fetch, so that a stalled server
+// doesn't block ReadInConfig or the reloads of WatchConfig. Like
+// RegisterConfigURLScheme, it is meant to be replaced from init functions.
+var ConfigURLClient = &http.Client{Timeout: 30 * time.Second}
+
+// configURLFetchers are the fetchers of remote configuration files by URL
+// scheme.
+var configURLFetchers = map[string]ConfigURLFetcher{
+	"http":  fetchHTTP,
+	"https": fetchHTTP,
+	"s3":    fetchS3,
+}
+
+// RegisterConfigURLScheme registers the fetcher of configuration files given
+// to SetConfigFile as a URL of the given scheme, e.g. "gs" for
+// "gs://bucket/config.yaml", replacing any fetcher registered for the scheme.
+// http, https, s3 and file URLs are supported out of the box.
+//
+// RegisterConfigURLScheme is meant to be called from init functions; it
+// isn't safe for concurrent use.
+func RegisterConfigURLScheme(scheme string, fetch ConfigURLFetcher) {
+	configURLFetchers[strings.ToLower(scheme)] = fetch
+}
+
+// configURL returns the parsed URL of the config file filename if it is a
+// URL with a registered scheme, and nil otherwise.
+func configURL(filename string) *url.URL {
+	u, err := url.Parse(filename)
+	// single letter schemes are Windows drive letters
+	if err != nil || len(u.Scheme) < 2 {
+		return nil
+	}
+	if _, ok := configURLFetchers[strings.ToLower(u.Scheme)]; !ok {
+		return nil
+	}
+	return u
+}
+
+// configFilePath returns the path of the config file filename, i.e. the
+// path of its URL for remote files.
+func configFilePath(filename string) string {
+	if u := configURL(filename); u != nil {
+		return u.Path
+	}
+	return filename
+}
+
+// localConfigFile returns the path of the config file in, which may be a
+// file URL.
+func localConfigFile(in string) string {
+	u, err := url.Parse(in)
+	if err != nil || u.Scheme != "file" {
+		return in
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		// file://relative/path
+		return filepath.FromSlash(u.Host + u.Path)
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// readConfigFile returns the contents of the config file filename, fetching
+// it if it is a URL.
+func (v *Viper) readConfigFile(filename string) ([]byte, error) {
+	u := configURL(filename)
+	if u == nil {
+		return afero.ReadFile(v.fs, filename)
+	}
+	jww.DEBUG.Println("Fetching config file: ", filename)
+	return configURLFetchers[strings.ToLower(u.Scheme)](u)
+}
+
+// ConfigURLError denotes a failure to fetch a configuration file by URL.
+type ConfigURLError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error returns the formatted configuration URL error.
+func (e ConfigURLError) Error() string {
+	return fmt.Sprintf("Fetching Config File %q: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func fetchHTTP(u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return fetchConfigURL(req, u.String())
+}
+
+// fetchS3 reads the object of an s3://bucket/key URL. The region is read
+// from the AWS_REGION or AWS_DEFAULT_REGION environment variables, and the
+// request is signed with the credentials from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, if set.
+// AWS_ENDPOINT_URL_S3 overrides the S3 endpoint, e.g. for S3 compatible
+// storage, addressing buckets by path.
+func fetchS3(u *url.URL) ([]byte, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var objectURL string
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+		objectURL = strings.TrimSuffix(endpoint, "/") + "/" + u.Host + u.EscapedPath()
+	} else {
+		objectURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", u.Host, region, u.EscapedPath())
+	}
+	req, err := http.NewRequest(http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	creds := sigv4.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" {
+		sigv4.Sign(req, nil, creds, region, "s3", time.Now())
+	}
+	return fetchConfigURL(req, u.String())
+}
+
+func fetchConfigURL(req *http.Request, rawURL string) ([]byte, error) {
+	resp, err := ConfigURLClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ConfigURLError{URL: rawURL, StatusCode: resp.StatusCode}
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,107 @@
+package viper
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFileURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config/app.yaml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("name: steve\n"))
+	}))
+	defer srv.Close()
+
+	v := New()
+	v.SetConfigFile(srv.URL + "/config/app.yaml?version=2")
+	assert.Equal(t, "yaml", v.getConfigType())
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+	assert.Error(t, v.WriteConfig())
+
+	v.SetConfigFile(srv.URL + "/missing.yaml")
+	assert.Equal(t, ConfigURLError{URL: srv.URL + "/missing.yaml", StatusCode: 404}, v.ReadInConfig())
+}
+
+func TestConfigFileURLTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+	client := ConfigURLClient
+	ConfigURLClient = &http.Client{Timeout: 10 * time.Millisecond}
+	defer func() { ConfigURLClient = client }()
+
+	v := New()
+	v.SetConfigFile(srv.URL + "/config.yaml")
+	err := v.ReadInConfig()
+	require.Error(t, err)
+	assert.True(t, err.(net.Error).Timeout(), err)
+}
+
+func TestConfigFileS3URL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/mybucket/prod/app.json", r.URL.Path)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+		w.Write([]byte(`{"name": "steve"}`))
+	}))
+	defer srv.Close()
+	for key, val := range map[string]string{
+		"AWS_ENDPOINT_URL_S3":   srv.URL,
+		"AWS_REGION":            "eu-west-1",
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	} {
+		os.Setenv(key, val)
+		defer os.Unsetenv(key)
+	}
+
+	v := New()
+	v.SetConfigFile("s3://mybucket/prod/app.json")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+}
+
+func TestConfigFileLocalURL(t *testing.T) {
+	v := New()
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: steve\n"), 0644))
+	v.SetFs(fs)
+
+	v.SetConfigFile("file:///etc/app/config.yaml")
+	assert.Equal(t, "/etc/app/config.yaml", v.ConfigFileUsed())
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+}
+
+func TestRegisterConfigURLScheme(t *testing.T) {
+	RegisterConfigURLScheme("test", func(u *url.URL) ([]byte, error) {
+		return []byte("name = \"" + u.Host + "\"\n"), nil
+	})
+	defer delete(configURLFetchers, "test")
+
+	v := New()
+	v.SetConfigFile("test://steve/config.toml")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+
+	// not a registered scheme, nor a drive letter
+	assert.Nil(t, configURL("other://host/config.toml"))
+	assert.Nil(t, configURL(`C:\config.toml`))
+}
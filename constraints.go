@@ -0,0 +1,129 @@
+package viper
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cast"
+)
+
+// A Constraint checks a value about to be stored for a key, returning an
+// error describing why it is rejected.
+type Constraint func(value interface{}) error
+
+// ConstraintError denotes a value rejected by a constraint registered through
+// AddConstraint.
+type ConstraintError struct {
+	Key   string
+	Value interface{}
+	err   error
+}
+
+// Error returns the formatted constraint error.
+func (ce ConstraintError) Error() string {
+	return fmt.Sprintf("invalid value %v for key %q: %s", ce.Value, ce.Key, ce.err.Error())
+}
+
+// MaxLength returns a Constraint rejecting strings longer than n characters.
+func MaxLength(n int) Constraint {
+	return func(value interface{}) error {
+		s, err := cast.ToStringE(value)
+		if err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(s) > n {
+			return fmt.Errorf("longer than %d characters", n)
+		}
+		return nil
+	}
+}
+
+// Range returns a Constraint rejecting values which aren't numbers between
+// min and max inclusive.
+func Range(min, max float64) Constraint {
+	return func(value interface{}) error {
+		f, err := cast.ToFloat64E(value)
+		if err != nil {
+			return err
+		}
+		if f < min || f > max {
+			return fmt.Errorf("out of range [%v, %v]", min, max)
+		}
+		return nil
+	}
+}
+
+// OneOf returns a Constraint rejecting values other than the given ones.
+// Values are compared by their string representation, so that the number 1
+// read from a config file matches both 1 and "1".
+func OneOf(allowed ...interface{}) Constraint {
+	return func(value interface{}) error {
+		s := cast.ToString(value)
+		for _, a := range allowed {
+			if cast.ToString(a) == s {
+				return nil
+			}
+		}
+		names := make([]string, len(allowed))
+		for i, a := range allowed {
+			names[i] = cast.ToString(a)
+		}
+		return fmt.Errorf("not one of %s", strings.Join(names, ", "))
+	}
+}
+
+// AddConstraint registers constraints enforced on the values stored for key
+// by Set, SetE and MergeConfigMap (and thus MergeConfig and MergeInConfig),
+// including values set through a parent key, e.g. Set("db", map[string]interface{}{"pool": 10})
+// for a constraint on "db.pool". Rejected values are not stored.
+// AddConstraint is case-insensitive for a key.
+func AddConstraint(key string, constraints ...Constraint) { v.AddConstraint(key, constraints...) }
+func (v *Viper) AddConstraint(key string, constraints ...Constraint) {
+	if v.constraints == nil {
+		v.constraints = make(map[string][]Constraint)
+	}
+	key = v.realKey(v.normalizeKey(key))
+	v.constraints[key] = append(v.constraints[key], constraints...)
+}
+
+// checkConstraints checks the value about to be stored for key against the
+// registered constraints, returning a ConstraintError per violation; an
+// empty key denotes a whole configuration map.
+func (v *Viper) checkConstraints(key string, value interface{}) []error {
+	var errs []error
+	for _, ckey := range sortedConstraintKeys(v.constraints) {
+		var cval interface{}
+		switch {
+		case ckey == key:
+			cval = value
+		case key == "" || strings.HasPrefix(ckey, key+v.keyDelim):
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := strings.Split(strings.TrimPrefix(ckey, key+v.keyDelim), v.keyDelim)
+			if key == "" {
+				path = strings.Split(ckey, v.keyDelim)
+			}
+			cval = v.searchMapWithPathPrefixes(m, path)
+		}
+		if cval == nil {
+			continue
+		}
+		for _, c := range v.constraints[ckey] {
+			if err := c(cval); err != nil {
+				errs = append(errs, ConstraintError{Key: ckey, Value: cval, err: err})
+			}
+		}
+	}
+	return errs
+}
+
+func sortedConstraintKeys(m map[string][]Constraint) []string {
+	keys := make(map[string]bool, len(m))
+	for key := range m {
+		keys[key] = true
+	}
+	return sortedKeys(keys)
+}
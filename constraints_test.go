@@ -0,0 +1,52 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraints(t *testing.T) {
+	v := New()
+	v.AddConstraint("Name", MaxLength(5))
+	v.AddConstraint("db.pool", Range(1, 100))
+	v.AddConstraint("log.level", OneOf("debug", "info", "error"))
+
+	assert.NoError(t, v.SetE("name", "steve"))
+	err := v.SetE("NAME", "stevens")
+	require.Error(t, err)
+	assert.Equal(t, `invalid value stevens for key "name": longer than 5 characters`, err.Error())
+	assert.Equal(t, "steve", v.GetString("name"))
+
+	// Set logs and ignores rejected values
+	v.Set("log.level", "verbose")
+	assert.False(t, v.IsSet("log.level"))
+	v.Set("log.level", "info")
+	assert.Equal(t, "info", v.GetString("log.level"))
+
+	// values set through a parent key are checked too
+	err = v.SetE("db", map[string]interface{}{"pool": 500})
+	assert.IsType(t, ConstraintError{}, err)
+	assert.Nil(t, v.Get("db"))
+	assert.Error(t, v.SetE("db.pool", "ten"))
+	assert.NoError(t, v.SetE("db.pool", "10"))
+	assert.Equal(t, 10, v.GetInt("db.pool"))
+}
+
+func TestConstraintsMergeConfig(t *testing.T) {
+	v := New()
+	v.AddConstraint("db.pool", Range(1, 100))
+	v.AddConstraint("log.level", OneOf("debug", "info"))
+	v.SetConfigType("yaml")
+
+	err := v.MergeConfig(bytes.NewBufferString("db:\n  pool: 0\nlog:\n  level: trace\nname: steve\n"))
+	require.IsType(t, MultiError{}, err)
+	assert.Len(t, err.(MultiError), 2)
+	assert.Nil(t, v.Get("name"), "nothing merged")
+
+	require.NoError(t, v.MergeConfig(bytes.NewBufferString("db:\n  pool: 50\nname: steve\n")))
+	assert.Equal(t, 50, v.GetInt("db.pool"))
+	assert.Equal(t, "steve", v.GetString("name"))
+}
@@ -0,0 +1,288 @@
+package viper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// CronSchedule is a parsed cron expression, as returned by GetCron. Its
+// signature matches the Schedule of github.com/robfig/cron.
+type CronSchedule interface {
+	// Next returns the first activation time after t, or the zero time if
+	// there is none.
+	Next(t time.Time) time.Time
+}
+
+// CronParser parses a cron expression, see SetCronParser.
+type CronParser func(spec string) (CronSchedule, error)
+
+// CronError denotes a value which isn't a valid cron expression.
+type CronError struct {
+	Key   string
+	Value interface{}
+	err   error
+}
+
+// Error returns the formatted cron error.
+func (e CronError) Error() string {
+	return fmt.Sprintf("invalid cron schedule %v for key %q: %s", e.Value, e.Key, e.err.Error())
+}
+
+// cronDescriptors are the predefined schedules accepted by ParseCron.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// SetCronParser sets the parser of the cron expressions read by GetCron,
+// e.g. to accept the syntax of the scheduler the schedules are given to. A
+// github.com/robfig/cron parser can be used as is:
+//
+//	viper.SetCronParser(func(spec string) (viper.CronSchedule, error) {
+//		return cron.ParseStandard(spec)
+//	})
+//
+// Setting nil restores ParseCron.
+func SetCronParser(parser CronParser) { v.SetCronParser(parser) }
+func (v *Viper) SetCronParser(parser CronParser) {
+	v.cronParser = parser
+}
+
+// GetCron returns the value associated with the key as a parsed cron
+// schedule, so that an invalid schedule is reported when the configuration
+// is read rather than when it's first due. Expressions are parsed by
+// ParseCron unless another parser is set with SetCronParser. GetCron returns
+// a KeyNotSetError if the key has no value and a CronError if it isn't a
+// valid cron expression.
+func GetCron(key string) (CronSchedule, error) { return v.GetCron(key) }
+func (v *Viper) GetCron(key string) (CronSchedule, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := cast.ToStringE(val)
+	if err != nil {
+		return nil, CronError{Key: key, Value: val, err: err}
+	}
+	parser := v.cronParser
+	if parser == nil {
+		parser = ParseCron
+	}
+	schedule, err := parser(strings.TrimSpace(spec))
+	if err != nil {
+		return nil, CronError{Key: key, Value: val, err: err}
+	}
+	return schedule, nil
+}
+
+// ParseCron parses a standard cron expression with five fields: minute,
+// hour, day of month, month and day of week, or six with a leading field for
+// seconds. Fields accept "*", lists, ranges and steps, e.g. "*/15" or
+// "1-5,10", and months and days of week their English abbreviations, e.g.
+// "JAN" or "mon-fri". The descriptors @yearly, @annually, @monthly, @weekly,
+// @daily, @midnight, @hourly and "@every <duration>" are accepted as well as
+// a "CRON_TZ=<location>" prefix.
+func ParseCron(spec string) (CronSchedule, error) {
+	loc := time.Local
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "CRON_TZ=") || strings.HasPrefix(fields[0], "TZ=")) {
+		name := fields[0][strings.Index(fields[0], "=")+1:]
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", name)
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+
+	if strings.HasPrefix(fields[0], "@") {
+		if fields[0] == "@every" {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("@every expects a duration")
+			}
+			d, err := time.ParseDuration(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			if d < time.Second {
+				return nil, fmt.Errorf("@every duration must be at least 1s, got %s", d)
+			}
+			return cronEvery(d.Truncate(time.Second)), nil
+		}
+		expr, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok || len(fields) != 1 {
+			return nil, fmt.Errorf("unknown descriptor %q", strings.Join(fields, " "))
+		}
+		fields = strings.Fields(expr)
+	}
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("expected 5 or 6 fields, got %d", len(fields))
+	}
+	s := &cronSpec{
+		loc:    loc,
+		domAny: fields[3] == "*" || fields[3] == "?",
+		dowAny: fields[5] == "*" || fields[5] == "?",
+	}
+	for i, f := range []struct {
+		name     string
+		bits     *uint64
+		min, max int
+		names    map[string]int
+	}{
+		{"second", &s.second, 0, 59, nil},
+		{"minute", &s.minute, 0, 59, nil},
+		{"hour", &s.hour, 0, 23, nil},
+		{"day of month", &s.dom, 1, 31, nil},
+		{"month", &s.month, 1, 12, cronMonths},
+		{"day of week", &s.dow, 0, 7, cronDays},
+	} {
+		bits, err := parseCronField(fields[i], f.min, f.max, f.names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %s", f.name, fields[i], err)
+		}
+		*f.bits = bits
+	}
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseCronField returns the values of a comma-separated list of values,
+// ranges and steps as a bit set.
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	value := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		if n < min || n > max {
+			return 0, fmt.Errorf("value %d out of range [%d, %d]", n, min, max)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+		first, last := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+			var err error
+			if first, err = value(part[:i]); err != nil {
+				return 0, err
+			}
+			if last, err = value(part[i+1:]); err != nil {
+				return 0, err
+			}
+			if last < first {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			var err error
+			if first, err = value(part); err != nil {
+				return 0, err
+			}
+			if step == 1 {
+				last = first
+			}
+		}
+		for n := first; n <= last; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+	return bits, nil
+}
+
+// cronSpec is a schedule parsed by ParseCron, with the allowed values of each
+// field as bit sets.
+type cronSpec struct {
+	second, minute, hour, dom, month, dow uint64
+	// The day of month or of week is "*" or "?".
+	domAny, dowAny bool
+	loc            *time.Location
+}
+
+// Next returns the first activation time after t, or the zero time if there
+// is none within five years.
+func (s *cronSpec) Next(t time.Time) time.Time {
+	orig := t.Location()
+	t = t.In(s.loc)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+
+	for t.Year() <= limit {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Duration(60-t.Second()) * time.Second)
+		case s.second&(1<<uint(t.Second())) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t.In(orig)
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches tells whether t is on an allowed day. As in cron, a day matches
+// either the day of month or of week if both are restricted.
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// cronEvery is a schedule of "@every <duration>".
+type cronEvery time.Duration
+
+// Next returns the time a duration after t, rounded down to the second.
+func (d cronEvery) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d) - time.Duration(t.Nanosecond()))
+}
@@ -0,0 +1,87 @@
+package viper
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCron(t *testing.T) {
+	from := time.Date(2021, time.March, 15, 10, 37, 12, 500, time.UTC) // a Monday
+	v := New()
+	for spec, want := range map[string]time.Time{
+		"CRON_TZ=UTC */15 * * * *":          time.Date(2021, time.March, 15, 10, 45, 0, 0, time.UTC),
+		"TZ=UTC 0 9 * * mon-fri":            time.Date(2021, time.March, 16, 9, 0, 0, 0, time.UTC),
+		"TZ=UTC 30 0 1 jan,jul *":           time.Date(2021, time.July, 1, 0, 30, 0, 0, time.UTC),
+		"TZ=UTC 0 12 * * 7":                 time.Date(2021, time.March, 21, 12, 0, 0, 0, time.UTC),
+		"TZ=UTC 0 0 13 * 5":                 time.Date(2021, time.March, 19, 0, 0, 0, 0, time.UTC),
+		"TZ=UTC 0 0 29 2 ?":                 time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"TZ=UTC */20 37 10 * * *":           time.Date(2021, time.March, 15, 10, 37, 20, 0, time.UTC),
+		"TZ=UTC @hourly":                    time.Date(2021, time.March, 15, 11, 0, 0, 0, time.UTC),
+		"TZ=UTC @yearly":                    time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"@every 1m30s":                      time.Date(2021, time.March, 15, 10, 38, 42, 0, time.UTC),
+		"CRON_TZ=Asia/Tokyo 0 9 * * *":      time.Date(2021, time.March, 16, 0, 0, 0, 0, time.UTC),
+		"TZ=UTC 0 0 1-7/3 * *":              time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC),
+		"TZ=UTC 0 0 31 2 *":                 {},
+		"  TZ=UTC   5   4   *   *   *     ": time.Date(2021, time.March, 16, 4, 5, 0, 0, time.UTC),
+	} {
+		v.Set("schedule", spec)
+		schedule, err := v.GetCron("schedule")
+		require.NoError(t, err, spec)
+		assert.True(t, want.Equal(schedule.Next(from)), "%s: %s", spec, schedule.Next(from))
+	}
+
+	for _, spec := range []interface{}{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *",
+		"* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "@weekday", "@every", "@every 10ms",
+		"TZ=Nowhere/Else * * * * *", "one * * * *", []int{1}} {
+		v.Set("schedule", spec)
+		_, err := v.GetCron("schedule")
+		assert.IsType(t, CronError{}, err, spec)
+	}
+	_, err := v.GetCron("missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+}
+
+func TestGetCronDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	v := New()
+	v.Set("schedule", "CRON_TZ=Europe/Paris 30 2 * * *")
+	schedule, err := v.GetCron("schedule")
+	require.NoError(t, err)
+	// 2:30 doesn't exist on the day the clocks go forward.
+	next := schedule.Next(time.Date(2021, time.March, 27, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2021, time.March, 29, 2, 30, 0, 0, loc), next)
+}
+
+type fixedSchedule time.Time
+
+func (s fixedSchedule) Next(time.Time) time.Time { return time.Time(s) }
+
+func TestSetCronParser(t *testing.T) {
+	at := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	v := New()
+	v.SetCronParser(func(spec string) (CronSchedule, error) {
+		if spec != "@launch" {
+			return nil, errors.New("only @launch is supported")
+		}
+		return fixedSchedule(at), nil
+	})
+	v.Set("schedule", "@launch")
+	schedule, err := v.GetCron("schedule")
+	require.NoError(t, err)
+	assert.Equal(t, at, schedule.Next(time.Now()))
+
+	v.Set("schedule", "@daily")
+	_, err = v.GetCron("schedule")
+	assert.EqualError(t, err, `invalid cron schedule @daily for key "schedule": only @launch is supported`)
+
+	v.SetCronParser(nil)
+	_, err = v.GetCron("schedule")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,292 @@
+package viper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// Deprecation describes a deprecated key, see DeprecateKey.
+type Deprecation struct {
+	Key string
+	// ReplacedBy is the key the values of Key move to, if any.
+	ReplacedBy string
+	// Sunset is the date from which using Key is an error, if not zero.
+	Sunset time.Time
+	// RemovedIn is the version of the application from which using Key is
+	// an error, if not empty, see SetAppVersion.
+	RemovedIn string
+}
+
+// removed returns when the key was removed, if it's no longer accepted at
+// time now by the application of the given version.
+func (d Deprecation) removed(now time.Time, version string) (string, bool) {
+	if !d.Sunset.IsZero() && !now.Before(d.Sunset) {
+		return "on " + d.Sunset.Format("2006-01-02"), true
+	}
+	if d.RemovedIn != "" && version != "" && compareVersions(version, d.RemovedIn) >= 0 {
+		return "in version " + d.RemovedIn, true
+	}
+	return "", false
+}
+
+func (d Deprecation) replacement() string {
+	if d.ReplacedBy == "" {
+		return ""
+	}
+	return fmt.Sprintf(", use %q instead", d.ReplacedBy)
+}
+
+// DeprecatedKeyError denotes the use of a deprecated key past its sunset
+// date or removal version.
+type DeprecatedKeyError struct {
+	Deprecation
+	when string
+}
+
+// Error returns the formatted deprecated key error.
+func (e DeprecatedKeyError) Error() string {
+	return fmt.Sprintf("key %q was removed %s%s", e.Key, e.when, e.replacement())
+}
+
+// deprecations holds the keys registered through DeprecateKey, guarded by
+// v.mu.
+type deprecations struct {
+	keys       map[string]Deprecation
+	appVersion string
+	// deprecated keys found in the loaded configuration
+	used map[string]bool
+}
+
+// DeprecateKey registers d.Key as deprecated. Until its sunset date or
+// removal version, its values loaded by ReadInConfig, the merge functions and
+// ReadRemoteConfig, or stored by Set, move to d.ReplacedBy, unless the same
+// configuration sets ReplacedBy as well, and a warning is logged once per
+// key. From then on, using the key is an error: loading fails with a
+// DeprecatedKeyError and keeps the previous configuration, SetE returns it
+// and Set logs it. PendingDeprecations lists the deprecated keys still in
+// use, i.e. the migrations left to complete.
+// DeprecateKey is case-insensitive for a key.
+//
+//	viper.DeprecateKey(viper.Deprecation{
+//		Key:        "db.host",
+//		ReplacedBy: "database.host",
+//		RemovedIn:  "3.0.0",
+//	})
+func DeprecateKey(d Deprecation) { v.DeprecateKey(d) }
+func (v *Viper) DeprecateKey(d Deprecation) {
+	d.Key = v.normalizeKey(d.Key)
+	if d.ReplacedBy != "" {
+		d.ReplacedBy = v.normalizeKey(d.ReplacedBy)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.deprecations.keys == nil {
+		v.deprecations.keys = make(map[string]Deprecation)
+	}
+	v.deprecations.keys[d.Key] = d
+}
+
+// SetAppVersion sets the version of the application, compared to the
+// RemovedIn versions of deprecated keys, e.g. "2.3.1". Versions are compared
+// by their dot-separated numbers, ignoring a "v" prefix.
+func SetAppVersion(version string) { v.SetAppVersion(version) }
+func (v *Viper) SetAppVersion(version string) {
+	v.mu.Lock()
+	v.deprecations.appVersion = version
+	v.mu.Unlock()
+}
+
+// Deprecations returns the deprecated keys, sorted by key.
+func Deprecations() []Deprecation { return v.Deprecations() }
+func (v *Viper) Deprecations() []Deprecation {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.sortedDeprecations(func(string) bool { return true })
+}
+
+// PendingDeprecations returns the deprecated keys found in the configuration
+// loaded or set since they were deprecated, sorted by key, e.g. to report
+// the migrations left to complete before their sunset.
+func PendingDeprecations() []Deprecation { return v.PendingDeprecations() }
+func (v *Viper) PendingDeprecations() []Deprecation {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.sortedDeprecations(func(key string) bool { return v.deprecations.used[key] })
+}
+
+// sortedDeprecations returns the deprecations whose key matches. The caller
+// must hold the read lock.
+func (v *Viper) sortedDeprecations(match func(key string) bool) []Deprecation {
+	var list []Deprecation
+	for key, d := range v.deprecations.keys {
+		if match(key) {
+			list = append(list, d)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// useDeprecated records the use of the deprecated keys, logging a warning
+// for the ones used for the first time, and returns a DeprecatedKeyError for
+// each of them past its sunset. It must be called without the lock held.
+func (v *Viper) useDeprecated(keys []string) error {
+	now := time.Now()
+	v.mu.Lock()
+	version := v.deprecations.appVersion
+	var errs []error
+	var warnings []Deprecation
+	for _, key := range keys {
+		d := v.deprecations.keys[key]
+		if when, ok := d.removed(now, version); ok {
+			errs = append(errs, DeprecatedKeyError{Deprecation: d, when: when})
+			continue
+		}
+		if v.deprecations.used == nil {
+			v.deprecations.used = make(map[string]bool)
+		}
+		if !v.deprecations.used[key] {
+			v.deprecations.used[key] = true
+			warnings = append(warnings, d)
+		}
+	}
+	v.mu.Unlock()
+
+	for _, d := range warnings {
+		jww.WARN.Printf("Key %q is deprecated%s", d.Key, d.replacement())
+	}
+	return errorList(errs)
+}
+
+// deprecatedKey returns the key to set in place of key, the key replacing it
+// if key is deprecated. It must be called without the lock held.
+func (v *Viper) deprecatedKey(key string) (string, error) {
+	v.mu.RLock()
+	d, ok := v.deprecations.keys[key]
+	v.mu.RUnlock()
+	if !ok {
+		return key, nil
+	}
+	if err := v.useDeprecated([]string{key}); err != nil {
+		return "", err
+	}
+	if d.ReplacedBy != "" {
+		return d.ReplacedBy, nil
+	}
+	return key, nil
+}
+
+// migrateDeprecated moves the values of the deprecated keys of cfg, a
+// configuration being loaded, to their replacement. Nothing is moved if an
+// error is returned.
+func (v *Viper) migrateDeprecated(cfg map[string]interface{}) error {
+	v.mu.RLock()
+	var found []Deprecation
+	for _, d := range v.deprecations.keys {
+		if v.searchMap(cfg, strings.Split(d.Key, v.keyDelim)) != nil {
+			found = append(found, d)
+		}
+	}
+	v.mu.RUnlock()
+	if len(found) == 0 {
+		return nil
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Key < found[j].Key })
+
+	keys := make([]string, len(found))
+	for i, d := range found {
+		keys[i] = d.Key
+	}
+	if err := v.useDeprecated(keys); err != nil {
+		return err
+	}
+	for _, d := range found {
+		if d.ReplacedBy == "" {
+			continue
+		}
+		value := removeFromMap(cfg, strings.Split(d.Key, v.keyDelim))
+		to := strings.Split(d.ReplacedBy, v.keyDelim)
+		if v.searchMap(cfg, to) == nil {
+			storeInMap(cfg, to, value)
+		}
+	}
+	return nil
+}
+
+// removeFromMap removes the value at path from the nested maps of m and
+// returns it.
+func removeFromMap(m map[string]interface{}, path []string) interface{} {
+	for _, k := range path[:len(path)-1] {
+		next, ok := m[k]
+		if !ok {
+			return nil
+		}
+		if mi, ok := next.(map[interface{}]interface{}); ok {
+			next = cast.ToStringMap(mi)
+			m[k] = next
+		}
+		if m, ok = next.(map[string]interface{}); !ok {
+			return nil
+		}
+	}
+	last := path[len(path)-1]
+	value := m[last]
+	delete(m, last)
+	return value
+}
+
+// storeInMap stores value at path in the nested maps of m, creating the
+// missing ones.
+func storeInMap(m map[string]interface{}, path []string, value interface{}) {
+	for _, k := range path[:len(path)-1] {
+		next := m[k]
+		if mi, ok := next.(map[interface{}]interface{}); ok {
+			next = cast.ToStringMap(mi)
+		}
+		nm, ok := next.(map[string]interface{})
+		if !ok {
+			nm = make(map[string]interface{})
+		}
+		m[k] = nm
+		m = nm
+	}
+	m[path[len(path)-1]] = value
+}
+
+// compareVersions compares the versions a and b by their dot-separated
+// numbers, returning -1, 0 or 1. Parts which aren't numbers are compared as
+// strings; missing parts count as 0.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		nx, errx := strconv.Atoi(x)
+		ny, erry := strconv.Atoi(y)
+		switch {
+		case errx == nil && erry == nil && nx != ny:
+			if nx < ny {
+				return -1
+			}
+			return 1
+		case (errx != nil || erry != nil) && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,68 @@
+package viper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeprecateKey(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	v.DeprecateKey(Deprecation{Key: "DB.Host", ReplacedBy: "database.host", RemovedIn: "3.0"})
+	v.DeprecateKey(Deprecation{Key: "verbose", Sunset: time.Now().AddDate(1, 0, 0)})
+	v.SetAppVersion("2.4.1")
+	assert.Len(t, v.Deprecations(), 2)
+	assert.Empty(t, v.PendingDeprecations())
+
+	require.NoError(t, v.ReadConfig(strings.NewReader("db:\n  host: old\n  port: 5432\n")))
+	assert.Equal(t, "old", v.Get("database.host"))
+	assert.Nil(t, v.Get("db.host"))
+	assert.Equal(t, 5432, v.Get("db.port"))
+
+	// the replacement wins when both are set
+	require.NoError(t, v.ReadConfig(strings.NewReader("db:\n  host: old\ndatabase:\n  host: new\n")))
+	assert.Equal(t, "new", v.Get("database.host"))
+
+	require.NoError(t, v.SetE("verbose", true))
+	assert.Equal(t, true, v.Get("verbose"))
+	require.NoError(t, v.SetE("db.host", "set"))
+	assert.Equal(t, "set", v.Get("database.host"))
+
+	pending := v.PendingDeprecations()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "db.host", pending[0].Key)
+	assert.Equal(t, "verbose", pending[1].Key)
+}
+
+func TestDeprecateKeySunset(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	v.DeprecateKey(Deprecation{Key: "db.host", ReplacedBy: "database.host", RemovedIn: "v3.0"})
+	v.DeprecateKey(Deprecation{Key: "verbose", Sunset: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	v.SetAppVersion("3.0.0")
+
+	require.NoError(t, v.ReadConfig(strings.NewReader("name: app\n")))
+	err := v.ReadConfig(strings.NewReader("name: other\nverbose: true\ndb:\n  host: old\n"))
+	require.Error(t, err)
+	errs := err.(MultiError)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], `key "db.host" was removed in version v3.0, use "database.host" instead`)
+	assert.EqualError(t, errs[1], `key "verbose" was removed on 2020-01-01`)
+	assert.Equal(t, "app", v.Get("name"))
+
+	err = v.SetE("verbose", true)
+	assert.IsType(t, DeprecatedKeyError{}, err)
+	assert.Nil(t, v.Get("verbose"))
+	assert.Empty(t, v.PendingDeprecations())
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("1.2", "v1.2.0"))
+	assert.Equal(t, -1, compareVersions("1.9", "1.10"))
+	assert.Equal(t, 1, compareVersions("2.0.1", "2"))
+	assert.Equal(t, -1, compareVersions("1.0.0-beta", "1.0.0-rc"))
+}
@@ -0,0 +1,201 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// typeFamily holds the concrete types registered for an interface.
+type typeFamily struct {
+	key   string
+	types map[string]reflect.Type
+}
+
+// families holds the types registered through RegisterType, by interface.
+var families = map[reflect.Type]*typeFamily{}
+
+// RegisterType registers the concrete type of prototype for the interface
+// iface points to, under name: fields of that interface type are then
+// decoded by Unmarshal, UnmarshalKey and GetAs from subtrees whose
+// discriminator key, "type" unless set with SetTypeKey, is name:
+//
+//	storage:
+//	  type: s3
+//	  bucket: backups
+//
+//	type Storage interface{ Open(name string) (io.ReadCloser, error) }
+//	type S3Storage struct{ Bucket string }
+//
+//	viper.RegisterType((*Storage)(nil), "s3", &S3Storage{})
+//
+// A string value is accepted as the name of a type without settings, e.g.
+// "storage: memory". Names are case-insensitive. As with other types,
+// interface fields already holding a value are decoded into that value, so
+// decode into zero values. RegisterType panics if iface isn't a pointer to
+// an interface or prototype doesn't implement it. Like RegisterConfigType,
+// it's meant to be called at initialization.
+func RegisterType(iface interface{}, name string, prototype interface{}) {
+	it := interfaceType(iface)
+	pt := reflect.TypeOf(prototype)
+	if pt == nil || !pt.Implements(it) {
+		panic(fmt.Sprintf("viper: %T doesn't implement %s", prototype, it))
+	}
+	f := families[it]
+	if f == nil {
+		f = &typeFamily{key: "type", types: make(map[string]reflect.Type)}
+		families[it] = f
+	}
+	f.types[strings.ToLower(name)] = pt
+}
+
+// SetTypeKey sets the discriminator key telling which of the types
+// registered for the interface iface points to is decoded, see RegisterType.
+// The default is "type". Like other keys, it's case-insensitive unless the
+// instance decoding it is created with KeysCaseSensitive.
+func SetTypeKey(iface interface{}, key string) {
+	it := interfaceType(iface)
+	f := families[it]
+	if f == nil {
+		f = &typeFamily{types: make(map[string]reflect.Type)}
+		families[it] = f
+	}
+	f.key = key
+}
+
+func interfaceType(iface interface{}) reflect.Type {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("viper: expected a pointer to an interface, got %T", iface))
+	}
+	return t.Elem()
+}
+
+// ItemError denotes an item of a list which can't be decoded into the type
+// its discriminator names, see RegisterType.
+type ItemError struct {
+	Index int
+	// Type is the name of the type of the item, if known.
+	Type string
+	err  error
+}
+
+// Error returns the formatted item error.
+func (e ItemError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("item %d (%s): %s", e.Index, e.Type, e.err.Error())
+	}
+	return fmt.Sprintf("item %d: %s", e.Index, e.err.Error())
+}
+
+// DiscriminatedTypeHookFunc returns a DecodeHookFunc that decodes the
+// subtrees of fields of interfaces registered with RegisterType into the
+// registered concrete types, including the items of slices of such
+// interfaces, e.g. []Output for heterogeneous lists. The errors of the items
+// of a list are ItemErrors, reporting the index and type of every item
+// which can't be decoded. It's one of the default decode hooks of Unmarshal;
+// compose it to keep this behavior when setting a DecodeHook.
+func DiscriminatedTypeHookFunc() mapstructure.DecodeHookFunc {
+	return discriminatedTypeHook(false)
+}
+
+// discriminatedTypeHook returns DiscriminatedTypeHookFunc, matching
+// discriminator keys with the same case if caseSensitive is set.
+func discriminatedTypeHook(caseSensitive bool) mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		switch t.Kind() {
+		case reflect.Interface:
+			family := families[t]
+			if family == nil || f != nil && f.Implements(t) {
+				return data, nil
+			}
+			val, _, err := family.decode(t, data, caseSensitive)
+			return val, err
+
+		case reflect.Slice:
+			family := families[t.Elem()]
+			if family == nil || t.Elem().Kind() != reflect.Interface || f == nil || f == t {
+				return data, nil
+			}
+			items := reflect.ValueOf(data)
+			if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+				return data, nil
+			}
+			out := reflect.MakeSlice(t, items.Len(), items.Len())
+			var errs []error
+			for i := 0; i < items.Len(); i++ {
+				val, name, err := family.decode(t.Elem(), items.Index(i).Interface(), caseSensitive)
+				if err != nil {
+					errs = append(errs, ItemError{Index: i, Type: name, err: err})
+					continue
+				}
+				if val != nil {
+					out.Index(i).Set(reflect.ValueOf(val))
+				}
+			}
+			if err := errorList(errs); err != nil {
+				return nil, err
+			}
+			return out.Interface(), nil
+		}
+		return data, nil
+	}
+}
+
+// decode returns data decoded into the concrete type its discriminator
+// names, along with that name.
+func (family *typeFamily) decode(t reflect.Type, data interface{}, caseSensitive bool) (interface{}, string, error) {
+	var name string
+	var settings map[string]interface{}
+	switch data := data.(type) {
+	case nil:
+		return nil, "", nil
+	case string:
+		name = data
+	case map[string]interface{}, map[interface{}]interface{}:
+		settings = cast.ToStringMap(data)
+		for key, val := range settings {
+			if key == family.key || !caseSensitive && strings.EqualFold(key, family.key) {
+				name = cast.ToString(val)
+				break
+			}
+		}
+		if name == "" {
+			return nil, "", fmt.Errorf("missing %q key telling which %s to decode, one of %s", family.key, t, family.names())
+		}
+	default:
+		return nil, "", fmt.Errorf("can't decode %T into %s", data, t)
+	}
+
+	pt, ok := family.types[strings.ToLower(name)]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown %s %q, expected one of %s", t, name, family.names())
+	}
+	// decode into a pointer to the value, or into the value for pointers
+	target := reflect.New(pt)
+	result := target.Elem()
+	if pt.Kind() == reflect.Ptr {
+		target = reflect.New(pt.Elem())
+		result = target
+	}
+	if settings != nil {
+		if err := decode(settings, newDecoderConfig(target.Interface(), caseSensitive)); err != nil {
+			return nil, name, err
+		}
+	}
+	return result.Interface(), name, nil
+}
+
+// names returns the registered names, sorted.
+func (family *typeFamily) names() string {
+	names := make([]string, 0, len(family.types))
+	for name := range family.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
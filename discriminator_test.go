@@ -0,0 +1,162 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStorage interface {
+	Kind() string
+}
+
+type testS3Storage struct {
+	Bucket  string
+	Timeout time.Duration
+}
+
+func (*testS3Storage) Kind() string { return "s3" }
+
+type testMemoryStorage struct {
+	Size int
+}
+
+func (testMemoryStorage) Kind() string { return "memory" }
+
+type testAuth interface {
+	Realm() string
+}
+
+type testOIDCAuth struct {
+	Issuer string
+}
+
+func (a testOIDCAuth) Realm() string { return a.Issuer }
+
+func init() {
+	RegisterType((*testStorage)(nil), "s3", &testS3Storage{})
+	RegisterType((*testStorage)(nil), "Memory", testMemoryStorage{})
+	RegisterType((*testAuth)(nil), "oidc", testOIDCAuth{})
+	SetTypeKey((*testAuth)(nil), "Provider")
+}
+
+func TestUnmarshalDiscriminatedType(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+storage:
+  type: s3
+  bucket: backups
+  timeout: 5s
+cache: memory
+auth:
+  provider: oidc
+  issuer: https://login.example.com
+`)))
+
+	var config struct {
+		Storage testStorage
+		Cache   testStorage
+		Auth    testAuth
+		Unset   testStorage
+	}
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, &testS3Storage{Bucket: "backups", Timeout: 5 * time.Second}, config.Storage)
+	assert.Equal(t, testMemoryStorage{}, config.Cache)
+	assert.Equal(t, testOIDCAuth{Issuer: "https://login.example.com"}, config.Auth)
+	assert.Nil(t, config.Unset)
+
+	var storage testStorage
+	v.Set("cache", map[string]interface{}{"type": "MEMORY", "size": 64})
+	require.NoError(t, v.UnmarshalKey("cache", &storage))
+	assert.Equal(t, testMemoryStorage{Size: 64}, storage)
+}
+
+func TestUnmarshalDiscriminatedTypeCaseSensitive(t *testing.T) {
+	v := NewWithOptions(KeysCaseSensitive())
+	v.Set("auth", map[string]interface{}{"Provider": "oidc", "Issuer": "https://login.example.com"})
+	var auth testAuth
+	require.NoError(t, v.UnmarshalKey("auth", &auth))
+	assert.Equal(t, testOIDCAuth{Issuer: "https://login.example.com"}, auth)
+
+	v.Set("auth", map[string]interface{}{"provider": "oidc"})
+	err := v.UnmarshalKey("auth", &auth)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `missing "Provider" key`)
+}
+
+func TestUnmarshalDiscriminatedTypeErrors(t *testing.T) {
+	var config struct {
+		Storage testStorage
+	}
+	for value, want := range map[interface{}]string{
+		"gcs": `unknown viper.testStorage "gcs", expected one of memory, s3`,
+		42:    `can't decode int into viper.testStorage`,
+	} {
+		v := New()
+		v.Set("storage", value)
+		err := v.Unmarshal(&config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), want)
+	}
+
+	v := New()
+	v.Set("storage", map[string]interface{}{"bucket": "backups"})
+	err := v.Unmarshal(&config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `missing "type" key telling which viper.testStorage to decode, one of memory, s3`)
+
+	v.Set("storage", map[string]interface{}{"type": "s3", "timeout": "soon"})
+	assert.Error(t, v.Unmarshal(&config))
+}
+
+func TestRegisterTypePanics(t *testing.T) {
+	assert.Panics(t, func() { RegisterType(testStorage(nil), "s3", &testS3Storage{}) })
+	assert.Panics(t, func() { RegisterType((*testStorage)(nil), "s3", testS3Storage{}) })
+	assert.Panics(t, func() { RegisterType((*testStorage)(nil), "none", nil) })
+}
+
+func TestUnmarshalDiscriminatedSlice(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+outputs:
+  - type: s3
+    bucket: archive
+  - memory
+  - type: memory
+    size: 32
+  -
+cache: memory,s3
+`)))
+
+	var config struct {
+		Outputs []testStorage
+		Cache   []testStorage
+	}
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, []testStorage{&testS3Storage{Bucket: "archive"}, testMemoryStorage{}, testMemoryStorage{Size: 32}, nil}, config.Outputs)
+	assert.Equal(t, []testStorage{testMemoryStorage{}, &testS3Storage{}}, config.Cache)
+
+	v.Set("outputs", []interface{}{
+		map[string]interface{}{"type": "s3", "bucket": "archive"},
+		map[string]interface{}{"type": "gcs"},
+		map[string]interface{}{"type": "s3", "timeout": "soon"},
+		map[string]interface{}{"bucket": "orphan"},
+	})
+	config.Outputs, config.Cache = nil, nil
+	err := v.Unmarshal(&config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `item 1: unknown viper.testStorage "gcs", expected one of memory, s3`)
+	assert.Contains(t, err.Error(), `item 2 (s3): 1 error(s) decoding:`)
+	assert.Contains(t, err.Error(), `time: invalid duration`)
+	assert.Contains(t, err.Error(), `item 3: missing "type" key`)
+
+	var storage map[string]testStorage
+	v.Set("outputs", map[string]interface{}{"primary": map[string]interface{}{"type": "s3"}, "fallback": "memory"})
+	require.NoError(t, v.UnmarshalKey("outputs", &storage))
+	assert.Equal(t, map[string]testStorage{"primary": &testS3Storage{}, "fallback": testMemoryStorage{}}, storage)
+}
@@ -0,0 +1,218 @@
+package viper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// KeySchema describes a key of the configuration, as returned by Schema.
+type KeySchema struct {
+	Key string
+	// Default is nil for keys without a default.
+	Default interface{}
+	// Doc is the description of the key, see SetDefaultWithDoc.
+	Doc      string
+	Required bool
+	// DefaultOrigin tells how the default is defined, e.g. "SetDefault at
+	// main.go:42", see DefaultOrigin.
+	DefaultOrigin string
+}
+
+// SetDefaultWithDoc sets the default value for the key like SetDefault, along
+// with a description of the key. Descriptions are returned by KeyDoc and
+// Schema, and written as comments by RenderExample, so that the
+// documentation of a key lives next to its default.
+// SetDefaultWithDoc is case-insensitive for a key.
+//
+//	viper.SetDefaultWithDoc("server.port", 8080, "Port the HTTP listener binds to")
+func SetDefaultWithDoc(key string, value interface{}, description string) {
+	v.SetDefaultWithDoc(key, value, description)
+}
+func (v *Viper) SetDefaultWithDoc(key string, value interface{}, description string) {
+	if err := v.setDefault(key, value, callerOrigin()); err != nil {
+		jww.ERROR.Println(err)
+		return
+	}
+	v.setDoc(key, description)
+}
+
+// setDoc records the description of key. It must be called without the
+// lock held.
+func (v *Viper) setDoc(key, description string) {
+	v.annotate(key, func(a *keyAnnotation) { a.doc = description })
+}
+
+// KeyDoc returns the description of key set through SetDefaultWithDoc, if
+// any.
+// KeyDoc is case-insensitive for a key.
+func KeyDoc(key string) string { return v.KeyDoc(key) }
+func (v *Viper) KeyDoc(key string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.annotations[v.realKey(v.normalizeKey(key))].doc
+}
+
+// Schema returns the keys with a default, a description or which are
+// required, sorted, e.g. to generate the documentation of the configuration.
+// The defaults of secret keys are SecretRedacted, see MarkSecret.
+func Schema() []KeySchema { return v.Schema() }
+func (v *Viper) Schema() []KeySchema {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	defaults := v.redactMapLocked(v.defaults, "")
+	keys := v.flattenAndMergeMap(nil, defaults, "")
+	for key, a := range v.annotations {
+		if a.doc != "" {
+			keys[key] = true
+		}
+	}
+	for key := range v.required {
+		keys[key] = true
+	}
+
+	schema := make([]KeySchema, 0, len(keys))
+	for _, key := range sortedKeys(keys) {
+		ks := KeySchema{
+			Key:      key,
+			Default:  v.searchMap(defaults, strings.Split(key, v.keyDelim)),
+			Doc:      v.annotations[key].doc,
+			Required: v.required[key],
+		}
+		if ks.Default != nil {
+			ks.DefaultOrigin = v.defaultOriginOf(key).String()
+		}
+		schema = append(schema, ks)
+	}
+	return schema
+}
+
+// RenderExample returns an example config file of the given format holding
+// the defaults, e.g. to ship along with an application. YAML and TOML
+// examples have the descriptions of the keys as comments, see
+// SetDefaultWithDoc, unless replaced through SetComment. The defaults of
+// secret keys are SecretRedacted, see MarkSecret.
+func RenderExample(format string) ([]byte, error) { return v.RenderExample(format) }
+func (v *Viper) RenderExample(format string) ([]byte, error) {
+	if !stringInSlice(format, SupportedExts) {
+		return nil, UnsupportedConfigError(format)
+	}
+	v.mu.RLock()
+	defaults := v.redactMapLocked(v.defaults, "")
+	v.mu.RUnlock()
+	docs := v.writeComments()
+
+	var lines []string
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		lines, err = v.yamlExample(nil, defaults, docs)
+	case "toml":
+		lines, err = v.tomlExample(nil, defaults, docs)
+	default:
+		example := New()
+		example.keyDelim = v.keyDelim
+		example.defaults = defaults
+		v.mu.RLock()
+		for key, a := range v.annotations {
+			example.annotate(key, func(ea *keyAnnotation) { *ea = a })
+		}
+		v.mu.RUnlock()
+		var buf bytes.Buffer
+		if err := example.marshalWriter(&buf, format); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// docComment returns the lines of the description doc as comments at
+// indent.
+func docComment(doc, indent string) []string {
+	if doc == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		lines = append(lines, strings.TrimRight(indent+"# "+line, " "))
+	}
+	return lines
+}
+
+// yamlExample returns the lines of the YAML example holding m, found at path.
+func (v *Viper) yamlExample(path []string, m map[string]interface{}, docs map[string]string) ([]string, error) {
+	indent := strings.Repeat("  ", len(path))
+	var lines []string
+	for _, name := range sortedMapKeys(m) {
+		p := append(path[:len(path):len(path)], name)
+		lines = append(lines, docComment(docs[strings.Join(p, v.keyDelim)], indent)...)
+
+		if sub, ok := m[name].(map[string]interface{}); ok && len(sub) > 0 {
+			key, err := yaml.Marshal(name)
+			if err != nil {
+				return nil, ConfigMarshalError{err}
+			}
+			lines = append(lines, indent+strings.TrimSuffix(string(key), "\n")+":")
+			subLines, err := v.yamlExample(p, sub, docs)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, subLines...)
+			continue
+		}
+
+		b, err := yaml.Marshal(map[string]interface{}{name: m[name]})
+		if err != nil {
+			return nil, ConfigMarshalError{err}
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+			lines = append(lines, indent+line)
+		}
+	}
+	return lines, nil
+}
+
+// tomlExample returns the lines of the TOML example holding m, the table at
+// path: its values, then its tables.
+func (v *Viper) tomlExample(path []string, m map[string]interface{}, docs map[string]string) ([]string, error) {
+	var lines, tables []string
+	for _, name := range sortedMapKeys(m) {
+		key := strings.Join(append(path[:len(path):len(path)], name), v.keyDelim)
+		if sub, ok := m[name].(map[string]interface{}); ok && len(sub) > 0 {
+			tables = append(tables, name)
+			continue
+		}
+		s, ok := tomlValue(m[name])
+		if !ok {
+			return nil, ConfigMarshalError{fmt.Errorf("can't write the default of %q as TOML", key)}
+		}
+		lines = append(lines, docComment(docs[key], "")...)
+		lines = append(lines, tomlKey(name)+" = "+s)
+	}
+
+	for _, name := range tables {
+		p := append(path[:len(path):len(path)], name)
+		header := make([]string, len(p))
+		for i, part := range p {
+			header[i] = tomlKey(part)
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, docComment(docs[strings.Join(p, v.keyDelim)], "")...)
+		lines = append(lines, "["+strings.Join(header, ".")+"]")
+		sub, err := v.tomlExample(p, m[name].(map[string]interface{}), docs)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, sub...)
+	}
+	return lines, nil
+}
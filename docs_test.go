@@ -0,0 +1,94 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultWithDoc(t *testing.T) {
+	v := New()
+	v.SetDefaultWithDoc("Server.Port", 8080, "Port the HTTP listener binds to")
+	v.SetDefault("server.host", "localhost")
+	v.SetDefaultWithDoc("db.password", "changeme", "Password of the database user")
+	v.SetRequired("db.user")
+	v.MarkSecret("db.password")
+
+	assert.Equal(t, 8080, v.GetInt("server.port"))
+	assert.Equal(t, "Port the HTTP listener binds to", v.KeyDoc("server.port"))
+	assert.Equal(t, "", v.KeyDoc("server.host"))
+	schema := v.Schema()
+	for i, ks := range schema {
+		if ks.Default != nil {
+			assert.Contains(t, ks.DefaultOrigin, "SetDefault at ", ks.Key)
+		}
+		schema[i].DefaultOrigin = ""
+	}
+	assert.Equal(t, []KeySchema{
+		{Key: "db.password", Default: SecretRedacted, Doc: "Password of the database user"},
+		{Key: "db.user", Required: true},
+		{Key: "server.host", Default: "localhost"},
+		{Key: "server.port", Default: 8080, Doc: "Port the HTTP listener binds to"},
+	}, schema)
+}
+
+func TestSetDefaultWithDocBindStruct(t *testing.T) {
+	type Config struct {
+		Port int `mapstructure:"port" default:"8080" doc:"Port the HTTP listener binds to"`
+	}
+	v := New()
+	require.NoError(t, v.BindStruct(&Config{}))
+	assert.Equal(t, "Port the HTTP listener binds to", v.KeyDoc("port"))
+
+	require.NoError(t, v.RegisterExtension("auth", ExtensionSchema{
+		Defaults: map[string]interface{}{"timeout": "5s"},
+		Docs:     map[string]string{"timeout": "Timeout of token checks"},
+	}))
+	assert.Equal(t, "Timeout of token checks", v.KeyDoc("auth.timeout"))
+}
+
+func TestRenderExample(t *testing.T) {
+	v := New()
+	v.SetDefaultWithDoc("server", map[string]interface{}{"host": "localhost"}, "HTTP listener")
+	v.SetDefaultWithDoc("server.port", 8080, "Port the HTTP listener binds to\nUse 0 for any port")
+	v.SetDefault("name", "app")
+	v.SetDefaultWithDoc("tags", []string{"a", "b"}, "Tags of the instance")
+	v.Set("name", "override")
+
+	b, err := v.RenderExample("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, `name: app
+# HTTP listener
+server:
+  host: localhost
+  # Port the HTTP listener binds to
+  # Use 0 for any port
+  port: 8080
+# Tags of the instance
+tags:
+- a
+- b
+`, string(b))
+
+	b, err = v.RenderExample("toml")
+	require.NoError(t, err)
+	assert.Equal(t, `name = "app"
+# Tags of the instance
+tags = ["a", "b"]
+
+# HTTP listener
+[server]
+host = "localhost"
+# Port the HTTP listener binds to
+# Use 0 for any port
+port = 8080
+`, string(b))
+
+	b, err = v.RenderExample("json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "app", "server": {"host": "localhost", "port": 8080}, "tags": ["a", "b"]}`, string(b))
+
+	_, err = v.RenderExample("xml")
+	assert.Equal(t, UnsupportedConfigError("xml"), err)
+}
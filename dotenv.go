@@ -0,0 +1,193 @@
+package viper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseDotenv parses data as a dotenv file, following the common dotenv
+// format:
+//
+//	# comment
+//	export NAME=value       # inline comment
+//	QUOTED="line 1\nline 2" # escapes and ${NAME} expanded in double quotes
+//	LITERAL='${NOT_EXPANDED}'
+//	MULTILINE="line 1
+//	line 2"
+//
+// Lines may end with CRLF. Unquoted values are trimmed and end at a "#"
+// preceded by whitespace; references to other variables, of the file or of
+// the environment, are expanded in unquoted and double-quoted values.
+func parseDotenv(data []byte) (map[string]string, error) {
+	src := strings.Replace(string(data), "\r\n", "\n", -1)
+	src = strings.Replace(src, "\r", "\n", -1)
+	env := make(map[string]string)
+	lookup := func(name string) string {
+		if val, ok := env[name]; ok {
+			return val
+		}
+		return os.Getenv(name)
+	}
+
+	line := 1
+	for len(src) > 0 {
+		var text string
+		if i := strings.IndexByte(src, '\n'); i >= 0 {
+			text, src = src[:i], src[i+1:]
+		} else {
+			text, src = src, ""
+		}
+		start := line
+		line++
+
+		text = strings.TrimSpace(text)
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(text, "export ") || strings.HasPrefix(text, "export\t") {
+			text = strings.TrimSpace(text[len("export"):])
+		}
+		sep := strings.IndexAny(text, "=:")
+		if sep <= 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=value, got %q", start, text)
+		}
+		name := strings.TrimSpace(text[:sep])
+		if !isDotenvName(name) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", start, name)
+		}
+		raw := text[sep+1:]
+		value := strings.TrimLeft(raw, " \t")
+
+		if value == "" || !strings.ContainsRune(`"'`+"`", rune(value[0])) {
+			if strings.HasPrefix(value, "#") && len(value) < len(raw) {
+				value = ""
+			} else if i := strings.Index(value, " #"); i >= 0 {
+				value = value[:i]
+			} else if i := strings.Index(value, "\t#"); i >= 0 {
+				value = value[:i]
+			}
+			env[name] = expandDotenv(strings.TrimSpace(value), lookup, false)
+			continue
+		}
+
+		// Quoted values may span lines: read up to the closing quote.
+		quote := value[0]
+		value = value[1:]
+		end := closingQuote(value, quote)
+		for end < 0 && len(src) > 0 {
+			var next string
+			if i := strings.IndexByte(src, '\n'); i >= 0 {
+				next, src = src[:i], src[i+1:]
+			} else {
+				next, src = src, ""
+			}
+			line++
+			value += "\n" + next
+			end = closingQuote(value, quote)
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated quoted value of %s", start, name)
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && rest[0] != '#' {
+			return nil, fmt.Errorf("line %d: unexpected %q after the quoted value of %s", start, rest, name)
+		}
+		value = value[:end]
+		if quote == '"' {
+			value = expandDotenv(value, lookup, true)
+		}
+		env[name] = value
+	}
+	return env, nil
+}
+
+// isDotenvName tells whether name is a valid variable name.
+func isDotenvName(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && (r >= '0' && r <= '9' || r == '.' || r == '-')) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// closingQuote returns the index of the quote closing s, or -1. Quotes can
+// be escaped with a backslash within double quotes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// expandDotenv replaces the references of the form $NAME, ${NAME} and
+// ${NAME:-default} in s by the values lookup returns, and the escape
+// sequences, such as "\n" and "\"", if escapes is set. "\$" is a literal "$".
+func expandDotenv(s string, lookup func(string) string, escapes bool) string {
+	if !strings.ContainsAny(s, `$\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (escapes || s[i+1] == '$'):
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			ref := s[i+2 : i+end]
+			name, def := ref, ""
+			if j := strings.Index(ref, ":-"); j >= 0 {
+				name, def = ref[:j], ref[j+2:]
+			}
+			if val := lookup(name); val != "" {
+				b.WriteString(val)
+			} else {
+				b.WriteString(def)
+			}
+			i += end
+		case s[i] == '$':
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || j > i+1 && s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			if j == i+1 {
+				b.WriteByte('$')
+				continue
+			}
+			b.WriteString(lookup(s[i+1 : j]))
+			i = j - 1
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// dotenvValue returns s as a dotenv value, double-quoted if it wouldn't be
+// read back as is otherwise.
+func dotenvValue(s string) string {
+	if s == "" || strings.TrimSpace(s) == s && !strings.ContainsAny(s, "\"'`#$\\\n\r") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
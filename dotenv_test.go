@@ -0,0 +1,96 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigDotenv(t *testing.T) {
+	os.Setenv("VIPER_DOTENV_HOME", "/home/app")
+	defer os.Unsetenv("VIPER_DOTENV_HOME")
+
+	v := New()
+	v.SetConfigType("env")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("# generated on Windows\r\n"+
+		"PLAIN=value\r\n"+
+		"export EXPORTED=yes\r\n"+
+		"  SPACED = padded value  \r\n"+
+		"COMMENTED=value # a comment\r\n"+
+		"COLOR=#fff\r\n"+
+		"EMPTY=\r\n"+
+		"BLANK= # nothing\r\n"+
+		"DOUBLE=\"line 1\\nline 2 \\\"quoted\\\" # kept\" # comment\r\n"+
+		"SINGLE='${NOT_EXPANDED} \\n # kept'\r\n"+
+		"MULTILINE=\"-----BEGIN KEY-----\r\nabc\r\n-----END KEY-----\"\r\n"+
+		"BACKTICK=`it's \"raw\"`\r\n"+
+		"DATA_DIR=${VIPER_DOTENV_HOME}/data\r\n"+
+		"CACHE_DIR=\"$DATA_DIR/cache\"\r\n"+
+		"PRICE=\\$5 and ${UNSET_VIPER_VAR:-default}\r\n"+
+		"COLON: legacy\r\n")))
+
+	for key, want := range map[string]string{
+		"plain":     "value",
+		"exported":  "yes",
+		"spaced":    "padded value",
+		"commented": "value",
+		"color":     "#fff",
+		"empty":     "",
+		"blank":     "",
+		"double":    "line 1\nline 2 \"quoted\" # kept",
+		"single":    "${NOT_EXPANDED} \\n # kept",
+		"multiline": "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"backtick":  "it's \"raw\"",
+		"data_dir":  "/home/app/data",
+		"cache_dir": "/home/app/data/cache",
+		"price":     "$5 and default",
+		"colon":     "legacy",
+	} {
+		assert.Equal(t, want, v.GetString(key), key)
+	}
+}
+
+func TestReadConfigDotenvErrors(t *testing.T) {
+	for data, want := range map[string]string{
+		"A=1\nnot a variable\n":     `line 2: expected NAME=value, got "not a variable"`,
+		"A=1\n\n1A=2\n":             `line 3: invalid variable name "1A"`,
+		"A=\"open\nB=2\n":           "line 1: unterminated quoted value of A",
+		"A=1\nB='x' trailing\n":     `line 2: unexpected "trailing" after the quoted value of B`,
+		"A=\"1\n2\"\nbroken line\n": `line 3: expected NAME=value, got "broken line"`,
+	} {
+		v := New()
+		v.SetConfigType("env")
+		err := v.ReadConfig(bytes.NewBufferString(data))
+		assert.EqualError(t, err, "While parsing config: "+want, data)
+	}
+}
+
+func TestWriteConfigDotenvQuoting(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	v := New()
+	v.SetFs(fs)
+	v.Set("plain", "two words")
+	v.Set("multiline", "line 1\nline 2")
+	v.Set("special", `a "b" $c \d # e`)
+	v.Set("padded", " x ")
+	require.NoError(t, v.WriteConfigAs("/c.env"))
+
+	data, err := afero.ReadFile(fs, "/c.env")
+	require.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	assert.Contains(t, lines, "PLAIN=two words")
+	assert.Contains(t, lines, `MULTILINE="line 1\nline 2"`)
+
+	v2 := New()
+	v2.SetFs(fs)
+	v2.SetConfigFile("/c.env")
+	require.NoError(t, v2.ReadInConfig())
+	for _, key := range []string{"plain", "multiline", "special", "padded"} {
+		assert.Equal(t, v.GetString(key), v2.GetString(key), key)
+	}
+}
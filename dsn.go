@@ -0,0 +1,151 @@
+package viper
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// DSNError denotes a database configuration which can't be assembled into a
+// DSN by GetDSN.
+type DSNError struct {
+	Key string
+	err error
+}
+
+// Error returns the formatted DSN error.
+func (e DSNError) Error() string {
+	return fmt.Sprintf("invalid database config %q: %s", e.Key, e.err.Error())
+}
+
+// GetDSN builds the data source name passed to sql.Open from the subtree at
+// key:
+//
+//	db:
+//	  driver: postgres
+//	  host: db.example.com
+//	  port: 5432
+//	  user: app
+//	  password: file://secrets/db-password
+//	  name: orders
+//	  params:
+//	    sslmode: verify-full
+//
+// driver selects the format: postgres (or postgresql, pgx) and sqlserver (or
+// mssql) build URLs, mysql builds a go-sql-driver/mysql DSN and sqlite (or
+// sqlite3) the path given by name. User, password, database name and
+// parameters are escaped as needed, except for the mysql user and password:
+// the driver reads them as is, splitting them at the last "@", so a mysql
+// user may hold anything but a colon. Since keys are case-insensitive, params
+// may also be given as a query string, e.g. "parseTime=true&loc=UTC", for
+// drivers with case-sensitive parameters. Values are read with Get, so
+// references and file references are resolved, see EnableExpansion and
+// EnableFileReferences. GetDSN returns a DSNError if the driver is missing
+// or unsupported.
+func GetDSN(key string) (string, error) { return v.GetDSN(key) }
+func (v *Viper) GetDSN(key string) (string, error) {
+	if v == nil {
+		return "", DSNError{Key: key + ".driver", err: fmt.Errorf("no driver")}
+	}
+	sub := func(name string) string { return key + v.keyDelim + name }
+	driver := strings.ToLower(v.GetString(sub("driver")))
+	host, port := v.GetString(sub("host")), v.GetString(sub("port"))
+	user, password := v.GetString(sub("user")), v.GetString(sub("password"))
+	name := v.GetString(sub("name"))
+	if name == "" {
+		name = v.GetString(sub("database"))
+	}
+	params, err := v.dsnParams(sub("params"))
+	if err != nil {
+		return "", DSNError{Key: sub("params"), err: err}
+	}
+
+	addr := host
+	if port != "" {
+		addr = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		addr = "[" + host + "]"
+	}
+	var userinfo *url.Userinfo
+	switch {
+	case password != "":
+		userinfo = url.UserPassword(user, password)
+	case user != "":
+		userinfo = url.User(user)
+	}
+
+	switch driver {
+	case "postgres", "postgresql", "pgx":
+		u := url.URL{Scheme: "postgres", User: userinfo, Host: addr, RawQuery: params.Encode()}
+		if name != "" {
+			u.Path = "/" + name
+		}
+		return u.String(), nil
+
+	case "sqlserver", "mssql":
+		if name != "" {
+			params.Set("database", name)
+		}
+		u := url.URL{Scheme: "sqlserver", User: userinfo, Host: addr, RawQuery: params.Encode()}
+		return u.String(), nil
+
+	case "mysql":
+		if strings.Contains(user, ":") {
+			return "", DSNError{Key: sub("user"), err: fmt.Errorf("mysql user names can't hold a colon")}
+		}
+		var b strings.Builder
+		if userinfo != nil {
+			b.WriteString(user)
+			if password != "" {
+				b.WriteString(":" + password)
+			}
+			b.WriteString("@")
+		}
+		if addr != "" {
+			b.WriteString("tcp(" + addr + ")")
+		}
+		b.WriteString("/" + url.PathEscape(name))
+		if len(params) > 0 {
+			b.WriteString("?" + params.Encode())
+		}
+		return b.String(), nil
+
+	case "sqlite", "sqlite3":
+		if name == "" {
+			return "", DSNError{Key: sub("name"), err: fmt.Errorf("no database file")}
+		}
+		if len(params) > 0 {
+			u := url.URL{Path: name}
+			return "file:" + u.EscapedPath() + "?" + params.Encode(), nil
+		}
+		return name, nil
+
+	case "":
+		return "", DSNError{Key: sub("driver"), err: fmt.Errorf("no driver")}
+	}
+	return "", DSNError{Key: sub("driver"), err: fmt.Errorf("unsupported driver %q", driver)}
+}
+
+// dsnParams returns the parameters of a DSN given at key as a map or a
+// query string.
+func (v *Viper) dsnParams(key string) (url.Values, error) {
+	switch val := v.Get(key).(type) {
+	case nil:
+		return url.Values{}, nil
+	case string:
+		return url.ParseQuery(val)
+	default:
+		m, err := cast.ToStringMapE(val)
+		if err != nil {
+			return nil, err
+		}
+		params := url.Values{}
+		for name, value := range m {
+			params.Set(name, cast.ToString(value))
+		}
+		return params, nil
+	}
+}
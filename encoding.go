@@ -0,0 +1,86 @@
+package viper
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF32BE = []byte{0x00, 0x00, 0xFE, 0xFF}
+	bomUTF32LE = []byte{0xFF, 0xFE, 0x00, 0x00}
+)
+
+// EncodingError denotes a config file which isn't valid UTF-8 text.
+type EncodingError struct {
+	Line int
+	err  error
+}
+
+// Error returns the formatted encoding error.
+func (e EncodingError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.err.Error())
+	}
+	return e.err.Error()
+}
+
+// toUTF8 returns data, the contents of a config file, as UTF-8 without a
+// byte order mark: files saved by Windows editors often start with one,
+// which the parsers would otherwise choke on, or are even encoded as UTF-16,
+// which is converted if it has a byte order mark. toUTF8 returns an
+// EncodingError for other encodings.
+func toUTF8(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		data = data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF32BE), bytes.HasPrefix(data, bomUTF32LE):
+		return nil, EncodingError{err: fmt.Errorf("config is encoded as UTF-32, save it as UTF-8")}
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data[len(bomUTF16BE):], true)
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data[len(bomUTF16LE):], false)
+	case len(data) >= 2 && (data[0] == 0) != (data[1] == 0):
+		// ASCII encoded as UTF-16 without a byte order mark
+		return nil, EncodingError{err: fmt.Errorf("config seems to be encoded as UTF-16 without a byte order mark, save it as UTF-8")}
+	}
+
+	if utf8.Valid(data) {
+		return data, nil
+	}
+	i := 0
+	for i < len(data) {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		i += size
+	}
+	line := bytes.Count(data[:i], []byte{'\n'}) + 1
+	return nil, EncodingError{Line: line, err: fmt.Errorf("invalid UTF-8 byte %#x, save the config as UTF-8", data[i])}
+}
+
+// decodeUTF16 returns data, UTF-16 text without a byte order mark, as UTF-8.
+func decodeUTF16(data []byte, bigEndian bool) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, EncodingError{err: fmt.Errorf("truncated UTF-16 config")}
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUTF16 returns s as UTF-16 with a byte order mark.
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune("\ufeff" + s)) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
+
+func TestReadConfigEncoding(t *testing.T) {
+	for name, test := range map[string]struct {
+		configType string
+		data       []byte
+	}{
+		"yaml with UTF-8 BOM": {"yaml", append(bomUTF8, "name: café\nport: 80\n"...)},
+		"json with UTF-8 BOM": {"json", append(bomUTF8, `{"name": "café", "port": 80}`...)},
+		"toml with UTF-8 BOM": {"toml", append(bomUTF8, "name = \"café\"\nport = 80\n"...)},
+		"UTF-16LE":            {"yaml", encodeUTF16("name: café\r\nport: 80\r\n", false)},
+		"UTF-16BE":            {"json", encodeUTF16(`{"name": "café", "port": 80}`, true)},
+	} {
+		v := New()
+		v.SetConfigType(test.configType)
+		require.NoError(t, v.ReadConfig(bytes.NewReader(test.data)), name)
+		assert.Equal(t, "café", v.GetString("name"), name)
+		assert.Equal(t, 80, v.GetInt("port"), name)
+	}
+}
+
+func TestReadConfigEncodingErrors(t *testing.T) {
+	for data, want := range map[string]string{
+		"name: caf\xe9\n":               "line 1: invalid UTF-8 byte 0xe9, save the config as UTF-8",
+		"a: 1\nb: 2\nname: caf\xe9\n":   "line 3: invalid UTF-8 byte 0xe9, save the config as UTF-8",
+		"n\x00a\x00m\x00e\x00:\x00":     "config seems to be encoded as UTF-16 without a byte order mark, save it as UTF-8",
+		"\xff\xfe\x00\x00n\x00\x00\x00": "config is encoded as UTF-32, save it as UTF-8",
+		"\xfe\xff\x00n\x00":             "truncated UTF-16 config",
+	} {
+		v := New()
+		v.SetConfigType("yaml")
+		err := v.ReadConfig(bytes.NewBufferString(data))
+		require.IsType(t, ConfigParseError{}, err, data)
+		assert.EqualError(t, err, "While parsing config: "+want)
+	}
+}
+
+func TestReadInConfigBOM(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", append(bomUTF8, "server:\n  port: 8080\n"...), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, 8080, v.GetInt("server.port"))
+	assert.Equal(t, []string{"server.port"}, v.AllKeys())
+}
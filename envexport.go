@@ -0,0 +1,85 @@
+package viper
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ExportEnvBindings writes the environment variables the configuration reads
+// as a dotenv file, e.g. to generate the env section of deployment
+// manifests, see EnvBindings. Each variable is preceded by a comment giving
+// its key and the layer the current value is read from, see Origin, and set
+// to that value. Variables are sorted by name, and secret keys are written
+// as SecretRedacted, see MarkSecret.
+//
+//	# server.port (default SetDefault at /src/app/main.go:42)
+//	APP_SERVER_PORT=8080
+func ExportEnvBindings(w io.Writer) error { return v.ExportEnvBindings(w) }
+func (v *Viper) ExportEnvBindings(w io.Writer) error {
+	bindings := v.EnvBindings()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for i, name := range names {
+		key := bindings[name]
+		origin := "unset"
+		if src, detail := v.Origin(key); src != SourceNone {
+			origin = strings.TrimSpace(src.String() + " " + detail)
+		}
+		var value string
+		switch val := v.Get(key); {
+		case v.IsSecret(key):
+			value = SecretRedacted
+		case val != nil:
+			value = dotenvValue(fmt.Sprint(val))
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s (%s)\n%s=%s\n", key, origin, name, value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// EnvBindings returns the environment variables the configuration reads,
+// mapped to their keys, as listed by ExportEnvBindings: the variables bound
+// through BindEnv, along with those AutomaticEnv reads for every key, or the
+// variables SetEnvKeyMapper maps to keys if set, less the keys reserved from
+// the environment.
+func EnvBindings() map[string]string { return v.EnvBindings() }
+func (v *Viper) EnvBindings() map[string]string {
+	keys := v.AllKeys()
+	bindings := map[string]string{}
+
+	v.mu.RLock()
+	for key, name := range v.env {
+		bindings[v.envName(name)] = key
+	}
+	if v.automaticEnvApplied && v.envKeyMapper == nil {
+		for _, key := range keys {
+			if _, bound := v.env[key]; !bound {
+				bindings[v.envName(v.mergeWithEnvPrefix(key))] = key
+			}
+		}
+	}
+	if v.automaticEnvApplied && v.envKeyMapper != nil {
+		for key, name := range v.mappedEnv() {
+			bindings[name] = key
+		}
+	}
+	v.mu.RUnlock()
+
+	for name, key := range bindings {
+		if v.IsReserved(key, SourceEnv) {
+			delete(bindings, name)
+		}
+	}
+	return bindings
+}
@@ -0,0 +1,51 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEnvBindings(t *testing.T) {
+	os.Setenv("APP_SERVER_HOST", "example.com")
+	defer os.Unsetenv("APP_SERVER_HOST")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetDefault("server.host", "localhost")
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("internal.debug", false)
+	v.ReservePrefix("internal")
+	v.Set("greeting", "hello world")
+	require.NoError(t, v.BindEnv("db.password", "DATABASE_PASSWORD"))
+	v.MarkSecret("db.password")
+	os.Setenv("DATABASE_PASSWORD", "hunter2")
+	defer os.Unsetenv("DATABASE_PASSWORD")
+
+	var buf bytes.Buffer
+	require.NoError(t, v.ExportEnvBindings(&buf))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "# greeting (override)\nAPP_GREETING=hello world\n\n"), out)
+	assert.True(t, strings.HasSuffix(out, "\n\n# db.password (env DATABASE_PASSWORD)\nDATABASE_PASSWORD=***\n"), out)
+	assert.Contains(t, out, "# server.host (env APP_SERVER_HOST)\nAPP_SERVER_HOST=example.com\n")
+	assert.Contains(t, out, "# server.port (default SetDefault at ")
+	assert.Contains(t, out, "\nAPP_SERVER_PORT=8080\n")
+	assert.NotContains(t, out, "INTERNAL")
+
+	// the output is a dotenv file
+	env, err := parseDotenv(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", env["APP_GREETING"])
+
+	v = New()
+	require.NoError(t, v.BindEnv("token"))
+	buf.Reset()
+	require.NoError(t, v.ExportEnvBindings(&buf))
+	assert.Equal(t, "# token (unset)\nTOKEN=\n", buf.String())
+}
@@ -0,0 +1,98 @@
+package viper
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// eventState holds the change notifications held back by SuspendEvents.
+type eventState struct {
+	mu        sync.Mutex
+	suspended int
+	changed   bool
+	// last config file event received while suspended
+	fileEvent *fsnotify.Event
+}
+
+// OnChange sets a function called after every change to the configuration:
+// on Set and SetDefault, on new env, flag and alias bindings, and whenever
+// configuration is read, merged or imported. Use SuspendEvents to coalesce
+// the notifications of a sequence of changes.
+func OnChange(run func()) { v.OnChange(run) }
+func (v *Viper) OnChange(run func()) {
+	v.onChange = run
+}
+
+// SuspendEvents holds back the OnChange and OnConfigChange notifications
+// until ResumeEvents is called, e.g. while applying a batch of Set calls or
+// re-reading several config files. Changes made in between are then
+// reported by a single notification. Calls can be nested: notifications
+// resume with the last ResumeEvents call.
+func SuspendEvents() { v.SuspendEvents() }
+func (v *Viper) SuspendEvents() {
+	v.events.mu.Lock()
+	v.events.suspended++
+	v.events.mu.Unlock()
+}
+
+// ResumeEvents undoes a SuspendEvents call. If the configuration changed
+// while events were suspended, OnChange is called once, and OnConfigChange
+// is called with the last config file event received.
+func ResumeEvents() { v.ResumeEvents() }
+func (v *Viper) ResumeEvents() {
+	v.events.mu.Lock()
+	if v.events.suspended > 0 {
+		v.events.suspended--
+	}
+	if v.events.suspended > 0 {
+		v.events.mu.Unlock()
+		return
+	}
+	changed, fileEvent := v.events.changed, v.events.fileEvent
+	v.events.changed, v.events.fileEvent = false, nil
+	v.events.mu.Unlock()
+
+	if changed {
+		v.recompile()
+		if v.onChange != nil {
+			v.onChange()
+		}
+	}
+	if fileEvent != nil && v.onConfigChange != nil {
+		v.onConfigChange(*fileEvent)
+	}
+}
+
+// emitChange updates the values returned by Compile and calls the OnChange
+// function, unless events are suspended.
+func (v *Viper) emitChange() {
+	v.events.mu.Lock()
+	if v.events.suspended > 0 {
+		v.events.changed = true
+		v.events.mu.Unlock()
+		return
+	}
+	v.events.mu.Unlock()
+
+	v.recompile()
+	if v.onChange != nil {
+		v.onChange()
+	}
+}
+
+// emitConfigChange calls the OnConfigChange function, unless events are
+// suspended.
+func (v *Viper) emitConfigChange(event fsnotify.Event) {
+	v.events.mu.Lock()
+	if v.events.suspended > 0 {
+		v.events.fileEvent = &event
+		v.events.mu.Unlock()
+		return
+	}
+	v.events.mu.Unlock()
+
+	if v.onConfigChange != nil {
+		v.onConfigChange(event)
+	}
+}
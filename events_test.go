@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnChange(t *testing.T) {
+	v := New()
+	calls := 0
+	v.OnChange(func() { calls++ })
+
+	v.Set("a", 1)
+	v.SetDefault("b", 2)
+	assert.Equal(t, 2, calls)
+
+	v.SetConfigType("yaml")
+	assert.NoError(t, v.ReadConfig(bytes.NewBufferString("c: 3\n")))
+	assert.Equal(t, 3, calls)
+}
+
+func TestSuspendEvents(t *testing.T) {
+	v := New()
+	calls := 0
+	v.OnChange(func() {
+		calls++
+		// all changes are visible by the time the notification is sent
+		assert.Equal(t, 3, v.GetInt("c"))
+	})
+
+	v.SuspendEvents()
+	v.Set("a", 1)
+	v.Set("b", 2)
+	v.SuspendEvents()
+	v.Set("c", 3)
+	v.ResumeEvents()
+	assert.Equal(t, 0, calls)
+	v.ResumeEvents()
+	assert.Equal(t, 1, calls)
+
+	// nothing changed, nothing to report
+	v.SuspendEvents()
+	v.ResumeEvents()
+	assert.Equal(t, 1, calls)
+
+	// unbalanced calls are ignored
+	v.ResumeEvents()
+	v.Set("c", 3)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSuspendEventsConfigChange(t *testing.T) {
+	v := New()
+	var events []fsnotify.Event
+	v.OnConfigChange(func(e fsnotify.Event) { events = append(events, e) })
+
+	v.SuspendEvents()
+	v.emitConfigChange(fsnotify.Event{Name: "/etc/app/a.yaml", Op: fsnotify.Write})
+	v.emitConfigChange(fsnotify.Event{Name: "/etc/app/b.yaml", Op: fsnotify.Write})
+	assert.Empty(t, events)
+	v.ResumeEvents()
+
+	assert.Equal(t, []fsnotify.Event{{Name: "/etc/app/b.yaml", Op: fsnotify.Write}}, events)
+}
@@ -0,0 +1,218 @@
+package viper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// ExpansionError denotes a reference in a value which can't be expanded.
+type ExpansionError struct {
+	// Reference is the expression which can't be expanded, e.g. "${db.host}".
+	Reference string
+	err       error
+}
+
+// Error returns the formatted expansion error.
+func (e ExpansionError) Error() string {
+	return fmt.Sprintf("can't expand %s: %s", e.Reference, e.err)
+}
+
+// EnableExpansion makes Get, and the many Get____ methods built on it,
+// expand the references in string values, including within maps and slices:
+//
+//	url: "https://${APP_HOST}:${ports.https}/api"
+//
+// A reference "${name}" is replaced by the value of the key name, found with
+// the usual precedence and itself expanded, or else by the value of the
+// environment variable name. "${name:-fallback}" is replaced by fallback if
+// name is unset or empty. "$${" yields a literal "${". A value consisting of
+// a single reference takes the value of the referenced key as is, e.g. an
+// int. Values which can't be expanded, because a reference is unset or part
+// of a cycle, are logged and returned unexpanded; use Expand to get the
+// error.
+func EnableExpansion() { v.EnableExpansion() }
+func (v *Viper) EnableExpansion() {
+	v.expansion = true
+	v.markChanged()
+}
+
+// Expand expands the references in s, see EnableExpansion.
+func Expand(s string) (string, error) { return v.Expand(s) }
+func (v *Viper) Expand(s string) (string, error) {
+	e := &expander{v: v}
+	val, err := e.expand(s)
+	if err != nil {
+		return s, err
+	}
+	return cast.ToStringE(val)
+}
+
+// expandValue returns val, the value of key, expanded.
+func (v *Viper) expandValue(key string, val interface{}) interface{} {
+	e := &expander{v: v, visiting: []string{key}}
+	expanded, err := e.value(val)
+	if err != nil {
+		jww.ERROR.Printf("value of %q: %s", key, err)
+		return val
+	}
+	return expanded
+}
+
+type expander struct {
+	v *Viper
+	// keys being expanded, to detect cycles
+	visiting []string
+}
+
+func (e *expander) value(val interface{}) (interface{}, error) {
+	switch val := val.(type) {
+	case string:
+		return e.expand(val)
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			x, err := e.expand(s)
+			if err != nil {
+				return nil, err
+			}
+			if out[i], err = cast.ToStringE(x); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			x, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			x, err := e.value(item)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = x
+		}
+		return out, nil
+	}
+	return val, nil
+}
+
+// expand expands the references in s. If s is a single reference, the
+// referenced value is returned as is.
+func (e *expander) expand(s string) (interface{}, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if ref, ok := parseReference(s); ok && len(ref.expr) == len(s) {
+		return e.resolve(ref)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			ref, ok := parseReference(s[i:])
+			if !ok {
+				b.WriteString(s[i:])
+				i = len(s)
+				continue
+			}
+			val, err := e.resolve(ref)
+			if err != nil {
+				return nil, err
+			}
+			str, err := cast.ToStringE(val)
+			if err != nil {
+				return nil, ExpansionError{ref.expr, fmt.Errorf("%T isn't a scalar", val)}
+			}
+			b.WriteString(str)
+			i += len(ref.expr)
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// resolve returns the value of the reference.
+func (e *expander) resolve(ref reference) (interface{}, error) {
+	key := e.v.normalizeKey(ref.name)
+	for i, k := range e.visiting {
+		if k == key {
+			cycle := append(append([]string{}, e.visiting[i:]...), key)
+			return nil, ExpansionError{ref.expr, fmt.Errorf("reference cycle %s", strings.Join(cycle, " -> "))}
+		}
+	}
+
+	if val := e.v.find(key); val != nil {
+		e.visiting = append(e.visiting, key)
+		expanded, err := e.value(val)
+		e.visiting = e.visiting[:len(e.visiting)-1]
+		if err != nil {
+			return nil, err
+		}
+		if !ref.hasFallback || expanded != "" {
+			return expanded, nil
+		}
+	} else if env, ok := os.LookupEnv(ref.name); ok && (env != "" || !ref.hasFallback) {
+		return env, nil
+	}
+
+	if ref.hasFallback {
+		return e.expand(ref.fallback)
+	}
+	return nil, ExpansionError{ref.expr, fmt.Errorf("%s is not set", ref.name)}
+}
+
+type reference struct {
+	// expr is the whole reference, e.g. "${name:-fallback}"
+	expr, name, fallback string
+	hasFallback          bool
+}
+
+// parseReference parses the reference s starts with, which may be followed
+// by other text.
+func parseReference(s string) (reference, bool) {
+	depth := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i += 2
+		case s[i] == '}':
+			depth--
+			i++
+			if depth == 0 {
+				ref := reference{expr: s[:i], name: s[2 : i-1]}
+				if j := strings.Index(ref.name, ":-"); j >= 0 {
+					ref.name, ref.fallback, ref.hasFallback = ref.name[:j], ref.name[j+2:], true
+				}
+				ref.name = strings.TrimSpace(ref.name)
+				if ref.name == "" || strings.ContainsAny(ref.name, "${}") {
+					return reference{}, false
+				}
+				return ref, true
+			}
+		default:
+			i++
+		}
+	}
+	return reference{}, false
+}
@@ -0,0 +1,80 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var expandYAML = []byte(`
+url: "https://${VIPER_TEST_HOST}:${ports.https}/api"
+port: ${ports.https}
+ports:
+  https: 8443
+  admin: "${ports.https:-1}0"
+home: ${VIPER_TEST_UNSET:-${VIPER_TEST_HOST}}
+literal: "$${ports.https}"
+hosts:
+  - ${VIPER_TEST_HOST}
+  - backup
+db:
+  url: "postgres://${db.user}@${VIPER_TEST_HOST}"
+  user: ${DB_USER:-steve}
+`)
+
+func TestExpansion(t *testing.T) {
+	os.Setenv("VIPER_TEST_HOST", "localhost")
+	defer os.Unsetenv("VIPER_TEST_HOST")
+
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(expandYAML)))
+	assert.Equal(t, "${ports.https}", v.Get("port"))
+
+	v.EnableExpansion()
+	assert.Equal(t, "https://localhost:8443/api", v.GetString("url"))
+	assert.Equal(t, 8443, v.Get("port"))
+	assert.Equal(t, "84430", v.GetString("ports.admin"))
+	assert.Equal(t, "localhost", v.GetString("home"))
+	assert.Equal(t, "${ports.https}", v.GetString("literal"))
+	assert.Equal(t, []interface{}{"localhost", "backup"}, v.Get("hosts"))
+	assert.Equal(t, map[string]interface{}{
+		"url":  "postgres://steve@localhost",
+		"user": "steve",
+	}, v.AllSettings()["db"])
+
+	// references follow the usual precedence
+	v.Set("ports.https", 443)
+	assert.Equal(t, "https://localhost:443/api", v.GetString("url"))
+
+	s, err := v.Expand("${db.url}/app")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://steve@localhost/app", s)
+}
+
+func TestExpansionErrors(t *testing.T) {
+	v := New()
+	v.EnableExpansion()
+	v.Set("a", "${b}")
+	v.Set("b", "x${a}")
+	v.Set("c", "${VIPER_TEST_UNSET}")
+	v.Set("m", map[string]interface{}{"x": 1})
+
+	// values which can't be expanded are returned as is
+	assert.Equal(t, "${b}", v.Get("a"))
+	assert.Equal(t, "${VIPER_TEST_UNSET}", v.Get("c"))
+
+	_, err := v.Expand("${a}")
+	assert.EqualError(t, err, "can't expand ${a}: reference cycle a -> b -> a")
+	_, err = v.Expand("${c}")
+	assert.EqualError(t, err, "can't expand ${VIPER_TEST_UNSET}: VIPER_TEST_UNSET is not set")
+	_, err = v.Expand("m is ${m}")
+	assert.IsType(t, ExpansionError{}, err)
+
+	s, err := v.Expand("unterminated ${a")
+	require.NoError(t, err)
+	assert.Equal(t, "unterminated ${a", s)
+}
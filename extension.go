@@ -0,0 +1,132 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// ExtensionSchema describes the configuration of an extension, e.g. a
+// plugin, registered below its namespace through RegisterExtension. Keys are
+// relative to the namespace.
+type ExtensionSchema struct {
+	// Defaults of the keys of the extension, see SetDefault.
+	Defaults map[string]interface{}
+	// Descriptions of the keys of the extension, see SetDefaultWithDoc.
+	Docs map[string]string
+	// Keys of the extension that must be set, see SetRequired.
+	Required []string
+	// Validators of the values of the keys of the extension, see
+	// SetValidator.
+	Validators map[string]func(value interface{}) error
+	// OnChange, if set, is called with the configuration of the extension
+	// when it changes on a config file reload triggered by WatchConfig.
+	OnChange func(config *View)
+}
+
+type extension struct {
+	name     string
+	onChange func(config *View)
+}
+
+// RegisterExtension registers the configuration schema of an extension
+// below the namespace name, e.g. "auth" for the keys "auth.*". The defaults,
+// descriptions, required keys and validators of the schema are registered
+// with the namespace prepended, so that Validate checks the configuration of
+// all extensions along with the rest, and the configuration of the extension
+// is handed to it through Extension and the OnChange function of the schema.
+// Registering a namespace twice returns an error.
+// RegisterExtension is case-insensitive for a key.
+func RegisterExtension(name string, schema ExtensionSchema) error {
+	return v.RegisterExtension(name, schema)
+}
+func (v *Viper) RegisterExtension(name string, schema ExtensionSchema) error {
+	name = v.normalizeKey(name)
+	if name == "" {
+		return fmt.Errorf("RegisterExtension requires a namespace")
+	}
+	if v.extensionIndex(name) >= 0 {
+		return fmt.Errorf("extension %q is already registered", name)
+	}
+
+	key := func(key string) string { return name + v.keyDelim + key }
+	for k, value := range schema.Defaults {
+		if err := v.setDefault(key(k), value, defaultOrigin{kind: DefaultSchema, detail: name}); err != nil {
+			jww.ERROR.Println(err)
+		}
+	}
+	for k, doc := range schema.Docs {
+		v.setDoc(key(k), doc)
+	}
+	for _, k := range schema.Required {
+		v.SetRequired(key(k))
+	}
+	for k, fn := range schema.Validators {
+		v.SetValidator(key(k), fn)
+	}
+	v.extensions = append(v.extensions, extension{name: name, onChange: schema.OnChange})
+	return nil
+}
+
+// Extension returns a View on the configuration of the extension registered
+// below the namespace name, or nil if there is none.
+// Extension is case-insensitive for a key.
+func Extension(name string) *View { return v.Extension(name) }
+func (v *Viper) Extension(name string) *View {
+	name = v.normalizeKey(name)
+	if v.extensionIndex(name) < 0 {
+		return nil
+	}
+	return v.SubView(name)
+}
+
+// Extensions returns the sorted namespaces of the registered extensions.
+func Extensions() []string { return v.Extensions() }
+func (v *Viper) Extensions() []string {
+	names := make([]string, len(v.extensions))
+	for i, ext := range v.extensions {
+		names[i] = ext.name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (v *Viper) extensionIndex(name string) int {
+	for i, ext := range v.extensions {
+		if ext.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// extensionSettings returns the configuration of the extensions with an
+// OnChange function, or nil if there is none.
+func (v *Viper) extensionSettings() map[string]map[string]interface{} {
+	var settings map[string]map[string]interface{}
+	for _, ext := range v.extensions {
+		if ext.onChange == nil {
+			continue
+		}
+		if settings == nil {
+			settings = map[string]map[string]interface{}{}
+		}
+		settings[ext.name] = v.SubView(ext.name).AllSettings()
+	}
+	return settings
+}
+
+// dispatchExtensionChanges calls the OnChange functions of the extensions
+// whose configuration differs between the given extensionSettings results.
+func (v *Viper) dispatchExtensionChanges(before, after map[string]map[string]interface{}) {
+	for _, ext := range v.extensions {
+		if ext.onChange == nil {
+			continue
+		}
+		if !reflect.DeepEqual(before[ext.name], after[ext.name]) {
+			ext.onChange(v.SubView(ext.name))
+		}
+	}
+}
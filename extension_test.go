@@ -0,0 +1,55 @@
+package viper
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterExtension(t *testing.T) {
+	v := New()
+	var changes []map[string]interface{}
+	require.NoError(t, v.RegisterExtension("Auth", ExtensionSchema{
+		Defaults: map[string]interface{}{"timeout": "5s"},
+		Required: []string{"provider"},
+		Validators: map[string]func(interface{}) error{
+			"retries": func(value interface{}) error {
+				if cast.ToInt(value) > 3 {
+					return errors.New("too many retries")
+				}
+				return nil
+			},
+		},
+		OnChange: func(config *View) { changes = append(changes, config.AllSettings()) },
+	}))
+	require.NoError(t, v.RegisterExtension("cache", ExtensionSchema{}))
+	assert.Error(t, v.RegisterExtension("auth", ExtensionSchema{}))
+	assert.Equal(t, []string{"auth", "cache"}, v.Extensions())
+
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("auth:\n  retries: 5\nname: steve\n")))
+	assert.Equal(t, MultiError{
+		RequiredKeyError("auth.provider"),
+		ConstraintError{Key: "auth.retries", Value: 5, err: errors.New("too many retries")},
+	}, v.Validate())
+
+	config := v.Extension("auth")
+	require.NotNil(t, config)
+	assert.Equal(t, "5s", config.GetString("timeout"))
+	assert.Nil(t, v.Extension("missing"))
+
+	// only the extensions whose configuration changed are notified
+	before := v.extensionSettings()
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("auth:\n  provider: ldap\nname: stevens\n")))
+	v.dispatchExtensionChanges(before, v.extensionSettings())
+	before = v.extensionSettings()
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("auth:\n  provider: ldap\nname: steve\n")))
+	v.dispatchExtensionChanges(before, v.extensionSettings())
+
+	assert.Equal(t, []map[string]interface{}{{"provider": "ldap", "timeout": "5s"}}, changes)
+	assert.NoError(t, v.Validate())
+}
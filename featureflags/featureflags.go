@@ -0,0 +1,135 @@
+// Package featureflags exposes a feature flag client, such as an OpenFeature
+// or LaunchDarkly client, to Viper as a remote provider, so that flags and
+// static configuration are read through the same API.
+//
+//	featureflags.Register("flags", myClient)
+//
+//	viper.AddRemoteProvider("flags", "launchdarkly", "features")
+//	viper.SetConfigType("json")
+//	err := viper.ReadRemoteConfig()
+//	enabled := viper.GetBool("features.new-checkout")
+//
+// Flags are served as a JSON document (valid YAML as well), nested under the
+// key given as the provider path; use "/" to serve them at the root.
+// Their values are only visible through Viper: like all remote providers, the
+// layer can't be written to.
+package featureflags
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Client evaluates feature flags. SDK clients are adapted with a few lines,
+// e.g. by evaluating the flags of an OpenFeature client for a given
+// evaluation context, or by returning the flag state map of LaunchDarkly's
+// AllFlagsState.
+type Client interface {
+	// AllFlags returns the current value of every flag, by flag name.
+	AllFlags() (map[string]interface{}, error)
+}
+
+// Notifier is implemented by clients able to signal flag changes, e.g. from
+// OpenFeature configuration changed events or a LaunchDarkly flag tracker.
+// Clients that don't implement it are polled.
+type Notifier interface {
+	// OnChange registers a function called whenever a flag value changes.
+	OnChange(run func())
+}
+
+// PollInterval is the interval at which clients not implementing Notifier
+// are polled by WatchRemoteConfigOnChannel.
+var PollInterval = 30 * time.Second
+
+// Register makes client available to AddRemoteProvider under name.
+func Register(name string, client Client) {
+	viper.RegisterRemoteConfigProvider(name, &remoteConfigProvider{client: client})
+}
+
+type remoteConfigProvider struct {
+	client Client
+}
+
+func (rc *remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	flags, err := rc.client.AllFlags()
+	if err != nil {
+		return nil, err
+	}
+	b, err := encode(flags, rp.Path())
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (rc *remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return rc.Get(rp)
+}
+
+func (rc *remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	quit := make(chan bool)
+	viperResponsCh := make(chan *viper.RemoteResponse)
+
+	changed := make(chan struct{}, 1)
+	notifier, notifies := rc.client.(Notifier)
+	if notifies {
+		notifier.OnChange(func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	go func() {
+		var last map[string]interface{}
+		for {
+			var tick <-chan time.Time
+			if !notifies {
+				tick = time.After(PollInterval)
+			}
+			select {
+			case <-quit:
+				return
+			case <-changed:
+			case <-tick:
+			}
+
+			flags, err := rc.client.AllFlags()
+			if err == nil && reflect.DeepEqual(flags, last) {
+				continue
+			}
+			resp := &viper.RemoteResponse{Error: err}
+			if err == nil {
+				last = flags
+				resp.Value, resp.Error = encode(flags, rp.Path())
+			}
+			select {
+			case <-quit:
+				return
+			case viperResponsCh <- resp:
+			}
+		}
+	}()
+	return viperResponsCh, quit
+}
+
+// encode returns the JSON document of flags, nested under the given
+// delimited path.
+func encode(flags map[string]interface{}, path string) ([]byte, error) {
+	var doc interface{} = flags
+	path = strings.Trim(path, "/")
+	if path != "" {
+		keys := strings.Split(path, ".")
+		for i := len(keys) - 1; i >= 0; i-- {
+			doc = map[string]interface{}{keys[i]: doc}
+		}
+	}
+	return json.Marshal(doc)
+}
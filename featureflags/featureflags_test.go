@@ -0,0 +1,75 @@
+package featureflags
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClient struct {
+	mu       sync.Mutex
+	flags    map[string]interface{}
+	onChange func()
+}
+
+func (c *testClient) AllFlags() (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flags := make(map[string]interface{}, len(c.flags))
+	for k, val := range c.flags {
+		flags[k] = val
+	}
+	return flags, nil
+}
+
+func (c *testClient) OnChange(run func()) {
+	c.onChange = run
+}
+
+func (c *testClient) set(flag string, value interface{}) {
+	c.mu.Lock()
+	c.flags[flag] = value
+	c.mu.Unlock()
+	c.onChange()
+}
+
+type testRemoteProvider struct{ path string }
+
+func (rp testRemoteProvider) Provider() string      { return "flags" }
+func (rp testRemoteProvider) Endpoint() string      { return "test" }
+func (rp testRemoteProvider) Path() string          { return rp.path }
+func (rp testRemoteProvider) SecretKeyring() string { return "" }
+
+func TestReadFlags(t *testing.T) {
+	Register("flags", &testClient{flags: map[string]interface{}{"new-checkout": true, "max-items": 5}})
+
+	v := viper.New()
+	require.NoError(t, v.AddRemoteProvider("flags", "test", "features"))
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.True(t, v.GetBool("features.new-checkout"))
+	assert.Equal(t, 5, v.GetInt("features.max-items"))
+}
+
+func TestWatchFlags(t *testing.T) {
+	client := &testClient{flags: map[string]interface{}{"new-checkout": false}}
+	rc := &remoteConfigProvider{client: client}
+
+	respc, quit := rc.WatchChannel(testRemoteProvider{path: "/"})
+	defer close(quit)
+
+	client.set("new-checkout", true)
+	resp := <-respc
+	require.NoError(t, resp.Error)
+	assert.JSONEq(t, `{"new-checkout": true}`, string(resp.Value))
+
+	r, err := rc.Get(testRemoteProvider{path: "a.b"})
+	require.NoError(t, err)
+	b, _ := ioutil.ReadAll(r)
+	assert.JSONEq(t, `{"a": {"b": {"new-checkout": true}}}`, string(b))
+}
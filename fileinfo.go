@@ -0,0 +1,60 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// FileInfo describes a configuration file that contributed to the config
+// registry.
+type FileInfo struct {
+	// Path of the file, as read from the filesystem.
+	Path string
+	// Format is the config type the file was parsed as, e.g. "yaml".
+	Format string
+	// Size and ModTime of the file when it was read.
+	Size    int64
+	ModTime time.Time
+	// Checksum is the hex encoded SHA-256 digest of the file contents.
+	Checksum string
+	// Order is the position of the file in the merge sequence: files with a
+	// higher order were merged on top of those with a lower one.
+	Order int
+}
+
+// ConfigFilesUsed returns all files the current configuration was read
+// from, in merge order: the file read by ReadInConfig first, followed by
+// the files merged into it by MergeInConfig. It is meant for diagnostics and
+// for detecting that a configuration needs reloading.
+func ConfigFilesUsed() []FileInfo { return v.ConfigFilesUsed() }
+func (v *Viper) ConfigFilesUsed() []FileInfo {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	files := make([]FileInfo, len(v.configFilesUsed))
+	copy(files, v.configFilesUsed)
+	return files
+}
+
+// recordConfigFile records that the file at path was read. Unless merged,
+// it replaces all previously recorded files. The caller must hold the write
+// lock.
+func (v *Viper) recordConfigFile(path, format string, contents []byte, merged bool) {
+	if !merged {
+		v.configFilesUsed = nil
+	}
+
+	sum := sha256.Sum256(contents)
+	info := FileInfo{
+		Path:     path,
+		Format:   format,
+		Size:     int64(len(contents)),
+		Checksum: hex.EncodeToString(sum[:]),
+		Order:    len(v.configFilesUsed),
+	}
+	if fi, err := v.fs.Stat(path); err == nil {
+		info.Size = fi.Size()
+		info.ModTime = fi.ModTime()
+	}
+	v.configFilesUsed = append(v.configFilesUsed, info)
+}
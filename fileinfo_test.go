@@ -0,0 +1,43 @@
+package viper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFilesUsed(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	base := []byte("name: steve\nage: 35\n")
+	overlay := []byte(`{"name": "bob"}`)
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", base, 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/prod.json", overlay, 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.SetConfigFile("/etc/app/prod.json")
+	require.NoError(t, v.MergeInConfig())
+	assert.Equal(t, "bob", v.GetString("name"))
+
+	files := v.ConfigFilesUsed()
+	require.Len(t, files, 2)
+	sum := sha256.Sum256(base)
+	assert.Equal(t, "/etc/app/config.yaml", files[0].Path)
+	assert.Equal(t, "yaml", files[0].Format)
+	assert.Equal(t, int64(len(base)), files[0].Size)
+	assert.Equal(t, hex.EncodeToString(sum[:]), files[0].Checksum)
+	assert.Equal(t, 0, files[0].Order)
+	assert.False(t, files[0].ModTime.IsZero())
+	assert.Equal(t, "json", files[1].Format)
+	assert.Equal(t, 1, files[1].Order)
+
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	assert.Len(t, v.ConfigFilesUsed(), 1)
+}
@@ -0,0 +1,219 @@
+package viper
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// fileRefPrefixes are the prefixes of the values referring to a file, see
+// EnableFileReferences.
+var fileRefPrefixes = []string{"file://", "@file:"}
+
+// fileRefs caches the contents of the files referred to by values.
+type fileRefs struct {
+	mu      sync.Mutex
+	entries map[string]fileRef
+	// set by WatchFileReferences
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+}
+
+type fileRef struct {
+	data    string
+	modTime time.Time
+	size    int64
+}
+
+// EnableFileReferences makes Get, and the many Get____ methods built on it,
+// return the contents of the file referred to by string values of the form
+// "file://path" or "@file:path", including within maps and slices, e.g. for
+// certificates or large blobs kept out of the config file:
+//
+//	tls:
+//	  cert: file://certs/server.pem
+//
+// Relative paths read from a config file are resolved against the directory
+// of that file, others against the working directory. Contents are cached
+// and read again when the file changes; see WatchFileReferences to be
+// notified of changes. Files which can't be read are logged and the value
+// is returned as is. Only enable file references for trusted configuration,
+// as any file readable by the process can be referred to.
+func EnableFileReferences() { v.EnableFileReferences() }
+func (v *Viper) EnableFileReferences() {
+	v.fileReferences = true
+	v.markChanged()
+}
+
+// WatchFileReferences watches the files read through file references, see
+// EnableFileReferences: whenever one of them changes, the configuration is
+// marked as changed, dropping the values cached by CacheKey and
+// EnableKeyIndex, and the OnChange and OnConfigChange functions are called.
+// Files are watched from the time they're first read.
+func WatchFileReferences() error { return v.WatchFileReferences() }
+func (v *Viper) WatchFileReferences() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	v.fileRefs.mu.Lock()
+	if v.fileRefs.watcher != nil {
+		v.fileRefs.mu.Unlock()
+		watcher.Close()
+		return nil
+	}
+	v.fileRefs.watcher = watcher
+	v.fileRefs.watched = make(map[string]bool)
+	for path := range v.fileRefs.entries {
+		v.watchFileRef(path)
+	}
+	v.fileRefs.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if v.fileRefChanged(filepath.Clean(event.Name)) {
+					v.markChanged()
+					v.emitConfigChange(event)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				jww.ERROR.Printf("watcher error: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// watchFileRef watches the directory of the file at path, so that it's seen
+// when replaced. The caller must hold v.fileRefs.mu.
+func (v *Viper) watchFileRef(path string) {
+	dir := filepath.Dir(path)
+	if v.fileRefs.watcher == nil || v.fileRefs.watched[dir] {
+		return
+	}
+	if err := v.fileRefs.watcher.Add(dir); err != nil {
+		jww.ERROR.Printf("can't watch %s: %v", dir, err)
+		return
+	}
+	v.fileRefs.watched[dir] = true
+}
+
+// fileRefChanged drops the cached contents of the file at path, reporting
+// whether a value referred to it.
+func (v *Viper) fileRefChanged(path string) bool {
+	v.fileRefs.mu.Lock()
+	defer v.fileRefs.mu.Unlock()
+	if _, ok := v.fileRefs.entries[path]; !ok {
+		return false
+	}
+	delete(v.fileRefs.entries, path)
+	return true
+}
+
+// resolveFileRefs returns val, the value of key, with the file references
+// replaced by the contents of the files.
+func (v *Viper) resolveFileRefs(key string, val interface{}) interface{} {
+	dir, known := "", false
+	var resolve func(val interface{}) interface{}
+	resolve = func(val interface{}) interface{} {
+		switch val := val.(type) {
+		case string:
+			path, ok := fileRefPath(val)
+			if !ok {
+				return val
+			}
+			if !filepath.IsAbs(path) {
+				if !known {
+					dir, known = v.fileRefDir(key), true
+				}
+				path = filepath.Join(dir, path)
+			}
+			data, err := v.readFileRef(filepath.Clean(path))
+			if err != nil {
+				jww.ERROR.Printf("value of %q: %s", key, err)
+				return val
+			}
+			return data
+		case []string:
+			out := make([]string, len(val))
+			for i, s := range val {
+				out[i] = resolve(s).(string)
+			}
+			return out
+		case []interface{}:
+			out := make([]interface{}, len(val))
+			for i, item := range val {
+				out[i] = resolve(item)
+			}
+			return out
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(val))
+			for k, item := range val {
+				out[k] = resolve(item)
+			}
+			return out
+		}
+		return val
+	}
+	return resolve(val)
+}
+
+// fileRefPath returns the path of the file s refers to, if any.
+func fileRefPath(s string) (string, bool) {
+	for _, prefix := range fileRefPrefixes {
+		if strings.HasPrefix(s, prefix) && len(s) > len(prefix) {
+			return filepath.FromSlash(s[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// fileRefDir returns the directory relative file references in the value of
+// key are resolved against: the one of the config file the value was read
+// from, if any.
+func (v *Viper) fileRefDir(key string) string {
+	if src, file := v.Origin(key); src == SourceConfig && file != "" && configURL(file) == nil {
+		return filepath.Dir(file)
+	}
+	return ""
+}
+
+// readFileRef returns the contents of the file at path, cached until the
+// file changes.
+func (v *Viper) readFileRef(path string) (string, error) {
+	fi, err := v.fs.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	v.fileRefs.mu.Lock()
+	e, ok := v.fileRefs.entries[path]
+	v.fileRefs.mu.Unlock()
+	if ok && e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+		return e.data, nil
+	}
+
+	data, err := afero.ReadFile(v.fs, path)
+	if err != nil {
+		return "", err
+	}
+	v.fileRefs.mu.Lock()
+	defer v.fileRefs.mu.Unlock()
+	if v.fileRefs.entries == nil {
+		v.fileRefs.entries = make(map[string]fileRef)
+	}
+	v.fileRefs.entries[path] = fileRef{data: string(data), modTime: fi.ModTime(), size: fi.Size()}
+	v.watchFileRef(path)
+	return string(data), nil
+}
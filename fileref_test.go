@@ -0,0 +1,53 @@
+package viper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReferences(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(`tls:
+  cert: file://certs/server.pem
+  ca: "@file:/etc/ssl/ca.pem"
+hosts:
+- file://missing.txt
+- plain
+`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/certs/server.pem", []byte("CERT"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/ssl/ca.pem", []byte("CA"), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+
+	assert.Equal(t, "file://certs/server.pem", v.GetString("tls.cert"))
+
+	v.EnableFileReferences()
+	assert.Equal(t, "CERT", v.GetString("tls.cert"))
+	assert.Equal(t, []byte("CA"), v.GetBytes("tls.ca"))
+	assert.Equal(t, map[string]interface{}{"cert": "CERT", "ca": "CA"}, v.GetStringMap("tls"))
+	assert.Equal(t, []interface{}{"file://missing.txt", "plain"}, v.Get("hosts"))
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/certs/server.pem", []byte("NEW CERT"), 0644))
+	assert.Equal(t, "NEW CERT", v.GetString("tls.cert"))
+}
+
+func TestFileReferenceChanged(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/run/secrets/token", []byte("t0"), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.EnableFileReferences()
+	v.Set("token", "file:///run/secrets/token")
+	assert.Equal(t, "t0", v.GetString("token"))
+
+	path := filepath.FromSlash("/run/secrets/token")
+	assert.False(t, v.fileRefChanged(filepath.FromSlash("/run/secrets/other")))
+	assert.True(t, v.fileRefChanged(path))
+	assert.False(t, v.fileRefChanged(path))
+}
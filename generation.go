@@ -0,0 +1,44 @@
+package viper
+
+import "sync/atomic"
+
+// Generation returns the configuration generation of this Viper instance.
+// The generation starts at zero and increases every time the configuration
+// may have changed: on Set and SetDefault, on new env, flag and alias
+// bindings, and whenever configuration is read, merged or imported (local or
+// remote, including reloads triggered by WatchConfig).
+//
+// Mutations are visible to Get by the time they return, and so is the new
+// generation: a caller that records the generation before an operation and
+// finds it unchanged afterwards knows the values it read were consistent.
+// Otherwise the operation can be retried.
+func Generation() uint64 { return v.Generation() }
+func (v *Viper) Generation() uint64 {
+	if v == nil {
+		return 0
+	}
+	gen := atomic.LoadUint64(&v.generation)
+	for _, m := range v.mountTable() {
+		gen += m.Generation()
+	}
+	return gen
+}
+
+// unlockChanged releases the write lock held while the layers were changed,
+// and records the change like markChanged. The generation is increased
+// before the lock is released, so that no reader sees the changed layers
+// along with the generation before the change.
+func (v *Viper) unlockChanged() {
+	v.InvalidateCache()
+	atomic.AddUint64(&v.generation, 1)
+	v.mu.Unlock()
+	v.emitChange()
+}
+
+// markChanged records a possible change of the configuration: cached values
+// are dropped, the generation is increased and OnChange is notified.
+func (v *Viper) markChanged() {
+	v.InvalidateCache()
+	atomic.AddUint64(&v.generation, 1)
+	v.emitChange()
+}
@@ -0,0 +1,37 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneration(t *testing.T) {
+	v := New()
+	assert.Equal(t, uint64(0), v.Generation())
+
+	last := v.Generation()
+	changed := func() bool {
+		g := v.Generation()
+		defer func() { last = g }()
+		return g > last
+	}
+
+	v.Set("a", 1)
+	assert.True(t, changed())
+	v.SetDefault("b", 2)
+	assert.True(t, changed())
+	v.BindEnv("c")
+	assert.True(t, changed())
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+	assert.True(t, changed())
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"d": 4}))
+	assert.True(t, changed())
+
+	v.Get("a")
+	v.AllSettings()
+	assert.False(t, changed())
+}
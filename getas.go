@@ -0,0 +1,92 @@
+//go:build go1.18
+// +build go1.18
+
+package viper
+
+import (
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// GetAs returns the value associated with the key of v converted to T, or a
+// KeyNotSetError if the key has no value and a CastError if its value can't
+// be converted. Basic types, durations, times and the slices and maps of the
+// Get____ methods are converted like by these methods; other types, e.g.
+// structs, are decoded like by UnmarshalKey.
+//
+//	port, err := viper.GetAs[uint16](viper.GetViper(), "port")
+func GetAs[T any](v *Viper, key string) (T, error) {
+	var zero T
+	val, err := v.getE(key)
+	if err != nil {
+		return zero, err
+	}
+	if t, ok := val.(T); ok {
+		return t, nil
+	}
+
+	var out interface{}
+	switch any(zero).(type) {
+	case string:
+		out, err = cast.ToStringE(val)
+	case bool:
+		out, err = cast.ToBoolE(val)
+	case int:
+		out, err = cast.ToIntE(val)
+	case int8:
+		out, err = cast.ToInt8E(val)
+	case int16:
+		out, err = cast.ToInt16E(val)
+	case int32:
+		out, err = cast.ToInt32E(val)
+	case int64:
+		out, err = cast.ToInt64E(val)
+	case uint:
+		out, err = cast.ToUintE(val)
+	case uint8:
+		out, err = cast.ToUint8E(val)
+	case uint16:
+		out, err = cast.ToUint16E(val)
+	case uint32:
+		out, err = cast.ToUint32E(val)
+	case uint64:
+		out, err = cast.ToUint64E(val)
+	case float32:
+		out, err = cast.ToFloat32E(val)
+	case float64:
+		out, err = cast.ToFloat64E(val)
+	case time.Duration:
+		out, err = cast.ToDurationE(val)
+	case time.Time:
+		out, err = cast.ToTimeE(val)
+	case []string:
+		out, err = cast.ToStringSliceE(val)
+	case []int:
+		out, err = cast.ToIntSliceE(val)
+	case []float64:
+		out, err = toFloat64SliceE(val)
+	case []time.Duration:
+		out, err = cast.ToDurationSliceE(val)
+	case map[string]interface{}:
+		out, err = cast.ToStringMapE(val)
+	case map[string]string:
+		out, err = cast.ToStringMapStringE(val)
+	case map[string][]string:
+		out, err = cast.ToStringMapStringSliceE(val)
+	case map[string]int64:
+		out, err = cast.ToStringMapInt64E(val)
+	case map[string]bool:
+		out, err = cast.ToStringMapBoolE(val)
+	default:
+		var t T
+		if err := decode(val, v.defaultDecoderConfig(&t)); err != nil {
+			return zero, v.castError(key, val, err)
+		}
+		return t, nil
+	}
+	if err != nil {
+		return zero, v.castError(key, val, err)
+	}
+	return out.(T), nil
+}
@@ -0,0 +1,44 @@
+//go:build go1.18
+// +build go1.18
+
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAs(t *testing.T) {
+	v := New()
+	v.Set("port", "8080")
+	v.Set("timeout", "5s")
+	v.Set("db", map[string]interface{}{"host": "localhost", "port": "5432"})
+
+	port, err := GetAs[uint16](v, "port")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(8080), port)
+
+	timeout, err := GetAs[time.Duration](v, "timeout")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	type database struct {
+		Host string
+		Port int
+	}
+	db, err := GetAs[database](v, "db")
+	require.NoError(t, err)
+	assert.Equal(t, database{Host: "localhost", Port: 5432}, db)
+
+	_, err = GetAs[int](v, "missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+	_, err = GetAs[bool](v, "timeout")
+	assert.IsType(t, CastError{}, err)
+
+	var nilv *Viper
+	_, err = GetAs[int](nilv, "Port")
+	assert.Equal(t, KeyNotSetError("port"), err)
+}
@@ -0,0 +1,342 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// KeyNotSetError denotes a key without a value, returned by the Get____E
+// methods.
+type KeyNotSetError string
+
+// Error returns the formatted key not set error.
+func (str KeyNotSetError) Error() string {
+	return fmt.Sprintf("key %q is not set", string(str))
+}
+
+// CastError denotes a value which can't be converted to the type requested
+// by a Get____E method.
+type CastError struct {
+	Key   string
+	Value interface{}
+	err   error
+}
+
+// Error returns the formatted cast error.
+func (ce CastError) Error() string {
+	return fmt.Sprintf("invalid value %v for key %q: %s", ce.Value, ce.Key, ce.err.Error())
+}
+
+// getE returns the value of key, or a KeyNotSetError if it has none.
+func (v *Viper) getE(key string) (interface{}, error) {
+	if v == nil {
+		return nil, KeyNotSetError(strings.ToLower(key))
+	}
+	val := v.Get(key)
+	if val == nil {
+		return nil, KeyNotSetError(v.normalizeKey(key))
+	}
+	return val, nil
+}
+
+// castError wraps err, returned by converting the value of key, if any.
+func (v *Viper) castError(key string, val interface{}, err error) error {
+	if err == nil {
+		return nil
+	}
+	return CastError{Key: v.normalizeKey(key), Value: val, err: err}
+}
+
+// GetStringE returns the value associated with the key as a string, or an
+// error if the key has no value or its value can't be converted.
+func GetStringE(key string) (string, error) { return v.GetStringE(key) }
+func (v *Viper) GetStringE(key string) (string, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return "", err
+	}
+	s, err := cast.ToStringE(val)
+	return s, v.castError(key, val, err)
+}
+
+// GetBoolE returns the value associated with the key as a boolean, or an
+// error if the key has no value or its value can't be converted.
+func GetBoolE(key string) (bool, error) { return v.GetBoolE(key) }
+func (v *Viper) GetBoolE(key string) (bool, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return false, err
+	}
+	b, err := cast.ToBoolE(val)
+	return b, v.castError(key, val, err)
+}
+
+// GetIntE returns the value associated with the key as an integer, or an
+// error if the key has no value or its value can't be converted.
+func GetIntE(key string) (int, error) { return v.GetIntE(key) }
+func (v *Viper) GetIntE(key string) (int, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToIntE(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetInt32E returns the value associated with the key as an integer, or an
+// error if the key has no value or its value can't be converted.
+func GetInt32E(key string) (int32, error) { return v.GetInt32E(key) }
+func (v *Viper) GetInt32E(key string) (int32, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToInt32E(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetInt64E returns the value associated with the key as an integer, or an
+// error if the key has no value or its value can't be converted.
+func GetInt64E(key string) (int64, error) { return v.GetInt64E(key) }
+func (v *Viper) GetInt64E(key string) (int64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToInt64E(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetUintE returns the value associated with the key as an unsigned
+// integer, or an error if the key has no value or its value can't be
+// converted.
+func GetUintE(key string) (uint, error) { return v.GetUintE(key) }
+func (v *Viper) GetUintE(key string) (uint, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToUintE(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetUint16E returns the value associated with the key as an unsigned
+// integer, or an error if the key has no value or its value can't be
+// converted.
+func GetUint16E(key string) (uint16, error) { return v.GetUint16E(key) }
+func (v *Viper) GetUint16E(key string) (uint16, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToUint16E(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetUint32E returns the value associated with the key as an unsigned
+// integer, or an error if the key has no value or its value can't be
+// converted.
+func GetUint32E(key string) (uint32, error) { return v.GetUint32E(key) }
+func (v *Viper) GetUint32E(key string) (uint32, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToUint32E(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetUint64E returns the value associated with the key as an unsigned
+// integer, or an error if the key has no value or its value can't be
+// converted.
+func GetUint64E(key string) (uint64, error) { return v.GetUint64E(key) }
+func (v *Viper) GetUint64E(key string) (uint64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := cast.ToUint64E(val)
+	return i, v.castError(key, val, err)
+}
+
+// GetFloat64E returns the value associated with the key as a float64, or an
+// error if the key has no value or its value can't be converted.
+func GetFloat64E(key string) (float64, error) { return v.GetFloat64E(key) }
+func (v *Viper) GetFloat64E(key string) (float64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := cast.ToFloat64E(val)
+	return f, v.castError(key, val, err)
+}
+
+// GetTimeE returns the value associated with the key as time, or an error
+// if the key has no value or its value can't be converted.
+func GetTimeE(key string) (time.Time, error) { return v.GetTimeE(key) }
+func (v *Viper) GetTimeE(key string) (time.Time, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := cast.ToTimeE(val)
+	return t, v.castError(key, val, err)
+}
+
+// GetDurationE returns the value associated with the key as a duration, or
+// an error if the key has no value or its value can't be converted.
+func GetDurationE(key string) (time.Duration, error) { return v.GetDurationE(key) }
+func (v *Viper) GetDurationE(key string) (time.Duration, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := cast.ToDurationE(val)
+	return d, v.castError(key, val, err)
+}
+
+// GetIntSliceE returns the value associated with the key as a slice of int
+// values, or an error if the key has no value or its value can't be
+// converted.
+func GetIntSliceE(key string) ([]int, error) { return v.GetIntSliceE(key) }
+func (v *Viper) GetIntSliceE(key string) ([]int, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	s, err := cast.ToIntSliceE(val)
+	return s, v.castError(key, val, err)
+}
+
+// GetFloat64SliceE returns the value associated with the key as a slice of
+// float64 values, or an error if the key has no value or its value can't be
+// converted.
+func GetFloat64SliceE(key string) ([]float64, error) { return v.GetFloat64SliceE(key) }
+func (v *Viper) GetFloat64SliceE(key string) ([]float64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	s, err := toFloat64SliceE(val)
+	return s, v.castError(key, val, err)
+}
+
+// GetStringSliceE returns the value associated with the key as a slice of
+// strings, or an error if the key has no value or its value can't be
+// converted.
+func GetStringSliceE(key string) ([]string, error) { return v.GetStringSliceE(key) }
+func (v *Viper) GetStringSliceE(key string) ([]string, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	s, err := cast.ToStringSliceE(val)
+	return s, v.castError(key, val, err)
+}
+
+// GetStringMapE returns the value associated with the key as a map of
+// interfaces, or an error if the key has no value or its value can't be
+// converted.
+func GetStringMapE(key string) (map[string]interface{}, error) { return v.GetStringMapE(key) }
+func (v *Viper) GetStringMapE(key string) (map[string]interface{}, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := cast.ToStringMapE(val)
+	return m, v.castError(key, val, err)
+}
+
+// GetStringMapStringE returns the value associated with the key as a map of
+// strings, or an error if the key has no value or its value can't be
+// converted.
+func GetStringMapStringE(key string) (map[string]string, error) { return v.GetStringMapStringE(key) }
+func (v *Viper) GetStringMapStringE(key string) (map[string]string, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := cast.ToStringMapStringE(val)
+	return m, v.castError(key, val, err)
+}
+
+// GetStringMapStringSliceE returns the value associated with the key as a
+// map to a slice of strings, or an error if the key has no value or its
+// value can't be converted.
+func GetStringMapStringSliceE(key string) (map[string][]string, error) {
+	return v.GetStringMapStringSliceE(key)
+}
+func (v *Viper) GetStringMapStringSliceE(key string) (map[string][]string, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := cast.ToStringMapStringSliceE(val)
+	return m, v.castError(key, val, err)
+}
+
+// GetStringToInt64MapE returns the value associated with the key as a map of
+// int64 values, or an error if the key has no value or its value can't be
+// converted.
+func GetStringToInt64MapE(key string) (map[string]int64, error) { return v.GetStringToInt64MapE(key) }
+func (v *Viper) GetStringToInt64MapE(key string) (map[string]int64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := cast.ToStringMapInt64E(val)
+	return m, v.castError(key, val, err)
+}
+
+// GetStringToBoolMapE returns the value associated with the key as a map of
+// booleans, or an error if the key has no value or its value can't be
+// converted.
+func GetStringToBoolMapE(key string) (map[string]bool, error) { return v.GetStringToBoolMapE(key) }
+func (v *Viper) GetStringToBoolMapE(key string) (map[string]bool, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := cast.ToStringMapBoolE(val)
+	return m, v.castError(key, val, err)
+}
+
+// toFloat64SliceE casts a slice, or a string of space separated values, to
+// a []float64.
+func toFloat64SliceE(i interface{}) ([]float64, error) {
+	switch val := i.(type) {
+	case nil:
+		return nil, fmt.Errorf("unable to cast %#v of type %T to []float64", i, i)
+	case []float64:
+		return val, nil
+	case string:
+		fields := strings.Fields(val)
+		out := make([]float64, len(fields))
+		for j, field := range fields {
+			f, err := cast.ToFloat64E(field)
+			if err != nil {
+				return nil, fmt.Errorf("unable to cast %#v of type %T to []float64", i, i)
+			}
+			out[j] = f
+		}
+		return out, nil
+	}
+
+	rv := reflect.ValueOf(i)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("unable to cast %#v of type %T to []float64", i, i)
+	}
+	out := make([]float64, rv.Len())
+	for j := range out {
+		f, err := cast.ToFloat64E(rv.Index(j).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("unable to cast %#v of type %T to []float64", i, i)
+		}
+		out[j] = f
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedGetters(t *testing.T) {
+	v := New()
+	v.Set("port", "8080")
+	v.Set("weights", []interface{}{1, "2.5", 3.0})
+	v.Set("limits", map[string]interface{}{"cpu": 2, "memory": "512"})
+	v.Set("features", map[string]interface{}{"beta": "true", "legacy": false})
+
+	assert.Equal(t, uint16(8080), v.GetUint16("port"))
+	assert.Equal(t, []float64{1, 2.5, 3}, v.GetFloat64Slice("weights"))
+	assert.Equal(t, map[string]int64{"cpu": 2, "memory": 512}, v.GetStringToInt64Map("limits"))
+	assert.Equal(t, map[string]bool{"beta": true, "legacy": false}, v.GetStringToBoolMap("features"))
+
+	v.Set("scores", "1 2.5")
+	assert.Equal(t, []float64{1, 2.5}, v.GetFloat64Slice("scores"))
+	assert.Nil(t, v.GetFloat64Slice("missing"))
+}
+
+func TestGetE(t *testing.T) {
+	v := New()
+	v.Set("port", "8080")
+	v.Set("zero", 0)
+	v.Set("name", "steve")
+	v.Set("timeout", "5s")
+
+	port, err := v.GetIntE("port")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	// zero values are told apart from missing keys
+	zero, err := v.GetIntE("zero")
+	require.NoError(t, err)
+	assert.Equal(t, 0, zero)
+	_, err = v.GetIntE("Missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+	assert.EqualError(t, err, `key "missing" is not set`)
+
+	_, err = v.GetIntE("name")
+	assert.IsType(t, CastError{}, err)
+	assert.Contains(t, err.Error(), `invalid value steve for key "name"`)
+	_, err = v.GetFloat64SliceE("name")
+	assert.IsType(t, CastError{}, err)
+
+	timeout, err := v.GetDurationE("timeout")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	name, err := v.GetStringE("name")
+	require.NoError(t, err)
+	assert.Equal(t, "steve", name)
+
+	_, err = v.GetStringMapE("name")
+	assert.IsType(t, CastError{}, err)
+}
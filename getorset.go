@@ -0,0 +1,77 @@
+package viper
+
+import "sync"
+
+// GetOrSet returns the value associated with the key if it has one, and
+// otherwise stores the value returned by compute as an override, see Set,
+// and returns it. The check and the store happen under a lock of the key, so
+// that concurrent GetOrSet calls for a key compute its value once, while
+// calls for other keys proceed; compute must not call GetOrSet for the same
+// key. Errors returned by compute, or by a constraint
+// rejecting the value, are returned without storing it.
+// On a nil *Viper, the value returned by compute is returned without being
+// stored.
+// GetOrSet is case-insensitive for a key.
+func GetOrSet(key string, compute func() (interface{}, error)) (interface{}, error) {
+	return v.GetOrSet(key, compute)
+}
+func (v *Viper) GetOrSet(key string, compute func() (interface{}, error)) (interface{}, error) {
+	if v == nil {
+		// nothing to store the value in
+		return compute()
+	}
+	v.mu.RLock()
+	lcaseKey := v.realKey(v.normalizeKey(key))
+	v.mu.RUnlock()
+	unlock := v.getOrSetLocks.lock(lcaseKey)
+	defer unlock()
+
+	if val := v.Get(key); val != nil {
+		return val, nil
+	}
+	val, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	if err := v.SetE(key, val); err != nil {
+		return nil, err
+	}
+	return v.Get(key), nil
+}
+
+// keyLocks are mutexes by key, which exist while locked or waited for.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	mu sync.Mutex
+	// the number of holders and waiters, guarded by keyLocks.mu
+	refs int
+}
+
+// lock locks the mutex of key and returns the function unlocking it.
+func (l *keyLocks) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	kl := l.locks[key]
+	if kl == nil {
+		kl = &keyLock{}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	kl.mu.Lock()
+	return func() {
+		kl.mu.Unlock()
+		l.mu.Lock()
+		if kl.refs--; kl.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,79 @@
+package viper
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrSet(t *testing.T) {
+	v := New()
+	v.SetDefault("name", "steve")
+
+	var calls int32
+	compute := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "token", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := v.GetOrSet("Auth.Token", compute)
+			assert.NoError(t, err)
+			assert.Equal(t, "token", val)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, "token", v.GetString("auth.token"))
+
+	val, err := v.GetOrSet("name", compute)
+	require.NoError(t, err)
+	assert.Equal(t, "steve", val)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestGetOrSetError(t *testing.T) {
+	v := New()
+	_, err := v.GetOrSet("token", func() (interface{}, error) { return nil, errors.New("unavailable") })
+	assert.EqualError(t, err, "unavailable")
+	assert.False(t, v.IsSet("token"))
+
+	v.AddConstraint("level", OneOf("debug", "info"))
+	_, err = v.GetOrSet("level", func() (interface{}, error) { return "verbose", nil })
+	assert.IsType(t, ConstraintError{}, err)
+	assert.False(t, v.IsSet("level"))
+}
+
+func TestGetOrSetPerKey(t *testing.T) {
+	v := New()
+	computing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := v.GetOrSet("slow", func() (interface{}, error) {
+			close(computing)
+			<-release
+			return "slow", nil
+		})
+		assert.NoError(t, err)
+	}()
+	<-computing
+
+	// other keys don't wait for the slow one
+	val, err := v.GetOrSet("fast", func() (interface{}, error) { return "fast", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "fast", val)
+	close(release)
+	<-done
+	assert.Equal(t, "slow", v.GetString("slow"))
+	assert.Empty(t, v.getOrSetLocks.locks)
+}
@@ -0,0 +1,184 @@
+package viper
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry records a reload of the configuration, see SetHistorySize.
+type HistoryEntry struct {
+	Time time.Time
+	// Operation is AuditRead for ReadInConfig and the reloads of
+	// WatchConfig, AuditMerge for MergeInConfig and AuditRemote for the
+	// reads of remote configuration.
+	Operation AuditOperation
+	// Source is the config files or remote provider read.
+	Source string
+	// Changes are the keys whose value changed, sorted.
+	Changes []HistoryChange
+	// Err is the error of a failed reload, which left the configuration
+	// as it was.
+	Err error
+}
+
+// HistoryChange is a key changed by a reload. Values are redacted like in
+// AuditRecords, see SetAuditRedactor.
+type HistoryChange struct {
+	Key string
+	// OldValue is nil if the key was added, NewValue if it was removed.
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// historyState is the ring buffer of the last reloads.
+type historyState struct {
+	mu      sync.Mutex
+	size    int
+	entries []HistoryEntry
+	// index of the oldest entry once the buffer is full
+	next int
+}
+
+// SetHistorySize keeps the last n reloads of the configuration in memory,
+// with the keys they changed, returned by History, so that "when did this
+// value change?" can be answered during incidents. n is 0 by default,
+// keeping no history; changing it drops the entries recorded so far.
+func SetHistorySize(n int) { v.SetHistorySize(n) }
+func (v *Viper) SetHistorySize(n int) {
+	if n < 0 {
+		n = 0
+	}
+	v.history.mu.Lock()
+	defer v.history.mu.Unlock()
+	v.history.size, v.history.entries, v.history.next = n, nil, 0
+}
+
+// History returns the last reloads of the configuration, oldest first, see
+// SetHistorySize.
+func History() []HistoryEntry { return v.History() }
+func (v *Viper) History() []HistoryEntry {
+	v.history.mu.Lock()
+	defer v.history.mu.Unlock()
+	entries := make([]HistoryEntry, 0, len(v.history.entries))
+	entries = append(entries, v.history.entries[v.history.next:]...)
+	return append(entries, v.history.entries[:v.history.next]...)
+}
+
+// historyBefore returns the settings before a reload, to be given to
+// recordReload, or nil if no history is kept.
+func (v *Viper) historyBefore() map[string]interface{} {
+	v.history.mu.Lock()
+	size := v.history.size
+	v.history.mu.Unlock()
+	if size == 0 {
+		return nil
+	}
+	settings := map[string]interface{}{}
+	for _, key := range v.AllKeys() {
+		settings[key] = v.Get(key)
+	}
+	return settings
+}
+
+// recordReload records a reload from source in the history, given the
+// settings returned by historyBefore.
+func (v *Viper) recordReload(op AuditOperation, source string, before map[string]interface{}, err error) {
+	if before == nil {
+		return
+	}
+	entry := HistoryEntry{Time: time.Now(), Operation: op, Source: source, Err: err}
+	keys := map[string]bool{}
+	after := map[string]interface{}{}
+	for _, key := range v.AllKeys() {
+		after[key], keys[key] = v.Get(key), true
+	}
+	for key := range before {
+		keys[key] = true
+	}
+	v.audit.mu.Lock()
+	redact := v.audit.redact
+	v.audit.mu.Unlock()
+	for _, key := range sortedKeys(keys) {
+		if reflect.DeepEqual(before[key], after[key]) {
+			continue
+		}
+		entry.Changes = append(entry.Changes, HistoryChange{
+			Key:      key,
+			OldValue: v.auditValue(redact, key, before[key]),
+			NewValue: v.auditValue(redact, key, after[key]),
+		})
+	}
+
+	h := &v.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.size == 0 {
+		return
+	}
+	if len(h.entries) < h.size {
+		h.entries = append(h.entries, entry)
+		return
+	}
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % h.size
+}
+
+// configFilesSource describes the config files read as the Source of a
+// HistoryEntry, falling back to filename if none was read.
+func (v *Viper) configFilesSource(filename string) string {
+	files := v.ConfigFilesUsed()
+	if len(files) == 0 {
+		return filename
+	}
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	return strings.Join(paths, ", ")
+}
+
+// historyJSON is a HistoryEntry served by HistoryHandler.
+type historyJSON struct {
+	Time      time.Time       `json:"time"`
+	Operation AuditOperation  `json:"operation"`
+	Source    string          `json:"source,omitempty"`
+	Changes   []historyChange `json:"changes,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+type historyChange struct {
+	Key      string      `json:"key"`
+	OldValue interface{} `json:"old"`
+	NewValue interface{} `json:"new"`
+}
+
+// HistoryHandler returns an http.Handler serving History as JSON, newest
+// first, to be mounted on a debug endpoint:
+//
+//	http.Handle("/debug/config/history", viper.HistoryHandler())
+func HistoryHandler() http.Handler { return v.HistoryHandler() }
+func (v *Viper) HistoryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entries := v.History()
+		out := make([]historyJSON, 0, len(entries))
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			j := historyJSON{Time: e.Time, Operation: e.Operation, Source: e.Source}
+			for _, c := range e.Changes {
+				j.Changes = append(j.Changes, historyChange{Key: c.Key, OldValue: c.OldValue, NewValue: c.NewValue})
+			}
+			if e.Err != nil {
+				j.Error = e.Err.Error()
+			}
+			out = append(out, j)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+	})
+}
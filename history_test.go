@@ -0,0 +1,68 @@
+package viper
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	write := func(contents string) {
+		require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(contents), 0644))
+	}
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.MarkSecret("db.password")
+	write("port: 8080\ndb:\n  password: hunter2\n")
+	require.NoError(t, v.ReadInConfig())
+	assert.Empty(t, v.History())
+
+	v.SetHistorySize(2)
+	write("port: 9090\nhost: example.com\napi_token: t0k3n\ndb:\n  password: s3cr3t\n")
+	require.NoError(t, v.ReadInConfig())
+
+	history := v.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, AuditRead, history[0].Operation)
+	assert.Equal(t, "/etc/app/config.yaml", history[0].Source)
+	assert.NoError(t, history[0].Err)
+	assert.Equal(t, []HistoryChange{
+		{Key: "api_token", NewValue: AuditRedacted},
+		{Key: "db.password", OldValue: SecretRedacted, NewValue: SecretRedacted},
+		{Key: "host", NewValue: "example.com"},
+		{Key: "port", OldValue: 8080, NewValue: 9090},
+	}, history[0].Changes)
+
+	write("port: [")
+	assert.Error(t, v.ReadInConfig())
+	write("host: example.org\n")
+	require.NoError(t, v.MergeInConfig())
+
+	// the first reload is dropped
+	history = v.History()
+	require.Len(t, history, 2)
+	assert.Error(t, history[0].Err)
+	assert.Empty(t, history[0].Changes)
+	assert.Equal(t, AuditMerge, history[1].Operation)
+	assert.Equal(t, []HistoryChange{{Key: "host", OldValue: "example.com", NewValue: "example.org"}}, history[1].Changes)
+
+	rec := httptest.NewRecorder()
+	v.HistoryHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/config/history", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var served []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	require.Len(t, served, 2)
+	assert.Equal(t, "merge", served[0]["operation"])
+	assert.NotEmpty(t, served[1]["error"])
+
+	v.SetHistorySize(0)
+	require.NoError(t, v.ReadInConfig())
+	assert.Empty(t, v.History())
+}
@@ -0,0 +1,272 @@
+package viper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IniOptions configures how INI files are read and written, see
+// IniLoadOptions.
+type IniOptions struct {
+	// DefaultSection is the name of the section holding the keys that come
+	// before the first section header. Its keys are stored at the root of
+	// the configuration, and so are the keys of a section header with that
+	// name. Defaults to "DEFAULT".
+	DefaultSection string
+	// IgnoreInlineComments keeps a ";" or "#" following a value, e.g.
+	// "color = #ff0000", as part of the value. By default it starts a
+	// comment when preceded by a space.
+	IgnoreInlineComments bool
+	// AllowDuplicateKeys collects the values of a key repeated within a
+	// section in a []string, and writes slices as repeated keys. By default
+	// the last value of a key wins, and slices are written comma separated.
+	AllowDuplicateKeys bool
+	// AllowBooleanKeys reads keys without a value, e.g. "skip-networking",
+	// as true.
+	AllowBooleanKeys bool
+}
+
+// IniLoadOptions returns an Option setting how INI files are read and
+// written. Sections become nested keys: "port" of section "[server]" is read
+// by Get("server.port"), and "[server.tls]" nests below "server". Values are
+// read as strings, unquoted if enclosed in double quotes, within which \",
+// \\, \n and \r stand for a double quote, a backslash, a newline and a
+// carriage return.
+func IniLoadOptions(opts IniOptions) Option {
+	return func(v *Viper) {
+		v.iniOptions = opts
+	}
+}
+
+func (o IniOptions) defaultSection() string {
+	if o.DefaultSection == "" {
+		return "DEFAULT"
+	}
+	return o.DefaultSection
+}
+
+// unmarshalIni parses the INI document data into c.
+func (v *Viper) unmarshalIni(data []byte, c map[string]interface{}) error {
+	opts := v.iniOptions
+	section := c
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// lines are as long as the document at most
+	scanner.Buffer(nil, len(data)+1)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return fmt.Errorf("line %d: unterminated section header %q", n, line)
+			}
+			name := strings.TrimSpace(line[1:end])
+			if name == "" {
+				return fmt.Errorf("line %d: empty section name", n)
+			}
+			if strings.EqualFold(name, opts.defaultSection()) {
+				section = c
+			} else {
+				var err error
+				if section, err = iniSection(c, strings.Split(name, ".")); err != nil {
+					return fmt.Errorf("line %d: %v", n, err)
+				}
+			}
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			if !opts.AllowBooleanKeys {
+				return fmt.Errorf("line %d: key %q has no value", n, line)
+			}
+			section[line] = true
+			continue
+		}
+		key, value := strings.TrimSpace(line[:i]), iniValue(line[i+1:], opts)
+		if key == "" {
+			return fmt.Errorf("line %d: empty key", n)
+		}
+
+		var val interface{} = value
+		if existing, ok := section[key]; ok && opts.AllowDuplicateKeys {
+			switch existing := existing.(type) {
+			case []string:
+				val = append(existing, value)
+			case string:
+				val = []string{existing, value}
+			}
+		}
+		section[key] = val
+	}
+	return scanner.Err()
+}
+
+// iniSection returns the map of the section at path, creating the missing
+// ones. It is an error for a key of path to hold a value rather than a
+// section.
+func iniSection(c map[string]interface{}, path []string) (map[string]interface{}, error) {
+	m := c
+	for i, k := range path {
+		val, ok := m[k]
+		if !ok {
+			sub := make(map[string]interface{})
+			m[k] = sub
+			m = sub
+			continue
+		}
+		sub, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("section %q conflicts with key %q", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+		}
+		m = sub
+	}
+	return m, nil
+}
+
+// iniValue returns the value of a key/value line, s following the
+// separator.
+func iniValue(s string, opts IniOptions) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' {
+		if value, ok := iniUnquote(s); ok {
+			return value
+		}
+	}
+	if !opts.IgnoreInlineComments {
+		for i := 1; i < len(s); i++ {
+			if (s[i] == ';' || s[i] == '#') && (s[i-1] == ' ' || s[i-1] == '\t') {
+				return strings.TrimSpace(s[:i])
+			}
+		}
+	}
+	return s
+}
+
+// marshalIni encodes c as an INI document: the values at the root come
+// first, followed by a section per nested map, in key order.
+func (v *Viper) marshalIni(c map[string]interface{}) []byte {
+	return v.marshalIniWithComments(c, nil)
+}
+
+// marshalIniWithComments encodes c, writing the comments of the keys and
+// sections above them, see SetComment.
+func (v *Viper) marshalIniWithComments(c map[string]interface{}, comments map[string]string) []byte {
+	var buf bytes.Buffer
+	v.writeIniSection(&buf, "", c, comments)
+	return buf.Bytes()
+}
+
+func (v *Viper) writeIniSection(buf *bytes.Buffer, name string, m map[string]interface{}, comments map[string]string) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sections []string
+	header := name != ""
+	for _, key := range keys {
+		if _, ok := m[key].(map[string]interface{}); ok {
+			sections = append(sections, key)
+			continue
+		}
+		if header {
+			if buf.Len() > 0 {
+				buf.WriteByte('\n')
+			}
+			writeIniComment(buf, comments[name])
+			fmt.Fprintf(buf, "[%s]\n", name)
+			header = false
+		}
+		if name == "" {
+			writeIniComment(buf, comments[key])
+		} else {
+			writeIniComment(buf, comments[name+"."+key])
+		}
+		for _, value := range v.iniValues(m[key]) {
+			fmt.Fprintf(buf, "%s = %s\n", key, value)
+		}
+	}
+	for _, key := range sections {
+		sub := key
+		if name != "" {
+			sub = name + "." + key
+		}
+		v.writeIniSection(buf, sub, m[key].(map[string]interface{}), comments)
+	}
+}
+
+// writeIniComment writes the lines of comment, if any.
+func writeIniComment(buf *bytes.Buffer, comment string) {
+	for _, line := range docComment(comment, "") {
+		buf.WriteString(line + "\n")
+	}
+}
+
+// iniValues returns the encoded values of a key: one per item of a slice
+// if duplicate keys are allowed, a single one otherwise.
+func (v *Viper) iniValues(val interface{}) []string {
+	var items []string
+	switch val := val.(type) {
+	case []string:
+		items = append(items, val...)
+	case []interface{}:
+		for _, item := range val {
+			items = append(items, fmt.Sprint(item))
+		}
+	default:
+		return []string{iniQuote(fmt.Sprint(val))}
+	}
+	if !v.iniOptions.AllowDuplicateKeys {
+		return []string{iniQuote(strings.Join(items, ","))}
+	}
+	for i, item := range items {
+		items[i] = iniQuote(item)
+	}
+	return items
+}
+
+// iniUnquote returns the value enclosed in the double quotes starting s,
+// ignoring what follows the closing quote. ok is false if there is none.
+func iniUnquote(s string) (string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return b.String(), true
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case c == '\\' && i+1 < len(s) && s[i+1] == 'n':
+			i++
+			b.WriteByte('\n')
+		case c == '\\' && i+1 < len(s) && s[i+1] == 'r':
+			i++
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// iniQuoter escapes the characters of a quoted value.
+var iniQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+// iniQuote quotes s if it wouldn't be read back as is.
+func iniQuote(s string) string {
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, ";#\"\n\r") {
+		return `"` + iniQuoter.Replace(s) + `"`
+	}
+	return s
+}
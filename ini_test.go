@@ -0,0 +1,148 @@
+package viper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var iniExample = []byte(`; legacy service config
+name = legacy
+debug
+
+[DEFAULT]
+Region = eu-west-1
+
+[server]
+host = "  localhost "
+port: 8080 ; inline comment
+color = #ff0000
+allow = 10.0.0.1
+allow = 10.0.0.2
+
+[server.tls]
+cert = /etc/ssl/cert.pem
+`)
+
+func TestReadIni(t *testing.T) {
+	v := NewWithOptions(IniLoadOptions(IniOptions{AllowBooleanKeys: true}))
+	v.SetConfigType("ini")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(iniExample)))
+
+	assert.Equal(t, "legacy", v.GetString("name"))
+	assert.Equal(t, true, v.Get("debug"))
+	assert.Equal(t, "eu-west-1", v.GetString("region"))
+	assert.Equal(t, "  localhost ", v.GetString("server.host"))
+	assert.Equal(t, 8080, v.GetInt("server.port"))
+	assert.Equal(t, "#ff0000", v.GetString("server.color"))
+	assert.Equal(t, "10.0.0.2", v.GetString("server.allow"))
+	assert.Equal(t, "/etc/ssl/cert.pem", v.GetString("server.tls.cert"))
+
+	v = New()
+	v.SetConfigType("ini")
+	err := v.ReadConfig(bytes.NewBuffer(iniExample))
+	assert.EqualError(t, err, `While parsing config: line 3: key "debug" has no value`)
+
+	// sections don't replace values
+	err = v.ReadConfig(bytes.NewBufferString("[server]\ntls = on\n[server.tls]\ncert = a.pem\n"))
+	assert.EqualError(t, err, `While parsing config: line 3: section "server.tls" conflicts with key "server.tls"`)
+}
+
+func TestReadIniOptions(t *testing.T) {
+	v := NewWithOptions(IniLoadOptions(IniOptions{
+		DefaultSection:       "general",
+		IgnoreInlineComments: true,
+		AllowDuplicateKeys:   true,
+	}))
+	v.SetConfigType("ini")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+[general]
+name = legacy ; not a comment
+
+[server]
+allow = 10.0.0.1
+allow = 10.0.0.2
+allow = 10.0.0.3
+`)))
+
+	assert.Equal(t, "legacy ; not a comment", v.GetString("name"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, v.GetStringSlice("server.allow"))
+	assert.False(t, v.IsSet("general.name"))
+}
+
+func TestWriteIni(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	v := NewWithOptions(IniLoadOptions(IniOptions{AllowDuplicateKeys: true}))
+	v.SetFs(fs)
+	v.Set("name", "legacy")
+	v.Set("server.host", " localhost")
+	v.Set("server.port", 8080)
+	v.Set("server.allow", []string{"10.0.0.1", "10.0.0.2"})
+	v.Set("server.tls.cert", "/etc/ssl/cert.pem")
+	v.Set("log.color", "#ff0000")
+	require.NoError(t, v.WriteConfigAs("/etc/app/config.ini"))
+
+	b, err := afero.ReadFile(fs, "/etc/app/config.ini")
+	require.NoError(t, err)
+	assert.Equal(t, `name = legacy
+
+[log]
+color = "#ff0000"
+
+[server]
+allow = 10.0.0.1
+allow = 10.0.0.2
+host = " localhost"
+port = 8080
+
+[server.tls]
+cert = /etc/ssl/cert.pem
+`, string(b))
+
+	// the file reads back the same settings
+	r := NewWithOptions(IniLoadOptions(IniOptions{AllowDuplicateKeys: true}))
+	r.SetFs(fs)
+	r.SetConfigFile("/etc/app/config.ini")
+	require.NoError(t, r.ReadInConfig())
+	assert.Equal(t, " localhost", r.GetString("server.host"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, r.GetStringSlice("server.allow"))
+	assert.Equal(t, "#ff0000", r.GetString("log.color"))
+	assert.Equal(t, "/etc/ssl/cert.pem", r.GetString("server.tls.cert"))
+
+	// without duplicate keys, slices are comma separated
+	v.iniOptions.AllowDuplicateKeys = false
+	assert.Contains(t, string(v.marshalIni(v.AllSettings())), "allow = 10.0.0.1,10.0.0.2\n")
+}
+
+func TestIniRoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 70*1024)
+	values := map[string]string{
+		"quoted":    `say "hi" # now`,
+		"backslash": `C:\temp\ "dir"\`,
+		"path":      `C:\temp`,
+		"multiline": "first\r\nsecond\n",
+		"escapes":   `\n is not a newline`,
+		"long":      long,
+		"padded":    ` "` + long + `" `,
+	}
+
+	fs := afero.NewMemMapFs()
+	v := New()
+	v.SetFs(fs)
+	for key, value := range values {
+		v.Set("app."+key, value)
+	}
+	require.NoError(t, v.WriteConfigAs("/etc/app/config.ini"))
+
+	r := New()
+	r.SetFs(fs)
+	r.SetConfigFile("/etc/app/config.ini")
+	require.NoError(t, r.ReadInConfig())
+	for key, value := range values {
+		assert.Equal(t, value, r.GetString("app."+key), key)
+	}
+}
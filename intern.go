@@ -0,0 +1,67 @@
+package viper
+
+// InternStrings is a load transform, see OnLoadTransform, making equal
+// strings of a configuration map share their memory: string values, within
+// slices and nested maps included, and keys. Configurations repeating the
+// same strings many times, e.g. host names in routing tables, then hold a
+// single copy of each.
+//
+//	viper.OnLoadTransform(viper.InternStrings)
+func InternStrings(cfg map[string]interface{}) error {
+	interned := make(map[string]string)
+	internMap(cfg, interned)
+	return nil
+}
+
+func intern(s string, interned map[string]string) string {
+	if is, ok := interned[s]; ok {
+		return is
+	}
+	interned[s] = s
+	return s
+}
+
+func internMap(m map[string]interface{}, interned map[string]string) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		val := internValue(m[k], interned)
+		// assigning to an existing key keeps the key, replace it
+		delete(m, k)
+		m[intern(k, interned)] = val
+	}
+}
+
+func internValue(val interface{}, interned map[string]string) interface{} {
+	switch val := val.(type) {
+	case string:
+		return intern(val, interned)
+	case map[string]interface{}:
+		internMap(val, interned)
+	case map[interface{}]interface{}:
+		// e.g. the items of YAML lists of maps
+		keys := make([]interface{}, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		for _, k := range keys {
+			item := internValue(val[k], interned)
+			delete(val, k)
+			if s, ok := k.(string); ok {
+				k = intern(s, interned)
+			}
+			val[k] = item
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = internValue(item, interned)
+		}
+	case []string:
+		for i, s := range val {
+			val[i] = intern(s, interned)
+		}
+	}
+	return val
+}
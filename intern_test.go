@@ -0,0 +1,65 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stringData returns the address of the bytes of s.
+func stringData(s string) uintptr {
+	return (*[2]uintptr)(unsafe.Pointer(&s))[0]
+}
+
+func TestInternStrings(t *testing.T) {
+	v := New()
+	v.OnLoadTransform(InternStrings)
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{
+		"routes": {
+			"api": {"host": "backend.internal", "hosts": ["backend.internal", "backup.internal"]},
+			"web": {"host": "backend.internal"}
+		}
+	}`)))
+
+	api := v.GetString("routes.api.host")
+	web := v.GetString("routes.web.host")
+	hosts := v.Get("routes.api.hosts").([]interface{})
+	assert.Equal(t, "backend.internal", web)
+	assert.Equal(t, stringData(api), stringData(web))
+	assert.Equal(t, stringData(api), stringData(hosts[0].(string)))
+	assert.Equal(t, "backup.internal", hosts[1])
+
+	// keys are interned too
+	var keys []string
+	for _, route := range v.GetStringMap("routes") {
+		for key := range route.(map[string]interface{}) {
+			if key == "host" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	require.Len(t, keys, 2)
+	assert.Equal(t, stringData(keys[0]), stringData(keys[1]))
+}
+
+func TestInternStringsYAMLLists(t *testing.T) {
+	v := New()
+	v.OnLoadTransform(InternStrings)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+upstreams:
+- host: backend.internal
+- host: backend.internal
+`)))
+
+	upstreams := v.Get("upstreams").([]interface{})
+	require.Len(t, upstreams, 2)
+	first := upstreams[0].(map[interface{}]interface{})
+	second := upstreams[1].(map[interface{}]interface{})
+	assert.Equal(t, "backend.internal", first["host"])
+	assert.Equal(t, stringData(first["host"].(string)), stringData(second["host"].(string)))
+}
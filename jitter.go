@@ -0,0 +1,133 @@
+package viper
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// jitterSeparators separate the base duration from the jitter, see
+// GetDurationJitter.
+var jitterSeparators = []string{"±", "+-", "+/-"}
+
+// JitteredDuration is a duration with a random variation, e.g. an interval
+// between retries, as returned by GetDurationJitter.
+type JitteredDuration struct {
+	Base   time.Duration
+	Jitter time.Duration
+}
+
+// Rand returns a duration picked uniformly between Base-Jitter and
+// Base+Jitter, and not below 0 nor above the largest duration.
+func (d JitteredDuration) Rand() time.Duration {
+	if d.Jitter <= 0 {
+		return d.Base
+	}
+	// 2*Jitter may not fit in an int64, but always fits in a uint64
+	span := 2 * uint64(d.Jitter)
+	var offset uint64
+	if span < math.MaxInt64 {
+		offset = uint64(rand.Int63n(int64(span) + 1))
+	} else {
+		offset = rand.Uint64() % (span + 1)
+	}
+
+	var delta time.Duration
+	if offset >= uint64(d.Jitter) {
+		delta = time.Duration(offset - uint64(d.Jitter))
+	} else {
+		delta = -time.Duration(uint64(d.Jitter) - offset)
+	}
+	switch {
+	case delta > 0 && d.Base > math.MaxInt64-delta:
+		return math.MaxInt64
+	case delta < 0 && d.Base < math.MinInt64-delta:
+		return 0
+	}
+	if val := d.Base + delta; val > 0 {
+		return val
+	}
+	return 0
+}
+
+// String returns the duration as parsed by GetDurationJitter.
+func (d JitteredDuration) String() string {
+	if d.Jitter == 0 {
+		return d.Base.String()
+	}
+	return d.Base.String() + "±" + d.Jitter.String()
+}
+
+// GetDurationJitter returns the value associated with the key as a duration
+// with a random variation, given as "30s±10%", "30s±5s" ("+-" and "+/-" are
+// accepted as well), a plain duration without jitter, or a map with base and
+// jitter keys. Negative jitters and percentages beyond 100% are errors. Call
+// Rand on the result for every interval, e.g. between retries, so that
+// clients don't act in lockstep.
+func GetDurationJitter(key string) JitteredDuration { return v.GetDurationJitter(key) }
+func (v *Viper) GetDurationJitter(key string) JitteredDuration {
+	d, _ := v.GetDurationJitterE(key)
+	return d
+}
+
+// GetDurationJitterE behaves like GetDurationJitter, but returns a
+// KeyNotSetError if the key has no value and a CastError if its value isn't a
+// valid duration with jitter.
+func GetDurationJitterE(key string) (JitteredDuration, error) { return v.GetDurationJitterE(key) }
+func (v *Viper) GetDurationJitterE(key string) (JitteredDuration, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return JitteredDuration{}, err
+	}
+	d, err := parseDurationJitter(val)
+	return d, v.castError(key, val, err)
+}
+
+func parseDurationJitter(val interface{}) (JitteredDuration, error) {
+	var base, jitter interface{}
+	switch val := val.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := cast.ToStringMap(val)
+		base, jitter = m["base"], m["jitter"]
+	case string:
+		base = strings.TrimSpace(val)
+		for _, sep := range jitterSeparators {
+			if i := strings.Index(val, sep); i >= 0 {
+				base, jitter = strings.TrimSpace(val[:i]), strings.TrimSpace(val[i+len(sep):])
+				break
+			}
+		}
+	default:
+		base = val
+	}
+
+	d := JitteredDuration{}
+	var err error
+	if d.Base, err = cast.ToDurationE(base); err != nil {
+		return JitteredDuration{}, err
+	}
+	if jitter == nil {
+		return d, nil
+	}
+	if s, ok := jitter.(string); ok && strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+		if err != nil || math.IsNaN(percent) {
+			return JitteredDuration{}, fmt.Errorf("invalid jitter %q", s)
+		}
+		if percent < 0 || percent > 100 {
+			return JitteredDuration{}, fmt.Errorf("jitter %q out of range 0%% to 100%%", s)
+		}
+		d.Jitter = time.Duration(float64(d.Base) * percent / 100)
+	} else if d.Jitter, err = cast.ToDurationE(jitter); err != nil {
+		return JitteredDuration{}, err
+	}
+	if d.Jitter < 0 {
+		return JitteredDuration{}, fmt.Errorf("negative jitter %v", jitter)
+	}
+	return d, nil
+}
@@ -0,0 +1,61 @@
+package viper
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDurationJitter(t *testing.T) {
+	v := New()
+	for value, want := range map[interface{}]JitteredDuration{
+		"30s±10%":    {Base: 30 * time.Second, Jitter: 3 * time.Second},
+		"1m +- 5s":   {Base: time.Minute, Jitter: 5 * time.Second},
+		"2s+/-50%":   {Base: 2 * time.Second, Jitter: time.Second},
+		"10s":        {Base: 10 * time.Second},
+		time.Second:  {Base: time.Second},
+		"500ms±0.5%": {Base: 500 * time.Millisecond, Jitter: 2500 * time.Microsecond},
+	} {
+		v.Set("retry", value)
+		d, err := v.GetDurationJitterE("retry")
+		require.NoError(t, err, value)
+		assert.Equal(t, want, d, value)
+	}
+
+	v.Set("poll", map[string]interface{}{"base": "1m", "jitter": "20%"})
+	d := v.GetDurationJitter("poll")
+	assert.Equal(t, JitteredDuration{Base: time.Minute, Jitter: 12 * time.Second}, d)
+	assert.Equal(t, "1m0s±12s", d.String())
+	for i := 0; i < 100; i++ {
+		r := d.Rand()
+		assert.True(t, r >= 48*time.Second && r <= 72*time.Second, r)
+	}
+	assert.Equal(t, time.Minute, JitteredDuration{Base: time.Minute}.Rand())
+	assert.True(t, JitteredDuration{Base: time.Second, Jitter: time.Hour}.Rand() >= 0)
+	for _, d := range []JitteredDuration{
+		{Base: time.Second, Jitter: math.MaxInt64},
+		{Base: math.MaxInt64, Jitter: math.MaxInt64},
+		{Base: math.MaxInt64, Jitter: math.MaxInt64 / 2},
+		{Base: math.MinInt64, Jitter: math.MaxInt64},
+	} {
+		for i := 0; i < 100; i++ {
+			assert.True(t, d.Rand() >= 0, d)
+		}
+	}
+
+	for _, value := range []string{"1m±250%", "1m±-5%", "1m±-5s"} {
+		v.Set("retry", value)
+		_, err := v.GetDurationJitterE("retry")
+		assert.IsType(t, CastError{}, err, value)
+	}
+
+	v.Set("retry", "30s±ten%")
+	_, err := v.GetDurationJitterE("retry")
+	assert.IsType(t, CastError{}, err)
+	assert.Equal(t, JitteredDuration{}, v.GetDurationJitter("retry"))
+	_, err = v.GetDurationJitterE("missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+}
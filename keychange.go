@@ -0,0 +1,110 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// keyWatchers holds the functions registered through OnKeyChange.
+type keyWatchers struct {
+	mu       sync.Mutex
+	watchers []keyWatcher
+	// serializes the dispatching of changes
+	dispatch sync.Mutex
+}
+
+type keyWatcher struct {
+	// key is the watched key, or the prefix of the watched keys if wildcard
+	// is set, e.g. "database." for "database.*"
+	key      string
+	wildcard bool
+	run      func(oldValue, newValue interface{})
+}
+
+// OnKeyChange sets a function called when the value of key changes on a
+// config file reload triggered by WatchConfig. run is given the values before
+// and after the reload; either is nil if the key was added or removed.
+//
+// key may end with a "*" path element, e.g. "database.*", to watch all keys
+// below a prefix, or be "*" to watch all keys: run is then called once per
+// changed key, in key order. Functions are called from the watcher goroutine,
+// one at a time, once the new configuration is in place.
+// OnKeyChange is case-insensitive for a key.
+func OnKeyChange(key string, run func(oldValue, newValue interface{})) {
+	v.OnKeyChange(key, run)
+}
+func (v *Viper) OnKeyChange(key string, run func(oldValue, newValue interface{})) {
+	w := keyWatcher{key: v.normalizeKey(key), run: run}
+	if w.key == "*" {
+		w.key, w.wildcard = "", true
+	} else if strings.HasSuffix(w.key, v.keyDelim+"*") {
+		w.key, w.wildcard = strings.TrimSuffix(w.key, "*"), true
+	}
+
+	v.keyWatchers.mu.Lock()
+	v.keyWatchers.watchers = append(v.keyWatchers.watchers, w)
+	v.keyWatchers.mu.Unlock()
+}
+
+// watchedValues returns the current values of the keys watched through
+// OnKeyChange, or nil if no key is watched.
+func (v *Viper) watchedValues() map[string]interface{} {
+	v.keyWatchers.mu.Lock()
+	watchers := v.keyWatchers.watchers
+	v.keyWatchers.mu.Unlock()
+	if len(watchers) == 0 {
+		return nil
+	}
+
+	values := map[string]interface{}{}
+	all := false
+	for _, w := range watchers {
+		if w.wildcard {
+			all = true
+			continue
+		}
+		values[w.key] = v.Get(w.key)
+	}
+	if all {
+		for _, key := range v.AllKeys() {
+			values[key] = v.Get(key)
+		}
+	}
+	return values
+}
+
+// dispatchKeyChanges calls the OnKeyChange functions of the keys whose value
+// differs between the given watchedValues results.
+func (v *Viper) dispatchKeyChanges(before, after map[string]interface{}) {
+	v.keyWatchers.mu.Lock()
+	watchers := v.keyWatchers.watchers
+	v.keyWatchers.mu.Unlock()
+	if len(watchers) == 0 {
+		return
+	}
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	changed := []string{}
+	for _, key := range sortedKeys(keys) {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			changed = append(changed, key)
+		}
+	}
+
+	v.keyWatchers.dispatch.Lock()
+	defer v.keyWatchers.dispatch.Unlock()
+	for _, w := range watchers {
+		for _, key := range changed {
+			if (w.wildcard && strings.HasPrefix(key, w.key)) || (!w.wildcard && key == w.key) {
+				w.run(before[key], after[key])
+			}
+		}
+	}
+}
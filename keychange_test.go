@@ -0,0 +1,54 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyChange struct {
+	old, new interface{}
+}
+
+func TestOnKeyChange(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+database:
+  pool_size: 10
+  host: localhost
+name: steve
+`)))
+
+	var poolSize, database, all []keyChange
+	v.OnKeyChange("Database.Pool_Size", func(o, n interface{}) { poolSize = append(poolSize, keyChange{o, n}) })
+	v.OnKeyChange("database.*", func(o, n interface{}) { database = append(database, keyChange{o, n}) })
+	v.OnKeyChange("*", func(o, n interface{}) { all = append(all, keyChange{o, n}) })
+
+	before := v.watchedValues()
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+database:
+  pool_size: 20
+  user: admin
+name: steve
+`)))
+	v.dispatchKeyChanges(before, v.watchedValues())
+
+	assert.Equal(t, []keyChange{{10, 20}}, poolSize)
+	// database.host removed, database.pool_size changed, database.user added
+	assert.Equal(t, []keyChange{{"localhost", nil}, {10, 20}, {nil, "admin"}}, database)
+	assert.Equal(t, database, all)
+}
+
+func TestOnKeyChangeUnwatched(t *testing.T) {
+	v := New()
+	assert.Nil(t, v.watchedValues())
+
+	v.OnKeyChange("name", func(o, n interface{}) { t.Error("unexpected change") })
+	v.Set("name", "steve")
+	before := v.watchedValues()
+	v.Set("age", 35)
+	v.dispatchKeyChanges(before, v.watchedValues())
+}
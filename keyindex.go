@@ -0,0 +1,70 @@
+package viper
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// keyIndex maps the keys given to Get to their values, for as long as the
+// configuration generation doesn't change.
+type keyIndex struct {
+	// current holds the *keyIndexEntries of the last generation
+	current atomic.Value
+}
+
+type keyIndexEntries struct {
+	gen    uint64
+	values sync.Map
+}
+
+// EnableKeyIndex makes Get, and the many Get____ methods built on it, keep
+// the value of every key they resolve in an index, so that later calls for
+// the key are a single map lookup instead of a walk through all
+// configuration layers. The index is dropped whenever the Generation of the
+// configuration increases, i.e. on Set, bindings, reads and merges, and by
+// InvalidateCache.
+//
+// Values from environment variables and flags are indexed too, while they
+// can change without the configuration generation increasing: call
+// InvalidateCache after changing them. Keys registered through CacheKey keep
+// their own expiry and aren't indexed.
+func EnableKeyIndex() { v.EnableKeyIndex() }
+func (v *Viper) EnableKeyIndex() {
+	if v.keyIndex == nil {
+		v.keyIndex = &keyIndex{}
+		v.keyIndex.reset()
+	}
+}
+
+func (ki *keyIndex) reset() {
+	ki.current.Store((*keyIndexEntries)(nil))
+}
+
+// get returns the value of key from the index, resolving and indexing it
+// if needed.
+func (ki *keyIndex) get(v *Viper, key string) interface{} {
+	gen := v.Generation()
+	entries := ki.current.Load().(*keyIndexEntries)
+	if entries != nil && entries.gen == gen {
+		if val, ok := entries.values.Load(key); ok {
+			return val
+		}
+	}
+
+	val := v.get(key)
+
+	v.cache.mu.Lock()
+	_, cached := v.cache.ttls[v.normalizeKey(key)]
+	v.cache.mu.Unlock()
+	if cached {
+		return val
+	}
+	// values resolved while the generation changes are indexed for the
+	// generation read before, which is no longer current
+	if entries == nil || entries.gen != gen {
+		entries = &keyIndexEntries{gen: gen}
+		ki.current.Store(entries)
+	}
+	entries.values.Store(key, val)
+	return val
+}
@@ -0,0 +1,77 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyIndex(t *testing.T) {
+	v := New()
+	v.EnableKeyIndex()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("db:\n  host: localhost\nname: steve\n")))
+
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+	assert.Equal(t, "localhost", v.GetString("DB.Host"))
+	assert.Nil(t, v.Get("missing"))
+
+	// changes drop the index
+	v.Set("db.host", "db.prod")
+	assert.Equal(t, "db.prod", v.GetString("db.host"))
+	v.SetDefault("missing", "found")
+	assert.Equal(t, "found", v.Get("missing"))
+	require.NoError(t, v.MergeConfig(bytes.NewBufferString("name: stevens\n")))
+	assert.Equal(t, "stevens", v.Get("name"))
+
+	// env variables are read again once the index is invalidated
+	v.BindEnv("token", "VIPER_TEST_TOKEN")
+	assert.Nil(t, v.Get("token"))
+	os.Setenv("VIPER_TEST_TOKEN", "secret")
+	defer os.Unsetenv("VIPER_TEST_TOKEN")
+	assert.Nil(t, v.Get("token"))
+	v.InvalidateCache()
+	assert.Equal(t, "secret", v.Get("token"))
+}
+
+func TestKeyIndexMounted(t *testing.T) {
+	auth := New()
+	auth.Set("provider", "ldap")
+	v := New()
+	v.EnableKeyIndex()
+	require.NoError(t, v.Mount("auth", auth))
+
+	assert.Equal(t, "ldap", v.Get("auth.provider"))
+	auth.Set("provider", "oidc")
+	assert.Equal(t, "oidc", v.Get("auth.provider"))
+}
+
+func TestKeyIndexCachedKeys(t *testing.T) {
+	v := New()
+	v.EnableKeyIndex()
+	v.CacheKey("token", time.Nanosecond)
+	v.BindEnv("token", "VIPER_TEST_TOKEN")
+	assert.Nil(t, v.Get("token"))
+
+	os.Setenv("VIPER_TEST_TOKEN", "secret")
+	defer os.Unsetenv("VIPER_TEST_TOKEN")
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, "secret", v.Get("token"))
+}
+
+func BenchmarkGetIndexed(b *testing.B) {
+	key := "BenchmarkGet"
+	v := New()
+	v.Set(key, true)
+	v.EnableKeyIndex()
+
+	for i := 0; i < b.N; i++ {
+		if !v.Get(key).(bool) {
+			b.Fatal("Get returned false")
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Package kubernetes integrates Kubernetes ConfigMaps and Secrets with Viper.
+//
+// Importing the package registers the "k8s" remote provider, reading a key
+// of a ConfigMap or Secret through the Kubernetes API:
+//
+//	import _ "github.com/spf13/viper/kubernetes"
+//
+//	viper.AddRemoteProvider("k8s", "default/myapp", "config.yaml")
+//	viper.SetConfigType("yaml")
+//	err := viper.ReadRemoteConfig()
+//
+// The endpoint is "namespace/name" for a ConfigMap, or "secrets/namespace/name"
+// for a Secret, and the path is the key of the ConfigMap or Secret data
+// holding the configuration, parsed according to SetConfigType.
+// WatchRemoteConfigOnChannel is driven by a Kubernetes watch rather than by
+// polling.
+//
+// The API server is reached with the service account of the pod when running
+// in a cluster, and with the current context of the kubeconfig file given by
+// the KUBECONFIG environment variable, or ~/.kube/config, otherwise.
+//
+// WriteManifest renders the configuration as a ConfigMap or Secret manifest.
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Client is a minimal client for the Kubernetes API.
+type Client struct {
+	// Host is the URL of the API server, e.g. "https://10.0.0.1:443".
+	Host string
+	// Token is the bearer token used to authenticate requests, if any.
+	Token string
+	// HTTPClient performs the requests, holding the TLS configuration of the
+	// cluster; a client timing out after 30 seconds is used when nil.
+	HTTPClient *http.Client
+}
+
+// defaultHTTPClient performs the requests of clients without an HTTPClient.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Error denotes an error response from the Kubernetes API.
+type Error struct {
+	StatusCode int
+	Reason     string
+	Message    string
+}
+
+// Error returns the formatted Kubernetes error.
+func (e Error) Error() string {
+	return fmt.Sprintf("Kubernetes Error %d %s: %s", e.StatusCode, e.Reason, e.Message)
+}
+
+// NewClient returns a client for the cluster the process runs in, or for the
+// current context of the kubeconfig file outside of a cluster.
+func NewClient() (*Client, error) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return InClusterClient()
+	}
+	path := os.Getenv("KUBECONFIG")
+	if path == "" {
+		path = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	// KUBECONFIG may list several files; the first one is used
+	return KubeconfigClient(filepath.SplitList(path)[0])
+}
+
+// InClusterClient returns a client authenticating with the service account
+// of the pod the process runs in.
+func InClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster")
+	}
+	token, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSConfig(ca, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Host:       "https://" + net.JoinHostPort(host, port),
+		Token:      strings.TrimSpace(string(token)),
+		HTTPClient: &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+	}, nil
+}
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string
+		Cluster struct {
+			Server                   string
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		}
+	}
+	Users []struct {
+		Name string
+		User struct {
+			Token                 string
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		}
+	}
+	Contexts []struct {
+		Name    string
+		Context struct {
+			Cluster string
+			User    string
+		}
+	}
+}
+
+// KubeconfigClient returns a client for the current context of the
+// kubeconfig file at path. Token and client certificate authentication are
+// supported.
+func KubeconfigClient(path string) (*Client, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg kubeconfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+
+	var clusterName, userName string
+	for _, c := range cfg.Contexts {
+		if c.Name == cfg.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("kubeconfig %s: context %q not found", path, cfg.CurrentContext)
+	}
+
+	client := &Client{}
+	var ca, cert, key []byte
+	insecure := false
+	for _, c := range cfg.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		client.Host = c.Cluster.Server
+		insecure = c.Cluster.InsecureSkipTLSVerify
+		if ca, err = readData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, dir); err != nil {
+			return nil, err
+		}
+	}
+	if client.Host == "" {
+		return nil, fmt.Errorf("kubeconfig %s: cluster %q not found", path, clusterName)
+	}
+	for _, u := range cfg.Users {
+		if u.Name != userName {
+			continue
+		}
+		client.Token = u.User.Token
+		if cert, err = readData(u.User.ClientCertificateData, u.User.ClientCertificate, dir); err != nil {
+			return nil, err
+		}
+		if key, err = readData(u.User.ClientKeyData, u.User.ClientKey, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	tlsConfig, err := newTLSConfig(ca, cert, key, insecure)
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	return client, nil
+}
+
+// readData returns the base64 encoded data, or the contents of the file if
+// no data is given. Relative file paths are relative to dir.
+func readData(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+func newTLSConfig(ca, cert, key []byte, insecure bool) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: insecure}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("invalid cluster certificate authority")
+		}
+		cfg.RootCAs = pool
+	}
+	if len(cert) > 0 && len(key) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return cfg, nil
+}
+
+// Object is a ConfigMap or Secret.
+type Object struct {
+	Metadata struct {
+		Name            string
+		Namespace       string
+		ResourceVersion string
+	}
+	// Data holds the values of the object; Secret values are base64 encoded.
+	Data map[string]string
+}
+
+// Value returns the value of key, decoding Secret values.
+func (o *Object) Value(resource, key string) ([]byte, error) {
+	val, ok := o.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found in %s %s/%s", key, resource, o.Metadata.Namespace, o.Metadata.Name)
+	}
+	if resource == "secrets" {
+		return base64.StdEncoding.DecodeString(val)
+	}
+	return []byte(val), nil
+}
+
+// Get returns the object of the given resource, "configmaps" or "secrets".
+func (c *Client) Get(resource, namespace, name string) (*Object, error) {
+	resp, err := c.do(fmt.Sprintf("/api/v1/namespaces/%s/%s/%s",
+		url.PathEscape(namespace), resource, url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	obj := &Object{}
+	if err := json.NewDecoder(resp.Body).Decode(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// WatchEvent is a change to a watched object.
+type WatchEvent struct {
+	// Type is "ADDED", "MODIFIED", "DELETED" or "ERROR".
+	Type   string
+	Object Object
+}
+
+// Watch watches the object of the given resource for changes after
+// resourceVersion, calling handle for each change until the server ends the
+// watch or stop is closed. An empty resourceVersion starts the watch with an
+// "ADDED" event for the current object. If the HTTP client times out, the
+// server is asked to end the watch within half the timeout.
+func (c *Client) Watch(resource, namespace, name, resourceVersion string, stop <-chan bool, handle func(WatchEvent)) error {
+	query := url.Values{
+		"watch":         {"true"},
+		"fieldSelector": {"metadata.name=" + name},
+	}
+	if resourceVersion != "" {
+		query.Set("resourceVersion", resourceVersion)
+	}
+	if timeout := c.httpClient().Timeout; timeout > 0 {
+		seconds := int(timeout / 2 / time.Second)
+		if seconds < 1 {
+			seconds = 1
+		}
+		query.Set("timeoutSeconds", strconv.Itoa(seconds))
+	}
+	resp, err := c.do(fmt.Sprintf("/api/v1/namespaces/%s/%s", url.PathEscape(namespace), resource), query)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	defer resp.Body.Close()
+	go func() {
+		select {
+		case <-stop:
+			resp.Body.Close()
+		case <-done:
+		}
+	}()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var event WatchEvent
+		if err := dec.Decode(&event); err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		handle(event)
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return defaultHTTPClient
+	}
+	return c.HTTPClient
+}
+
+func (c *Client) do(path string, query url.Values) (*http.Response, error) {
+	u := strings.TrimSuffix(c.Host, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		kerr := Error{StatusCode: resp.StatusCode}
+		var status struct {
+			Reason  string
+			Message string
+		}
+		if json.NewDecoder(resp.Body).Decode(&status) == nil {
+			kerr.Reason, kerr.Message = status.Reason, status.Message
+		}
+		return nil, kerr
+	}
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ManifestOptions configures the manifest written by WriteManifest.
+type ManifestOptions struct {
+	// Name and Namespace are the metadata of the object; the namespace is
+	// left out if empty.
+	Name      string
+	Namespace string
+	// Secret writes a Secret rather than a ConfigMap.
+	Secret bool
+	// Keys selects the keys written, along with the keys below them, e.g.
+	// "server" for "server.host" and "server.port"; every key is written if
+	// empty.
+	Keys []string
+	// Env writes the environment variables the configuration reads as the
+	// data of the object, see viper.EnvBindings, to be loaded with envFrom.
+	// The configuration is otherwise written as a YAML file under FileKey.
+	Env bool
+	// FileKey is the data key holding the configuration file, "config.yaml"
+	// if empty, to be read back with the "k8s" remote provider or mounted as
+	// a volume.
+	FileKey string
+}
+
+// manifest is a ConfigMap or Secret, as written by WriteManifest.
+type manifest struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace,omitempty"`
+	} `yaml:"metadata"`
+	Data map[string]string `yaml:"data"`
+}
+
+// WriteManifest writes the effective configuration of v as a ConfigMap or
+// Secret manifest, so that deployments don't keep a template of the same
+// values. Secret keys, see viper.MarkSecret, are left out of ConfigMaps and
+// are expected in a Secret written with the same keys; Secret values are
+// base64 encoded. Keys without a value are left out.
+//
+//	err := kubernetes.WriteManifest(os.Stdout, viper.GetViper(), kubernetes.ManifestOptions{
+//		Name: "myapp",
+//		Keys: []string{"server", "log"},
+//	})
+func WriteManifest(w io.Writer, v *viper.Viper, opts ManifestOptions) error {
+	if opts.Name == "" {
+		return fmt.Errorf("manifest name is required")
+	}
+
+	var m manifest
+	m.APIVersion, m.Kind = "v1", "ConfigMap"
+	if opts.Secret {
+		m.Kind = "Secret"
+	}
+	m.Metadata.Name, m.Metadata.Namespace = opts.Name, opts.Namespace
+	m.Data = map[string]string{}
+
+	selected := func(key string) bool {
+		if !opts.Secret && v.IsSecret(key) {
+			return false
+		}
+		if len(opts.Keys) == 0 {
+			return true
+		}
+		for _, prefix := range opts.Keys {
+			prefix = strings.ToLower(prefix)
+			if key == prefix || strings.HasPrefix(key, prefix+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if opts.Env {
+		for name, key := range v.EnvBindings() {
+			if val := v.Get(key); val != nil && selected(key) {
+				m.Data[name] = fmt.Sprint(val)
+			}
+		}
+	} else {
+		settings := viper.New()
+		for _, key := range v.AllKeys() {
+			if val := v.Get(key); val != nil && selected(key) {
+				settings.Set(key, val)
+			}
+		}
+		b, err := yaml.Marshal(settings.AllSettings())
+		if err != nil {
+			return err
+		}
+		fileKey := opts.FileKey
+		if fileKey == "" {
+			fileKey = "config.yaml"
+		}
+		m.Data[fileKey] = string(b)
+	}
+
+	if opts.Secret {
+		for k, val := range m.Data {
+			m.Data[k] = base64.StdEncoding.EncodeToString([]byte(val))
+		}
+	}
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,78 @@
+package kubernetes
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newManifestViper() *viper.Viper {
+	v := viper.New()
+	v.SetDefault("server.host", "localhost")
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("log.level", "info")
+	v.Set("db.password", "hunter2")
+	v.MarkSecret("db.password")
+	return v
+}
+
+func TestWriteManifestConfigMap(t *testing.T) {
+	v := newManifestViper()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteManifest(&buf, v, ManifestOptions{Name: "myapp", Namespace: "default", Keys: []string{"Server", "db"}}))
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp
+  namespace: default
+data:
+  config.yaml: |
+    server:
+      host: localhost
+      port: 8080
+`, buf.String())
+}
+
+func TestWriteManifestSecret(t *testing.T) {
+	v := newManifestViper()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteManifest(&buf, v, ManifestOptions{Name: "myapp", Secret: true, Keys: []string{"db"}, FileKey: "secrets.yaml"}))
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: myapp
+data:
+  secrets.yaml: ZGI6CiAgcGFzc3dvcmQ6IGh1bnRlcjIK
+`, buf.String())
+}
+
+func TestWriteManifestEnv(t *testing.T) {
+	os.Setenv("APP_SERVER_HOST", "example.com")
+	defer os.Unsetenv("APP_SERVER_HOST")
+
+	v := newManifestViper()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteManifest(&buf, v, ManifestOptions{Name: "myapp-env", Env: true}))
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: myapp-env
+data:
+  APP_LOG_LEVEL: info
+  APP_SERVER_HOST: example.com
+  APP_SERVER_PORT: "8080"
+`, buf.String())
+
+	assert.Error(t, WriteManifest(&buf, v, ManifestOptions{}))
+}
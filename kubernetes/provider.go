@@ -0,0 +1,141 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RetryInterval is the interval at which WatchRemoteConfigOnChannel retries
+// a failed watch.
+var RetryInterval = 10 * time.Second
+
+// remoteConfigProvider serves ConfigMaps and Secrets as the "k8s" remote
+// provider.
+type remoteConfigProvider struct{}
+
+func (rc remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	b, _, err := rc.read(rp)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (rc remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return rc.Get(rp)
+}
+
+// IsSecret marks the keys read from Secrets, rather than ConfigMaps, as
+// secrets, see viper.MarkSecret.
+func (rc remoteConfigProvider) IsSecret(rp viper.RemoteProvider) bool {
+	resource, _, _, err := parseEndpoint(rp.Endpoint())
+	return err == nil && resource == "secrets"
+}
+
+func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	quit := make(chan bool)
+	viperResponsCh := make(chan *viper.RemoteResponse)
+	last, version, _ := rc.read(rp)
+	go func() {
+		send := func(resp *viper.RemoteResponse) {
+			select {
+			case <-quit:
+			case viperResponsCh <- resp:
+			}
+		}
+		resource, namespace, name, err := parseEndpoint(rp.Endpoint())
+		if err != nil {
+			send(&viper.RemoteResponse{Error: err})
+			return
+		}
+		for {
+			client, err := NewClient()
+			if err == nil {
+				err = client.Watch(resource, namespace, name, version, quit, func(event WatchEvent) {
+					switch event.Type {
+					case "ADDED", "MODIFIED":
+						version = event.Object.Metadata.ResourceVersion
+						b, err := event.Object.Value(resource, rp.Path())
+						if err == nil {
+							if bytes.Equal(b, last) {
+								return
+							}
+							last = b
+						}
+						send(&viper.RemoteResponse{Value: b, Error: err})
+					case "DELETED":
+						version, last = "", nil
+						send(&viper.RemoteResponse{Error: fmt.Errorf("%s %s/%s deleted", resource, namespace, name)})
+					default:
+						// the watched version is gone; restart from the current object
+						version = ""
+					}
+				})
+			}
+			select {
+			case <-quit:
+				return
+			default:
+			}
+			if err == nil {
+				continue
+			}
+			send(&viper.RemoteResponse{Error: err})
+			select {
+			case <-quit:
+				return
+			case <-time.After(RetryInterval):
+			}
+		}
+	}()
+	return viperResponsCh, quit
+}
+
+// read returns the value of the key of the object of rp, along with the
+// version of the object.
+func (rc remoteConfigProvider) read(rp viper.RemoteProvider) ([]byte, string, error) {
+	resource, namespace, name, err := parseEndpoint(rp.Endpoint())
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := NewClient()
+	if err != nil {
+		return nil, "", err
+	}
+	obj, err := client.Get(resource, namespace, name)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := obj.Value(resource, rp.Path())
+	return b, obj.Metadata.ResourceVersion, err
+}
+
+// parseEndpoint splits a provider endpoint into the resource, namespace and
+// name of the object.
+func parseEndpoint(endpoint string) (string, string, string, error) {
+	parts := strings.Split(endpoint, "/")
+	resource := "configmaps"
+	if len(parts) == 3 {
+		switch parts[0] {
+		case "configmaps", "configmap", "cm":
+		case "secrets", "secret":
+			resource = "secrets"
+		default:
+			return "", "", "", fmt.Errorf("unsupported Kubernetes resource %q", parts[0])
+		}
+		parts = parts[1:]
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid Kubernetes endpoint %q, expected namespace/name", endpoint)
+	}
+	return resource, parts[0], parts[1], nil
+}
+
+func init() {
+	viper.RegisterRemoteConfigProvider("k8s", remoteConfigProvider{})
+}
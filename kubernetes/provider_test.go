@@ -0,0 +1,174 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func object(name, version string, data map[string]string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "default", "resourceVersion": version},
+		"data":     data,
+	}
+}
+
+func newTestAPIServer(t *testing.T, events <-chan map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/configmaps/myapp":
+			json.NewEncoder(w).Encode(object("myapp", "1", map[string]string{"config.yaml": "name: steve\n"}))
+		case "/api/v1/namespaces/default/secrets/myapp":
+			json.NewEncoder(w).Encode(object("myapp", "1", map[string]string{
+				"config.json": base64.StdEncoding.EncodeToString([]byte(`{"password": "hunter2"}`)),
+			}))
+		case "/api/v1/namespaces/default/configmaps":
+			assert.Equal(t, "true", r.URL.Query().Get("watch"))
+			assert.Equal(t, "metadata.name=myapp", r.URL.Query().Get("fieldSelector"))
+			assert.Equal(t, "1", r.URL.Query().Get("resourceVersion"))
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-events:
+					json.NewEncoder(w).Encode(event)
+					w.(http.Flusher).Flush()
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind": "Status", "reason": "NotFound", "message": "not found"}`)
+		}
+	}))
+}
+
+func setKubeconfig(t *testing.T, server string) func() {
+	dir, err := ioutil.TempDir("", "viper-k8s")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "config")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+current-context: test
+contexts:
+- name: test
+  context: {cluster: test, user: test}
+clusters:
+- name: test
+  cluster: {server: "`+server+`"}
+users:
+- name: test
+  user: {token: s3cr3t}
+`), 0600))
+	os.Unsetenv("KUBERNETES_SERVICE_HOST")
+	os.Setenv("KUBECONFIG", path)
+	return func() {
+		os.Unsetenv("KUBECONFIG")
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRemoteProvider(t *testing.T) {
+	srv := newTestAPIServer(t, nil)
+	defer srv.Close()
+	defer setKubeconfig(t, srv.URL)()
+
+	v := viper.New()
+	require.NoError(t, v.AddRemoteProvider("k8s", "default/myapp", "config.yaml"))
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+	assert.False(t, v.IsSecret("name"))
+
+	v = viper.New()
+	require.NoError(t, v.AddRemoteProvider("k8s", "secrets/default/myapp", "config.json"))
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "hunter2", v.GetString("password"))
+	assert.True(t, v.IsSecret("password"))
+
+	_, err := remoteConfigProvider{}.Get(testProvider{endpoint: "default/other", path: "config.yaml"})
+	assert.Equal(t, Error{StatusCode: 404, Reason: "NotFound", Message: "not found"}, err)
+
+	_, err = remoteConfigProvider{}.Get(testProvider{endpoint: "default/myapp", path: "missing"})
+	assert.EqualError(t, err, `key "missing" not found in configmaps default/myapp`)
+}
+
+func TestParseEndpoint(t *testing.T) {
+	resource, namespace, name, err := parseEndpoint("kube-system/myapp")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"configmaps", "kube-system", "myapp"}, []string{resource, namespace, name})
+
+	resource, _, _, err = parseEndpoint("secret/default/myapp")
+	require.NoError(t, err)
+	assert.Equal(t, "secrets", resource)
+
+	_, _, _, err = parseEndpoint("myapp")
+	assert.Error(t, err)
+	_, _, _, err = parseEndpoint("pods/default/myapp")
+	assert.Error(t, err)
+}
+
+func TestRemoteProviderWatchChannel(t *testing.T) {
+	events := make(chan map[string]interface{})
+	srv := newTestAPIServer(t, events)
+	defer srv.Close()
+	defer setKubeconfig(t, srv.URL)()
+
+	respc, quit := remoteConfigProvider{}.WatchChannel(testProvider{endpoint: "default/myapp", path: "config.yaml"})
+	defer close(quit)
+
+	// unchanged values aren't sent
+	events <- map[string]interface{}{
+		"type":   "MODIFIED",
+		"object": object("myapp", "2", map[string]string{"config.yaml": "name: steve\n", "other": "x"}),
+	}
+	events <- map[string]interface{}{
+		"type":   "MODIFIED",
+		"object": object("myapp", "3", map[string]string{"config.yaml": "name: bob\n"}),
+	}
+	select {
+	case resp := <-respc:
+		require.NoError(t, resp.Error)
+		assert.Equal(t, "name: bob\n", string(resp.Value))
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not sent")
+	}
+}
+
+type testProvider struct {
+	endpoint, path string
+}
+
+func (rp testProvider) Provider() string      { return "k8s" }
+func (rp testProvider) Endpoint() string      { return rp.endpoint }
+func (rp testProvider) Path() string          { return rp.path }
+func (rp testProvider) SecretKeyring() string { return "" }
+
+func TestWatchTimeout(t *testing.T) {
+	timeouts := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeouts <- r.URL.Query().Get("timeoutSeconds")
+	}))
+	defer srv.Close()
+
+	// the default client times out, so the server ends the watch first
+	client := &Client{Host: srv.URL}
+	require.NoError(t, client.Watch("configmaps", "default", "myapp", "", nil, func(WatchEvent) {}))
+	assert.Equal(t, "15", <-timeouts)
+
+	client.HTTPClient = &http.Client{}
+	require.NoError(t, client.Watch("configmaps", "default", "myapp", "", nil, func(WatchEvent) {}))
+	assert.Empty(t, <-timeouts)
+}
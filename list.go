@@ -0,0 +1,46 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// GetByName returns a new Viper instance representing the element of the
+// list at key whose name field is name, like Sub does for maps:
+//
+//	endpoints:
+//	  - name: payments
+//	    url: https://payments.example.com
+//	  - name: search
+//	    url: https://search.example.com
+//
+// GetByName("endpoints", "payments").GetString("url") returns
+// "https://payments.example.com". Names are compared exactly; the first
+// matching element is returned, or nil if there is none. As with Sub, the
+// returned instance is a detached copy of the values.
+func GetByName(key, name string) *Viper { return v.GetByName(key, name) }
+func (v *Viper) GetByName(key, name string) *Viper {
+	items, err := cast.ToSliceE(v.Get(key))
+	if err != nil {
+		return nil
+	}
+	for _, item := range items {
+		m, err := cast.ToStringMapE(item)
+		if err != nil {
+			continue
+		}
+		for field, val := range m {
+			if field == "name" || !v.caseSensitive && strings.EqualFold(field, "name") {
+				if cast.ToString(val) == name {
+					subv := New()
+					subv.caseSensitive = v.caseSensitive
+					subv.config = copyMap(m, !v.caseSensitive)
+					return subv
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
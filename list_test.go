@@ -0,0 +1,61 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var endpointsYAML = []byte(`
+endpoints:
+  - name: payments
+    url: https://payments.example.com
+    Timeout: 5s
+    retry:
+      attempts: 3
+  - name: search
+    url: https://search.example.com
+  - Name: Legacy
+    url: https://legacy.example.com
+  - just a string
+  - name: payments
+    url: https://duplicate.example.com
+`)
+
+func TestGetByName(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(endpointsYAML)))
+
+	payments := v.GetByName("endpoints", "payments")
+	require.NotNil(t, payments)
+	assert.Equal(t, "https://payments.example.com", payments.GetString("url"))
+	assert.Equal(t, "5s", payments.GetString("timeout"))
+	assert.Equal(t, 3, payments.GetInt("retry.attempts"))
+
+	legacy := v.GetByName("endpoints", "Legacy")
+	require.NotNil(t, legacy)
+	assert.Equal(t, "https://legacy.example.com", legacy.GetString("url"))
+
+	assert.Nil(t, v.GetByName("endpoints", "legacy"))
+	assert.Nil(t, v.GetByName("endpoints", "billing"))
+	assert.Nil(t, v.GetByName("endpoints.0", "payments"))
+	assert.Nil(t, v.GetByName("missing", "payments"))
+
+	// The returned instance is detached.
+	payments.Set("url", "https://changed.example.com")
+	assert.Equal(t, "https://payments.example.com", v.GetByName("endpoints", "payments").GetString("url"))
+}
+
+func TestGetByNameCaseSensitive(t *testing.T) {
+	v := NewWithOptions(KeysCaseSensitive())
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(endpointsYAML)))
+
+	assert.Nil(t, v.GetByName("endpoints", "Legacy"))
+	payments := v.GetByName("endpoints", "payments")
+	require.NotNil(t, payments)
+	assert.Equal(t, "5s", payments.GetString("Timeout"))
+}
@@ -0,0 +1,118 @@
+package viper
+
+import (
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+)
+
+// Loader loads a configuration in one pipeline, see Load.
+type Loader struct {
+	v     *Viper
+	files []string
+	dirs  []string
+	flags []*pflag.FlagSet
+	opts  []ReadOption
+}
+
+// Load returns a Loader for a new Viper instance, configured with the given
+// options, replacing the setup repeated by every application:
+//
+//	var config Config
+//	cfg, err := viper.Load().
+//		File("/etc/app/config.yaml").
+//		Dir("/etc/app/conf.d").
+//		EnvPrefix("APP").
+//		Flags(pflag.CommandLine).
+//		Into(&config)
+//
+// Into runs the pipeline and returns the instance, e.g. to WatchConfig.
+func Load(opts ...Option) *Loader {
+	return &Loader{v: NewWithOptions(opts...)}
+}
+
+// Fs sets the filesystem the config files are read from, see SetFs.
+func (l *Loader) Fs(fs afero.Fs) *Loader {
+	l.v.SetFs(fs)
+	return l
+}
+
+// File adds a config file, which must exist. Files are merged in the order
+// they are added, see SetConfigFiles.
+func (l *Loader) File(path string) *Loader {
+	l.files = append(l.files, path)
+	return l
+}
+
+// Dir adds a directory of drop-in config files, merged on top of the files,
+// see AddConfigDir.
+func (l *Loader) Dir(dir string) *Loader {
+	l.dirs = append(l.dirs, dir)
+	return l
+}
+
+// EnvPrefix reads every key from the environment variable of the same name
+// with the given prefix, "." being replaced by "_", e.g. APP_SERVER_PORT for
+// "server.port", see SetEnvPrefix and AutomaticEnv.
+func (l *Loader) EnvPrefix(prefix string) *Loader {
+	l.v.SetEnvPrefix(prefix)
+	l.v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	l.v.AutomaticEnv()
+	return l
+}
+
+// Flags binds the flags of fs to the keys of the same name, see BindPFlags.
+func (l *Loader) Flags(fs *pflag.FlagSet) *Loader {
+	l.flags = append(l.flags, fs)
+	return l
+}
+
+// ReadOptions sets options of the read of the config files, see
+// ReadInConfig.
+func (l *Loader) ReadOptions(opts ...ReadOption) *Loader {
+	l.opts = append(l.opts, opts...)
+	return l
+}
+
+// Into runs the pipeline: the defaults, required keys and environment
+// variables of the struct ptr points to are registered, see BindStruct, the
+// flags are bound, the config files are read, the configuration is
+// validated, see Validate, and finally unmarshaled into ptr. ptr may be nil
+// to only load the configuration. Into stops at the first step failing,
+// returning its error along with the instance.
+func (l *Loader) Into(ptr interface{}) (*Viper, error) {
+	v := l.v
+	if ptr != nil {
+		if err := v.BindStruct(ptr); err != nil {
+			return v, err
+		}
+	}
+	for _, fs := range l.flags {
+		if err := v.BindPFlags(fs); err != nil {
+			return v, err
+		}
+	}
+
+	if len(l.files) > 0 {
+		v.SetConfigFiles(l.files...)
+	}
+	for _, dir := range l.dirs {
+		v.AddConfigDir(dir)
+	}
+	if len(l.files) > 0 || len(l.dirs) > 0 {
+		if err := v.ReadInConfigWithOptions(l.opts...); err != nil {
+			return v, err
+		}
+	}
+
+	if err := v.Validate(); err != nil {
+		return v, err
+	}
+	if ptr != nil {
+		if err := v.Unmarshal(ptr); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,72 @@
+package viper
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loaderConfig struct {
+	Name    string        `mapstructure:"name" required:"true"`
+	Timeout time.Duration `mapstructure:"timeout" default:"5s"`
+	Verbose bool          `mapstructure:"verbose"`
+	DB      struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port" default:"3306"`
+	} `mapstructure:"db"`
+	Cache struct {
+		Size int `mapstructure:"size"`
+	} `mapstructure:"cache"`
+}
+
+func TestLoad(t *testing.T) {
+	os.Setenv("APP_DB_PORT", "6543")
+	defer os.Unsetenv("APP_DB_PORT")
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.Bool("verbose", false, "")
+	require.NoError(t, fs.Parse([]string{"--verbose"}))
+
+	var config loaderConfig
+	v, err := Load().
+		Fs(newConfigFilesFs(t)).
+		File("/etc/app/config.yaml").
+		Dir("/etc/app/conf.d").
+		EnvPrefix("APP").
+		Flags(fs).
+		Into(&config)
+	require.NoError(t, err)
+
+	assert.Equal(t, "base", config.Name)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.True(t, config.Verbose)
+	assert.Equal(t, "db.local", config.DB.Host)
+	assert.Equal(t, 6543, config.DB.Port)
+	assert.Equal(t, 20, config.Cache.Size)
+	assert.Len(t, v.ConfigFilesUsed(), 3)
+}
+
+func TestLoadErrors(t *testing.T) {
+	var config loaderConfig
+	v, err := Load().Into(&config)
+	assert.Equal(t, RequiredKeyError("name"), err)
+	assert.NotNil(t, v)
+
+	_, err = Load().Fs(newConfigFilesFs(t)).File("/etc/app/missing.yaml").Into(&config)
+	assert.IsType(t, ConfigFileNotFoundError{}, err)
+
+	_, err = Load().Into(config)
+	assert.Error(t, err)
+
+	v, err = Load().Fs(newConfigFilesFs(t)).File("/etc/app/prod.json").Into(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", v.GetString("name"))
+
+	v, err = Load().Fs(newConfigFilesFs(t)).Dir("/etc/app/conf.d").Into(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20, v.GetInt("cache.size"))
+}
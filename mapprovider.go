@@ -0,0 +1,42 @@
+package viper
+
+// MapProvider is a source of nested settings, such as another configuration
+// library, e.g. a koanf instance returning its Raw settings. It's the common
+// ground of teams moving between libraries, see FromMapProvider and
+// ToMapProvider.
+type MapProvider interface {
+	Read() map[string]interface{}
+}
+
+// FromMapProvider merges the settings read from p into the config, like
+// MergeConfigMapWithOptions does with the given options, so that one source
+// of truth is shared during a migration from another configuration library.
+// The map read from p isn't modified. Call it again to pick up changes.
+//
+//	k := koanf.New(".")
+//	k.Load(file.Provider("config.yaml"), yaml.Parser())
+//	err := viper.FromMapProvider(koanfMap{k}) // Read returns k.Raw()
+func FromMapProvider(p MapProvider, opts ...MergeOption) error {
+	return v.FromMapProvider(p, opts...)
+}
+func (v *Viper) FromMapProvider(p MapProvider, opts ...MergeOption) error {
+	return v.MergeConfigMapWithOptions(copyMap(p.Read(), false), opts...)
+}
+
+// ToMapProvider returns a MapProvider whose Read returns the current
+// settings, see AllSettings, for the other direction of a migration. The
+// secrets are read as they are, see MarkSecret.
+//
+//	k.Load(confmap.Provider(viper.ToMapProvider().Read(), "."), nil)
+func ToMapProvider() MapProvider { return v.ToMapProvider() }
+func (v *Viper) ToMapProvider() MapProvider {
+	return settingsProvider{v}
+}
+
+type settingsProvider struct {
+	v *Viper
+}
+
+func (p settingsProvider) Read() map[string]interface{} {
+	return p.v.AllSettings()
+}
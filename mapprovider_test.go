@@ -0,0 +1,38 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticProvider map[string]interface{}
+
+func (p staticProvider) Read() map[string]interface{} { return p }
+
+func TestFromMapProvider(t *testing.T) {
+	v := New()
+	v.SetDefault("server.port", 8080)
+	v.Set("name", "viper")
+	provider := staticProvider{
+		"Server": map[string]interface{}{"Host": "example.com"},
+		"log":    map[interface{}]interface{}{"level": "debug"},
+	}
+	require.NoError(t, v.FromMapProvider(provider))
+	assert.Equal(t, "example.com", v.GetString("server.host"))
+	assert.Equal(t, 8080, v.GetInt("server.port"))
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	// the provider's map is left as is
+	assert.Contains(t, provider, "Server")
+	src, _ := v.Origin("server.host")
+	assert.Equal(t, SourceConfig, src)
+
+	other := New()
+	require.NoError(t, other.FromMapProvider(v.ToMapProvider()))
+	assert.Equal(t, v.AllSettings(), other.AllSettings())
+
+	exported := v.ToMapProvider()
+	v.Set("name", "cobra")
+	assert.Equal(t, "cobra", exported.Read()["name"])
+}
@@ -0,0 +1,53 @@
+package viper
+
+import (
+	"bytes"
+	"sync"
+)
+
+// DiscardSource makes ReadInConfigWithOptions, and the re-reads of
+// WatchConfig, drop the contents of the config file once parsed, rather than
+// keep them for WriteConfig to patch the file in place and to write
+// properties files back with their comments; WriteConfig then rewrites the
+// whole file. This cuts the memory held between reloads of large config
+// files, which are also read into buffers reused across reloads.
+func DiscardSource() ReadOption {
+	return func(c *readConfig) {
+		c.discardSource = true
+	}
+}
+
+// readBuffers holds the buffers config files are read into when their
+// contents are discarded once parsed.
+var readBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// readConfigFileDiscarded behaves like readConfigFile, but reads local
+// files into a buffer which release returns to the pool once their contents
+// are no longer used, if the source is discarded and configType doesn't use
+// a registered codec, which may retain them.
+func (v *Viper) readConfigFileDiscarded(filename, configType string) ([]byte, func(), error) {
+	_, hasCodec := codecFor(configType)
+	if !v.readOptions().discardSource || hasCodec || configURL(filename) != nil {
+		data, err := v.readConfigFile(filename)
+		return data, func() {}, err
+	}
+
+	f, err := v.fs.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	buf := readBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	if fi, err := f.Stat(); err == nil && int64(buf.Cap()) < fi.Size()+bytes.MinRead {
+		buf.Grow(int(fi.Size()) + bytes.MinRead)
+	}
+	release := func() { readBuffers.Put(buf) }
+	if _, err := buf.ReadFrom(f); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return buf.Bytes(), release, nil
+}
@@ -0,0 +1,48 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscardSource(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("# the service\nname: steve\nhosts: [a, b]\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfigWithOptions(DiscardSource()))
+	assert.Nil(t, v.configSource)
+
+	// the file read into the reused buffer is overwritten by the next read
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: xxxxx\nhosts: [c, d]\n"), 0644))
+	first := v.AllSettings()
+	require.NoError(t, v.readInConfig())
+	assert.Equal(t, map[string]interface{}{"name": "steve", "hosts": []interface{}{"a", "b"}}, first)
+	assert.Equal(t, "xxxxx", v.GetString("name"))
+
+	// without the source, the file is rewritten as a whole
+	v.Set("name", "stevens")
+	require.NoError(t, v.WriteConfig())
+	written, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(written), "#")
+	assert.Len(t, v.ConfigFilesUsed(), 1)
+}
+
+func TestReadConfigReusesBuffers(t *testing.T) {
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`{"name": "steve", "tags": ["a"]}`)))
+	w := New()
+	w.SetConfigType("json")
+	require.NoError(t, w.ReadConfig(bytes.NewBufferString(`{"name": "xxxxx", "tags": ["b"]}`)))
+
+	assert.Equal(t, "steve", v.GetString("name"))
+	assert.Equal(t, []string{"a"}, v.GetStringSlice("tags"))
+}
@@ -0,0 +1,171 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MergeConflictError denotes a value that can't be merged because the value
+// already held for its key has a different structure, e.g. a string merged
+// into a map.
+type MergeConflictError struct {
+	Key           string
+	Value, Target interface{}
+}
+
+// Error returns the formatted merge error.
+func (mce MergeConflictError) Error() string {
+	return fmt.Sprintf("cannot merge %T into %T for key %q", mce.Value, mce.Target, mce.Key)
+}
+
+// A MergeOption can be passed to MergeConfigWithOptions,
+// MergeInConfigWithOptions and MergeConfigMapWithOptions to configure how
+// configuration is merged.
+type MergeOption func(*mergeConfig)
+
+type mergeConflictMode int
+
+const (
+	// conflicting values are logged and skipped
+	mergeSkipConflicts mergeConflictMode = iota
+	// conflicting values are skipped and reported
+	mergeReportConflicts
+	// nothing is merged if any value conflicts
+	mergeAtomic
+)
+
+type mergeArrayMode int
+
+const (
+	mergeArrayReplace mergeArrayMode = iota
+	mergeArrayAppend
+)
+
+type mergeConfig struct {
+	conflicts   mergeConflictMode
+	arrays      mergeArrayMode
+	nullDeletes bool
+	// config file merged, recorded in AuditRecords, and its contents
+	source string
+	data   []byte
+	strict bool
+	// keys only match with the same case, see KeysCaseSensitive
+	caseSensitive bool
+}
+
+// MergeAtomic returns a MergeOption which checks the whole configuration
+// before merging it: if any value conflicts with the existing configuration,
+// nothing is merged and a MergeConflictError is returned for every such value.
+func MergeAtomic() MergeOption {
+	return func(c *mergeConfig) {
+		c.conflicts = mergeAtomic
+	}
+}
+
+// MergeReportConflicts returns a MergeOption which merges all values that
+// don't conflict with the existing configuration and returns a
+// MergeConflictError for every value that does. By default, conflicting
+// values are only logged.
+func MergeReportConflicts() MergeOption {
+	return func(c *mergeConfig) {
+		c.conflicts = mergeReportConflicts
+	}
+}
+
+// MergeWithArrayAppend returns a MergeOption which appends merged slices to
+// the slices already held for their keys, e.g. to add items to a list
+// defined in a base config file.
+func MergeWithArrayAppend() MergeOption {
+	return func(c *mergeConfig) {
+		c.arrays = mergeArrayAppend
+	}
+}
+
+// MergeWithArrayReplace returns a MergeOption which replaces the slices
+// already held for their keys by the merged ones. This is the default.
+func MergeWithArrayReplace() MergeOption {
+	return func(c *mergeConfig) {
+		c.arrays = mergeArrayReplace
+	}
+}
+
+// MergeWithNullDelete returns a MergeOption which removes the keys merged
+// with a null value, e.g. "key: ~" in YAML, from the existing config. By
+// default, null values are ignored.
+func MergeWithNullDelete() MergeOption {
+	return func(c *mergeConfig) {
+		c.nullDeletes = true
+	}
+}
+
+func newMergeConfig(opts ...MergeOption) *mergeConfig {
+	c := &mergeConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// mergeConflicts returns a MergeConflictError for every value of src that
+// mergeMapsWith would skip because of a type mismatch with tgt.
+func (v *Viper) mergeConflicts(src, tgt map[string]interface{}, prefix string, c *mergeConfig) []error {
+	var errs []error
+	for _, sk := range sortedMapKeys(src) {
+		sv := src[sk]
+		tk := keyExists(sk, tgt, c.caseSensitive)
+		if tk == "" || (sv == nil && c.nullDeletes) {
+			continue
+		}
+		tv, ok := tgt[tk]
+		if !ok {
+			continue
+		}
+		if c.arrays == mergeArrayAppend && isSlice(sv) && isSlice(tv) {
+			continue
+		}
+		if reflect.TypeOf(sv) != reflect.TypeOf(tv) {
+			errs = append(errs, MergeConflictError{Key: prefix + sk, Value: sv, Target: tv})
+			continue
+		}
+		switch ttv := tv.(type) {
+		case map[interface{}]interface{}:
+			errs = append(errs, v.mergeConflicts(
+				castToMapStringInterface(sv.(map[interface{}]interface{})),
+				castToMapStringInterface(ttv), prefix+sk+v.keyDelim, c)...)
+		case map[string]interface{}:
+			errs = append(errs, v.mergeConflicts(
+				sv.(map[string]interface{}), ttv, prefix+sk+v.keyDelim, c)...)
+		}
+	}
+	return errs
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make(map[string]bool, len(m))
+	for key := range m {
+		keys[key] = true
+	}
+	return sortedKeys(keys)
+}
+
+func isSlice(value interface{}) bool {
+	return value != nil && reflect.TypeOf(value).Kind() == reflect.Slice
+}
+
+// appendSlices returns a new slice holding the items of a followed by those
+// of b. The slice has the type of both slices if they match, and is a
+// []interface{} otherwise.
+func appendSlices(a, b interface{}) interface{} {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.Type() == bv.Type() {
+		res := reflect.MakeSlice(av.Type(), 0, av.Len()+bv.Len())
+		return reflect.AppendSlice(reflect.AppendSlice(res, av), bv).Interface()
+	}
+	res := make([]interface{}, 0, av.Len()+bv.Len())
+	for _, sv := range []reflect.Value{av, bv} {
+		for i := 0; i < sv.Len(); i++ {
+			res = append(res, sv.Index(i).Interface())
+		}
+	}
+	return res
+}
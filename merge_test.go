@@ -0,0 +1,98 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mergeBaseYAML = []byte(`
+name: steve
+db:
+  host: localhost
+  port: 5432
+hobbies:
+- go
+`)
+
+var mergeConflictingYAML = []byte(`
+name: bob
+db: postgres://localhost
+hobbies:
+  first: go
+`)
+
+func newMergeTestViper(t *testing.T) *Viper {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(mergeBaseYAML)))
+	return v
+}
+
+func TestMergeConfigSkipsConflicts(t *testing.T) {
+	v := newMergeTestViper(t)
+	require.NoError(t, v.MergeConfig(bytes.NewBuffer(mergeConflictingYAML)))
+	assert.Equal(t, "bob", v.GetString("name"))
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+}
+
+func TestMergeConfigAtomic(t *testing.T) {
+	v := newMergeTestViper(t)
+	err := v.MergeConfigWithOptions(bytes.NewBuffer(mergeConflictingYAML), MergeAtomic())
+	require.IsType(t, MultiError{}, err)
+	errs := err.(MultiError)
+	require.Len(t, errs, 2)
+	assert.Equal(t, MergeConflictError{
+		Key:    "db",
+		Value:  "postgres://localhost",
+		Target: map[string]interface{}{"host": "localhost", "port": 5432},
+	}, errs[0])
+	assert.Equal(t, "hobbies", errs[1].(MergeConflictError).Key)
+	assert.Equal(t, "steve", v.GetString("name"), "nothing merged")
+
+	require.NoError(t, v.MergeConfigWithOptions(bytes.NewBufferString("db:\n  port: 5433\n"), MergeAtomic()))
+	assert.Equal(t, 5433, v.GetInt("db.port"))
+}
+
+func TestMergeConfigReportConflicts(t *testing.T) {
+	v := newMergeTestViper(t)
+	err := v.MergeConfigMapWithOptions(map[string]interface{}{
+		"name": "bob",
+		"db":   map[string]interface{}{"port": "5433", "user": "admin"},
+	}, MergeReportConflicts())
+
+	require.IsType(t, MergeConflictError{}, err)
+	assert.Equal(t, `cannot merge string into int for key "db.port"`, err.Error())
+	assert.Equal(t, "bob", v.GetString("name"))
+	assert.Equal(t, "admin", v.GetString("db.user"))
+	assert.Equal(t, 5432, v.GetInt("db.port"))
+}
+
+func TestMergeWithArrayAppend(t *testing.T) {
+	v := newMergeTestViper(t)
+	require.NoError(t, v.MergeConfigWithOptions(bytes.NewBufferString("hobbies:\n- skateboarding\n"), MergeWithArrayAppend()))
+	assert.Equal(t, []interface{}{"go", "skateboarding"}, v.Get("hobbies"))
+
+	require.NoError(t, v.MergeConfigMapWithOptions(map[string]interface{}{"hobbies": []string{"chess"}}, MergeWithArrayAppend()))
+	assert.Equal(t, []interface{}{"go", "skateboarding", "chess"}, v.Get("hobbies"))
+
+	require.NoError(t, v.MergeConfigWithOptions(bytes.NewBufferString("hobbies:\n- snowboarding\n"), MergeWithArrayReplace()))
+	assert.Equal(t, []interface{}{"snowboarding"}, v.Get("hobbies"))
+}
+
+func TestMergeWithNullDelete(t *testing.T) {
+	v := newMergeTestViper(t)
+	overlay := "name: ~\ndb:\n  port: null\n  user: admin\nextra: ~\n"
+
+	require.NoError(t, v.MergeConfig(bytes.NewBufferString(overlay)))
+	assert.Equal(t, "steve", v.GetString("name"), "null values are ignored by default")
+
+	require.NoError(t, v.MergeConfigWithOptions(bytes.NewBufferString(overlay), MergeWithNullDelete(), MergeAtomic()))
+	assert.False(t, v.IsSet("name"))
+	assert.False(t, v.IsSet("db.port"))
+	assert.False(t, v.IsSet("extra"))
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+	assert.Equal(t, "admin", v.GetString("db.user"))
+}
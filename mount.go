@@ -0,0 +1,165 @@
+package viper
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Mount mounts the keys of another Viper instance below prefix, e.g. a
+// library exposes its own instance and the host mounts it at "plugins.auth",
+// so that the key "plugins.auth.timeout" of the host reads "timeout" of the
+// library's instance. Reads are delegated live: the host sees every Set,
+// binding and reload of the mounted instance, and its own values below
+// prefix are hidden by the mount. The Generation of the host accounts for
+// the changes of the mounted instances, which don't trigger the OnChange
+// function of the host.
+//
+// Mounting nil unmounts the instance mounted at prefix. Mounting an instance
+// which mounts the host, directly or not, returns an error.
+// Mount is case-insensitive for a key.
+func Mount(prefix string, mounted *Viper) error { return v.Mount(prefix, mounted) }
+func (v *Viper) Mount(prefix string, mounted *Viper) error {
+	prefix = v.normalizeKey(prefix)
+	if prefix == "" {
+		return fmt.Errorf("Mount requires a prefix")
+	}
+	if mounted != nil && mounted.mounts(v) {
+		return fmt.Errorf("can't mount an instance at %q which mounts this one", prefix)
+	}
+
+	v.mu.Lock()
+	old, replaced := v.mounted[prefix]
+	// the table is replaced rather than modified, see mountTable
+	table := make(map[string]*Viper, len(v.mounted)+1)
+	for p, m := range v.mounted {
+		table[p] = m
+	}
+	if mounted == nil {
+		delete(table, prefix)
+	} else {
+		table[prefix] = mounted
+	}
+	v.mounted = table
+	if replaced {
+		// keep the generation increasing without the old instance
+		atomic.AddUint64(&v.generation, old.Generation())
+	}
+	v.unlockChanged()
+	return nil
+}
+
+// mountTable returns the instances mounted by prefix. The map must not be
+// modified.
+func (v *Viper) mountTable() map[string]*Viper {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.mounted
+}
+
+// mounts reports whether other is v or is mounted in v, directly or not.
+func (v *Viper) mounts(other *Viper) bool {
+	if v == other {
+		return true
+	}
+	for _, m := range v.mountTable() {
+		if m.mounts(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountFor returns the instance mounted at or above key, along with the key
+// of that instance, empty if key is the prefix of the mount.
+func (v *Viper) mountFor(key string) (*Viper, string, bool) {
+	var (
+		found  *Viper
+		prefix string
+	)
+	for p, m := range v.mountTable() {
+		if (key == p || strings.HasPrefix(key, p+v.keyDelim)) && len(p) > len(prefix) {
+			found, prefix = m, p
+		}
+	}
+	if found == nil {
+		return nil, "", false
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(key, prefix), v.keyDelim)
+	path := strings.Split(rest, v.keyDelim)
+	return found, found.normalizeKey(strings.Join(path, found.keyDelim)), true
+}
+
+// findWithOrigin is find, additionally reporting the layer the value was
+// found in. Keys of mounted instances are delegated to them.
+func (v *Viper) findWithOrigin(lcaseKey string) (interface{}, SourceKind, string) {
+	mounted := v.mountTable()
+	if len(mounted) == 0 {
+		return v.findOwnWithOrigin(lcaseKey)
+	}
+
+	key := v.lockedRealKey(lcaseKey)
+	if m, rest, ok := v.mountFor(key); ok {
+		if rest == "" {
+			if settings := m.AllSettings(); len(settings) > 0 {
+				return settings, SourceNone, ""
+			}
+			return nil, SourceNone, ""
+		}
+		return m.findWithOrigin(rest)
+	}
+
+	val, src, detail := v.findOwnWithOrigin(lcaseKey)
+	if val != nil {
+		if _, isMap := val.(map[string]interface{}); !isMap {
+			return val, src, detail
+		}
+	}
+	// key holds a map, or nothing, which may contain mounted instances
+	prefix := key + v.keyDelim
+	var merged map[string]interface{}
+	for p, m := range mounted {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		settings := m.AllSettings()
+		if len(settings) == 0 {
+			continue
+		}
+		if merged == nil {
+			own, _ := val.(map[string]interface{})
+			merged = copyMap(own, false)
+		}
+		path := strings.Split(strings.TrimPrefix(p, prefix), v.keyDelim)
+		deepSearch(merged, path[:len(path)-1])[path[len(path)-1]] = settings
+	}
+	if merged == nil {
+		return val, src, detail
+	}
+	return merged, src, detail
+}
+
+// mountedKeys replaces the keys of keys hidden by mounted instances with
+// the keys of these instances.
+func (v *Viper) mountedKeys(keys map[string]bool) map[string]bool {
+	mounted := v.mountTable()
+	if len(mounted) == 0 {
+		return keys
+	}
+	for key := range keys {
+		if _, _, ok := v.mountFor(key); ok {
+			delete(keys, key)
+		}
+	}
+	for p, m := range mounted {
+		for _, key := range m.AllKeys() {
+			path := strings.Split(key, m.keyDelim)
+			full := p + v.keyDelim + strings.Join(path, v.keyDelim)
+			// keys of deeper mounts take precedence
+			if found, _, _ := v.mountFor(full); found == m {
+				keys[full] = true
+			}
+		}
+	}
+	return keys
+}
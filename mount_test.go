@@ -0,0 +1,58 @@
+package viper
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMount(t *testing.T) {
+	auth := New()
+	auth.SetDefault("timeout", "5s")
+	auth.Set("Provider", "ldap")
+
+	host := New()
+	host.Set("name", "steve")
+	host.Set("plugins.auth.timeout", "1s") // hidden by the mount
+	host.Set("plugins.cache.size", 10)
+	require.NoError(t, host.Mount("Plugins.Auth", auth))
+
+	assert.Equal(t, "ldap", host.GetString("plugins.auth.provider"))
+	assert.Equal(t, "5s", host.GetString("plugins.auth.timeout"))
+	assert.Equal(t, 10, host.GetInt("plugins.cache.size"))
+	assert.Equal(t, map[string]interface{}{"provider": "ldap", "timeout": "5s"}, host.Get("plugins.auth"))
+	assert.Equal(t, map[string]interface{}{
+		"auth":  map[string]interface{}{"provider": "ldap", "timeout": "5s"},
+		"cache": map[string]interface{}{"size": 10},
+	}, host.Get("plugins"))
+
+	keys := host.AllKeys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"name", "plugins.auth.provider", "plugins.auth.timeout", "plugins.cache.size"}, keys)
+
+	source, _ := host.Origin("plugins.auth.timeout")
+	assert.Equal(t, SourceDefault, source)
+
+	// reads are live
+	gen := host.Generation()
+	auth.Set("timeout", "10s")
+	assert.Equal(t, "10s", host.GetString("plugins.auth.timeout"))
+	assert.True(t, host.Generation() > gen)
+
+	// unmounting uncovers the values of the host
+	gen = host.Generation()
+	require.NoError(t, host.Mount("plugins.auth", nil))
+	assert.Equal(t, "1s", host.GetString("plugins.auth.timeout"))
+	assert.False(t, host.IsSet("plugins.auth.provider"))
+	assert.True(t, host.Generation() > gen)
+}
+
+func TestMountCycle(t *testing.T) {
+	a, b := New(), New()
+	require.NoError(t, a.Mount("b", b))
+	assert.Error(t, b.Mount("a", a))
+	assert.Error(t, a.Mount("self", a))
+	assert.Error(t, a.Mount("", New()))
+}
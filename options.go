@@ -0,0 +1,48 @@
+package viper
+
+import "strings"
+
+// An Option configures a Viper instance created by NewWithOptions.
+type Option func(*Viper)
+
+// KeysCaseSensitive returns an Option which makes keys case sensitive: keys
+// keep the casing they were set, bound or read with through Get, AllKeys,
+// AllSettings, Sub and WriteConfig, so that configuration can round-trip to
+// systems with case sensitive keys. "Foo" and "foo" are then distinct keys.
+// By default, keys are case insensitive and lower-cased.
+func KeysCaseSensitive() Option {
+	return func(v *Viper) {
+		v.caseSensitive = true
+	}
+}
+
+// NewWithOptions returns an initialized Viper instance configured with the
+// given options.
+func NewWithOptions(opts ...Option) *Viper {
+	v := New()
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// normalizeKey lower-cases key, unless keys are case sensitive.
+func (v *Viper) normalizeKey(key string) string {
+	if v.caseSensitive {
+		return key
+	}
+	return strings.ToLower(key)
+}
+
+// insensitiviseMap lower-cases the keys of m recursively, unless keys are
+// case sensitive. Nested maps are converted to map[string]interface{} in
+// any case.
+func (v *Viper) insensitiviseMap(m map[string]interface{}) {
+	normalizeMap(m, !v.caseSensitive)
+}
+
+// toCaseInsensitiveValue checks if the value is a map; if so, create a copy
+// and lower-case the keys recursively, unless keys are case sensitive.
+func (v *Viper) toCaseInsensitiveValue(value interface{}) interface{} {
+	return copyMapValue(value, !v.caseSensitive)
+}
@@ -0,0 +1,85 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var caseSensitiveYAML = []byte(`Server:
+  listenAddr: ":8080"
+  LOG_LEVEL: debug
+env:
+  HOME: /root
+  home: /home/steve
+`)
+
+func TestKeysCaseSensitive(t *testing.T) {
+	v := NewWithOptions(KeysCaseSensitive())
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(caseSensitiveYAML)))
+	v.SetDefault("Server.Timeout", "5s")
+	v.Set("env.PATH", "/usr/bin")
+
+	assert.Equal(t, ":8080", v.Get("Server.listenAddr"))
+	assert.Nil(t, v.Get("server.listenaddr"))
+	assert.Equal(t, "/root", v.GetString("env.HOME"))
+	assert.Equal(t, "/home/steve", v.GetString("env.home"))
+	assert.True(t, v.IsSet("Server.Timeout"))
+	assert.False(t, v.IsSet("server.timeout"))
+
+	assert.ElementsMatch(t, []string{
+		"Server.listenAddr", "Server.LOG_LEVEL", "Server.Timeout",
+		"env.HOME", "env.home", "env.PATH",
+	}, v.AllKeys())
+	assert.Equal(t, map[string]interface{}{
+		"Server": map[string]interface{}{
+			"listenAddr": ":8080",
+			"LOG_LEVEL":  "debug",
+			"Timeout":    "5s",
+		},
+		"env": map[string]interface{}{
+			"HOME": "/root",
+			"home": "/home/steve",
+			"PATH": "/usr/bin",
+		},
+	}, v.AllSettings())
+
+	sub := v.Sub("Server")
+	require.NotNil(t, sub)
+	assert.Equal(t, "debug", sub.Get("LOG_LEVEL"))
+	assert.Nil(t, sub.Get("log_level"))
+
+	fs := afero.NewMemMapFs()
+	v.SetFs(fs)
+	require.NoError(t, v.WriteConfigAs("/out.yaml"))
+	w := NewWithOptions(KeysCaseSensitive())
+	w.SetFs(fs)
+	w.SetConfigFile("/out.yaml")
+	require.NoError(t, w.ReadInConfig())
+	assert.Equal(t, v.AllSettings(), w.AllSettings())
+}
+
+func TestKeysCaseInsensitiveByDefault(t *testing.T) {
+	v := NewWithOptions()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer([]byte("Server:\n  listenAddr: \":8080\"\n"))))
+
+	assert.Equal(t, ":8080", v.Get("server.listenaddr"))
+	assert.Equal(t, ":8080", v.Get("SERVER.LISTENADDR"))
+	assert.Equal(t, []string{"server.listenaddr"}, v.AllKeys())
+}
+
+func TestKeysCaseSensitiveMerge(t *testing.T) {
+	v := NewWithOptions(KeysCaseSensitive())
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"foo": 1}))
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"Foo": 2}))
+	assert.Equal(t, map[string]interface{}{"foo": 1, "Foo": 2}, v.AllSettings())
+
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"foo": 3}))
+	assert.Equal(t, 3, v.Get("foo"))
+	assert.Equal(t, 2, v.Get("Foo"))
+}
@@ -0,0 +1,96 @@
+package viper
+
+import (
+	"strings"
+)
+
+// Origin reports which configuration layer the value of key is read from,
+// following the same precedence as Get. detail locates the value within the
+// layer: the flag name, the environment variable name, the path of the
+// config file or the remote provider the value was read from, or how the
+// default is defined, see DefaultOrigin. It is empty for overrides and when
+// the source can't be told.
+// Origin returns SourceNone if the key has no value.
+func Origin(key string) (SourceKind, string) { return v.Origin(key) }
+func (v *Viper) Origin(key string) (SourceKind, string) {
+	if v == nil {
+		return SourceNone, ""
+	}
+	lcaseKey := v.normalizeKey(key)
+	if m, rest, ok := v.mountFor(v.lockedRealKey(lcaseKey)); ok && rest != "" {
+		return m.Origin(rest)
+	}
+	_, src, detail := v.findWithOrigin(lcaseKey)
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	switch src {
+	case SourceConfig:
+		detail = v.configOrigin(v.realKey(lcaseKey))
+	case SourceDefault:
+		detail = v.defaultOriginOf(v.realKey(lcaseKey)).String()
+	case SourceKVStore:
+		if v.kvstoreProvider != nil {
+			rp := v.kvstoreProvider
+			detail = rp.Provider() + " " + rp.Endpoint() + " " + rp.Path()
+		}
+	}
+	return src, detail
+}
+
+// DebugSetting is the value of a key annotated with its origin.
+type DebugSetting struct {
+	Value  interface{}
+	Source SourceKind
+	Detail string
+}
+
+// DebugSettings returns the value of every key from AllKeys along with the
+// layer it was read from, see Origin. It is meant to help tracking down
+// misconfigurations.
+func DebugSettings() map[string]DebugSetting { return v.DebugSettings() }
+func (v *Viper) DebugSettings() map[string]DebugSetting {
+	m := map[string]DebugSetting{}
+	for _, k := range v.AllKeys() {
+		value := v.Get(k)
+		if value == nil {
+			continue
+		}
+		src, detail := v.Origin(k)
+		m[k] = DebugSetting{Value: value, Source: src, Detail: detail}
+	}
+	return m
+}
+
+// recordConfigOrigins records that the keys of cfg were read from the
+// config file at path. Unless merged, it replaces all previously recorded
+// keys. The caller must hold the write lock.
+func (v *Viper) recordConfigOrigins(cfg map[string]interface{}, path string, merged bool) {
+	if !merged || v.configOrigins == nil {
+		v.configOrigins = map[string]string{}
+	}
+	for key := range v.flattenAndMergeMap(nil, cfg, "") {
+		v.configOrigins[key] = path
+	}
+}
+
+// configOrigin returns the path of the config file key was read from. The
+// caller must hold the read lock.
+func (v *Viper) configOrigin(key string) string {
+	if path, ok := v.configOrigins[key]; ok {
+		return path
+	}
+	// key holds a map, report the file if all its children come from one
+	prefix := key + v.keyDelim
+	origin, found := "", false
+	for k, path := range v.configOrigins {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if found && path != origin {
+			return ""
+		}
+		origin, found = path, true
+	}
+	return origin
+}
@@ -0,0 +1,81 @@
+package viper
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrigin(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml",
+		[]byte("name: steve\nage: 35\ndb:\n  host: localhost\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/prod.yaml",
+		[]byte("name: bob\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.SetConfigFile("/etc/app/prod.yaml")
+	require.NoError(t, v.MergeInConfig())
+
+	_, file, line, _ := runtime.Caller(0)
+	v.SetDefault("port", 8080)
+	v.SetDefault("age", 20)
+	v.Set("debug", true)
+
+	os.Setenv("APP_DB_USER", "admin")
+	defer os.Unsetenv("APP_DB_USER")
+	require.NoError(t, v.BindEnv("db.user", "APP_DB_USER"))
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("log-level", "info", "")
+	require.NoError(t, flags.Set("log-level", "debug"))
+	require.NoError(t, v.BindPFlag("log.level", flags.Lookup("log-level")))
+
+	tests := []struct {
+		key    string
+		source SourceKind
+		detail string
+	}{
+		{"name", SourceConfig, "/etc/app/prod.yaml"},
+		{"age", SourceConfig, "/etc/app/config.yaml"},
+		{"db.host", SourceConfig, "/etc/app/config.yaml"},
+		{"port", SourceDefault, fmt.Sprintf("SetDefault at %s:%d", file, line+1)},
+		{"debug", SourceOverride, ""},
+		{"db.user", SourceEnv, "APP_DB_USER"},
+		{"log.level", SourceFlag, "log-level"},
+		{"missing", SourceNone, ""},
+	}
+	for _, tt := range tests {
+		source, detail := v.Origin(tt.key)
+		assert.Equal(t, tt.source, source, tt.key)
+		assert.Equal(t, tt.detail, detail, tt.key)
+	}
+
+	settings := v.DebugSettings()
+	assert.Len(t, settings, len(v.AllKeys()))
+	assert.Equal(t, DebugSetting{Value: "bob", Source: SourceConfig, Detail: "/etc/app/prod.yaml"}, settings["name"])
+	assert.Equal(t, DebugSetting{Value: 8080, Source: SourceDefault, Detail: fmt.Sprintf("SetDefault at %s:%d", file, line+1)}, settings["port"])
+}
+
+func TestOriginAutomaticEnv(t *testing.T) {
+	v := New()
+	v.SetEnvPrefix("app")
+	v.AutomaticEnv()
+	v.SetDefault("timeout", "5s")
+
+	os.Setenv("APP_TIMEOUT", "10s")
+	defer os.Unsetenv("APP_TIMEOUT")
+
+	source, detail := v.Origin("timeout")
+	assert.Equal(t, SourceEnv, source)
+	assert.Equal(t, "APP_TIMEOUT", detail)
+}
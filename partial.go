@@ -0,0 +1,135 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PartialUnmarshalError denotes the keys skipped by UnmarshalPartial.
+type PartialUnmarshalError struct {
+	// Skipped are the keys of the subtrees which weren't decoded, sorted.
+	Skipped []string
+	// Errors are the errors of the skipped subtrees, see UnmarshalError.
+	Errors []error
+}
+
+// Error returns the formatted partial unmarshal error.
+func (e PartialUnmarshalError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("skipped %s which can't be decoded:\n%s", strings.Join(e.Skipped, ", "), strings.Join(msgs, "\n"))
+}
+
+// UnmarshalPartial unmarshals the config into a Struct like Unmarshal, but
+// skips the subtrees which can't be decoded rather than failing: the fields
+// they would be decoded into keep their value, and the rest of the struct is
+// decoded. The skipped keys are reported along with their errors by a
+// PartialUnmarshalError, so that an application can start in a degraded
+// mode and tell which sections of its configuration need fixing. Other
+// errors, e.g. rawVal not being a pointer, are returned as they are by
+// Unmarshal and nothing is decoded.
+func UnmarshalPartial(rawVal interface{}, opts ...DecoderConfigOption) error {
+	return v.UnmarshalPartial(rawVal, opts...)
+}
+func (v *Viper) UnmarshalPartial(rawVal interface{}, opts ...DecoderConfigOption) error {
+	val := reflect.ValueOf(rawVal)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return v.Unmarshal(rawVal, opts...)
+	}
+
+	var input interface{} = v.AllSettings()
+	var partial PartialUnmarshalError
+	for {
+		// decode into a scratch value to find the subtrees to skip, so that
+		// their fields in rawVal are left untouched
+		scratch := reflect.New(val.Type().Elem()).Interface()
+		err := decode(input, v.defaultDecoderConfig(scratch, opts...))
+		if err == nil {
+			break
+		}
+		err = v.unmarshalError(err, "", input)
+		errs, ok := err.(MultiError)
+		if !ok {
+			errs = MultiError{err}
+		}
+		for _, err := range errs {
+			e, ok := err.(UnmarshalError)
+			if !ok || e.Key == "" {
+				return err
+			}
+			settings, ok := withoutSetting(input, strings.Split(e.Key, v.keyDelim))
+			if !ok {
+				return err
+			}
+			input = settings
+			partial.Skipped = append(partial.Skipped, e.Key)
+			partial.Errors = append(partial.Errors, e)
+		}
+	}
+
+	if err := decode(input, v.defaultDecoderConfig(rawVal, opts...)); err != nil {
+		return v.unmarshalError(err, "", input)
+	}
+	if len(partial.Skipped) == 0 {
+		return nil
+	}
+	sort.Strings(partial.Skipped)
+	sort.SliceStable(partial.Errors, func(i, j int) bool {
+		return errorKey(partial.Errors[i]) < errorKey(partial.Errors[j])
+	})
+	return partial
+}
+
+// withoutSetting returns a copy of settings without the key at path, the
+// items of slices being set to nil rather than removed so that the indices
+// of the others are kept. The values along path are copied, settings isn't
+// modified. ok is false if path isn't found.
+func withoutSetting(settings interface{}, path []string) (interface{}, bool) {
+	val := reflect.ValueOf(settings)
+	switch val.Kind() {
+	case reflect.Map:
+		for _, k := range val.MapKeys() {
+			if fmt.Sprint(k.Interface()) != path[0] {
+				continue
+			}
+			out := reflect.MakeMapWithSize(val.Type(), val.Len())
+			for _, other := range val.MapKeys() {
+				out.SetMapIndex(other, val.MapIndex(other))
+			}
+			if len(path) == 1 {
+				out.SetMapIndex(k, reflect.Value{})
+				return out.Interface(), true
+			}
+			child, ok := withoutSetting(val.MapIndex(k).Interface(), path[1:])
+			if !ok {
+				return settings, false
+			}
+			out.SetMapIndex(k, reflect.ValueOf(child))
+			return out.Interface(), true
+		}
+
+	case reflect.Slice:
+		n, err := strconv.Atoi(path[0])
+		if err != nil || n < 0 || n >= val.Len() {
+			return settings, false
+		}
+		out := reflect.MakeSlice(val.Type(), val.Len(), val.Len())
+		reflect.Copy(out, val)
+		if len(path) == 1 {
+			out.Index(n).Set(reflect.Zero(val.Type().Elem()))
+			return out.Interface(), true
+		}
+		child, ok := withoutSetting(val.Index(n).Interface(), path[1:])
+		if !ok {
+			return settings, false
+		}
+		out.Index(n).Set(reflect.ValueOf(child))
+		return out.Interface(), true
+	}
+	return settings, false
+}
@@ -0,0 +1,58 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalPartial(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+name: app
+server:
+  port: 8080
+  timeout: soon
+upstreams:
+  - retries: 3
+  - retries: many
+limits:
+  conns: 10
+`)))
+
+	var config struct {
+		unmarshalErrorConfig `mapstructure:",squash"`
+		Name                 string
+	}
+	config.Server.Timeout = time.Second
+	err := v.UnmarshalPartial(&config)
+	require.IsType(t, PartialUnmarshalError{}, err)
+	partial := err.(PartialUnmarshalError)
+	assert.Equal(t, []string{"server.timeout", "upstreams.1.retries"}, partial.Skipped)
+	require.Len(t, partial.Errors, 2)
+	assert.Equal(t, "server.timeout", partial.Errors[0].(UnmarshalError).Key)
+	assert.Contains(t, err.Error(), "skipped server.timeout, upstreams.1.retries which can't be decoded:\n")
+
+	assert.Equal(t, "app", config.Name)
+	assert.Equal(t, 8080, config.Server.Port)
+	assert.Equal(t, time.Second, config.Server.Timeout)
+	require.Len(t, config.Upstreams, 2)
+	assert.Equal(t, 3, config.Upstreams[0].Retries)
+	assert.Equal(t, 0, config.Upstreams[1].Retries)
+	assert.Equal(t, map[string]int{"conns": 10}, config.Limits)
+
+	// the configuration itself is left untouched
+	assert.Equal(t, "soon", v.Get("server.timeout"))
+	assert.Equal(t, "many", v.Get("upstreams.1.retries"))
+
+	v.Set("server", map[string]interface{}{"timeout": "1m"})
+	v.Set("upstreams", []interface{}{})
+	assert.NoError(t, v.UnmarshalPartial(&config))
+	assert.Equal(t, time.Minute, config.Server.Timeout)
+
+	assert.EqualError(t, v.UnmarshalPartial(config), "result must be a pointer")
+}
@@ -0,0 +1,62 @@
+package viper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetPathsRelativeToConfig sets whether GetPath resolves relative paths read
+// from a config file against the directory of that file rather than the
+// working directory of the process, so that a config file can refer to the
+// files next to it wherever it's installed.
+func SetPathsRelativeToConfig(enable bool) { v.SetPathsRelativeToConfig(enable) }
+func (v *Viper) SetPathsRelativeToConfig(enable bool) {
+	v.relativePaths = enable
+}
+
+// GetPath returns the value associated with the key as a file system path:
+// both "/" and "\" are converted to the separator of the OS, a leading "~"
+// is replaced by the home directory of the user, if known, and the path is
+// cleaned.
+// If SetPathsRelativeToConfig is enabled, a relative path read from a config
+// file is resolved against the directory of that file. GetPath returns ""
+// if the key has no value.
+func GetPath(key string) string { return v.GetPath(key) }
+func (v *Viper) GetPath(key string) string {
+	p := v.GetString(key)
+	if p == "" {
+		return ""
+	}
+	p = osPath(p)
+	if p == "~" || strings.HasPrefix(p, "~"+string(os.PathSeparator)) {
+		home := userHomeDir()
+		if home == "" {
+			// left as is rather than expanded to the root
+			return filepath.Clean(p)
+		}
+		p = home + p[1:]
+	}
+
+	if v.relativePaths && !filepath.IsAbs(p) && !isVolumeRelative(p) {
+		if src, file := v.Origin(key); src == SourceConfig && file != "" && configURL(file) == nil {
+			p = filepath.Join(filepath.Dir(file), p)
+		}
+	}
+	return filepath.Clean(p)
+}
+
+// osPath converts the separators of p to the ones of the OS.
+func osPath(p string) string {
+	if os.PathSeparator == '\\' {
+		return strings.Replace(p, "/", `\`, -1)
+	}
+	return strings.Replace(p, `\`, "/", -1)
+}
+
+// isVolumeRelative tells whether p is relative to the current directory of a
+// volume or to the root of the current volume on Windows, e.g. "C:dir" or
+// "\dir", which can't be resolved against another directory.
+func isVolumeRelative(p string) bool {
+	return filepath.VolumeName(p) != "" || strings.HasPrefix(p, string(os.PathSeparator))
+}
@@ -0,0 +1,55 @@
+package viper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setHomeDir sets the home directory returned by userHomeDir for the test.
+func setHomeDir(t *testing.T, dir string) {
+	t.Setenv("HOME", dir)
+	t.Setenv("USERPROFILE", dir)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+}
+
+func TestGetPath(t *testing.T) {
+	setHomeDir(t, filepath.FromSlash("/home/gopher"))
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(`tls:
+  cert: certs\server.pem
+  key: /var/lib/app/../app/server.key
+cache: ~/cache
+`), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.SetDefault("data", "data/./files/")
+
+	assert.Equal(t, filepath.FromSlash("certs/server.pem"), v.GetPath("tls.cert"))
+	assert.Equal(t, filepath.FromSlash("/var/lib/app/server.key"), v.GetPath("tls.key"))
+	assert.Equal(t, filepath.FromSlash("/home/gopher/cache"), v.GetPath("cache"))
+	assert.Equal(t, filepath.FromSlash("data/files"), v.GetPath("data"))
+	assert.Equal(t, "", v.GetPath("missing"))
+
+	v.SetPathsRelativeToConfig(true)
+	assert.Equal(t, filepath.FromSlash("/etc/app/certs/server.pem"), v.GetPath("tls.cert"))
+	assert.Equal(t, filepath.FromSlash("/var/lib/app/server.key"), v.GetPath("tls.key"))
+	// relative to the working directory unless read from a config file
+	assert.Equal(t, filepath.FromSlash("data/files"), v.GetPath("data"))
+
+	// without a home directory, "~" isn't expanded
+	setHomeDir(t, "")
+	assert.Equal(t, filepath.FromSlash("~/cache"), v.GetPath("cache"))
+}
+
+func TestOSPath(t *testing.T) {
+	want := "a" + string(os.PathSeparator) + "b" + string(os.PathSeparator) + "c"
+	assert.Equal(t, want, osPath(`a/b\c`))
+}
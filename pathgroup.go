@@ -0,0 +1,69 @@
+package viper
+
+import (
+	"fmt"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+type configPathGroup struct {
+	name  string
+	paths []string
+}
+
+// AddConfigPathGroup adds paths to the named group of config paths, e.g.
+// "system", "user" or "project", creating the group if needed. Groups allow
+// git-style layered configuration: ReadInConfig searches the paths of each
+// group in order and uses the first config file found in it, then merges the
+// files of all groups in the order the groups were added, later groups taking
+// precedence.
+//
+//	viper.SetConfigName("config")
+//	viper.AddConfigPathGroup("system", "/etc/app")
+//	viper.AddConfigPathGroup("user", "$XDG_CONFIG_HOME/app", "$HOME/.app")
+//	viper.AddConfigPathGroup("project", ".")
+//	err := viper.ReadInConfig()
+//
+// Groups without a config file are skipped; ReadInConfig returns a
+// ConfigFileNotFoundError only if no group has one. Groups are ignored once
+// a config file is set with SetConfigFile or SetConfigFiles. The files of
+// directories added with AddConfigDir are merged on top of the groups.
+func AddConfigPathGroup(group string, paths ...string) { v.AddConfigPathGroup(group, paths...) }
+func (v *Viper) AddConfigPathGroup(group string, paths ...string) {
+	i := 0
+	for i < len(v.configGroups) && v.configGroups[i].name != group {
+		i++
+	}
+	if i == len(v.configGroups) {
+		v.configGroups = append(v.configGroups, configPathGroup{name: group})
+	}
+	for _, in := range paths {
+		if in == "" {
+			continue
+		}
+		absin := absPathify(in)
+		jww.INFO.Println("adding", absin, "to paths to search in group", group)
+		if !stringInSlice(absin, v.configGroups[i].paths) {
+			v.configGroups[i].paths = append(v.configGroups[i].paths, absin)
+		}
+	}
+}
+
+// findConfigGroupFiles returns the first config file found in each group.
+func (v *Viper) findConfigGroupFiles() ([]string, error) {
+	var files, searched []string
+	for _, g := range v.configGroups {
+		for _, cp := range g.paths {
+			searched = append(searched, cp)
+			if file := v.searchInPath(cp); file != "" {
+				jww.DEBUG.Println("Using", file, "for group", g.name)
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, ConfigFileNotFoundError{v.configName, fmt.Sprintf("%s", searched)}
+	}
+	return files, nil
+}
@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigPathGroups(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for name, contents := range map[string]string{
+		"/etc/app/config.yaml":                "name: system\ncolor: blue\ndb:\n  host: db.prod\n",
+		"/home/steve/.config/app/config.toml": "name = \"user\"\n",
+		// shadowed by the first path of the user group
+		"/home/steve/.app/config.json": `{"name": "steve", "db": {"user": "steve"}}`,
+		"/src/app/config.yaml":         "db:\n  host: localhost\n",
+	} {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(contents), 0644))
+	}
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigName("config")
+	v.AddConfigPathGroup("system", "/etc/app")
+	v.AddConfigPathGroup("user", "/home/steve/.config/app", "/home/steve/.app")
+	v.AddConfigPathGroup("project", "/src/app")
+	require.NoError(t, v.ReadInConfig())
+
+	assert.Equal(t, "user", v.GetString("name"))
+	assert.Equal(t, "blue", v.GetString("color"))
+	assert.Equal(t, "localhost", v.GetString("db.host"))
+	assert.False(t, v.IsSet("db.user"))
+
+	files := v.ConfigFilesUsed()
+	require.Len(t, files, 3)
+	assert.Equal(t, "/etc/app/config.yaml", files[0].Path)
+	assert.Equal(t, "/home/steve/.config/app/config.toml", files[1].Path)
+	assert.Equal(t, "toml", files[1].Format)
+	assert.Equal(t, "/src/app/config.yaml", files[2].Path)
+
+	source, detail := v.Origin("db.host")
+	assert.Equal(t, SourceConfig, source)
+	assert.Equal(t, "/src/app/config.yaml", detail)
+}
+
+func TestConfigPathGroupsMissing(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/src/app/config.yaml", []byte("name: steve\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigName("config")
+	v.AddConfigPathGroup("system", "/etc/app")
+	v.AddConfigPathGroup("project", "/src/app")
+	v.AddConfigPathGroup("system", "/usr/local/etc/app")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+
+	v = New()
+	v.SetFs(fs)
+	v.SetConfigName("config")
+	v.AddConfigPathGroup("system", "/etc/app")
+	_, ok := v.ReadInConfig().(ConfigFileNotFoundError)
+	assert.True(t, ok)
+}
@@ -0,0 +1,92 @@
+package viper
+
+import (
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// profiles holds the defaults set through SetDefaultFor, guarded by v.mu.
+type profiles struct {
+	active   string
+	defaults map[string]map[string]interface{}
+}
+
+// SetProfile selects the profile whose defaults apply, e.g. "production",
+// see SetDefaultFor. The empty name selects no profile.
+func SetProfile(name string) { v.SetProfile(name) }
+func (v *Viper) SetProfile(name string) {
+	v.mu.Lock()
+	v.profiles.active = name
+	v.unlockChanged()
+}
+
+// Profile returns the name of the selected profile, see SetProfile.
+func Profile() string { return v.Profile() }
+func (v *Viper) Profile() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.profiles.active
+}
+
+// SetDefaultFor sets the default value for the key when profile is selected
+// through SetProfile, so that the defaults of each environment live next to
+// the generic ones. The defaults of the selected profile take precedence over
+// the ones set through SetDefault, and like them over every other source.
+// SetDefaultFor is case-insensitive for a key.
+//
+//	viper.SetDefault("log.level", "debug")
+//	viper.SetDefaultFor("production", "log.level", "warn")
+//	viper.SetProfile(os.Getenv("APP_ENV"))
+func SetDefaultFor(profile, key string, value interface{}) { v.SetDefaultFor(profile, key, value) }
+func (v *Viper) SetDefaultFor(profile, key string, value interface{}) {
+	key = v.lockedRealKey(v.normalizeKey(key))
+	if err := v.Authorize(ActionSetDefault, key); err != nil {
+		jww.ERROR.Println(err)
+		return
+	}
+	value = v.toCaseInsensitiveValue(value)
+
+	path := strings.Split(key, v.keyDelim)
+	auditing := v.auditing()
+	v.mu.Lock()
+	if v.profiles.defaults == nil {
+		v.profiles.defaults = make(map[string]map[string]interface{})
+	}
+	defaults := v.profiles.defaults[profile]
+	if defaults == nil {
+		defaults = make(map[string]interface{})
+		v.profiles.defaults[profile] = defaults
+	}
+	var old interface{}
+	if auditing {
+		old = v.searchMap(defaults, path)
+	}
+	if err := v.setInLayer(defaults, path, value, nil, nil); err != nil {
+		v.mu.Unlock()
+		jww.ERROR.Println(err)
+		return
+	}
+	v.unlockChanged()
+	if auditing {
+		v.recordAudit(nil, AuditRecord{Operation: AuditSetDefault, Key: key, OldValue: old, NewValue: value, Source: profile})
+	}
+}
+
+// profileDefaults returns the defaults of the selected profile, nil if
+// none. The caller must hold the read lock.
+func (v *Viper) profileDefaults() map[string]interface{} {
+	if v.profiles.active == "" {
+		return nil
+	}
+	return v.profiles.defaults[v.profiles.active]
+}
+
+// searchDefaults returns the default at path, the one of the selected
+// profile if any. The caller must hold the read lock.
+func (v *Viper) searchDefaults(path []string) interface{} {
+	if val := v.searchMap(v.profileDefaults(), path); val != nil {
+		return val
+	}
+	return v.searchMap(v.defaults, path)
+}
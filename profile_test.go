@@ -0,0 +1,49 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDefaultFor(t *testing.T) {
+	v := New()
+	v.SetDefault("log.level", "debug")
+	v.SetDefault("log.format", "text")
+	v.SetDefaultFor("production", "Log.Level", "warn")
+	v.SetDefaultFor("production", "tracing.enabled", true)
+	v.SetDefaultFor("staging", "log.level", "info")
+
+	assert.Equal(t, "", v.Profile())
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	assert.False(t, v.IsSet("tracing.enabled"))
+
+	v.SetProfile("production")
+	assert.Equal(t, "production", v.Profile())
+	assert.Equal(t, "warn", v.GetString("log.level"))
+	assert.Equal(t, "text", v.GetString("log.format"))
+	assert.True(t, v.GetBool("tracing.enabled"))
+	assert.ElementsMatch(t, []string{"log.format", "log.level", "tracing.enabled"}, v.AllKeys())
+	assert.Equal(t, map[string]interface{}{"format": "text", "level": "warn"}, v.AllSettings()["log"])
+
+	v.Set("log.level", "error")
+	assert.Equal(t, "error", v.GetString("log.level"))
+
+	v.SetProfile("staging")
+	assert.False(t, v.IsSet("tracing.enabled"))
+	v.SetProfile("")
+	assert.Equal(t, "error", v.GetString("log.level"))
+}
+
+func TestSetDefaultForValidate(t *testing.T) {
+	v := New()
+	v.SetDefault("port", "8080")
+	v.SetDefaultFor("production", "port", 80)
+	v.SetProfile("production")
+	v.Set("port", "http")
+
+	err := v.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"port"`)
+}
@@ -0,0 +1,56 @@
+package viper
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// DefaultProjectRootMarkers are the files and directories marking the root
+// of a project for AddConfigPathProjectRoot.
+var DefaultProjectRootMarkers = []string{".git", "go.mod"}
+
+// AddConfigPathProjectRoot adds the root of the project the working
+// directory is in to the paths to search for the config file in, so that
+// project configuration is found regardless of the directory a command is
+// run from. The root is the first directory, walking up from the working
+// directory, containing one of the given markers, or one of
+// DefaultProjectRootMarkers if none are given. It reports whether a root was
+// found.
+func AddConfigPathProjectRoot(markers ...string) bool { return v.AddConfigPathProjectRoot(markers...) }
+func (v *Viper) AddConfigPathProjectRoot(markers ...string) bool {
+	wd, err := os.Getwd()
+	if err != nil {
+		jww.ERROR.Println("unable to get the working directory:", err)
+		return false
+	}
+	if len(markers) == 0 {
+		markers = DefaultProjectRootMarkers
+	}
+	root := findProjectRoot(v.fs, wd, markers)
+	if root == "" {
+		jww.INFO.Println("no project root found from", wd)
+		return false
+	}
+	v.AddConfigPath(root)
+	return true
+}
+
+// findProjectRoot returns the first directory containing one of markers,
+// walking up from dir, or "" if there is none.
+func findProjectRoot(fs afero.Fs, dir string, markers []string) string {
+	for {
+		for _, marker := range markers {
+			if ok, _ := exists(fs, filepath.Join(dir, marker)); ok {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
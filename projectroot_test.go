@@ -0,0 +1,50 @@
+package viper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectRoot(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/src/app/.git", 0755))
+	require.NoError(t, fs.MkdirAll("/src/app/cmd/tool", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/src/app/cmd/go.mod", []byte("module tool\n"), 0644))
+
+	markers := []string{".git"}
+	assert.Equal(t, "/src/app", findProjectRoot(fs, "/src/app/cmd/tool", markers))
+	assert.Equal(t, "/src/app", findProjectRoot(fs, "/src/app", markers))
+	assert.Equal(t, "", findProjectRoot(fs, "/src", markers))
+	assert.Equal(t, "/src/app/cmd", findProjectRoot(fs, "/src/app/cmd/tool", DefaultProjectRootMarkers))
+}
+
+func TestAddConfigPathProjectRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "cmd", "tool"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module app\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "config.yaml"), []byte("name: steve\n"), 0644))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(filepath.Join(root, "cmd", "tool")))
+
+	v := New()
+	assert.False(t, v.AddConfigPathProjectRoot("no-such-marker"))
+	assert.True(t, v.AddConfigPathProjectRoot())
+	assert.Equal(t, []string{root}, v.configPaths)
+
+	v.SetConfigName("config")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "steve", v.GetString("name"))
+}
@@ -0,0 +1,216 @@
+package viper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultKind tells how the default of a key is defined, see DefaultOrigin.
+type DefaultKind int
+
+const (
+	DefaultNone DefaultKind = iota
+	// DefaultSetDefault is a default set through SetDefault or
+	// SetDefaultWithDoc.
+	DefaultSetDefault
+	// DefaultStructTag is the default tag of a field, see BindStruct.
+	DefaultStructTag
+	// DefaultFlag is the default value of a bound flag.
+	DefaultFlag
+	// DefaultSchema is a default of the schema of an extension, see
+	// RegisterExtension.
+	DefaultSchema
+	// DefaultProfile is a default of the selected profile, see
+	// SetDefaultFor.
+	DefaultProfile
+)
+
+// String returns the name of the kind of default.
+func (k DefaultKind) String() string {
+	switch k {
+	case DefaultSetDefault:
+		return "SetDefault"
+	case DefaultStructTag:
+		return "struct tag"
+	case DefaultFlag:
+		return "flag"
+	case DefaultSchema:
+		return "schema"
+	case DefaultProfile:
+		return "profile"
+	default:
+		return "none"
+	}
+}
+
+// defaultOrigin is where the default of a key is defined.
+type defaultOrigin struct {
+	kind   DefaultKind
+	detail string
+}
+
+// String returns the description of the origin, e.g. "SetDefault at
+// main.go:42", or "" if unknown.
+func (o defaultOrigin) String() string {
+	switch {
+	case o.kind == DefaultNone:
+		return ""
+	case o.detail == "":
+		return o.kind.String()
+	case o.kind == DefaultSetDefault:
+		return "SetDefault at " + o.detail
+	case o.kind == DefaultSchema:
+		return "schema of extension " + o.detail
+	}
+	return o.kind.String() + " " + o.detail
+}
+
+// packageDir is the directory of the sources of this package, see
+// callerOrigin.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerOrigin returns the origin of a default set by the closest caller
+// outside of this package, located by its file and line.
+func callerOrigin() defaultOrigin {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			return defaultOrigin{kind: DefaultSetDefault, detail: fmt.Sprintf("%s:%d", frame.File, frame.Line)}
+		}
+		if !more {
+			return defaultOrigin{kind: DefaultSetDefault}
+		}
+	}
+}
+
+// DefaultOrigin reports how the default of key is defined, following the
+// same precedence as Get, so that "where does this default live in code?"
+// has an answer. detail is the file and line SetDefault was called from, the
+// struct field holding the default tag, e.g. "main.Config.Port", the name of
+// the flag, the extension or the profile. DefaultOrigin returns DefaultNone
+// if the key has no default.
+// DefaultOrigin is case-insensitive for a key.
+func DefaultOrigin(key string) (DefaultKind, string) { return v.DefaultOrigin(key) }
+func (v *Viper) DefaultOrigin(key string) (DefaultKind, string) {
+	lcaseKey := v.normalizeKey(key)
+	if m, rest, ok := v.mountFor(v.lockedRealKey(lcaseKey)); ok && rest != "" {
+		return m.DefaultOrigin(rest)
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	o := v.defaultOriginOf(v.realKey(lcaseKey))
+	return o.kind, o.detail
+}
+
+// defaultOriginOf returns the origin of the default of key. The caller must
+// hold the read lock.
+func (v *Viper) defaultOriginOf(key string) defaultOrigin {
+	path := strings.Split(key, v.keyDelim)
+	if profile := v.profileDefaults(); profile != nil && v.searchMap(profile, path) != nil {
+		return defaultOrigin{kind: DefaultProfile, detail: v.profiles.active}
+	}
+	if v.searchMap(v.defaults, path) == nil {
+		if flag, ok := v.pflags[key]; ok {
+			return defaultOrigin{kind: DefaultFlag, detail: flag.Name()}
+		}
+		return defaultOrigin{}
+	}
+
+	for i := len(path); i > 0; i-- {
+		if o, ok := v.defaultOrigins[strings.Join(path[:i], v.keyDelim)]; ok {
+			return o
+		}
+	}
+	// key holds a map, report the origin if all its children share it
+	prefix := key + v.keyDelim
+	var origin defaultOrigin
+	found := false
+	for k, o := range v.defaultOrigins {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if found && o != origin {
+			return defaultOrigin{}
+		}
+		origin, found = o, true
+	}
+	return origin
+}
+
+// recordDefaultOrigin records the origin of the default of key, replacing
+// the ones of the keys below it. The caller must hold the write lock.
+func (v *Viper) recordDefaultOrigin(key string, origin defaultOrigin) {
+	if v.defaultOrigins == nil {
+		v.defaultOrigins = make(map[string]defaultOrigin)
+	}
+	prefix := key + v.keyDelim
+	for k := range v.defaultOrigins {
+		if strings.HasPrefix(k, prefix) {
+			delete(v.defaultOrigins, k)
+		}
+	}
+	v.defaultOrigins[key] = origin
+}
+
+// defaultOriginStrings returns the description of the origin of every
+// default, by key. The caller must hold the read lock.
+func (v *Viper) defaultOriginStrings() map[string]string {
+	m := make(map[string]string, len(v.defaultOrigins))
+	for key, o := range v.defaultOrigins {
+		m[key] = o.String()
+	}
+	return m
+}
+
+// provenance is the value of a key written by ExportProvenance.
+type provenance struct {
+	Value         interface{} `json:"value"`
+	Source        string      `json:"source"`
+	Detail        string      `json:"detail,omitempty"`
+	Default       string      `json:"default,omitempty"`
+	DefaultDetail string      `json:"defaultDetail,omitempty"`
+}
+
+// ExportProvenance writes the value of every key from AllKeys as JSON, along
+// with the layer it was read from, see Origin, and how its default is
+// defined, if it has one, see DefaultOrigin. Secret keys are written as
+// SecretRedacted, see MarkSecret.
+//
+//	{
+//	  "server.port": {
+//	    "value": 9090,
+//	    "source": "env",
+//	    "detail": "APP_SERVER_PORT",
+//	    "default": "SetDefault",
+//	    "defaultDetail": "/src/app/main.go:42"
+//	  }
+//	}
+func ExportProvenance(w io.Writer) error { return v.ExportProvenance(w) }
+func (v *Viper) ExportProvenance(w io.Writer) error {
+	out := map[string]provenance{}
+	for key, setting := range v.DebugSettings() {
+		p := provenance{Value: setting.Value, Source: setting.Source.String(), Detail: setting.Detail}
+		if v.IsSecret(key) {
+			p.Value = SecretRedacted
+		}
+		if kind, detail := v.DefaultOrigin(key); kind != DefaultNone {
+			p.Default, p.DefaultDetail = kind.String(), detail
+		}
+		out[key] = p
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
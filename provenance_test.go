@@ -0,0 +1,98 @@
+package viper
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type provenanceConfig struct {
+	Server struct {
+		Host string `default:"localhost"`
+	}
+}
+
+func TestDefaultOrigin(t *testing.T) {
+	v := New()
+	_, file, line, _ := runtime.Caller(0)
+	v.SetDefault("server.port", 8080)
+	require.NoError(t, v.BindStruct(&provenanceConfig{}))
+	require.NoError(t, v.RegisterExtension("metrics", ExtensionSchema{Defaults: map[string]interface{}{"interval": "10s"}}))
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("log-level", "info", "")
+	require.NoError(t, v.BindPFlag("log.level", flags.Lookup("log-level")))
+	v.SetDefaultFor("dev", "server.port", 3000)
+
+	kind, detail := v.DefaultOrigin("Server.Port")
+	assert.Equal(t, DefaultSetDefault, kind)
+	assert.Equal(t, file+":"+strconv.Itoa(line+1), detail)
+
+	kind, detail = v.DefaultOrigin("server.host")
+	assert.Equal(t, DefaultStructTag, kind)
+	assert.Contains(t, detail, ".Host")
+	kind, detail = v.DefaultOrigin("metrics.interval")
+	assert.Equal(t, DefaultSchema, kind)
+	assert.Equal(t, "metrics", detail)
+	kind, detail = v.DefaultOrigin("log.level")
+	assert.Equal(t, DefaultFlag, kind)
+	assert.Equal(t, "log-level", detail)
+	kind, _ = v.DefaultOrigin("missing")
+	assert.Equal(t, DefaultNone, kind)
+
+	v.SetProfile("dev")
+	kind, detail = v.DefaultOrigin("server.port")
+	assert.Equal(t, DefaultProfile, kind)
+	assert.Equal(t, "dev", detail)
+	v.SetProfile("")
+
+	// a map default is reported for its keys, and replaces their origins
+	_, _, line, _ = runtime.Caller(0)
+	v.SetDefault("server", map[string]interface{}{"port": 80, "host": "example.com"})
+	kind, detail = v.DefaultOrigin("server.host")
+	assert.Equal(t, DefaultSetDefault, kind)
+	assert.Equal(t, file+":"+strconv.Itoa(line+1), detail)
+	kind, _ = v.DefaultOrigin("server")
+	assert.Equal(t, DefaultSetDefault, kind)
+
+	src, detail := v.Origin("metrics.interval")
+	assert.Equal(t, SourceDefault, src)
+	assert.Equal(t, "schema of extension metrics", detail)
+	src, detail = v.Origin("server.port")
+	assert.Equal(t, SourceDefault, src)
+	assert.Equal(t, "SetDefault at "+file+":"+strconv.Itoa(line+1), detail)
+
+	for _, ks := range v.Schema() {
+		if ks.Key == "metrics.interval" {
+			assert.Equal(t, "schema of extension metrics", ks.DefaultOrigin)
+		}
+	}
+}
+
+func TestExportProvenance(t *testing.T) {
+	v := New()
+	v.SetDefault("port", 8080)
+	v.SetDefault("password", "default")
+	v.MarkSecret("password")
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("port: 9090\nname: app\n")))
+
+	var buf bytes.Buffer
+	require.NoError(t, v.ExportProvenance(&buf))
+	var out map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+
+	assert.Equal(t, float64(9090), out["port"]["value"])
+	assert.Equal(t, "config", out["port"]["source"])
+	assert.Equal(t, "SetDefault", out["port"]["default"])
+	assert.Contains(t, out["port"]["defaultDetail"], "provenance_test.go:")
+	assert.Equal(t, SecretRedacted, out["password"]["value"])
+	assert.Equal(t, "default", out["password"]["source"])
+	assert.Contains(t, out["password"]["detail"], "SetDefault at ")
+	assert.NotContains(t, out["name"], "default")
+}
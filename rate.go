@@ -0,0 +1,145 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// rateUnits are the names of the intervals accepted by GetRate, besides
+// durations such as "5s".
+var rateUnits = map[string]time.Duration{
+	"ms":     time.Millisecond,
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hr":     time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// Rate is a number of events allowed per interval, e.g. for throttling, as
+// returned by GetRate.
+type Rate struct {
+	Count    int
+	Interval time.Duration
+}
+
+// PerSecond returns the rate as a number of events per second, e.g. to
+// create a golang.org/x/time/rate limiter:
+//
+//	r := viper.GetRate("api.limit")
+//	limiter := rate.NewLimiter(rate.Limit(r.PerSecond()), r.Count)
+func (r Rate) PerSecond() float64 {
+	if r.Interval <= 0 {
+		return 0
+	}
+	return float64(r.Count) / r.Interval.Seconds()
+}
+
+// Every returns the time between two events at the rate, or 0 if no events
+// are allowed.
+func (r Rate) Every() time.Duration {
+	if r.Count <= 0 {
+		return 0
+	}
+	return r.Interval / time.Duration(r.Count)
+}
+
+// String returns the rate as parsed by GetRate.
+func (r Rate) String() string {
+	for _, unit := range []string{"d", "h", "m", "s", "ms"} {
+		if r.Interval == rateUnits[unit] {
+			return strconv.Itoa(r.Count) + "/" + unit
+		}
+	}
+	return strconv.Itoa(r.Count) + "/" + r.Interval.String()
+}
+
+// GetRate returns the value associated with the key as a rate, given as
+// "100/s", "5000/m", "10/hour" or "50/10s", or a map with count and
+// interval keys.
+func GetRate(key string) Rate { return v.GetRate(key) }
+func (v *Viper) GetRate(key string) Rate {
+	r, _ := v.GetRateE(key)
+	return r
+}
+
+// GetRateE behaves like GetRate, but returns a KeyNotSetError if the key has
+// no value and a CastError if its value isn't a valid rate.
+func GetRateE(key string) (Rate, error) { return v.GetRateE(key) }
+func (v *Viper) GetRateE(key string) (Rate, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return Rate{}, err
+	}
+	r, err := parseRate(val)
+	return r, v.castError(key, val, err)
+}
+
+// StringToRateHookFunc returns a DecodeHookFunc that converts strings and
+// maps to Rate, like GetRate. It's one of the default decode hooks of
+// Unmarshal.
+func StringToRateHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if t != reflect.TypeOf(Rate{}) || f == t {
+			return data, nil
+		}
+		return parseRate(data)
+	}
+}
+
+func parseRate(val interface{}) (Rate, error) {
+	var count, interval interface{}
+	switch val := val.(type) {
+	case Rate:
+		return val, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := cast.ToStringMap(val)
+		count, interval = m["count"], m["interval"]
+	case string:
+		i := strings.Index(val, "/")
+		if i < 0 {
+			return Rate{}, fmt.Errorf("invalid rate %q, expected count/interval", val)
+		}
+		count = strings.TrimSpace(val[:i])
+		unit := strings.ToLower(strings.TrimSpace(val[i+1:]))
+		if d, ok := rateUnits[unit]; ok {
+			interval = d
+		} else if d, ok := rateUnits[strings.TrimSuffix(unit, "s")]; ok && len(unit) > 2 {
+			interval = d
+		} else if d, err := time.ParseDuration(unit); err == nil {
+			interval = d
+		} else {
+			return Rate{}, fmt.Errorf("invalid rate interval %q", unit)
+		}
+	default:
+		return Rate{}, fmt.Errorf("unable to cast %#v of type %T to Rate", val, val)
+	}
+
+	r := Rate{}
+	var err error
+	if r.Count, err = cast.ToIntE(count); err != nil {
+		return Rate{}, fmt.Errorf("invalid rate count %v", count)
+	}
+	if r.Interval, err = cast.ToDurationE(interval); err != nil {
+		return Rate{}, fmt.Errorf("invalid rate interval %v", interval)
+	}
+	if r.Count < 0 {
+		return Rate{}, fmt.Errorf("negative rate count %d", r.Count)
+	}
+	if r.Interval <= 0 {
+		return Rate{}, fmt.Errorf("rate interval must be positive, got %v", interval)
+	}
+	return r, nil
+}
@@ -0,0 +1,75 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRate(t *testing.T) {
+	v := New()
+	for value, want := range map[interface{}]Rate{
+		"100/s":       {Count: 100, Interval: time.Second},
+		"5000/m":      {Count: 5000, Interval: time.Minute},
+		"10 / hour":   {Count: 10, Interval: time.Hour},
+		"3/days":      {Count: 3, Interval: 24 * time.Hour},
+		"50/10s":      {Count: 50, Interval: 10 * time.Second},
+		"1/ms":        {Count: 1, Interval: time.Millisecond},
+		"0/s":         {Count: 0, Interval: time.Second},
+		"20/Minutes":  {Count: 20, Interval: time.Minute},
+		"7/1m30s":     {Count: 7, Interval: 90 * time.Second},
+		"600/seconds": {Count: 600, Interval: time.Second},
+	} {
+		v.Set("limit", value)
+		r, err := v.GetRateE("limit")
+		require.NoError(t, err, value)
+		assert.Equal(t, want, r, value)
+	}
+
+	v.Set("limit", map[string]interface{}{"count": 30, "interval": "1m"})
+	r := v.GetRate("limit")
+	assert.Equal(t, Rate{Count: 30, Interval: time.Minute}, r)
+	assert.Equal(t, "30/m", r.String())
+	assert.Equal(t, 0.5, r.PerSecond())
+	assert.Equal(t, 2*time.Second, r.Every())
+	assert.Equal(t, "50/10s", Rate{Count: 50, Interval: 10 * time.Second}.String())
+	assert.Equal(t, time.Duration(0), Rate{Interval: time.Second}.Every())
+
+	for _, value := range []interface{}{"100", "100/fortnight", "ten/s", "-1/s", "1/0s", "1/5", 100} {
+		v.Set("limit", value)
+		_, err := v.GetRateE("limit")
+		assert.IsType(t, CastError{}, err, value)
+	}
+
+	_, err := v.GetRateE("missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+	assert.Equal(t, Rate{}, v.GetRate("missing"))
+}
+
+func TestUnmarshalRate(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+api:
+  limit: 100/s
+  burst:
+    count: 20
+    interval: 1s
+`)))
+
+	var config struct {
+		API struct {
+			Limit Rate
+			Burst Rate
+		}
+	}
+	require.NoError(t, v.Unmarshal(&config))
+	assert.Equal(t, Rate{Count: 100, Interval: time.Second}, config.API.Limit)
+	assert.Equal(t, Rate{Count: 20, Interval: time.Second}, config.API.Burst)
+
+	v.Set("api.limit", "lots")
+	assert.Error(t, v.Unmarshal(&config))
+}
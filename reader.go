@@ -0,0 +1,67 @@
+package viper
+
+import (
+	"time"
+)
+
+// ConfigReader is the read-only side of a configuration, implemented by
+// *Viper and *Snapshot. Libraries can accept a ConfigReader to read their
+// settings without being able to change the configuration of the
+// application. SubReader returns the sub tree of a key as a ConfigReader as
+// well, a read-only copy detached from the instance it was taken from.
+//
+//	func NewClient(cfg viper.ConfigReader) *Client {
+//		return &Client{timeout: cfg.GetDuration("client.timeout")}
+//	}
+//
+//	client := NewClient(viper.GetViper())
+type ConfigReader interface {
+	Get(key string) interface{}
+	IsSet(key string) bool
+	AllKeys() []string
+	AllSettings() map[string]interface{}
+	SubReader(key string) ConfigReader
+
+	GetString(key string) string
+	GetBool(key string) bool
+	GetInt(key string) int
+	GetInt32(key string) int32
+	GetInt64(key string) int64
+	GetUint(key string) uint
+	GetUint16(key string) uint16
+	GetUint32(key string) uint32
+	GetUint64(key string) uint64
+	GetFloat64(key string) float64
+	GetTime(key string) time.Time
+	GetDuration(key string) time.Duration
+	GetIntSlice(key string) []int
+	GetFloat64Slice(key string) []float64
+	GetStringSlice(key string) []string
+	GetBytes(key string) []byte
+	GetStringMap(key string) map[string]interface{}
+	GetStringMapString(key string) map[string]string
+	GetStringMapStringSlice(key string) map[string][]string
+	GetStringToInt64Map(key string) map[string]int64
+	GetStringToBoolMap(key string) map[string]bool
+	GetSizeInBytes(key string) uint
+
+	UnmarshalKey(key string, rawVal interface{}, opts ...DecoderConfigOption) error
+	Unmarshal(rawVal interface{}, opts ...DecoderConfigOption) error
+}
+
+var (
+	_ ConfigReader = (*Viper)(nil)
+	_ ConfigReader = (*Snapshot)(nil)
+)
+
+// SubReader returns the sub tree of key like Sub, as a read-only Snapshot,
+// or nil if key doesn't hold a map.
+// SubReader is case-insensitive for a key.
+func SubReader(key string) ConfigReader { return v.SubReader(key) }
+func (v *Viper) SubReader(key string) ConfigReader {
+	sub := v.Sub(key)
+	if sub == nil {
+		return nil
+	}
+	return &Snapshot{generation: v.Generation(), v: sub}
+}
@@ -0,0 +1,44 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readClientConfig(cfg ConfigReader) (time.Duration, []float64, map[string]bool) {
+	return cfg.GetDuration("client.timeout"), cfg.GetFloat64Slice("client.weights"), cfg.GetStringToBoolMap("client.features")
+}
+
+func TestConfigReader(t *testing.T) {
+	v := New()
+	v.Set("client", map[string]interface{}{
+		"timeout":  "5s",
+		"weights":  []interface{}{0.5, 1.5},
+		"features": map[string]interface{}{"retry": true},
+		"port":     8080,
+		"limits":   map[string]interface{}{"conns": 10},
+	})
+	snapshot := v.Pin()
+
+	for _, cfg := range []ConfigReader{v, snapshot} {
+		timeout, weights, features := readClientConfig(cfg)
+		assert.Equal(t, 5*time.Second, timeout)
+		assert.Equal(t, []float64{0.5, 1.5}, weights)
+		assert.Equal(t, map[string]bool{"retry": true}, features)
+		assert.Equal(t, uint16(8080), cfg.GetUint16("client.port"))
+		assert.Equal(t, map[string]int64{"conns": 10}, cfg.GetStringToInt64Map("client.limits"))
+	}
+
+	// the sub-tree of a reader is read-only as well
+	for _, cfg := range []ConfigReader{v, snapshot} {
+		sub := cfg.SubReader("client")
+		assert.IsType(t, &Snapshot{}, sub)
+		assert.Equal(t, 5*time.Second, sub.GetDuration("timeout"))
+		assert.Equal(t, int64(10), sub.SubReader("limits").GetInt64("conns"))
+		assert.Nil(t, cfg.SubReader("client.port"))
+	}
+	v.Set("client.timeout", "1s")
+	assert.Equal(t, 5*time.Second, snapshot.SubReader("client").GetDuration("timeout"))
+}
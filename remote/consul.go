@@ -0,0 +1,15 @@
+//go:build !viper_noconsul
+// +build !viper_noconsul
+
+package remote
+
+import (
+	"github.com/xordataexchange/crypt/backend"
+	"github.com/xordataexchange/crypt/backend/consul"
+)
+
+func init() {
+	register("consul", func(machines []string) (backend.Store, error) {
+		return consul.New(machines)
+	})
+}
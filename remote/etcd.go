@@ -0,0 +1,15 @@
+//go:build !viper_noetcd
+// +build !viper_noetcd
+
+package remote
+
+import (
+	"github.com/xordataexchange/crypt/backend"
+	"github.com/xordataexchange/crypt/backend/etcd"
+)
+
+func init() {
+	register("etcd", func(machines []string) (backend.Store, error) {
+		return etcd.New(machines)
+	})
+}
@@ -4,17 +4,38 @@
 // license that can be found in the LICENSE file.
 
 // Package remote integrates the remote features of Viper.
+//
+// Importing the package registers the "etcd" and "consul" remote providers.
+// Each of them is compiled in by its own file, so that binaries which don't
+// need one of them drop its client by building with the viper_noetcd or
+// viper_noconsul tag:
+//
+//	go build -tags viper_noetcd ./...
+//
+// Reading from a provider left out returns viper.ErrProviderNotCompiled.
 package remote
 
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/spf13/viper"
-	crypt "github.com/xordataexchange/crypt/config"
+	"github.com/xordataexchange/crypt/backend"
+	"github.com/xordataexchange/crypt/encoding/secconf"
 )
 
+// stores opens the key/value store of each provider compiled in, by name.
+var stores = map[string]func(machines []string) (backend.Store, error){}
+
+// register makes the provider name, whose store is opened by open,
+// available to viper.AddRemoteProvider and viper.AddSecureRemoteProvider.
+func register(name string, open func(machines []string) (backend.Store, error)) {
+	stores[name] = open
+	viper.RegisterRemoteConfigProvider(name, remoteConfigProvider{})
+}
+
 type remoteConfigProvider struct{}
 
 func (rc remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
@@ -22,7 +43,7 @@ func (rc remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
 	if err != nil {
 		return nil, err
 	}
-	b, err := cm.Get(rp.Path())
+	b, err := cm.get(rp.Path())
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +55,7 @@ func (rc remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := cm.Get(rp.Path())
+	resp, err := cm.get(rp.Path())
 	if err != nil {
 		return nil, err
 	}
@@ -50,56 +71,78 @@ func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *vi
 	quit := make(chan bool)
 	quitwc := make(chan bool)
 	viperResponsCh := make(chan *viper.RemoteResponse)
-	cryptoResponseCh := cm.Watch(rp.Path(), quit)
-	// need this function to convert the Channel response form crypt.Response to viper.Response
-	go func(cr <-chan *crypt.Response, vr chan<- *viper.RemoteResponse, quitwc <-chan bool, quit chan<- bool) {
+	backendResponseCh := cm.store.Watch(rp.Path(), quit)
+	// need this function to convert the Channel response form backend.Response to viper.Response
+	go func(br <-chan *backend.Response, vr chan<- *viper.RemoteResponse, quitwc <-chan bool, quit chan<- bool) {
 		for {
 			select {
 			case <-quitwc:
 				quit <- true
 				return
-			case resp := <-cr:
+			case resp := <-br:
+				if resp.Error != nil {
+					vr <- &viper.RemoteResponse{Error: resp.Error}
+					continue
+				}
+				value, err := cm.decode(resp.Value)
 				vr <- &viper.RemoteResponse{
-					Error: resp.Error,
-					Value: resp.Value,
+					Error: err,
+					Value: value,
 				}
 
 			}
 
 		}
-	}(cryptoResponseCh, viperResponsCh, quitwc, quit)
+	}(backendResponseCh, viperResponsCh, quitwc, quit)
 
 	return viperResponsCh, quitwc
 }
 
-func getConfigManager(rp viper.RemoteProvider) (crypt.ConfigManager, error) {
-	var cm crypt.ConfigManager
-	var err error
+// configManager reads values from a key/value store, decrypting them with
+// the secret keyring of secure remote providers.
+type configManager struct {
+	store backend.Store
+	// the secret keyring, nil unless the provider is secure
+	keystore []byte
+}
+
+// get returns the value stored at key.
+func (cm configManager) get(key string) ([]byte, error) {
+	value, err := cm.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return cm.decode(value)
+}
+
+// decode decrypts value if the provider is secure.
+func (cm configManager) decode(value []byte) ([]byte, error) {
+	if cm.keystore == nil {
+		return value, nil
+	}
+	return secconf.Decode(value, bytes.NewBuffer(cm.keystore))
+}
+
+func getConfigManager(rp viper.RemoteProvider) (configManager, error) {
+	open, ok := stores[rp.Provider()]
+	if !ok {
+		return configManager{}, viper.ErrProviderNotCompiled
+	}
+	store, err := open([]string{rp.Endpoint()})
+	if err != nil {
+		return configManager{}, err
+	}
+	cm := configManager{store: store}
 
 	if rp.SecretKeyring() != "" {
 		kr, err := os.Open(rp.SecretKeyring())
-		defer kr.Close()
 		if err != nil {
-			return nil, err
+			return configManager{}, err
 		}
-		if rp.Provider() == "etcd" {
-			cm, err = crypt.NewEtcdConfigManager([]string{rp.Endpoint()}, kr)
-		} else {
-			cm, err = crypt.NewConsulConfigManager([]string{rp.Endpoint()}, kr)
-		}
-	} else {
-		if rp.Provider() == "etcd" {
-			cm, err = crypt.NewStandardEtcdConfigManager([]string{rp.Endpoint()})
-		} else {
-			cm, err = crypt.NewStandardConsulConfigManager([]string{rp.Endpoint()})
+		defer kr.Close()
+		if cm.keystore, err = ioutil.ReadAll(kr); err != nil {
+			return configManager{}, err
 		}
 	}
-	if err != nil {
-		return nil, err
-	}
 	return cm, nil
 }
-
-func init() {
-	viper.RemoteConfig = &remoteConfigProvider{}
-}
@@ -0,0 +1,94 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ReservePrefix reserves the keys at and below prefix, e.g. "internal", from
+// the given configuration layers, by default the environment, flags and the
+// key/value store: values from these layers are ignored for reserved keys,
+// which are read from the next layers, down to the defaults, and left out of
+// the subtrees read from these layers, e.g. by Get and Sub for a parent
+// key. It stops end users from toggling dangerous internals, e.g. through
+// environment variables with AutomaticEnv. Any layer but the defaults can be
+// reserved, e.g. SourceConfig to keep the keys out of reach of config files
+// as well.
+// Reserving a prefix again replaces its layers.
+// ReservePrefix is case-insensitive for a key.
+//
+//	viper.ReservePrefix("internal")
+//	viper.ReservePrefix("features.beta", viper.SourceEnv)
+func ReservePrefix(prefix string, layers ...SourceKind) { v.ReservePrefix(prefix, layers...) }
+func (v *Viper) ReservePrefix(prefix string, layers ...SourceKind) {
+	if len(layers) == 0 {
+		layers = []SourceKind{SourceEnv, SourceFlag, SourceKVStore}
+	}
+	set := make(map[SourceKind]bool, len(layers))
+	for _, layer := range layers {
+		set[layer] = true
+	}
+
+	v.mu.Lock()
+	if v.reserved == nil {
+		v.reserved = make(map[string]map[SourceKind]bool)
+	}
+	v.reserved[v.realKey(v.normalizeKey(prefix))] = set
+	v.unlockChanged()
+}
+
+// IsReserved tells whether key is reserved from the layer, see
+// ReservePrefix.
+// IsReserved is case-insensitive for a key.
+func IsReserved(key string, layer SourceKind) bool { return v.IsReserved(key, layer) }
+func (v *Viper) IsReserved(key string, layer SourceKind) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.reservedLayers(strings.Split(v.realKey(v.normalizeKey(key)), v.keyDelim))[layer]
+}
+
+// reservedLayers returns the layers the key at path is reserved from, nil
+// if none. The caller must hold the read lock.
+func (v *Viper) reservedLayers(path []string) map[SourceKind]bool {
+	if len(v.reserved) == 0 {
+		return nil
+	}
+	var layers map[SourceKind]bool
+	for i := len(path); i > 0; i-- {
+		set, ok := v.reserved[strings.Join(path[:i], v.keyDelim)]
+		if !ok {
+			continue
+		}
+		if layers == nil {
+			layers = make(map[SourceKind]bool, len(set))
+		}
+		for layer := range set {
+			layers[layer] = true
+		}
+	}
+	return layers
+}
+
+// withoutReserved returns val, the value of the key at path read from the
+// layer, without the keys below path reserved from the layer, or nil if no
+// key is left. val isn't modified. The caller must hold the read lock.
+func (v *Viper) withoutReserved(val interface{}, path []string, layer SourceKind) interface{} {
+	if len(v.reserved) == 0 || val == nil {
+		return val
+	}
+	prefix := strings.Join(path, v.keyDelim) + v.keyDelim
+	stripped := false
+	for key, layers := range v.reserved {
+		if !layers[layer] || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		var ok bool
+		if val, ok = withoutSetting(val, strings.Split(strings.TrimPrefix(key, prefix), v.keyDelim)); ok {
+			stripped = true
+		}
+	}
+	if rv := reflect.ValueOf(val); stripped && rv.Kind() == reflect.Map && rv.Len() == 0 {
+		return nil
+	}
+	return val
+}
@@ -0,0 +1,77 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservePrefix(t *testing.T) {
+	os.Setenv("APP_INTERNAL_DEBUG", "true")
+	defer os.Unsetenv("APP_INTERNAL_DEBUG")
+	os.Setenv("APP_LOG_LEVEL", "debug")
+	defer os.Unsetenv("APP_LOG_LEVEL")
+
+	v := New()
+	v.SetEnvPrefix("app")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	v.SetDefault("internal.debug", false)
+	v.SetDefault("internal.workers", 2)
+	v.SetDefault("log.level", "info")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int("workers", 4, "")
+	require.NoError(t, flags.Set("workers", "64"))
+	require.NoError(t, v.BindPFlag("internal.workers", flags.Lookup("workers")))
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("internal:\n  workers: 8\n")))
+
+	assert.True(t, v.GetBool("internal.debug"))
+	assert.Equal(t, 64, v.GetInt("internal.workers"))
+
+	v.ReservePrefix("Internal")
+	assert.True(t, v.IsReserved("internal.debug", SourceEnv))
+	assert.False(t, v.IsReserved("internal.debug", SourceConfig))
+	assert.False(t, v.IsReserved("log.level", SourceEnv))
+	assert.False(t, v.GetBool("internal.debug"))
+	assert.Equal(t, 8, v.GetInt("internal.workers"))
+	assert.Equal(t, map[string]interface{}{"debug": false, "workers": 8}, v.AllSettings()["internal"])
+	assert.Equal(t, "debug", v.GetString("log.level"))
+	src, _ := v.Origin("internal.workers")
+	assert.Equal(t, SourceConfig, src)
+
+	// reserving a nested prefix adds its layers
+	v.ReservePrefix("internal.workers", SourceConfig)
+	assert.Equal(t, 2, v.GetInt("internal.workers"))
+
+	// reserving a prefix again replaces its layers
+	v.ReservePrefix("internal", SourceFlag)
+	assert.True(t, v.GetBool("internal.debug"))
+}
+
+func TestReservePrefixParentKeys(t *testing.T) {
+	v := New()
+	v.SetDefault("server.internal.debug", false)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("server:\n  port: 8080\n  internal:\n    debug: true\n")))
+	v.ReservePrefix("server.internal", SourceConfig)
+
+	assert.False(t, v.GetBool("server.internal.debug"))
+	assert.Equal(t, map[string]interface{}{"port": 8080}, v.Get("server"))
+	sub := v.Sub("server")
+	require.NotNil(t, sub)
+	assert.Nil(t, sub.Get("internal.debug"))
+	assert.Equal(t, map[string]interface{}{
+		"port":     8080,
+		"internal": map[string]interface{}{"debug": false},
+	}, v.AllSettings()["server"])
+
+	// a subtree holding only reserved keys is read from the next layers
+	v.ReservePrefix("server.port", SourceConfig)
+	assert.Equal(t, map[string]interface{}{"internal": map[string]interface{}{"debug": false}}, v.Get("server"))
+}
@@ -0,0 +1,233 @@
+package viper
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// configSource is the config file read by ReadInConfig, kept so that
+// WriteConfig can patch it rather than rewrite it.
+type configSource struct {
+	path string
+	data []byte
+}
+
+// A configDocument is an editable config file, patched key by key while
+// keeping the comments, order and formatting of untouched keys. Its methods
+// return false for edits the document doesn't support.
+type configDocument interface {
+	// replace replaces the value of the key at path.
+	replace(path []string, value interface{}) bool
+	// remove removes the key at path and its value.
+	remove(path []string) bool
+	// add adds the key at path, whose parent exists unless it's top-level.
+	add(path []string, value interface{}) bool
+	// line returns the line number of the key at path, 0 if not found.
+	line(path []string) int
+	bytes() []byte
+}
+
+// patchedConfigFile returns the contents of the config file read by
+// ReadInConfig with the changes of the current settings applied, if the
+// file is written back to filename and its format supports round trips.
+func (v *Viper) patchedConfigFile(filename, configType string) ([]byte, bool) {
+	v.mu.RLock()
+	src := v.configSource
+	v.mu.RUnlock()
+	if src == nil || filepath.Clean(src.path) != filepath.Clean(filename) {
+		return nil, false
+	}
+	if _, ok := codecFor(configType); ok {
+		return nil, false
+	}
+	if data, err := decompress(src.data); err != nil || !bytes.Equal(data, src.data) {
+		return nil, false
+	}
+
+	doc := v.documentOf(src.data, configType)
+	if doc == nil {
+		return nil, false
+	}
+
+	old := make(map[string]interface{})
+	if err := v.unmarshalReaderAs(bytes.NewReader(src.data), old, configType); err != nil {
+		return nil, false
+	}
+	if !patchDocument(doc, nil, old, v.outputSettings()) {
+		jww.INFO.Println("Unable to patch", filename, "in place, rewriting it")
+		return nil, false
+	}
+	return doc.bytes(), true
+}
+
+// documentOf returns the document of the config file data of the given
+// type, or nil if the format has no document or data can't be parsed.
+func (v *Viper) documentOf(data []byte, configType string) configDocument {
+	switch strings.ToLower(configType) {
+	case "yaml", "yml":
+		if d := newYAMLDocument(data, v.normalizeKey); d != nil {
+			return d
+		}
+	case "toml":
+		if d := newTOMLDocument(data, v.normalizeKey); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// patchDocument applies the differences between the old and new settings
+// at path to doc.
+func patchDocument(doc configDocument, path []string, old, new map[string]interface{}) bool {
+	keys := make(map[string]bool, len(old)+len(new))
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range new {
+		keys[key] = true
+	}
+	for _, key := range sortedKeys(keys) {
+		p := append(path[:len(path):len(path)], key)
+		ov, inOld := old[key]
+		nv, inNew := new[key]
+		ok := true
+		switch {
+		case !inNew:
+			ok = doc.remove(p)
+		case !inOld:
+			ok = doc.add(p, nv)
+		default:
+			om, oldIsMap := ov.(map[string]interface{})
+			nm, newIsMap := nv.(map[string]interface{})
+			if oldIsMap && newIsMap && len(om) > 0 && len(nm) > 0 {
+				ok = patchDocument(doc, p, om, nm)
+			} else if !reflect.DeepEqual(ov, nv) {
+				ok = doc.replace(p, nv)
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type lineEdit struct {
+	start, end int
+	lines      []string
+	seq        int
+}
+
+// lineEditor collects edits of ranges of the lines of a document, applied
+// all at once by bytes so that line numbers stay those of the original.
+type lineEditor struct {
+	lines    []string
+	eol      string
+	trailing bool
+	edits    []lineEdit
+}
+
+func newLineEditor(data []byte) lineEditor {
+	e := lineEditor{eol: "\n"}
+	s := string(data)
+	if strings.Contains(s, "\r\n") {
+		e.eol = "\r\n"
+	}
+	if s != "" {
+		e.lines = strings.Split(s, e.eol)
+		if e.lines[len(e.lines)-1] == "" {
+			e.lines = e.lines[:len(e.lines)-1]
+			e.trailing = true
+		}
+	}
+	return e
+}
+
+// edit replaces the lines [start, end) with lines; start == end inserts
+// them. Edits of overlapping ranges are rejected.
+func (e *lineEditor) edit(start, end int, lines []string) bool {
+	for _, o := range e.edits {
+		if start < o.end && o.start < end ||
+			start == end && o.start < start && start < o.end ||
+			o.start == o.end && start < o.start && o.start < end {
+			return false
+		}
+	}
+	e.edits = append(e.edits, lineEdit{start: start, end: end, lines: lines, seq: len(e.edits)})
+	return true
+}
+
+func (e *lineEditor) bytes() []byte {
+	edits := make([]lineEdit, len(e.edits))
+	copy(edits, e.edits)
+	// apply from the bottom up; at the same line, replace the range first so
+	// that insertions end up before it, in the order they were made
+	sort.Slice(edits, func(i, j int) bool {
+		a, b := edits[i], edits[j]
+		if a.start != b.start {
+			return a.start > b.start
+		}
+		if (a.end > a.start) != (b.end > b.start) {
+			return a.end > a.start
+		}
+		return a.seq > b.seq
+	})
+
+	lines := append([]string{}, e.lines...)
+	for _, ed := range edits {
+		tail := append(append([]string{}, ed.lines...), lines[ed.end:]...)
+		lines = append(lines[:ed.start], tail...)
+	}
+	s := strings.Join(lines, e.eol)
+	if e.trailing || len(e.lines) == 0 && len(lines) > 0 {
+		s += e.eol
+	}
+	return []byte(s)
+}
+
+// splitComment splits a line into its content and its trailing comment,
+// including the whitespace before the "#" outside of quoted strings.
+func splitComment(line string) (string, string) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && startsValue(line[:i]):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			j := i
+			for j > 0 && (line[j-1] == ' ' || line[j-1] == '\t') {
+				j--
+			}
+			return line[:j], line[j:]
+		}
+	}
+	return line, ""
+}
+
+// startsValue reports whether a value starts after prefix, i.e. whether a
+// quote following it opens a quoted string rather than being part of a plain
+// one, as in "it's".
+func startsValue(prefix string) bool {
+	prefix = strings.TrimRight(prefix, " \t")
+	return prefix == "" || strings.IndexByte(":=[{,-", prefix[len(prefix)-1]) >= 0
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
@@ -0,0 +1,176 @@
+package viper
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func roundTrip(t *testing.T, name, contents string, change func(v *Viper)) string {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, name, []byte(contents), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile(name)
+	require.NoError(t, v.ReadInConfig())
+	change(v)
+	require.NoError(t, v.WriteConfig())
+
+	written, err := afero.ReadFile(fs, name)
+	require.NoError(t, err)
+
+	reread := New()
+	reread.SetFs(fs)
+	reread.SetConfigFile(name)
+	require.NoError(t, reread.ReadInConfig())
+	// compare values rather than their types, which depend on the format
+	want, err := json.Marshal(v.AllSettings())
+	require.NoError(t, err)
+	got, err := json.Marshal(reread.AllSettings())
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+	return string(written)
+}
+
+var yamlRoundTrip = `# the service
+name: steve # who
+port: 8080
+
+# the database
+db:
+  host: localhost
+  user: steve
+  hosts:
+    - a
+    - b
+motd: |
+  hello
+  world
+`
+
+func TestRoundTripYAML(t *testing.T) {
+	written := roundTrip(t, "/etc/app/config.yaml", yamlRoundTrip, func(v *Viper) {
+		v.Set("port", 9090)
+		v.Set("db.user", "admin")
+		v.Set("db.pass", "secret")
+		v.Set("cache", map[string]interface{}{"ttl": "1m"})
+	})
+	assert.Equal(t, `# the service
+name: steve # who
+port: 9090
+
+# the database
+db:
+  host: localhost
+  user: admin
+  hosts:
+    - a
+    - b
+  pass: secret
+motd: |
+  hello
+  world
+cache:
+  ttl: 1m
+`, written)
+}
+
+func TestRoundTripYAMLNested(t *testing.T) {
+	written := roundTrip(t, "/etc/app/config.yaml", yamlRoundTrip, func(v *Viper) {
+		v.Set("db.hosts", []string{"c"})
+		v.Set("motd", "bye")
+	})
+	assert.Equal(t, `# the service
+name: steve # who
+port: 8080
+
+# the database
+db:
+  host: localhost
+  user: steve
+  hosts:
+    - c
+motd: bye
+`, written)
+}
+
+func TestRoundTripYAMLUnsupported(t *testing.T) {
+	// anchors are rewritten
+	written := roundTrip(t, "/etc/app/config.yaml", "# defaults\nbase: &base\n  x: 1\nother: *base\n", func(v *Viper) {
+		v.Set("base.x", 2)
+	})
+	assert.NotContains(t, written, "# defaults")
+}
+
+var tomlRoundTrip = `# the service
+name = "steve" # who
+port = 8080
+
+# the database
+[db]
+  host = "localhost"
+  user = "steve"
+  hosts = [
+    "a",
+    "b",
+  ]
+
+[[servers]]
+name = "alpha"
+`
+
+func TestRoundTripTOML(t *testing.T) {
+	written := roundTrip(t, "/etc/app/config.toml", tomlRoundTrip, func(v *Viper) {
+		v.Set("port", 9090)
+		v.Set("db.user", "admin")
+		v.Set("db.hosts", []string{"c"})
+		v.Set("db.pass", "secret")
+		v.Set("debug", true)
+		v.Set("cache", map[string]interface{}{"ttl": "1m"})
+	})
+	assert.Equal(t, `# the service
+name = "steve" # who
+port = 9090
+debug = true
+
+# the database
+[db]
+  host = "localhost"
+  user = "admin"
+  hosts = ["c"]
+  pass = "secret"
+
+[[servers]]
+name = "alpha"
+
+[cache]
+ttl = "1m"
+`, written)
+}
+
+func TestRoundTripTOMLUnsupported(t *testing.T) {
+	// arrays of tables are rewritten
+	written := roundTrip(t, "/etc/app/config.toml", tomlRoundTrip, func(v *Viper) {
+		v.Set("servers", []map[string]interface{}{{"name": "beta"}})
+	})
+	assert.NotContains(t, written, "# the service")
+}
+
+func TestRoundTripOtherFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte(yamlRoundTrip), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	require.NoError(t, v.WriteConfigAs("/etc/app/copy.yaml"))
+
+	written, err := afero.ReadFile(fs, "/etc/app/copy.yaml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(written), "# the service")
+}
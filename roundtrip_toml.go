@@ -0,0 +1,462 @@
+package viper
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tomlEntry is a key or table of a TOML document.
+type tomlEntry struct {
+	// line of the key or table header and end of its value, exclusive
+	line, end int
+	table     bool
+	// head is the line up to the value of a key, its comment follows it
+	head, comment string
+	// indentation of the keys of a table
+	childIndent string
+}
+
+type tomlDocument struct {
+	lineEditor
+	entries    map[string]*tomlEntry
+	arrays     []string
+	rootEnd    int
+	contentEnd int
+}
+
+// newTOMLDocument returns the document of the TOML data, or nil if it can't
+// be parsed line by line.
+func newTOMLDocument(data []byte, normalize func(string) string) *tomlDocument {
+	doc := &tomlDocument{
+		lineEditor: newLineEditor(data),
+		entries:    make(map[string]*tomlEntry),
+	}
+
+	var (
+		table   []string
+		current *tomlEntry
+		inArray bool
+		// end of the multi-line value of key, either a string delimiter or
+		// an array or inline table to balance
+		multi   string
+		depth   int
+		key     *tomlEntry
+		normKey = func(parts []string) []string {
+			for i, part := range parts {
+				parts[i] = normalize(part)
+			}
+			return parts
+		}
+	)
+	markContent := func(i int) {
+		if key != nil {
+			key.end = i + 1
+		}
+		if current != nil {
+			current.end = i + 1
+		}
+		if len(table) == 0 && !inArray {
+			doc.rootEnd = i + 1
+		}
+		doc.contentEnd = i + 1
+	}
+
+	for i, line := range doc.lines {
+		if multi != "" {
+			if strings.Contains(line, multi) {
+				multi = ""
+			}
+			markContent(i)
+			continue
+		}
+		if depth > 0 {
+			content, _ := splitComment(line)
+			depth += tomlDepth(content)
+			markContent(i)
+			continue
+		}
+		key = nil
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		content, comment := splitComment(line)
+		content = strings.TrimRight(content, " \t")
+		trimmed = strings.TrimSpace(content)
+
+		if strings.HasPrefix(trimmed, "[") {
+			array := strings.HasPrefix(trimmed, "[[")
+			name := strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]")
+			if array {
+				name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+			}
+			parts, ok := splitTOMLKey(name)
+			if !ok {
+				return nil
+			}
+			table, inArray, current = normKey(parts), array, nil
+			if array {
+				doc.arrays = append(doc.arrays, pathKey(table))
+			} else {
+				current = &tomlEntry{line: i, end: i + 1, table: true}
+				if _, dup := doc.entries[pathKey(table)]; dup {
+					return nil
+				}
+				doc.entries[pathKey(table)] = current
+			}
+			doc.contentEnd = i + 1
+			continue
+		}
+
+		eq := tomlKeyEnd(content)
+		if eq < 0 {
+			return nil
+		}
+		parts, ok := splitTOMLKey(content[:eq])
+		if !ok {
+			return nil
+		}
+		value := strings.TrimSpace(content[eq+1:])
+		head := content[:len(content)-len(strings.TrimLeft(content[eq+1:], " \t"))]
+		if head == content[:eq+1] {
+			head += " "
+		}
+		switch {
+		case strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''"):
+			delim := value[:3]
+			if !strings.Contains(value[3:], delim) {
+				multi = delim
+				comment = ""
+			}
+		default:
+			depth = tomlDepth(value)
+		}
+
+		if !inArray {
+			path := append(append([]string{}, table...), normKey(parts)...)
+			key = &tomlEntry{line: i, end: i + 1, head: head, comment: comment}
+			if _, dup := doc.entries[pathKey(path)]; dup {
+				return nil
+			}
+			doc.entries[pathKey(path)] = key
+			if current != nil && current.childIndent == "" {
+				current.childIndent = content[:indentation(content)]
+			}
+		}
+		markContent(i)
+	}
+	return doc
+}
+
+// tomlKeyEnd returns the index of the equal sign following the key of a
+// key/value line, or -1 if there is none.
+func tomlKeyEnd(content string) int {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTOMLKey splits a possibly dotted and quoted TOML key into its parts.
+func splitTOMLKey(key string) ([]string, bool) {
+	var parts []string
+	for {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, false
+		}
+		var part string
+		switch key[0] {
+		case '"':
+			end := 1
+			for end < len(key) && key[end] != '"' {
+				if key[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(key) {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(key[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			part, key = unquoted, key[end+1:]
+		case '\'':
+			end := strings.IndexByte(key[1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			part, key = key[1:end+1], key[end+2:]
+		default:
+			end := strings.IndexByte(key, '.')
+			if end < 0 {
+				end = len(key)
+			}
+			part, key = strings.TrimSpace(key[:end]), key[end:]
+			if part == "" || strings.ContainsAny(part, " \t\"'") {
+				return nil, false
+			}
+		}
+		parts = append(parts, part)
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return parts, true
+		}
+		if key[0] != '.' {
+			return nil, false
+		}
+		key = key[1:]
+	}
+}
+
+// tomlDepth returns the difference between the opening and closing brackets
+// and braces of content, outside of strings.
+func tomlDepth(content string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+func (doc *tomlDocument) replace(path []string, value interface{}) bool {
+	e, ok := doc.entries[pathKey(path)]
+	if !ok || e.table || doc.inArray(path) {
+		return false
+	}
+	s, ok := tomlValue(value)
+	return ok && doc.edit(e.line, e.end, []string{e.head + s + e.comment})
+}
+
+func (doc *tomlDocument) line(path []string) int {
+	if e, ok := doc.entries[pathKey(path)]; ok {
+		return e.line + 1
+	}
+	return 0
+}
+
+func (doc *tomlDocument) remove(path []string) bool {
+	if doc.inArray(path) {
+		return false
+	}
+	prefix := pathKey(path)
+	var ranges [][2]int
+	for key, e := range doc.entries {
+		if key == prefix || strings.HasPrefix(key, prefix+"\x00") {
+			ranges = append(ranges, [2]int{e.line, e.end})
+		}
+	}
+	if len(ranges) == 0 {
+		return false
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	end := -1
+	for _, r := range ranges {
+		// the keys of removed tables go with them
+		if r[0] < end {
+			continue
+		}
+		if !doc.edit(r[0], r[1], nil) {
+			return false
+		}
+		end = r[1]
+	}
+	return true
+}
+
+func (doc *tomlDocument) add(path []string, value interface{}) bool {
+	if doc.inArray(path) {
+		return false
+	}
+	if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+		lines, ok := renderTOMLTable(path, m)
+		return ok && doc.edit(doc.contentEnd, doc.contentEnd, lines)
+	}
+
+	s, ok := tomlValue(value)
+	if !ok {
+		return false
+	}
+	pos, indent := doc.rootEnd, ""
+	if len(path) > 1 {
+		parent, ok := doc.entries[pathKey(path[:len(path)-1])]
+		if !ok || !parent.table {
+			return false
+		}
+		pos, indent = parent.end, parent.childIndent
+	}
+	return doc.edit(pos, pos, []string{indent + tomlKey(path[len(path)-1]) + " = " + s})
+}
+
+// inArray reports whether path is within an array of tables.
+func (doc *tomlDocument) inArray(path []string) bool {
+	key := pathKey(path)
+	for _, array := range doc.arrays {
+		if key == array || strings.HasPrefix(key, array+"\x00") {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTOMLTable returns the lines of the table at path holding m.
+func renderTOMLTable(path []string, m map[string]interface{}) ([]string, bool) {
+	keys := make([]string, len(path))
+	for i, part := range path {
+		keys[i] = tomlKey(part)
+	}
+	lines := []string{"", "[" + strings.Join(keys, ".") + "]"}
+
+	names := sortedMapKeys(m)
+	var tables []string
+	for _, name := range names {
+		if sub, ok := m[name].(map[string]interface{}); ok && len(sub) > 0 {
+			tables = append(tables, name)
+			continue
+		}
+		s, ok := tomlValue(m[name])
+		if !ok {
+			return nil, false
+		}
+		lines = append(lines, tomlKey(name)+" = "+s)
+	}
+	for _, name := range tables {
+		sub, ok := renderTOMLTable(append(path[:len(path):len(path)], name), m[name].(map[string]interface{}))
+		if !ok {
+			return nil, false
+		}
+		lines = append(lines, sub...)
+	}
+	return lines, true
+}
+
+// tomlValue returns the TOML representation of value, which must be a
+// scalar, an array or an empty table.
+func tomlValue(value interface{}) (string, bool) {
+	switch val := value.(type) {
+	case string:
+		return tomlString(val), true
+	case bool:
+		return strconv.FormatBool(val), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", val), true
+	case float32:
+		return tomlFloat(float64(val)), true
+	case float64:
+		return tomlFloat(val), true
+	case time.Duration:
+		return tomlString(val.String()), true
+	case time.Time:
+		return val.Format(time.RFC3339Nano), true
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return "{}", true
+		}
+		return "", false
+	}
+
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", false
+	}
+	elems := make([]string, rv.Len())
+	for i := range elems {
+		s, ok := tomlValue(rv.Index(i).Interface())
+		if !ok {
+			return "", false
+		}
+		elems[i] = s
+	}
+	return "[" + strings.Join(elems, ", ") + "]", true
+}
+
+func tomlFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func tomlKey(key string) string {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return tomlString(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
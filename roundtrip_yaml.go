@@ -0,0 +1,261 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// yamlEntry is a key of a block style YAML document.
+type yamlEntry struct {
+	// line of the key and end of its value, exclusive
+	line, end int
+	indent    int
+	// indentation of the children of the key, if any
+	childIndent int
+	// head is the line up to and including the colon following the key, the
+	// value and comment follow it on the line
+	head, value, comment string
+}
+
+type yamlDocument struct {
+	lineEditor
+	entries    map[string]*yamlEntry
+	contentEnd int
+	indentUnit int
+}
+
+// newYAMLDocument returns the document of the YAML data, or nil if it isn't
+// a single block style mapping without anchors, aliases or tags.
+func newYAMLDocument(data []byte, normalize func(string) string) *yamlDocument {
+	doc := &yamlDocument{
+		lineEditor: newLineEditor(data),
+		entries:    make(map[string]*yamlEntry),
+		indentUnit: 2,
+	}
+
+	var stack []yamlOpenEntry
+	// lines more indented than skip belong to the value of the previous
+	// line, e.g. block scalars and sequence items
+	skip, skipSeq := -1, false
+	unit := 0
+	for i, line := range doc.lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		if trimmed == "---" || trimmed == "..." {
+			if doc.contentEnd > 0 {
+				return nil
+			}
+			continue
+		}
+		indent := indentation(line)
+		if strings.ContainsRune(line[:indent], '\t') {
+			return nil
+		}
+		isSeq := trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+
+		if skip >= 0 && (indent > skip || skipSeq && isSeq && indent == skip) {
+			doc.markContent(i, stack)
+			continue
+		}
+		skip = -1
+
+		// sequences may be indented as much as their key
+		for len(stack) > 0 && (stack[len(stack)-1].entry.indent > indent ||
+			stack[len(stack)-1].entry.indent == indent && !isSeq) {
+			stack = stack[:len(stack)-1]
+		}
+		if isSeq {
+			if len(stack) == 0 {
+				return nil
+			}
+			skip, skipSeq = indent, true
+			doc.markContent(i, stack)
+			continue
+		}
+
+		content, comment := splitComment(line)
+		colon := yamlKeyEnd(content)
+		if colon < 0 {
+			// continuation of a multi-line plain or flow scalar
+			if len(stack) == 0 {
+				return nil
+			}
+			doc.markContent(i, stack)
+			continue
+		}
+		key := strings.TrimSpace(content[:colon])
+		if len(key) > 1 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+			var unquoted string
+			if yaml.Unmarshal([]byte(key), &unquoted) != nil {
+				return nil
+			}
+			key = unquoted
+		}
+		value := strings.TrimSpace(content[colon+1:])
+		if key == "<<" || key == "?" || value != "" && strings.IndexByte("&*!", value[0]) >= 0 {
+			return nil
+		}
+
+		e := &yamlEntry{
+			line:    i,
+			end:     i + 1,
+			indent:  indent,
+			head:    content[:colon+1],
+			value:   value,
+			comment: comment,
+		}
+		path := []string{normalize(key)}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			path = append(strings.Split(parent.key, "\x00"), path...)
+			if parent.entry.childIndent == 0 {
+				parent.entry.childIndent = indent
+				if unit == 0 || indent-parent.entry.indent < unit {
+					unit = indent - parent.entry.indent
+				}
+			}
+		} else if indent > 0 {
+			return nil
+		}
+		pk := pathKey(path)
+		if _, dup := doc.entries[pk]; dup {
+			return nil
+		}
+		doc.entries[pk] = e
+		doc.markContent(i, stack)
+		stack = append(stack, yamlOpenEntry{key: pk, entry: e})
+
+		if value != "" && (value[0] == '|' || value[0] == '>' || value[0] == '[' || value[0] == '{' ||
+			value[0] == '"' || value[0] == '\'') {
+			// block scalars and values which may span several lines
+			skip, skipSeq = indent, false
+		}
+	}
+	if unit > 0 {
+		doc.indentUnit = unit
+	}
+	return doc
+}
+
+// yamlOpenEntry is an entry whose value may continue on the next lines.
+type yamlOpenEntry struct {
+	key   string
+	entry *yamlEntry
+}
+
+// markContent records line i as part of the values of the open entries.
+func (doc *yamlDocument) markContent(i int, stack []yamlOpenEntry) {
+	for _, o := range stack {
+		o.entry.end = i + 1
+	}
+	doc.contentEnd = i + 1
+}
+
+// yamlKeyEnd returns the index of the colon ending the key of a mapping
+// line, or -1 if there is none.
+func yamlKeyEnd(content string) int {
+	s := strings.TrimLeft(content, " ")
+	offset := len(content) - len(s)
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		q, j := s[0], 1
+		for ; j < len(s); j++ {
+			if q == '"' && s[j] == '\\' {
+				j++
+			} else if s[j] == q {
+				if q == '\'' && j+1 < len(s) && s[j+1] == '\'' {
+					j++
+					continue
+				}
+				break
+			}
+		}
+		if j >= len(s) {
+			return -1
+		}
+		rest := strings.TrimLeft(s[j+1:], " ")
+		if !strings.HasPrefix(rest, ":") || len(rest) > 1 && rest[1] != ' ' {
+			return -1
+		}
+		return len(content) - len(rest)
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return offset + i
+		}
+	}
+	return -1
+}
+
+func (doc *yamlDocument) replace(path []string, value interface{}) bool {
+	e, ok := doc.entries[pathKey(path)]
+	if !ok {
+		return false
+	}
+	lines, ok := doc.render(e.head, e.comment, e.indent, value)
+	return ok && doc.edit(e.line, e.end, lines)
+}
+
+func (doc *yamlDocument) line(path []string) int {
+	if e, ok := doc.entries[pathKey(path)]; ok {
+		return e.line + 1
+	}
+	return 0
+}
+
+func (doc *yamlDocument) remove(path []string) bool {
+	e, ok := doc.entries[pathKey(path)]
+	return ok && doc.edit(e.line, e.end, nil)
+}
+
+func (doc *yamlDocument) add(path []string, value interface{}) bool {
+	pos, indent := doc.contentEnd, 0
+	if len(path) > 1 {
+		parent, ok := doc.entries[pathKey(path[:len(path)-1])]
+		if !ok || parent.value != "" {
+			return false
+		}
+		pos, indent = parent.end, parent.childIndent
+		if indent == 0 {
+			indent = parent.indent + doc.indentUnit
+		}
+	}
+	key, err := yaml.Marshal(path[len(path)-1])
+	if err != nil {
+		return false
+	}
+	head := strings.Repeat(" ", indent) + strings.TrimSuffix(string(key), "\n") + ":"
+	lines, ok := doc.render(head, "", indent, value)
+	return ok && doc.edit(pos, pos, lines)
+}
+
+// render returns the lines of the key given by head, at indent, set to
+// value.
+func (doc *yamlDocument) render(head, comment string, indent int, value interface{}) ([]string, bool) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	rv := reflect.ValueOf(value)
+	nested := rv.IsValid() && (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.Len() > 0
+	if !nested {
+		// scalars, possibly block scalars whose content yaml indents
+		out := []string{head + " " + lines[0] + comment}
+		for _, line := range lines[1:] {
+			out = append(out, strings.Repeat(" ", indent)+line)
+		}
+		return out, true
+	}
+
+	out := []string{head + comment}
+	prefix := strings.Repeat(" ", indent+doc.indentUnit)
+	for _, line := range lines {
+		out = append(out, prefix+line)
+	}
+	return out, true
+}
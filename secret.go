@@ -0,0 +1,229 @@
+package viper
+
+import (
+	"bytes"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// SecretRedacted replaces the values of secret keys in redacted output and
+// in AuditRecords, see MarkSecret and SetAuditRedactor.
+const SecretRedacted = "***"
+
+// secrets holds the keys marked through MarkSecret, guarded by v.mu.
+type secrets struct {
+	keys     map[string]bool
+	patterns []string
+	// WriteConfig, RenderConfig and Debug redact secrets, see
+	// SetRedactSecrets
+	redactOutput bool
+}
+
+// SecretConfigFactory is implemented by the RemoteConfigFactory of remote
+// providers serving secrets, e.g. the vault and secretsmanager packages: the
+// keys read from a provider for which IsSecret returns true are marked as
+// secrets, see MarkSecret.
+type SecretConfigFactory interface {
+	RemoteConfigFactory
+	IsSecret(rp RemoteProvider) bool
+}
+
+// MarkSecret marks keys whose values are secrets, e.g. passwords, so that
+// AllSettingsRedacted and, if SetRedactSecrets is enabled, WriteConfig and
+// Debug output SecretRedacted instead of their value. Get still returns the
+// value. keys are keys or patterns matched against keys like by path.Match,
+// e.g. "api.*_token", and "servers.*.password" for the items of a slice;
+// keys nested below a secret key are secrets as well.
+// MarkSecret is case-insensitive for a key.
+func MarkSecret(keys ...string) { v.MarkSecret(keys...) }
+func (v *Viper) MarkSecret(keys ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.secrets.keys == nil {
+		v.secrets.keys = map[string]bool{}
+	}
+	for _, key := range keys {
+		key = v.realKey(v.normalizeKey(key))
+		if strings.ContainsAny(key, "*?[") {
+			v.secrets.patterns = append(v.secrets.patterns, key)
+		} else {
+			v.secrets.keys[key] = true
+		}
+	}
+}
+
+// IsSecret tells whether key is marked as a secret, see MarkSecret.
+func IsSecret(key string) bool { return v.IsSecret(key) }
+func (v *Viper) IsSecret(key string) bool {
+	if v == nil {
+		return false
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.isSecret(v.realKey(v.normalizeKey(key)))
+}
+
+// isSecret is IsSecret for a normalized key. The caller must hold the read
+// lock.
+func (v *Viper) isSecret(key string) bool {
+	if len(v.secrets.keys) == 0 && len(v.secrets.patterns) == 0 {
+		return false
+	}
+	for k := key; ; {
+		if v.secrets.keys[k] {
+			return true
+		}
+		for _, pattern := range v.secrets.patterns {
+			if ok, _ := path.Match(pattern, k); ok {
+				return true
+			}
+		}
+		i := strings.LastIndex(k, v.keyDelim)
+		if i < 0 {
+			return false
+		}
+		k = k[:i]
+	}
+}
+
+// SetRedactSecrets sets whether WriteConfig, RenderConfig and Debug output
+// SecretRedacted instead of the values of secret keys, see MarkSecret.
+// Note that the config file written then no longer holds the secrets.
+func SetRedactSecrets(redact bool) { v.SetRedactSecrets(redact) }
+func (v *Viper) SetRedactSecrets(redact bool) {
+	v.mu.Lock()
+	v.secrets.redactOutput = redact
+	v.mu.Unlock()
+}
+
+// AllSettingsRedacted behaves like AllSettings, but with the values of secret
+// keys replaced by SecretRedacted, e.g. to log the configuration.
+func AllSettingsRedacted() map[string]interface{} { return v.AllSettingsRedacted() }
+func (v *Viper) AllSettingsRedacted() map[string]interface{} {
+	if v == nil {
+		return map[string]interface{}{}
+	}
+	return v.redactMap(v.AllSettings(), "")
+}
+
+// redactMap returns a copy of m, found at prefix, with the values of secret
+// keys replaced by SecretRedacted.
+func (v *Viper) redactMap(m map[string]interface{}, prefix string) map[string]interface{} {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.redactMapLocked(m, prefix)
+}
+
+func (v *Viper) redactMapLocked(m map[string]interface{}, prefix string) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, val := range m {
+		redacted[k] = v.redactValue(prefix+k, val, v.isSecret, nil)
+	}
+	return redacted
+}
+
+// redactValue returns a copy of value, the value of key, with the values of
+// the keys for which hide returns true replaced by SecretRedacted. Maps and
+// slices not hidden as a whole are redacted per key, the items of slices
+// being keyed by their index; the other values are replaced by the value
+// returned by leaf, if not nil.
+func (v *Viper) redactValue(key string, value interface{}, hide func(key string) bool, leaf func(key string, value interface{}) interface{}) interface{} {
+	if value != nil && hide != nil && hide(key) {
+		return SecretRedacted
+	}
+	switch val := value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		m := cast.ToStringMap(val)
+		redacted := make(map[string]interface{}, len(m))
+		for k, item := range m {
+			redacted[k] = v.redactValue(key+v.keyDelim+k, item, hide, leaf)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(val))
+		for i, item := range val {
+			redacted[i] = v.redactValue(key+v.keyDelim+strconv.Itoa(i), item, hide, leaf)
+		}
+		return redacted
+	}
+	if leaf != nil {
+		return leaf(key, value)
+	}
+	return value
+}
+
+// redactFlags returns the flags bound through BindPFlag, with the ones of
+// secret keys replaced by SecretRedacted. The caller must hold the read
+// lock.
+func (v *Viper) redactFlags() map[string]interface{} {
+	flags := make(map[string]interface{}, len(v.pflags))
+	for key, flag := range v.pflags {
+		if v.isSecret(key) {
+			flags[key] = SecretRedacted
+		} else {
+			flags[key] = flag
+		}
+	}
+	return flags
+}
+
+func isStringMap(val interface{}) bool {
+	switch val.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return true
+	}
+	return false
+}
+
+// redactingOutput tells whether WriteConfig, RenderConfig and Debug redact
+// secrets.
+func (v *Viper) redactingOutput() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.secrets.redactOutput
+}
+
+// outputSettings returns the settings written by WriteConfig.
+func (v *Viper) outputSettings() map[string]interface{} {
+	if v.redactingOutput() {
+		return v.AllSettingsRedacted()
+	}
+	return v.AllSettings()
+}
+
+// outputValue returns the value of key written by WriteConfig, for the
+// formats writing keys one by one.
+func (v *Viper) outputValue(key string) interface{} {
+	if v.redactingOutput() && v.IsSecret(key) {
+		return SecretRedacted
+	}
+	return v.Get(key)
+}
+
+// markRemoteSecrets marks the keys of payload, read from provider, as
+// secrets if the provider serves secrets. It must be called without the lock
+// held.
+func (v *Viper) markRemoteSecrets(provider RemoteProvider, payload []byte) {
+	if provider == nil {
+		return
+	}
+	factory, err := remoteConfigFactory(provider)
+	if err != nil {
+		return
+	}
+	if sf, ok := factory.(SecretConfigFactory); !ok || !sf.IsSecret(provider) {
+		return
+	}
+	cfg := make(map[string]interface{})
+	if err := v.unmarshalReader(bytes.NewReader(payload), cfg); err != nil {
+		return
+	}
+	keys := make([]string, 0, len(cfg))
+	for key := range v.flattenAndMergeMap(nil, cfg, "") {
+		keys = append(keys, key)
+	}
+	v.MarkSecret(keys...)
+}
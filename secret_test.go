@@ -0,0 +1,105 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkSecret(t *testing.T) {
+	v := New()
+	v.Set("db.host", "localhost")
+	v.Set("db.password", "hunter2")
+	v.Set("api.github_token", "ghp")
+	v.Set("api.url", "https://api")
+	v.Set("tls.key", map[string]interface{}{"pem": "---"})
+	v.MarkSecret("DB.Password", "api.*_token", "tls.key")
+
+	assert.True(t, v.IsSecret("db.password"))
+	assert.True(t, v.IsSecret("api.gitlab_token"))
+	assert.True(t, v.IsSecret("tls.key.pem"))
+	assert.False(t, v.IsSecret("db.host"))
+	assert.False(t, v.IsSecret("api.url"))
+
+	assert.Equal(t, "hunter2", v.GetString("db.password"))
+	assert.Equal(t, map[string]interface{}{
+		"db":  map[string]interface{}{"host": "localhost", "password": SecretRedacted},
+		"api": map[string]interface{}{"github_token": SecretRedacted, "url": "https://api"},
+		"tls": map[string]interface{}{"key": SecretRedacted},
+	}, v.AllSettingsRedacted())
+}
+
+func TestMarkSecretSliceItems(t *testing.T) {
+	v := New()
+	v.Set("servers", []interface{}{
+		map[string]interface{}{"host": "a", "password": "pa"},
+		map[string]interface{}{"host": "b", "password": "pb"},
+	})
+	v.Set("tokens", []interface{}{"t1", "t2"})
+	v.MarkSecret("servers.*.password", "tokens")
+
+	assert.True(t, v.IsSecret("servers.1.password"))
+	assert.Equal(t, map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a", "password": SecretRedacted},
+			map[string]interface{}{"host": "b", "password": SecretRedacted},
+		},
+		"tokens": SecretRedacted,
+	}, v.AllSettingsRedacted())
+	assert.Equal(t, "pa", v.GetString("servers.0.password"))
+}
+
+func TestRedactSecrets(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	v := New()
+	v.SetFs(fs)
+	v.Set("db.host", "localhost")
+	v.Set("db.password", "hunter2")
+	v.MarkSecret("db.password")
+
+	b, err := v.RenderConfig("yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "hunter2")
+
+	v.SetRedactSecrets(true)
+	b, err = v.RenderConfig("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "db:\n  host: localhost\n  password: '***'\n", string(b))
+	b, err = v.RenderConfig("env")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "DB_PASSWORD=***")
+	assert.Contains(t, string(b), "DB_HOST=localhost")
+
+	require.NoError(t, v.WriteConfigAs("/etc/app/config.json"))
+	written, err := afero.ReadFile(fs, "/etc/app/config.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"db": {"host": "localhost", "password": "***"}}`, string(written))
+	assert.Equal(t, "hunter2", v.GetString("db.password"))
+}
+
+type testSecretConfigFactory struct {
+	*testRemoteConfigFactory
+}
+
+func (testSecretConfigFactory) IsSecret(rp RemoteProvider) bool {
+	return rp.Path() == "/secrets"
+}
+
+func TestRemoteSecrets(t *testing.T) {
+	registerTestProvider(t, "test-secret", testSecretConfigFactory{&testRemoteConfigFactory{payload: remoteExample}})
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("test-secret", "localhost", "/config"))
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.False(t, v.IsSecret("newkey"))
+
+	v = New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("test-secret", "localhost", "/secrets"))
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.True(t, v.IsSecret("newkey"))
+	assert.Equal(t, SecretRedacted, v.AllSettingsRedacted()["newkey"])
+}
@@ -0,0 +1,127 @@
+package secretsmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RefreshInterval is the interval at which WatchRemoteConfigOnChannel
+// re-reads secrets.
+var RefreshInterval = 5 * time.Minute
+
+// remoteConfigProvider serves secrets as the "secretsmanager" remote
+// provider.
+type remoteConfigProvider struct{}
+
+func (rc remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	b, err := rc.read(rp)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (rc remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return rc.Get(rp)
+}
+
+// IsSecret marks the keys read from Secrets Manager as secrets, see
+// viper.MarkSecret.
+func (rc remoteConfigProvider) IsSecret(rp viper.RemoteProvider) bool {
+	return true
+}
+
+func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	quit := make(chan bool)
+	viperResponsCh := make(chan *viper.RemoteResponse)
+	last, _ := rc.read(rp)
+	go func() {
+		for {
+			select {
+			case <-quit:
+				return
+			case <-time.After(RefreshInterval):
+			}
+			b, err := rc.read(rp)
+			if err == nil {
+				if bytes.Equal(b, last) {
+					continue
+				}
+				last = b
+			}
+			select {
+			case <-quit:
+				return
+			case viperResponsCh <- &viper.RemoteResponse{Value: b, Error: err}:
+			}
+		}
+	}()
+	return viperResponsCh, quit
+}
+
+// read returns the JSON encoded secret of rp.
+func (rc remoteConfigProvider) read(rp viper.RemoteProvider) ([]byte, error) {
+	secretID, prefix := splitPath(rp.Path())
+
+	var client *Client
+	if strings.Contains(rp.Endpoint(), "://") {
+		client = NewClient("")
+		client.Endpoint = rp.Endpoint()
+		if client.Region == "" {
+			client.Region = "us-east-1"
+		}
+	} else {
+		client = NewClient(rp.Endpoint())
+	}
+
+	secret, err := client.GetSecretValue(secretID)
+	if err != nil {
+		return nil, err
+	}
+	raw := []byte(secret.SecretString)
+	if secret.SecretString == "" {
+		raw = secret.SecretBinary
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		if prefix == "" {
+			return nil, fmt.Errorf("secret %q isn't a JSON object and must be mounted under a key", secretID)
+		}
+		parts := strings.Split(prefix, ".")
+		prefix = strings.Join(parts[:len(parts)-1], ".")
+		data = map[string]interface{}{parts[len(parts)-1]: string(raw)}
+	}
+	return json.Marshal(mount(data, prefix))
+}
+
+// splitPath splits a provider path into the secret ID and the key to mount
+// the secret under.
+func splitPath(path string) (string, string) {
+	if i := strings.LastIndex(path, "#"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// mount nests data under the dot separated key prefix.
+func mount(data map[string]interface{}, prefix string) map[string]interface{} {
+	if prefix == "" {
+		return data
+	}
+	parts := strings.Split(prefix, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		data = map[string]interface{}{parts[i]: data}
+	}
+	return data
+}
+
+func init() {
+	viper.RegisterRemoteConfigProvider("secretsmanager", remoteConfigProvider{})
+}
@@ -0,0 +1,152 @@
+// Package secretsmanager integrates AWS Secrets Manager with Viper.
+//
+// Importing the package registers the "secretsmanager" remote provider,
+// serving secrets through the key/value store layer:
+//
+//	import _ "github.com/spf13/viper/secretsmanager"
+//
+//	viper.AddRemoteProvider("secretsmanager", "eu-west-1", "prod/myapp/db#database")
+//	viper.SetConfigType("json")
+//	err := viper.ReadRemoteConfig()
+//	password := viper.GetString("database.password")
+//
+// The endpoint is either an AWS region or the URL of a Secrets Manager
+// endpoint, and the path the name or ARN of the secret, optionally followed
+// by "#" and the key to mount the secret under. Secrets holding a JSON object
+// are served as is; other secrets must be mounted and are served as a single
+// string value. Credentials are read from the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+// WatchRemoteConfigOnChannel re-reads secrets every RefreshInterval, e.g. to
+// pick up rotated credentials.
+package secretsmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper/internal/sigv4"
+)
+
+// Credentials are the AWS credentials used to sign requests.
+type Credentials = sigv4.Credentials
+
+// Client is a minimal client for the AWS Secrets Manager API.
+type Client struct {
+	// Region of the secrets, e.g. "eu-west-1".
+	Region string
+	// Credentials used to sign requests.
+	Credentials Credentials
+	// Endpoint of the API; defaults to the regional AWS endpoint.
+	Endpoint string
+	// HTTPClient performs the requests; a client timing out after 30
+	// seconds is used when nil.
+	HTTPClient *http.Client
+
+	now func() time.Time
+}
+
+// defaultHTTPClient performs the requests of clients without an HTTPClient.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// NewClient returns a client for the given region, using the credentials
+// found in the environment. An empty region falls back to the AWS_REGION and
+// AWS_DEFAULT_REGION environment variables.
+func NewClient(region string) *Client {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &Client{
+		Region: region,
+		Credentials: Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		now: time.Now,
+	}
+}
+
+// Error denotes an error response from the Secrets Manager API.
+type Error struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+// Error returns the formatted Secrets Manager error.
+func (e Error) Error() string {
+	return fmt.Sprintf("Secrets Manager Error %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// SecretValue is the current version of a secret.
+type SecretValue struct {
+	ARN       string
+	Name      string
+	VersionID string `json:"VersionId"`
+	// Either SecretString or SecretBinary is set.
+	SecretString string
+	SecretBinary []byte
+}
+
+// GetSecretValue returns the current version of the secret identified by
+// secretID, a secret name or ARN.
+func (c *Client) GetSecretValue(secretID string) (*SecretValue, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint()+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	sigv4.Sign(req, body, c.Credentials, c.Region, "secretsmanager", now())
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		serr := Error{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Type    string `json:"__type"`
+			Message string
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
+			serr.Type, serr.Message = errBody.Type, errBody.Message
+		}
+		return nil, serr
+	}
+
+	secret := &SecretValue{}
+	if err := json.NewDecoder(resp.Body).Decode(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func (c *Client) endpoint() string {
+	if c.Endpoint != "" {
+		return strings.TrimSuffix(c.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", c.Region)
+}
@@ -0,0 +1,83 @@
+package secretsmanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSecretsManager(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/secretsmanager/aws4_request")
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req["SecretId"] {
+		case "prod/myapp/db":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"Name":         "prod/myapp/db",
+				"VersionId":    "v1",
+				"SecretString": `{"user": "admin", "password": "hunter2"}`,
+			})
+		case "prod/myapp/api-key":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"Name":         "prod/myapp/api-key",
+				"SecretBinary": []byte("s3cr3t"),
+			})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "ResourceNotFoundException", "Message": "Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+}
+
+func TestGetSecretValue(t *testing.T) {
+	srv := newTestSecretsManager(t)
+	defer srv.Close()
+
+	c := NewClient("eu-west-1")
+	c.Credentials = Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	c.Endpoint = srv.URL
+
+	secret, err := c.GetSecretValue("prod/myapp/db")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", secret.VersionID)
+	assert.JSONEq(t, `{"user": "admin", "password": "hunter2"}`, secret.SecretString)
+
+	_, err = c.GetSecretValue("missing")
+	assert.Equal(t, Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       "ResourceNotFoundException",
+		Message:    "Secrets Manager can't find the specified secret.",
+	}, err)
+}
+
+func TestRemoteProvider(t *testing.T) {
+	srv := newTestSecretsManager(t)
+	defer srv.Close()
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	v := viper.New()
+	require.NoError(t, v.AddRemoteProvider("secretsmanager", srv.URL, "prod/myapp/db#database"))
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "admin", v.GetString("database.user"))
+	assert.Equal(t, "hunter2", v.GetString("database.password"))
+
+	w := viper.New()
+	require.NoError(t, w.AddRemoteProvider("secretsmanager", srv.URL, "prod/myapp/api-key#api.key"))
+	w.SetConfigType("json")
+	require.NoError(t, w.ReadRemoteConfig())
+	assert.Equal(t, "s3cr3t", w.GetString("api.key"))
+}
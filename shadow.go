@@ -0,0 +1,157 @@
+package viper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// SourceKind identifies the configuration layer a value was found in.
+// Layers are listed by increasing priority.
+type SourceKind int
+
+const (
+	SourceNone SourceKind = iota
+	SourceDefault
+	SourceKVStore
+	SourceConfig
+	SourceEnv
+	SourceFlag
+	SourceOverride
+)
+
+// String returns the name of the configuration layer.
+func (s SourceKind) String() string {
+	switch s {
+	case SourceDefault:
+		return "default"
+	case SourceKVStore:
+		return "key/value store"
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	case SourceOverride:
+		return "override"
+	default:
+		return "none"
+	}
+}
+
+// ShadowedKey describes a key that cannot be read because a layer with a
+// higher priority holds a plain value at one of its parent paths.
+// e.g. Set("clothing.jacket", "leather") hides "clothing.jacket.price" from
+// the config file.
+type ShadowedKey struct {
+	// Key is the unreachable key and Source the layer defining it.
+	Key    string
+	Source SourceKind
+
+	// ShadowedBy is the parent key holding a value in the ShadowingSource
+	// layer.
+	ShadowedBy      string
+	ShadowingSource SourceKind
+}
+
+// String returns a human readable description of the shadowing.
+func (sk ShadowedKey) String() string {
+	return fmt.Sprintf("key %q from %s is shadowed by %q from %s",
+		sk.Key, sk.Source, sk.ShadowedBy, sk.ShadowingSource)
+}
+
+// ShadowedKeys returns all keys holding a value in some layer that are
+// hidden by a value set on one of their parent keys in a higher priority
+// layer, sorted by key.
+func ShadowedKeys() []ShadowedKey { return v.ShadowedKeys() }
+func (v *Viper) ShadowedKeys() []ShadowedKey {
+	// leaf keys seen so far in higher priority layers
+	leaves := map[string]SourceKind{}
+	shadowed := []ShadowedKey{}
+
+	for _, layer := range v.layerKeys() {
+		for _, key := range layer.keys {
+			path := strings.Split(key, v.keyDelim)
+			for i := 1; i < len(path); i++ {
+				parentKey := strings.Join(path[0:i], v.keyDelim)
+				if src, ok := leaves[parentKey]; ok {
+					shadowed = append(shadowed, ShadowedKey{
+						Key:             key,
+						Source:          layer.kind,
+						ShadowedBy:      parentKey,
+						ShadowingSource: src,
+					})
+					break
+				}
+			}
+		}
+		for _, key := range layer.keys {
+			if _, ok := leaves[key]; !ok {
+				leaves[key] = layer.kind
+			}
+		}
+	}
+
+	sort.Slice(shadowed, func(i, j int) bool { return shadowed[i].Key < shadowed[j].Key })
+	return shadowed
+}
+
+// warnShadowedKeys logs a warning for every shadowed key.
+func (v *Viper) warnShadowedKeys() {
+	for _, sk := range v.ShadowedKeys() {
+		jww.WARN.Println(sk.String())
+	}
+}
+
+type layerKeySet struct {
+	kind SourceKind
+	keys []string
+}
+
+// layerKeys returns the flattened keys of every layer, by order of
+// descending priority. Keys of automatic env variables can't be enumerated
+// unless an env key mapper is set.
+func (v *Viper) layerKeys() []layerKeySet {
+	flatten := func(m map[string]interface{}) []string {
+		return sortedKeys(v.flattenAndMergeMap(nil, m, ""))
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	env := map[string]bool{}
+	for key := range v.env {
+		env[key] = true
+	}
+	if v.automaticEnvApplied && v.envKeyMapper != nil {
+		for key := range v.mappedEnvKeys() {
+			env[key] = true
+		}
+	}
+	flags := map[string]bool{}
+	for key := range v.pflags {
+		flags[key] = true
+	}
+
+	return []layerKeySet{
+		{SourceOverride, flatten(v.override)},
+		{SourceFlag, sortedKeys(flags)},
+		{SourceEnv, sortedKeys(env)},
+		{SourceConfig, flatten(v.config)},
+		{SourceKVStore, flatten(v.kvstore)},
+		{SourceDefault, flatten(v.profileDefaults())},
+		{SourceDefault, flatten(v.defaults)},
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,26 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowedKeys(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+	assert.Empty(t, v.ShadowedKeys())
+
+	v.Set("clothing.pants", "jeans")
+	v.SetDefault("age.unit", "years")
+
+	assert.Equal(t, []ShadowedKey{
+		{Key: "age.unit", Source: SourceDefault, ShadowedBy: "age", ShadowingSource: SourceConfig},
+		{Key: "clothing.pants.size", Source: SourceConfig, ShadowedBy: "clothing.pants", ShadowingSource: SourceOverride},
+	}, v.ShadowedKeys())
+	assert.Equal(t, `key "clothing.pants.size" from config is shadowed by "clothing.pants" from override`,
+		v.ShadowedKeys()[1].String())
+}
@@ -0,0 +1,184 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Keys address the elements of slices by their index: "servers.0.host" is
+// the host of the first item of servers, and negative indices count from the
+// end, "servers.-1" being the last item. An index out of range of a slice
+// addresses no value: the slice shadows the same key in lower-priority
+// layers, as it would be replaced as a whole by a merge.
+
+// SliceIndexError is returned when setting a value at an index out of range
+// of a slice. Setting the index following the last item appends to the
+// slice.
+type SliceIndexError struct {
+	Key   string
+	Index int
+	Len   int
+}
+
+func (e SliceIndexError) Error() string {
+	return fmt.Sprintf("index %d of %q out of range, slice has %d items", e.Index, e.Key, e.Len)
+}
+
+// sliceIndex parses the path element elem as an index into a slice of n
+// items, resolving negative indices from the end. ok is false if elem isn't
+// an integer; the index may be out of range.
+func sliceIndex(elem string, n int) (index int, ok bool) {
+	if elem == "" || (elem[0] != '-' && (elem[0] < '0' || elem[0] > '9')) {
+		return 0, false
+	}
+	i, err := strconv.Atoi(elem)
+	if err != nil {
+		return 0, false
+	}
+	if i < 0 {
+		i += n
+	}
+	return i, true
+}
+
+// indexable tells whether val is a slice whose items keys can address.
+func indexable(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	if _, ok := val.([]byte); ok {
+		return false
+	}
+	k := reflect.TypeOf(val).Kind()
+	return k == reflect.Slice || k == reflect.Array
+}
+
+// sliceItem returns the item of the slice val addressed by the path element
+// elem, if any.
+func sliceItem(val interface{}, elem string) (interface{}, bool) {
+	if !indexable(val) {
+		return nil, false
+	}
+	s := reflect.ValueOf(val)
+	i, ok := sliceIndex(elem, s.Len())
+	if !ok || i < 0 || i >= s.Len() {
+		return nil, false
+	}
+	return s.Index(i).Interface(), true
+}
+
+// searchSlice searches for a value for path in the slice val, path starting
+// with an index. Returns nil if not found.
+func (v *Viper) searchSlice(val interface{}, path []string) interface{} {
+	item, ok := sliceItem(val, path[0])
+	if !ok {
+		return nil
+	}
+	if len(path) == 1 {
+		return item
+	}
+	switch item.(type) {
+	case map[interface{}]interface{}, map[string]interface{}:
+		// the keys of maps in slices aren't normalized when loaded
+		return v.searchMap(copyMapValue(item, !v.caseSensitive).(map[string]interface{}), path[1:])
+	default:
+		return v.searchSlice(item, path[1:])
+	}
+}
+
+// sliceShadows tells whether the slice val holds no value for path, path
+// starting after the key of val.
+func sliceShadows(val interface{}, path []string) bool {
+	_, ok := sliceItem(val, path[0])
+	return !ok
+}
+
+// setCopy returns container, a value of a layer found at key, with value
+// stored at path below it. Maps and slices along path are copied rather than
+// modified, as values handed out by Get may be in use; other values are
+// replaced by maps.
+func (v *Viper) setCopy(container interface{}, key string, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	childKey := key + v.keyDelim + path[0]
+
+	if indexable(container) {
+		s := reflect.ValueOf(container)
+		if i, ok := sliceIndex(path[0], s.Len()); ok {
+			if i < 0 || i > s.Len() {
+				index, _ := strconv.Atoi(path[0])
+				return nil, SliceIndexError{Key: key, Index: index, Len: s.Len()}
+			}
+			items := make([]interface{}, s.Len(), s.Len()+1)
+			for j := range items {
+				items[j] = copyMapValue(s.Index(j).Interface(), !v.caseSensitive)
+			}
+			if i == len(items) {
+				items = append(items, nil)
+			}
+			item, err := v.setCopy(items[i], childKey, path[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+			return items, nil
+		}
+	}
+
+	var m map[string]interface{}
+	switch container.(type) {
+	case map[interface{}]interface{}, map[string]interface{}:
+		m = cast.ToStringMap(container)
+	}
+	nm := make(map[string]interface{}, len(m)+1)
+	for k, val := range m {
+		nm[k] = val
+	}
+	item, err := v.setCopy(nm[path[0]], childKey, path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	nm[path[0]] = item
+	return nm, nil
+}
+
+// setInLayer stores value at path in the layer m, copying the maps and
+// slices along path. If seed is not nil, it is first stored at seedPath, a
+// prefix of path, unless the layer already holds a slice there. Nothing is
+// stored if an error is returned. The caller must hold the write lock.
+func (v *Viper) setInLayer(m map[string]interface{}, path []string, value interface{}, seedPath []string, seed interface{}) error {
+	root := m[path[0]]
+	var err error
+	if seed != nil && !indexable(v.searchMap(m, seedPath)) {
+		if root, err = v.setCopy(root, path[0], seedPath[1:], seed); err != nil {
+			return err
+		}
+	}
+	if root, err = v.setCopy(root, path[0], path[1:], value); err != nil {
+		return err
+	}
+	m[path[0]] = root
+	return nil
+}
+
+// sliceSeed returns the current value of the first prefix of path that
+// holds a slice addressed by the following path element, if any, so that
+// setting "servers.0.host" overrides the host of the first item of the
+// servers read from a config file rather than creating a "0" map key.
+// It must be called without the lock held.
+func (v *Viper) sliceSeed(path []string) ([]string, interface{}) {
+	for i := 1; i < len(path); i++ {
+		if _, ok := sliceIndex(path[i], 0); !ok {
+			continue
+		}
+		if val := v.Get(strings.Join(path[:i], v.keyDelim)); indexable(val) {
+			return path[:i], val
+		}
+	}
+	return nil, nil
+}
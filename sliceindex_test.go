@@ -0,0 +1,86 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sliceIndexExample = []byte(`
+hobbies:
+- skateboarding
+- snowboarding
+- go
+servers:
+- Host: alpha
+  port: 8080
+- host: beta
+  port: 8081
+matrix:
+- [1, 2]
+- [3, 4]
+`)
+
+func TestGetSliceIndex(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(sliceIndexExample)))
+
+	assert.Equal(t, "go", v.GetString("hobbies.2"))
+	assert.Equal(t, "go", v.GetString("hobbies.-1"))
+	assert.Equal(t, "skateboarding", v.GetString("hobbies.-3"))
+	assert.Equal(t, "alpha", v.GetString("servers.0.host"))
+	assert.Equal(t, 8081, v.GetInt("servers.-1.port"))
+	assert.Equal(t, 4, v.GetInt("matrix.1.1"))
+
+	assert.True(t, v.IsSet("servers.1.host"))
+	assert.False(t, v.IsSet("hobbies.3"))
+	assert.False(t, v.IsSet("hobbies.-4"))
+	assert.False(t, v.IsSet("servers.0.missing"))
+	assert.False(t, v.IsSet("hobbies.first"))
+
+	// slices shadow lower-priority layers, even out of range
+	v.SetDefault("hobbies.5", "chess")
+	assert.False(t, v.IsSet("hobbies.5"))
+
+	sub := v.Sub("servers.1")
+	require.NotNil(t, sub)
+	assert.Equal(t, "beta", sub.GetString("host"))
+
+	var server struct {
+		Host string
+		Port int
+	}
+	require.NoError(t, v.UnmarshalKey("servers.0", &server))
+	assert.Equal(t, "alpha", server.Host)
+	assert.Equal(t, 8080, server.Port)
+}
+
+func TestSetSliceIndex(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(sliceIndexExample)))
+	hobbies := v.Get("hobbies")
+
+	// items are set within a copy of the current slice
+	require.NoError(t, v.SetE("hobbies.1", "surfing"))
+	require.NoError(t, v.SetE("hobbies.3", "chess"))
+	require.NoError(t, v.SetE("servers.-1.port", 9090))
+	assert.Equal(t, []interface{}{"skateboarding", "surfing", "go", "chess"}, v.Get("hobbies"))
+	assert.Equal(t, []interface{}{"skateboarding", "snowboarding", "go"}, hobbies)
+	assert.Equal(t, 9090, v.GetInt("servers.1.port"))
+	assert.Equal(t, "beta", v.GetString("servers.1.host"))
+	assert.Equal(t, "alpha", v.GetString("servers.0.host"))
+
+	err := v.SetE("hobbies.9", "no")
+	assert.Equal(t, SliceIndexError{Key: "hobbies", Index: 9, Len: 4}, err)
+	assert.EqualError(t, err, `index 9 of "hobbies" out of range, slice has 4 items`)
+	assert.Error(t, v.SetE("servers.-5.port", 1))
+	assert.Len(t, v.GetStringSlice("hobbies"), 4)
+
+	// numeric keys without a slice are map keys
+	v.Set("ports.80", "http")
+	assert.Equal(t, map[string]interface{}{"80": "http"}, v.Get("ports"))
+}
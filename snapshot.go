@@ -0,0 +1,152 @@
+package viper
+
+import "time"
+
+// Snapshot is a read-only view of the configuration of a Viper instance at
+// a given generation. It is not affected by later changes to the instance,
+// such as Set calls or reloads triggered by WatchConfig, so that a long
+// running operation can read a consistent configuration from start to end.
+//
+// Values are resolved through the full precedence chain when the snapshot
+// is taken; nested values such as slices are shared with the instance and
+// must not be modified.
+type Snapshot struct {
+	generation uint64
+	v          *Viper
+}
+
+// Pin returns a Snapshot of the current configuration.
+func Pin() *Snapshot { return v.Pin() }
+func (v *Viper) Pin() *Snapshot {
+	frozen := New()
+	if v == nil {
+		return &Snapshot{v: frozen}
+	}
+	frozen.keyDelim = v.keyDelim
+	frozen.caseSensitive = v.caseSensitive
+	// mutations increase the generation before releasing the write lock,
+	// see unlockChanged, so settings read while the generation stays the
+	// same are the ones of that generation
+	for {
+		generation := v.Generation()
+		settings := v.AllSettings()
+		if v.Generation() == generation {
+			frozen.config = settings
+			return &Snapshot{generation: generation, v: frozen}
+		}
+	}
+}
+
+// Generation returns the generation of the configuration the snapshot was
+// taken at.
+func (s *Snapshot) Generation() uint64 { return s.generation }
+
+// Get returns the value associated with the key.
+func (s *Snapshot) Get(key string) interface{} { return s.v.Get(key) }
+
+// IsSet checks to see if the key holds a value in the snapshot.
+func (s *Snapshot) IsSet(key string) bool { return s.v.IsSet(key) }
+
+// AllKeys returns all keys holding a value in the snapshot.
+func (s *Snapshot) AllKeys() []string { return s.v.AllKeys() }
+
+// AllSettings returns all settings of the snapshot as a map[string]interface{}.
+func (s *Snapshot) AllSettings() map[string]interface{} { return s.v.AllSettings() }
+
+// Sub returns a new Viper instance representing a sub tree of the snapshot.
+func (s *Snapshot) Sub(key string) *Viper { return s.v.Sub(key) }
+
+// SubReader returns a Snapshot of a sub tree of the snapshot, or nil if key
+// doesn't hold a map.
+func (s *Snapshot) SubReader(key string) ConfigReader {
+	sub := s.v.Sub(key)
+	if sub == nil {
+		return nil
+	}
+	return &Snapshot{generation: s.generation, v: sub}
+}
+
+// GetString returns the value associated with the key as a string.
+func (s *Snapshot) GetString(key string) string { return s.v.GetString(key) }
+
+// GetBool returns the value associated with the key as a boolean.
+func (s *Snapshot) GetBool(key string) bool { return s.v.GetBool(key) }
+
+// GetInt returns the value associated with the key as an integer.
+func (s *Snapshot) GetInt(key string) int { return s.v.GetInt(key) }
+
+// GetInt32 returns the value associated with the key as an integer.
+func (s *Snapshot) GetInt32(key string) int32 { return s.v.GetInt32(key) }
+
+// GetInt64 returns the value associated with the key as an integer.
+func (s *Snapshot) GetInt64(key string) int64 { return s.v.GetInt64(key) }
+
+// GetUint returns the value associated with the key as an unsigned integer.
+func (s *Snapshot) GetUint(key string) uint { return s.v.GetUint(key) }
+
+// GetUint16 returns the value associated with the key as an unsigned integer.
+func (s *Snapshot) GetUint16(key string) uint16 { return s.v.GetUint16(key) }
+
+// GetUint32 returns the value associated with the key as an unsigned integer.
+func (s *Snapshot) GetUint32(key string) uint32 { return s.v.GetUint32(key) }
+
+// GetUint64 returns the value associated with the key as an unsigned integer.
+func (s *Snapshot) GetUint64(key string) uint64 { return s.v.GetUint64(key) }
+
+// GetFloat64 returns the value associated with the key as a float64.
+func (s *Snapshot) GetFloat64(key string) float64 { return s.v.GetFloat64(key) }
+
+// GetTime returns the value associated with the key as time.
+func (s *Snapshot) GetTime(key string) time.Time { return s.v.GetTime(key) }
+
+// GetDuration returns the value associated with the key as a duration.
+func (s *Snapshot) GetDuration(key string) time.Duration { return s.v.GetDuration(key) }
+
+// GetIntSlice returns the value associated with the key as a slice of int values.
+func (s *Snapshot) GetIntSlice(key string) []int { return s.v.GetIntSlice(key) }
+
+// GetFloat64Slice returns the value associated with the key as a slice of float64 values.
+func (s *Snapshot) GetFloat64Slice(key string) []float64 { return s.v.GetFloat64Slice(key) }
+
+// GetStringSlice returns the value associated with the key as a slice of strings.
+func (s *Snapshot) GetStringSlice(key string) []string { return s.v.GetStringSlice(key) }
+
+// GetBytes returns the value associated with the key as a slice of bytes.
+func (s *Snapshot) GetBytes(key string) []byte { return s.v.GetBytes(key) }
+
+// GetStringMap returns the value associated with the key as a map of interfaces.
+func (s *Snapshot) GetStringMap(key string) map[string]interface{} { return s.v.GetStringMap(key) }
+
+// GetStringMapString returns the value associated with the key as a map of strings.
+func (s *Snapshot) GetStringMapString(key string) map[string]string {
+	return s.v.GetStringMapString(key)
+}
+
+// GetStringMapStringSlice returns the value associated with the key as a map to a slice of strings.
+func (s *Snapshot) GetStringMapStringSlice(key string) map[string][]string {
+	return s.v.GetStringMapStringSlice(key)
+}
+
+// GetStringToInt64Map returns the value associated with the key as a map of int64 values.
+func (s *Snapshot) GetStringToInt64Map(key string) map[string]int64 {
+	return s.v.GetStringToInt64Map(key)
+}
+
+// GetStringToBoolMap returns the value associated with the key as a map of booleans.
+func (s *Snapshot) GetStringToBoolMap(key string) map[string]bool {
+	return s.v.GetStringToBoolMap(key)
+}
+
+// GetSizeInBytes returns the size of the value associated with the given key
+// in bytes.
+func (s *Snapshot) GetSizeInBytes(key string) uint { return s.v.GetSizeInBytes(key) }
+
+// UnmarshalKey takes a single key and unmarshals it into a Struct.
+func (s *Snapshot) UnmarshalKey(key string, rawVal interface{}, opts ...DecoderConfigOption) error {
+	return s.v.UnmarshalKey(key, rawVal, opts...)
+}
+
+// Unmarshal unmarshals the snapshot into a Struct.
+func (s *Snapshot) Unmarshal(rawVal interface{}, opts ...DecoderConfigOption) error {
+	return s.v.Unmarshal(rawVal, opts...)
+}
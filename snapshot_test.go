@@ -0,0 +1,62 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPin(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+	v.SetDefault("workers", 4)
+
+	pinned := v.Pin()
+	assert.Equal(t, v.Generation(), pinned.Generation())
+
+	v.Set("workers", 8)
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("name: bob\n")))
+
+	assert.Equal(t, 4, pinned.GetInt("workers"))
+	assert.Equal(t, "steve", pinned.GetString("name"))
+	assert.Equal(t, "leather", pinned.GetString("clothing.jacket"))
+	assert.True(t, pinned.IsSet("age"))
+	assert.Equal(t, 8, v.GetInt("workers"))
+	assert.False(t, v.IsSet("age"))
+	assert.True(t, v.Generation() > pinned.Generation())
+
+	var cfg struct {
+		Name    string
+		Workers int
+	}
+	require.NoError(t, pinned.Unmarshal(&cfg))
+	assert.Equal(t, "steve", cfg.Name)
+	assert.Equal(t, 4, cfg.Workers)
+}
+
+func TestPinConsistent(t *testing.T) {
+	v := New()
+	v.Set("n", 0)
+	base := v.Generation()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 1000; i++ {
+			v.Set("n", i)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		// every Set increases the generation once
+		pinned := v.Pin()
+		require.Equal(t, int(pinned.Generation()-base), pinned.GetInt("n"))
+	}
+}
@@ -0,0 +1,182 @@
+package viper
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// A Source is a configuration source read by ReadSources, created with
+// FileSource, ReaderSource, BytesSource, RemoteSource or MapSource.
+type Source struct {
+	// name is the detail reported by Origin for the keys of the source
+	name     string
+	format   string
+	file     bool
+	priority int
+	optional bool
+	read     func(v *Viper) ([]byte, error)
+	settings map[string]interface{}
+}
+
+// FileSource returns the Source of the config file at path, which may also
+// be a URL, see SetConfigFile. format is the config type of the file, given
+// by its extension if empty.
+func FileSource(path, format string) Source {
+	if format == "" {
+		format = fileConfigType(filepath.ToSlash(path))
+	}
+	return Source{name: path, format: format, file: true, read: func(v *Viper) ([]byte, error) {
+		return v.readConfigFile(path)
+	}}
+}
+
+// ReaderSource returns the Source of the configuration read from r, parsed
+// as format, e.g. "yaml".
+func ReaderSource(r io.Reader, format string) Source {
+	return Source{format: format, read: func(*Viper) ([]byte, error) {
+		return ioutil.ReadAll(r)
+	}}
+}
+
+// BytesSource returns the Source of the configuration held by data, parsed
+// as format, e.g. "yaml".
+func BytesSource(data []byte, format string) Source {
+	return Source{format: format, read: func(*Viper) ([]byte, error) {
+		return data, nil
+	}}
+}
+
+// RemoteSource returns the Source of the configuration at path of a remote
+// provider, parsed as format, see AddRemoteProvider. Unlike ReadRemoteConfig,
+// the configuration is read into the config layer, along with the other
+// sources.
+func RemoteSource(provider, endpoint, path, format string) Source {
+	rp := defaultRemoteProvider{provider: provider, endpoint: endpoint, path: path}
+	return Source{name: provider + " " + endpoint + path, format: format, read: func(*Viper) ([]byte, error) {
+		factory, err := remoteConfigFactory(rp)
+		if err != nil {
+			return nil, err
+		}
+		r, err := factory.Get(rp)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	}}
+}
+
+// MapSource returns the Source of the settings of m, which isn't modified.
+func MapSource(m map[string]interface{}) Source {
+	return Source{settings: m}
+}
+
+// Named returns the source named name, the detail reported by Origin for its
+// keys, e.g. to tell readers apart. File and remote sources are named after
+// their path.
+func (s Source) Named(name string) Source {
+	s.name = name
+	return s
+}
+
+// WithPriority returns the source with the given priority, 0 by default:
+// sources of a higher priority take precedence over those of a lower one,
+// and sources of the same priority over the ones given before them.
+func (s Source) WithPriority(priority int) Source {
+	s.priority = priority
+	return s
+}
+
+// Optional returns the source skipped if it can't be read, e.g. a config
+// file which doesn't exist. Optional sources which can't be parsed still
+// fail ReadSources.
+func (s Source) Optional() Source {
+	s.optional = true
+	return s
+}
+
+// ReadSources replaces the config layer with the merge of the given sources,
+// each of them parsed with its own format, so that the configuration is
+// bootstrapped in one call rather than through SetConfigType, ReadConfig
+// and MergeConfig. Sources are merged by order of priority, see
+// WithPriority, and then in the order given, later sources taking
+// precedence. opts apply like for ReadInConfigWithOptions, e.g. StrictRead
+// checks the sources like config files, but only to this read. Nothing is
+// read if a source fails.
+//
+//	err := viper.ReadSources([]viper.Source{
+//		viper.BytesSource(defaults, "yaml"),
+//		viper.FileSource("/etc/app/config.toml", ""),
+//		viper.FileSource("/etc/app/local.yaml", "").Optional(),
+//		viper.MapSource(overrides).WithPriority(1),
+//	}, viper.StrictRead())
+func ReadSources(sources []Source, opts ...ReadOption) error { return v.ReadSources(sources, opts...) }
+func (v *Viper) ReadSources(sources []Source, opts ...ReadOption) error {
+	c := readConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	sorted := make([]Source, len(sources))
+	copy(sorted, sources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].priority < sorted[j].priority
+	})
+
+	var layers []configLayer
+	var names []string
+	for _, s := range sorted {
+		layer, ok, err := v.readSource(s)
+		if err != nil {
+			return err
+		}
+		if ok {
+			layers = append(layers, layer)
+			names = append(names, s.String())
+		}
+	}
+	if err := v.readLayers(layers, strings.Join(names, ", "), c.strict); err != nil {
+		return err
+	}
+	if c.validate {
+		return v.Validate()
+	}
+	return nil
+}
+
+// readSource reads the source as a layer of the config layer, or reports no
+// layer if the source is optional and can't be read.
+func (v *Viper) readSource(s Source) (configLayer, bool, error) {
+	if s.read == nil {
+		cfg := copyMap(s.settings, false)
+		v.insensitiviseMap(cfg)
+		return configLayer{name: s.name, cfg: cfg}, true, nil
+	}
+	if !stringInSlice(s.format, SupportedExts) {
+		return configLayer{}, false, UnsupportedConfigError(s.format)
+	}
+	data, err := s.read(v)
+	if err != nil && s.optional {
+		jww.INFO.Printf("skipping config source %s: %v", s, err)
+		return configLayer{}, false, nil
+	}
+	if err != nil {
+		return configLayer{}, false, err
+	}
+	return configLayer{name: s.name, format: s.format, data: data, file: s.file}, true, nil
+}
+
+// String returns the name of the source, or its format if it has none.
+func (s Source) String() string {
+	switch {
+	case s.name != "":
+		return s.name
+	case s.read == nil:
+		return "map"
+	}
+	return s.format
+}
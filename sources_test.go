@@ -0,0 +1,83 @@
+package viper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSources(t *testing.T) {
+	v := New()
+	v.SetFs(newConfigFilesFs(t))
+	v.SetConfigType("json") // ignored by ReadSources
+	overrides := map[string]interface{}{"Cache": map[string]interface{}{"Size": 50}}
+
+	err := v.ReadSources([]Source{
+		MapSource(overrides).WithPriority(1).Named("overrides"),
+		BytesSource([]byte("name: bytes\nlog: debug\n"), "yaml"),
+		FileSource("/etc/app/config.yaml", ""),
+		FileSource("/etc/app/missing.yaml", "").Optional(),
+		ReaderSource(strings.NewReader("[db]\nhost = \"db.inline\"\n"), "toml").Named("inline"),
+		FileSource("/etc/app/conf.d/20-cache.yaml", ""),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "base", v.GetString("name"))
+	assert.Equal(t, "debug", v.GetString("log"))
+	assert.Equal(t, "db.inline", v.GetString("db.host"))
+	assert.Equal(t, 5432, v.GetInt("db.port"))
+	assert.Equal(t, 50, v.GetInt("cache.size"))
+	assert.Equal(t, map[string]interface{}{"Size": 50}, overrides["Cache"])
+
+	src, detail := v.Origin("db.host")
+	assert.Equal(t, SourceConfig, src)
+	assert.Equal(t, "inline", detail)
+	_, detail = v.Origin("cache.size")
+	assert.Equal(t, "overrides", detail)
+	_, detail = v.Origin("name")
+	assert.Equal(t, "/etc/app/config.yaml", detail)
+	files := v.ConfigFilesUsed()
+	require.Len(t, files, 2)
+	assert.Equal(t, "/etc/app/conf.d/20-cache.yaml", files[1].Path)
+}
+
+func TestReadSourcesErrors(t *testing.T) {
+	v := New()
+	v.SetFs(newConfigFilesFs(t))
+	v.Set("keep", true)
+	require.NoError(t, v.ReadSources([]Source{BytesSource([]byte(`{"name": "first"}`), "json")}))
+
+	err := v.ReadSources([]Source{FileSource("/etc/app/config.yaml", ""), FileSource("/etc/app/missing.yaml", "")})
+	assert.Error(t, err)
+	assert.Equal(t, "first", v.GetString("name"))
+
+	err = v.ReadSources([]Source{BytesSource([]byte("name: [a"), "yaml").Optional()})
+	assert.IsType(t, ConfigParseError{}, err)
+
+	err = v.ReadSources([]Source{BytesSource(nil, "nope")})
+	assert.Equal(t, UnsupportedConfigError("nope"), err)
+	assert.Equal(t, "first", v.GetString("name"))
+}
+
+func TestReadSourcesStrict(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: app\n"), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.SetDefault("name", "")
+	require.NoError(t, v.ReadInConfig())
+	sources := []Source{FileSource("/etc/app/config.yaml", ""), BytesSource([]byte("nmae: typo\n"), "yaml").Named("overrides")}
+
+	err := v.ReadSources(sources, StrictRead())
+	assert.Equal(t, StrictKeyError{Key: "nmae", File: "overrides", Line: 1}, err)
+	assert.Equal(t, "app", v.GetString("name"))
+
+	// the options of ReadInConfigWithOptions don't apply
+	require.NoError(t, v.ReadInConfigWithOptions(StrictRead()))
+	require.NoError(t, v.ReadSources(sources))
+	assert.Equal(t, "typo", v.GetString("nmae"))
+}
@@ -0,0 +1,400 @@
+package viper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// stateMagic starts every state exported by ExportState, followed by the
+// version of the encoding.
+const (
+	stateMagic   = "VIPERSTATE"
+	stateVersion = 1
+)
+
+// Value tags of the state encoding. The numbers are part of the format and
+// must never be reused.
+const (
+	stateNil byte = iota
+	stateBool
+	stateInt
+	stateUint
+	stateFloat
+	stateString
+	stateBytes
+	stateTime
+	stateDuration
+	stateList
+	stateMap
+	stateStringSlice
+	stateIntSlice
+	stateStringMapString
+)
+
+// StateError denotes failing to export or import a state.
+type StateError struct {
+	// decoding is set for errors of ImportState
+	decoding bool
+	err      error
+}
+
+// Error returns the formatted state error.
+func (e StateError) Error() string {
+	if e.decoding {
+		return fmt.Sprintf("While decoding state: %s", e.err.Error())
+	}
+	return fmt.Sprintf("While encoding state: %s", e.err.Error())
+}
+
+// ExportState writes the fully resolved configuration (as returned by
+// AllSettings, so including remote values, env variables and flags) to w,
+// so that it can be handed to another process and loaded with ImportState.
+//
+// The encoding is a stable, versioned binary format: states exported by a
+// version of Viper can be imported by later versions. It preserves the types
+// of values Viper commonly holds (booleans, sized integers and floats,
+// strings, []byte, time.Time, time.Duration, []string, []int, lists and
+// maps); other slice and map types are exported as generic lists and maps,
+// and any other value is an error.
+func ExportState(w io.Writer) error { return v.ExportState(w) }
+func (v *Viper) ExportState(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(stateMagic)
+	bw.WriteByte(stateVersion)
+	if err := encodeStateValue(bw, v.AllSettings()); err != nil {
+		return StateError{err: err}
+	}
+	if err := bw.Flush(); err != nil {
+		return StateError{err: err}
+	}
+	return nil
+}
+
+// ImportState reads a state written by ExportState into the config
+// registry, replacing any configuration read so far as ReadConfig would.
+// Env variables, flags and overrides of the importing instance keep taking
+// precedence over the imported values.
+func ImportState(r io.Reader) error { return v.ImportState(r) }
+func (v *Viper) ImportState(r io.Reader) error {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(stateMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return StateError{decoding: true, err: err}
+	}
+	if string(header[:len(stateMagic)]) != stateMagic {
+		return StateError{decoding: true, err: fmt.Errorf("not a viper state")}
+	}
+	if header[len(stateMagic)] > stateVersion {
+		return StateError{decoding: true, err: fmt.Errorf("unsupported state version %d", header[len(stateMagic)])}
+	}
+
+	val, err := decodeStateValue(br)
+	if err != nil {
+		return StateError{decoding: true, err: err}
+	}
+	config, ok := val.(map[string]interface{})
+	if !ok {
+		return StateError{decoding: true, err: fmt.Errorf("state does not hold a configuration map")}
+	}
+
+	v.mu.Lock()
+	old := v.config
+	v.config = config
+	v.configSource = nil
+	v.recordConfigOrigins(config, "", false)
+	v.unlockChanged()
+	if v.auditing() {
+		v.auditLayer(AuditRead, "", old, config)
+	}
+	return nil
+}
+
+func encodeStateValue(w *bufio.Writer, val interface{}) error {
+	switch val := val.(type) {
+	case nil:
+		return w.WriteByte(stateNil)
+	case bool:
+		w.WriteByte(stateBool)
+		if val {
+			return w.WriteByte(1)
+		}
+		return w.WriteByte(0)
+	case string:
+		w.WriteByte(stateString)
+		writeStateString(w, val)
+		return nil
+	case []byte:
+		w.WriteByte(stateBytes)
+		writeStateUvarint(w, uint64(len(val)))
+		_, err := w.Write(val)
+		return err
+	case time.Time:
+		b, err := val.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		w.WriteByte(stateTime)
+		writeStateUvarint(w, uint64(len(b)))
+		_, err = w.Write(b)
+		return err
+	case time.Duration:
+		w.WriteByte(stateDuration)
+		writeStateVarint(w, int64(val))
+		return nil
+	case []string:
+		w.WriteByte(stateStringSlice)
+		writeStateUvarint(w, uint64(len(val)))
+		for _, s := range val {
+			writeStateString(w, s)
+		}
+		return nil
+	case []int:
+		w.WriteByte(stateIntSlice)
+		writeStateUvarint(w, uint64(len(val)))
+		for _, i := range val {
+			writeStateVarint(w, int64(i))
+		}
+		return nil
+	case map[string]string:
+		w.WriteByte(stateStringMapString)
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeStateUvarint(w, uint64(len(keys)))
+		for _, k := range keys {
+			writeStateString(w, k)
+			writeStateString(w, val[k])
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.WriteByte(stateInt)
+		w.WriteByte(byte(rv.Kind()))
+		writeStateVarint(w, rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		w.WriteByte(stateUint)
+		w.WriteByte(byte(rv.Kind()))
+		writeStateUvarint(w, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		w.WriteByte(stateFloat)
+		w.WriteByte(byte(rv.Kind()))
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(rv.Float()))
+		w.Write(b[:])
+	case reflect.Slice, reflect.Array:
+		w.WriteByte(stateList)
+		writeStateUvarint(w, uint64(rv.Len()))
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeStateValue(w, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			sk := fmt.Sprintf("%v", k.Interface())
+			keys = append(keys, sk)
+			values[sk] = rv.MapIndex(k).Interface()
+		}
+		sort.Strings(keys)
+		w.WriteByte(stateMap)
+		writeStateUvarint(w, uint64(len(keys)))
+		for _, k := range keys {
+			writeStateString(w, k)
+			if err := encodeStateValue(w, values[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported value type %T", val)
+	}
+	return nil
+}
+
+func decodeStateValue(r *bufio.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case stateNil:
+		return nil, nil
+	case stateBool:
+		b, err := r.ReadByte()
+		return b == 1, err
+	case stateString:
+		return readStateString(r)
+	case stateBytes:
+		return readStateBytes(r)
+	case stateTime:
+		b, err := readStateBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		var t time.Time
+		err = t.UnmarshalBinary(b)
+		return t, err
+	case stateDuration:
+		d, err := binary.ReadVarint(r)
+		return time.Duration(d), err
+	case stateInt, stateUint, stateFloat:
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		typ, ok := kindTypes[reflect.Kind(kind)]
+		if !ok {
+			return nil, fmt.Errorf("unknown numeric kind %d", kind)
+		}
+		var num reflect.Value
+		switch tag {
+		case stateInt:
+			i, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			num = reflect.ValueOf(i)
+		case stateUint:
+			u, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+			num = reflect.ValueOf(u)
+		default:
+			var b [8]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return nil, err
+			}
+			num = reflect.ValueOf(math.Float64frombits(binary.BigEndian.Uint64(b[:])))
+		}
+		return num.Convert(typ).Interface(), nil
+	case stateStringSlice:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]string, 0, stateCap(r, n))
+		for i := uint64(0); i < n; i++ {
+			s, err := readStateString(r)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	case stateIntSlice:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]int, 0, stateCap(r, n))
+		for i := uint64(0); i < n; i++ {
+			num, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, int(num))
+		}
+		return list, nil
+	case stateList:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, stateCap(r, n))
+		for i := uint64(0); i < n; i++ {
+			item, err := decodeStateValue(r)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case stateMap, stateStringMapString:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, stateCap(r, n))
+		ms := make(map[string]string, stateCap(r, n))
+		for i := uint64(0); i < n; i++ {
+			k, err := readStateString(r)
+			if err != nil {
+				return nil, err
+			}
+			if tag == stateStringMapString {
+				if ms[k], err = readStateString(r); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if m[k], err = decodeStateValue(r); err != nil {
+				return nil, err
+			}
+		}
+		if tag == stateStringMapString {
+			return ms, nil
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unknown value tag %d", tag)
+}
+
+// stateCap returns the capacity to allocate for n items read from r. n is
+// read from the input, so it's bounded by the input buffered, every item
+// taking at least a byte.
+func stateCap(r *bufio.Reader, n uint64) int {
+	if buffered := uint64(r.Buffered()); n > buffered {
+		return int(buffered)
+	}
+	return int(n)
+}
+
+func writeStateUvarint(w *bufio.Writer, x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutUvarint(b[:], x)])
+}
+
+func writeStateVarint(w *bufio.Writer, x int64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutVarint(b[:], x)])
+}
+
+func writeStateString(w *bufio.Writer, s string) {
+	writeStateUvarint(w, uint64(len(s)))
+	w.WriteString(s)
+}
+
+func readStateBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid length %d", n)
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readStateString(r *bufio.Reader) (string, error) {
+	b, err := readStateBytes(r)
+	return string(b), err
+}
@@ -0,0 +1,87 @@
+package viper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportState(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+	v.SetDefault("timeout", 3*time.Second)
+	v.SetDefault("ports", []int{80, 443})
+	v.SetDefault("tags", []string{"a", "b"})
+	v.Set("ratio", float32(0.5))
+	v.Set("small", int8(-3))
+	v.Set("labels", map[string]string{"team": "core"})
+	v.Set("blob", []byte{0, 1, 2})
+	v.Set("born", time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	require.NoError(t, v.ExportState(&buf))
+	exported := append([]byte{}, buf.Bytes()...)
+
+	worker := New()
+	require.NoError(t, worker.ImportState(bytes.NewReader(exported)))
+	assert.Equal(t, v.AllSettings(), worker.AllSettings())
+	assert.Equal(t, 3*time.Second, worker.Get("timeout"))
+	assert.Equal(t, int8(-3), worker.Get("small"))
+
+	// the encoding is deterministic
+	buf.Reset()
+	require.NoError(t, worker.ExportState(&buf))
+	assert.Equal(t, exported, buf.Bytes())
+
+	assert.Error(t, worker.ImportState(bytes.NewReader([]byte("garbage"))))
+
+	v.Set("unsupported", struct{}{})
+	assert.Error(t, v.ExportState(&buf))
+}
+
+func TestImportStateReplacesConfigFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: steve\nport: 80\n"), 0644))
+
+	exporter := New()
+	exporter.Set("name", "bob")
+	var buf bytes.Buffer
+	require.NoError(t, exporter.ExportState(&buf))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	require.NotNil(t, v.configSource)
+
+	require.NoError(t, v.ImportState(&buf))
+	assert.Nil(t, v.configSource)
+	src, detail := v.Origin("name")
+	assert.Equal(t, SourceConfig, src)
+	assert.Empty(t, detail)
+	src, _ = v.Origin("port")
+	assert.Equal(t, SourceNone, src)
+}
+
+func TestImportStateCorrupt(t *testing.T) {
+	v := New()
+	v.Set("keep", true)
+
+	count := make([]byte, binary.MaxVarintLen64)
+	count = count[:binary.PutUvarint(count, 1<<62)]
+	for _, tag := range []byte{stateStringSlice, stateIntSlice, stateList, stateMap, stateStringMapString, stateBytes, stateString} {
+		corrupt := append([]byte(stateMagic+"\x01"), tag)
+		corrupt = append(corrupt, count...)
+		err := v.ImportState(bytes.NewReader(corrupt))
+		require.Error(t, err, "tag %d", tag)
+		assert.IsType(t, StateError{}, err)
+		assert.Contains(t, err.Error(), "While decoding state")
+	}
+	assert.Equal(t, true, v.Get("keep"))
+}
@@ -0,0 +1,157 @@
+package viper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StrictKeyError denotes a key read from a config file in strict mode, see
+// StrictRead, which isn't part of the schema or whose value can't be cast
+// to the type of its default. File and Line locate the key, if known.
+type StrictKeyError struct {
+	Key   string
+	Value interface{}
+	File  string
+	Line  int
+	// err is nil for unknown keys
+	err error
+}
+
+// Unknown tells whether the key isn't part of the schema.
+func (e StrictKeyError) Unknown() bool {
+	return e.err == nil
+}
+
+// Error returns the formatted strict key error.
+func (e StrictKeyError) Error() string {
+	var msg string
+	if e.Unknown() {
+		msg = fmt.Sprintf("unknown key %q", e.Key)
+	} else {
+		msg = fmt.Sprintf("invalid value %v for key %q: %s", e.Value, e.Key, e.err.Error())
+	}
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, msg)
+	case e.File != "":
+		return e.File + ": " + msg
+	}
+	return msg
+}
+
+// RegisterKeys adds keys to the schema checked in strict mode, see
+// StrictRead. Keys nested below a registered key are part of the schema as
+// well. RegisterKeys is case-insensitive for a key.
+func RegisterKeys(keys ...string) { v.RegisterKeys(keys...) }
+func (v *Viper) RegisterKeys(keys ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.knownKeys == nil {
+		v.knownKeys = make(map[string]bool)
+	}
+	for _, key := range keys {
+		v.knownKeys[v.realKey(v.normalizeKey(key))] = true
+	}
+}
+
+// StrictRead makes ReadInConfigWithOptions, and the re-reads of WatchConfig,
+// reject config files holding keys which aren't part of the schema, or whose
+// values can't be cast to the type of the default of their key, e.g. a
+// misspelt key. The schema is made of the keys with a default, the keys registered
+// through RegisterKeys and BindStruct, the keys bound to a flag or an
+// environment variable, and the keys that are required or have a validator
+// or a constraint. Offending keys are reported as
+// StrictKeyErrors, several of them as a MultiError, and the configuration
+// isn't loaded: WatchConfig logs the errors and keeps the current
+// configuration. See MergeStrict for merges.
+func StrictRead() ReadOption {
+	return func(c *readConfig) {
+		c.strict = true
+	}
+}
+
+// MergeStrict returns a MergeOption which rejects the configuration merged
+// like StrictRead does: nothing is merged if any key isn't part of the
+// schema, or has a value which can't be cast to the type of its default.
+func MergeStrict() MergeOption {
+	return func(c *mergeConfig) {
+		c.strict = true
+	}
+}
+
+// checkStrict returns a StrictKeyError for every key of cfg which isn't part
+// of the schema or whose value can't be cast to the type of its default.
+// cfg was read from file, whose contents data of the given config type are
+// used to locate the keys if not nil. It must be called without the lock
+// held.
+func (v *Viper) checkStrict(cfg map[string]interface{}, file string, data []byte, configType string) error {
+	var doc configDocument
+	if data != nil {
+		if plain, err := decompress(data); err == nil {
+			doc = v.documentOf(plain, configType)
+		}
+	}
+
+	v.mu.RLock()
+	leaves := v.flattenAndMergeMap(nil, cfg, "")
+	keys := make([]string, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		path := strings.Split(key, v.keyDelim)
+		e := StrictKeyError{Key: key, File: file}
+		if doc != nil {
+			e.Line = doc.line(path)
+		}
+		realKey := v.realKey(key)
+		if !v.inSchema(realKey) {
+			errs = append(errs, e)
+			continue
+		}
+		if def := v.searchDefaults(strings.Split(realKey, v.keyDelim)); def != nil {
+			e.Value = v.searchMap(cfg, path)
+			if err := castLike(e.Value, def); err != nil {
+				e.err = err
+				errs = append(errs, e)
+			}
+		}
+	}
+	v.mu.RUnlock()
+	return errorList(errs)
+}
+
+// inSchema tells whether key is part of the schema checked in strict mode.
+// The caller must hold the read lock.
+func (v *Viper) inSchema(key string) bool {
+	path := strings.Split(key, v.keyDelim)
+	if def := v.searchDefaults(path); def != nil {
+		return true
+	}
+	for i := len(path); i > 0; i-- {
+		prefix := strings.Join(path[:i], v.keyDelim)
+		if v.knownKeys[prefix] || v.required[prefix] || v.validators[prefix] != nil || len(v.constraints[prefix]) > 0 {
+			return true
+		}
+		if _, ok := v.deprecations.keys[prefix]; ok {
+			return true
+		}
+		if _, ok := v.pflags[prefix]; ok {
+			return true
+		}
+		if _, ok := v.env[prefix]; ok {
+			return true
+		}
+		if i < len(path) {
+			// a key below a default which isn't a map, e.g. a slice
+			if def := v.searchDefaults(path[:i]); def != nil && !isStringMap(def) {
+				return true
+			}
+		}
+	}
+	return false
+}
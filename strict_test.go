@@ -0,0 +1,100 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var strictYAML = []byte(`server:
+  host: example.com
+  prot: 8080
+timeout: soon
+`)
+
+func TestStrictRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", strictYAML, 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.SetDefault("server.host", "localhost")
+	v.SetDefault("server.port", 80)
+	v.SetDefault("timeout", 5)
+
+	err := v.ReadInConfigWithOptions(StrictRead())
+	require.Error(t, err)
+	errs := err.(MultiError)
+	require.Len(t, errs, 2)
+	assert.EqualError(t, errs[0], `/etc/app/config.yaml:3: unknown key "server.prot"`)
+	assert.True(t, errs[0].(StrictKeyError).Unknown())
+	assert.Contains(t, errs[1].Error(), `/etc/app/config.yaml:4: invalid value soon for key "timeout"`)
+	assert.False(t, errs[1].(StrictKeyError).Unknown())
+	assert.Equal(t, "localhost", v.GetString("server.host"))
+
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, "example.com", v.GetString("server.host"))
+}
+
+func TestStrictReadRegisteredKeys(t *testing.T) {
+	type Config struct {
+		Name   string
+		Server struct {
+			Host string
+		}
+	}
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.toml", []byte(`name = "app"
+[server]
+host = "example.com"
+[plugins.cache]
+size = 10
+`), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.toml")
+	require.NoError(t, v.BindStruct(&Config{}))
+
+	err := v.ReadInConfigWithOptions(StrictRead())
+	assert.EqualError(t, err, `/etc/app/config.toml:5: unknown key "plugins.cache.size"`)
+
+	v.RegisterKeys("Plugins")
+	require.NoError(t, v.ReadInConfigWithOptions(StrictRead()))
+	assert.Equal(t, 10, v.GetInt("plugins.cache.size"))
+}
+
+func TestStrictReadBoundKeys(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: app\nlog:\n  level: debug\ndb:\n  host: example.com\n"), 0644))
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.String("name", "", "")
+	require.NoError(t, v.BindPFlag("Name", flags.Lookup("name")))
+	require.NoError(t, v.BindEnv("log.level", "LOG_LEVEL"))
+
+	err := v.ReadInConfigWithOptions(StrictRead())
+	assert.EqualError(t, err, `/etc/app/config.yaml:5: unknown key "db.host"`)
+
+	// keys nested below a bound key are part of the schema as well
+	require.NoError(t, v.BindEnv("db", "DB"))
+	require.NoError(t, v.ReadInConfigWithOptions(StrictRead()))
+	assert.Equal(t, "example.com", v.GetString("db.host"))
+}
+
+func TestMergeStrict(t *testing.T) {
+	v := New()
+	v.SetDefault("port", 80)
+	v.RegisterKeys("name")
+
+	err := v.MergeConfigMapWithOptions(map[string]interface{}{"name": "app", "prot": 8080}, MergeStrict())
+	assert.EqualError(t, err, `unknown key "prot"`)
+	assert.False(t, v.IsSet("name"))
+
+	require.NoError(t, v.MergeConfigMapWithOptions(map[string]interface{}{"name": "app", "port": "8080"}, MergeStrict()))
+	assert.Equal(t, 8080, v.GetInt("port"))
+}
@@ -0,0 +1,251 @@
+package viper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cast"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// tlsVersions are the names of the TLS versions accepted by GetTLSConfig.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsCipherSuites are the names of the cipher suites accepted by
+// GetTLSConfig.
+var tlsCipherSuites = map[string]uint16{
+	"TLS_RSA_WITH_AES_128_CBC_SHA":                  tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":                  tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":               tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":               tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":          tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":          tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256":       tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384":       tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":          tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":        tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256":   tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256": tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	"TLS_AES_128_GCM_SHA256":                        tls.TLS_AES_128_GCM_SHA256,
+	"TLS_AES_256_GCM_SHA384":                        tls.TLS_AES_256_GCM_SHA384,
+	"TLS_CHACHA20_POLY1305_SHA256":                  tls.TLS_CHACHA20_POLY1305_SHA256,
+}
+
+// tlsClientAuth are the names of the client authentication policies
+// accepted by GetTLSConfig.
+var tlsClientAuth = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify":             tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfigError denotes a TLS configuration which can't be assembled by
+// GetTLSConfig.
+type TLSConfigError struct {
+	Key string
+	err error
+}
+
+// Error returns the formatted TLS config error.
+func (e TLSConfigError) Error() string {
+	return fmt.Sprintf("invalid TLS config %q: %s", e.Key, e.err.Error())
+}
+
+// GetTLSConfig assembles a *tls.Config from the subtree at key:
+//
+//	server:
+//	  tls:
+//	    cert: /etc/app/server.pem     # path or inline PEM
+//	    key: /etc/app/server.key      # path or inline PEM
+//	    ca: /etc/app/ca.pem           # path or inline PEM, optional
+//	    client_auth: require_and_verify
+//	    min_version: "1.2"
+//	    max_version: "1.3"
+//	    cipher_suites: [TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256]
+//	    server_name: example.com
+//	    insecure_skip_verify: false
+//
+// ca sets both the RootCAs, to verify servers, and the ClientCAs, to verify
+// clients. client_auth is one of none, request, require, verify and
+// require_and_verify. Paths are read like GetPath, see
+// SetPathsRelativeToConfig. The certificate is rotated without restarting:
+// it's loaded again on the next handshake once the configuration changes,
+// e.g. when WatchConfig reloads the config file, or once the certificate
+// files change, e.g. when a certificate is renewed, which is checked every
+// 10 seconds at most. The CA bundle is read once: call GetTLSConfig again
+// to use a new one. GetTLSConfig returns nil and no error if the key has no
+// value, and a TLSConfigError if the configuration is invalid.
+func GetTLSConfig(key string) (*tls.Config, error) { return v.GetTLSConfig(key) }
+func (v *Viper) GetTLSConfig(key string) (*tls.Config, error) {
+	if v.Get(key) == nil {
+		return nil, nil
+	}
+	sub := func(name string) string { return key + v.keyDelim + name }
+	fail := func(name string, err error) (*tls.Config, error) {
+		return nil, TLSConfigError{Key: sub(name), err: err}
+	}
+	config := &tls.Config{
+		ServerName:         v.GetString(sub("server_name")),
+		InsecureSkipVerify: v.GetBool(sub("insecure_skip_verify")),
+	}
+
+	for name, version := range map[string]*uint16{"min_version": &config.MinVersion, "max_version": &config.MaxVersion} {
+		if s := v.GetString(sub(name)); s != "" {
+			ver, ok := tlsVersions[strings.TrimPrefix(strings.ToUpper(s), "TLS")]
+			if !ok {
+				return fail(name, fmt.Errorf("unknown TLS version %q", s))
+			}
+			*version = ver
+		}
+	}
+	for _, name := range cast.ToStringSlice(v.Get(sub("cipher_suites"))) {
+		suite, ok := tlsCipherSuites[strings.ToUpper(name)]
+		if !ok {
+			return fail("cipher_suites", fmt.Errorf("unknown cipher suite %q", name))
+		}
+		config.CipherSuites = append(config.CipherSuites, suite)
+	}
+	if s := v.GetString(sub("client_auth")); s != "" {
+		auth, ok := tlsClientAuth[strings.ToLower(s)]
+		if !ok {
+			return fail("client_auth", fmt.Errorf("unknown client auth %q", s))
+		}
+		config.ClientAuth = auth
+	}
+
+	if v.IsSet(sub("ca")) {
+		pem, _, err := v.readPEM(sub("ca"))
+		if err != nil {
+			return fail("ca", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fail("ca", fmt.Errorf("no certificate found"))
+		}
+		config.RootCAs, config.ClientCAs = pool, pool
+	}
+
+	if v.IsSet(sub("cert")) || v.IsSet(sub("key")) {
+		r := &certReloader{v: v, key: key}
+		if _, err := r.certificate(); err != nil {
+			return nil, err
+		}
+		config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+	return config, nil
+}
+
+// readPEM returns the PEM data of key, inline or read from the file it
+// refers to, along with the modification time of the file.
+func (v *Viper) readPEM(key string) ([]byte, time.Time, error) {
+	s := v.GetString(key)
+	if strings.Contains(s, "-----BEGIN") {
+		return []byte(s), time.Time{}, nil
+	}
+	path := v.GetPath(key)
+	if path == "" {
+		return nil, time.Time{}, fmt.Errorf("no PEM data or path")
+	}
+	fi, err := v.fs.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := afero.ReadFile(v.fs, path)
+	return data, fi.ModTime(), err
+}
+
+// certFileCheckInterval is the interval at which the files of the key pairs
+// of TLS configs are checked for changes, rather than on every handshake.
+var certFileCheckInterval = 10 * time.Second
+
+// certReloader loads the key pair of a TLS config again when the
+// configuration or the files of the key pair change.
+type certReloader struct {
+	v   *Viper
+	key string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	gen      uint64
+	modTimes [2]time.Time
+	// when the files were last checked, see filesChanged
+	checked time.Time
+}
+
+func (r *certReloader) certificate() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gen := r.v.Generation()
+	if r.cert != nil && gen == r.gen && !r.filesChanged() {
+		return r.cert, nil
+	}
+
+	var pems [2][]byte
+	var modTimes [2]time.Time
+	for i, name := range []string{"cert", "key"} {
+		key := r.key + r.v.keyDelim + name
+		pem, modTime, err := r.v.readPEM(key)
+		if err != nil {
+			return r.failed(TLSConfigError{Key: key, err: err})
+		}
+		pems[i], modTimes[i] = pem, modTime
+	}
+	cert, err := tls.X509KeyPair(pems[0], pems[1])
+	if err != nil {
+		return r.failed(TLSConfigError{Key: r.key, err: err})
+	}
+	r.cert, r.gen, r.modTimes, r.checked = &cert, gen, modTimes, time.Now()
+	return r.cert, nil
+}
+
+// failed returns err, or the current certificate if there is one: a
+// rotation which fails keeps the current certificate, e.g. while a new key
+// pair is written.
+func (r *certReloader) failed(err error) (*tls.Certificate, error) {
+	if r.cert != nil {
+		jww.ERROR.Printf("keeping the current certificate: %s", err)
+		return r.cert, nil
+	}
+	return nil, err
+}
+
+// filesChanged tells whether the files of the key pair were modified, if
+// they weren't checked within certFileCheckInterval. The caller must hold
+// r.mu.
+func (r *certReloader) filesChanged() bool {
+	if time.Since(r.checked) < certFileCheckInterval {
+		return false
+	}
+	r.checked = time.Now()
+	for i, name := range []string{"cert", "key"} {
+		if r.modTimes[i].IsZero() {
+			continue
+		}
+		path := r.v.GetPath(r.key + r.v.keyDelim + name)
+		if fi, err := r.v.fs.Stat(path); err != nil || !fi.ModTime().Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package viper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeyPair returns a self-signed certificate for name and its key, PEM
+// encoded.
+func testKeyPair(t *testing.T, name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestGetTLSConfig(t *testing.T) {
+	certPEM, keyPEM := testKeyPair(t, "one")
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/server.pem", certPEM, 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/server.key", keyPEM, 0600))
+	v := New()
+	v.SetFs(fs)
+	v.Set("server.tls", map[string]interface{}{
+		"cert":          "/etc/app/server.pem",
+		"key":           "/etc/app/server.key",
+		"ca":            string(certPEM),
+		"client_auth":   "require_and_verify",
+		"min_version":   "1.2",
+		"cipher_suites": []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	})
+
+	config, err := v.GetTLSConfig("server.tls")
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, config.CipherSuites)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.NotNil(t, config.RootCAs)
+	cert, err := config.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, "one", leaf.Subject.CommonName)
+
+	// rotated once the configuration changes
+	certPEM, keyPEM = testKeyPair(t, "two")
+	v.Set("server.tls.cert", string(certPEM))
+	v.Set("server.tls.key", string(keyPEM))
+	cert, err = config.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, "two", leaf.Subject.CommonName)
+
+	// rotated once the files change, checked at intervals
+	certPEM, keyPEM = testKeyPair(t, "three")
+	v.Set("server.tls.cert", "/etc/app/server.pem")
+	v.Set("server.tls.key", "/etc/app/server.key")
+	cert, err = config.GetCertificate(nil)
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/server.pem", certPEM, 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/server.key", keyPEM, 0600))
+	require.NoError(t, fs.Chtimes("/etc/app/server.pem", time.Now(), time.Now().Add(time.Minute)))
+	unchanged, err := config.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.True(t, cert == unchanged)
+	defer func(interval time.Duration) { certFileCheckInterval = interval }(certFileCheckInterval)
+	certFileCheckInterval = 0
+	cert, err = config.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, "three", leaf.Subject.CommonName)
+
+	// a broken key pair keeps the current certificate
+	v.Set("server.tls.key", "/etc/app/missing.key")
+	rotated, err := config.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cert, rotated)
+}
+
+func TestGetTLSConfigErrors(t *testing.T) {
+	v := New()
+	config, err := v.GetTLSConfig("tls")
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	v.Set("tls.min_version", "1.9")
+	_, err = v.GetTLSConfig("tls")
+	assert.EqualError(t, err, `invalid TLS config "tls.min_version": unknown TLS version "1.9"`)
+
+	v.Set("tls.min_version", "TLS1.3")
+	v.Set("tls.cert", "/missing.pem")
+	v.Set("tls.key", "/missing.key")
+	_, err = v.GetTLSConfig("tls")
+	assert.IsType(t, TLSConfigError{}, err)
+	assert.Equal(t, "tls.cert", err.(TLSConfigError).Key)
+}
@@ -0,0 +1,118 @@
+package viper
+
+import (
+	"strconv"
+)
+
+// Tracer starts the spans Viper records around the reads of configuration,
+// so that the latency configuration adds to startups and live reloads shows
+// in traces. A Tracer adapts a tracing library, e.g. OpenTelemetry, in a few
+// lines:
+//
+//	type otelTracer struct {
+//		ctx    context.Context
+//		tracer trace.Tracer
+//	}
+//
+//	func (t otelTracer) StartSpan(name string, attrs map[string]string) viper.Span {
+//		_, span := t.tracer.Start(t.ctx, name, trace.WithAttributes(otelAttributes(attrs)...))
+//		return otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) AddEvent(name string, attrs map[string]string) {
+//		s.Span.AddEvent(name, trace.WithAttributes(otelAttributes(attrs)...))
+//	}
+//
+//	func (s otelSpan) End(err error) {
+//		if err != nil {
+//			s.RecordError(err)
+//			s.SetStatus(codes.Error, err.Error())
+//		}
+//		s.Span.End()
+//	}
+type Tracer interface {
+	// StartSpan starts a span named name, with the given attributes.
+	StartSpan(name string, attrs map[string]string) Span
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// AddEvent records an event within the span.
+	AddEvent(name string, attrs map[string]string)
+	// End ends the span, which failed with err if not nil.
+	End(err error)
+}
+
+// Names of the spans started by Viper.
+const (
+	// SpanReadInConfig spans ReadInConfig.
+	SpanReadInConfig = "viper.ReadInConfig"
+	// SpanMergeInConfig spans MergeInConfig.
+	SpanMergeInConfig = "viper.MergeInConfig"
+	// SpanReload spans the re-reads of WatchConfig, with the "event.name"
+	// and "event.op" attributes of the file event triggering them.
+	SpanReload = "viper.Reload"
+	// SpanRemoteFetch spans the fetches of ReadRemoteConfig and
+	// WatchRemoteConfig, and the updates received by
+	// WatchRemoteConfigOnChannel, with the "remote.provider",
+	// "remote.endpoint" and "remote.path" attributes of the provider.
+	SpanRemoteFetch = "viper.RemoteFetch"
+)
+
+// EventConfigFile is the name of the events recorded by the spans of
+// ReadInConfig, MergeInConfig and reloads for every config file read, with
+// the "file.path", "file.format" and "file.size" attributes.
+const EventConfigFile = "viper.ConfigFile"
+
+// SetTracer sets the Tracer starting spans around ReadInConfig,
+// MergeInConfig, the reloads of WatchConfig and the fetches of remote
+// configuration. Spans are ended with the error of the operation, if any.
+func SetTracer(t Tracer) { v.SetTracer(t) }
+func (v *Viper) SetTracer(t Tracer) {
+	v.tracer = t
+}
+
+type noopSpan struct{}
+
+func (noopSpan) AddEvent(string, map[string]string) {}
+func (noopSpan) End(error)                          {}
+
+// startSpan starts a span with the tracer, if any.
+func (v *Viper) startSpan(name string, attrs map[string]string) Span {
+	if v.tracer == nil {
+		return noopSpan{}
+	}
+	return v.tracer.StartSpan(name, attrs)
+}
+
+// endConfigSpan ends span, started around a read of config files, with an
+// event per file read: all files in use, or only the last one if merged.
+func (v *Viper) endConfigSpan(span Span, err error, merged bool) {
+	if _, ok := span.(noopSpan); ok {
+		return
+	}
+	if err == nil {
+		files := v.ConfigFilesUsed()
+		if merged && len(files) > 0 {
+			files = files[len(files)-1:]
+		}
+		for _, file := range files {
+			span.AddEvent(EventConfigFile, map[string]string{
+				"file.path":   file.Path,
+				"file.format": file.Format,
+				"file.size":   strconv.FormatInt(file.Size, 10),
+			})
+		}
+	}
+	span.End(err)
+}
+
+func remoteSpanAttributes(rp RemoteProvider) map[string]string {
+	return map[string]string{
+		"remote.provider": rp.Provider(),
+		"remote.endpoint": rp.Endpoint(),
+		"remote.path":     rp.Path(),
+	}
+}
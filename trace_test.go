@@ -0,0 +1,95 @@
+package viper
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSpan struct {
+	name   string
+	attrs  map[string]string
+	events []map[string]string
+	ended  bool
+	err    error
+}
+
+func (s *testSpan) AddEvent(name string, attrs map[string]string) {
+	attrs["event"] = name
+	s.events = append(s.events, attrs)
+}
+
+func (s *testSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(name string, attrs map[string]string) Span {
+	s := &testSpan{name: name, attrs: attrs}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracer(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: app\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/extra.json", []byte(`{"extra": true}`), 0644))
+
+	tracer := &testTracer{}
+	v := New()
+	v.SetFs(fs)
+	v.SetTracer(tracer)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.SetConfigFile("/etc/app/extra.json")
+	require.NoError(t, v.MergeInConfig())
+	v.SetConfigFile("/etc/app/missing.yaml")
+	assert.Error(t, v.ReadInConfig())
+
+	require.Len(t, tracer.spans, 3)
+	read, merge, failed := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	assert.Equal(t, SpanReadInConfig, read.name)
+	assert.True(t, read.ended)
+	assert.NoError(t, read.err)
+	assert.Equal(t, []map[string]string{{
+		"event":       EventConfigFile,
+		"file.path":   "/etc/app/config.yaml",
+		"file.format": "yaml",
+		"file.size":   "10",
+	}}, read.events)
+
+	assert.Equal(t, SpanMergeInConfig, merge.name)
+	require.Len(t, merge.events, 1)
+	assert.Equal(t, "/etc/app/extra.json", merge.events[0]["file.path"])
+
+	assert.True(t, failed.ended)
+	assert.Error(t, failed.err)
+	assert.Empty(t, failed.events)
+}
+
+func TestTracerRemoteFetch(t *testing.T) {
+	registerTestProvider(t, "test-trace", &testRemoteConfigFactory{payload: remoteExample})
+
+	tracer := &testTracer{}
+	v := New()
+	v.SetTracer(tracer)
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("test-trace", "localhost", "/config"))
+	require.NoError(t, v.ReadRemoteConfig())
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, SpanRemoteFetch, span.name)
+	assert.Equal(t, map[string]string{
+		"remote.provider": "test-trace",
+		"remote.endpoint": "localhost",
+		"remote.path":     "/config",
+	}, span.attrs)
+	assert.True(t, span.ended)
+	assert.NoError(t, span.err)
+}
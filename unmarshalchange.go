@@ -0,0 +1,245 @@
+package viper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Unmarshaled keeps a struct in sync with the configuration, see
+// UnmarshalOnChange.
+type Unmarshaled struct {
+	v    *Viper
+	typ  reflect.Type
+	opts []DecoderConfigOption
+	// set for the values of Compile, which are decoded after every change
+	// rather than on reloads only
+	everyChange bool
+	value       atomic.Value
+
+	// serializes decodes and guards the fields below
+	mu       sync.Mutex
+	gen      uint64
+	onError  func(error)
+	onUpdate []func(interface{})
+	updates  []chan interface{}
+	// the latest decode not yet handed to onUpdate or onError, and whether
+	// a goroutine is handing decodes out, see deliver
+	pending    *decoded
+	delivering bool
+}
+
+// decoded is the outcome of a decode of an Unmarshaled.
+type decoded struct {
+	val interface{}
+	err error
+}
+
+// unmarshalers holds the Unmarshaled values returned by UnmarshalOnChange
+// and Compile.
+type unmarshalers struct {
+	mu   sync.Mutex
+	list []*Unmarshaled
+	// serializes the decodes of a dispatch, see reloadUnmarshalers
+	dispatch sync.Mutex
+}
+
+// UnmarshalOnChange unmarshals the config into rawVal, a pointer to a struct,
+// and keeps a copy of the result up to date: every time WatchConfig re-reads the
+// config file, and every time remote configuration is read, the config is
+// unmarshaled into a fresh value of the type of rawVal, which then replaces
+// the current one. Values are never modified once handed out, so they can be
+// used without locking; Load returns the current one. rawVal stays the
+// caller's and is never handed out.
+//
+// If the new configuration can't be decoded, onError is called with the
+// error, if not nil, and the current value is kept. An error is returned if
+// the initial unmarshal fails.
+//
+//	var cfg Config
+//	live, err := viper.UnmarshalOnChange(&cfg, func(err error) {
+//		log.Printf("config not applied: %v", err)
+//	})
+//	...
+//	timeout := live.Load().(*Config).Timeout
+func UnmarshalOnChange(rawVal interface{}, onError func(error), opts ...DecoderConfigOption) (*Unmarshaled, error) {
+	return v.UnmarshalOnChange(rawVal, onError, opts...)
+}
+func (v *Viper) UnmarshalOnChange(rawVal interface{}, onError func(error), opts ...DecoderConfigOption) (*Unmarshaled, error) {
+	u, err := v.newUnmarshaled("UnmarshalOnChange", rawVal, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	u.onError = onError
+	v.unmarshalers.mu.Lock()
+	v.unmarshalers.list = append(v.unmarshalers.list, u)
+	v.unmarshalers.mu.Unlock()
+	return u, nil
+}
+
+// newUnmarshaled unmarshals the config into rawVal and returns an
+// Unmarshaled of its type, to be registered by the caller, whose current
+// value is a fresh copy of rawVal. fn names the caller in errors.
+func (v *Viper) newUnmarshaled(fn string, rawVal interface{}, opts []DecoderConfigOption, everyChange bool) (*Unmarshaled, error) {
+	typ := reflect.TypeOf(rawVal)
+	if typ == nil || typ.Kind() != reflect.Ptr || reflect.ValueOf(rawVal).IsNil() {
+		return nil, fmt.Errorf("%s requires a non-nil pointer, got %T", fn, rawVal)
+	}
+	gen := v.Generation()
+	if err := v.Unmarshal(rawVal, opts...); err != nil {
+		return nil, err
+	}
+	// decoded again rather than copied, so that the copy shares no maps
+	// or slices with rawVal
+	val := reflect.New(typ.Elem()).Interface()
+	if err := v.Unmarshal(val, opts...); err != nil {
+		return nil, err
+	}
+	u := &Unmarshaled{v: v, typ: typ.Elem(), opts: opts, everyChange: everyChange, gen: gen}
+	u.value.Store(val)
+	return u, nil
+}
+
+// Load returns the current value, a pointer of the type given to
+// UnmarshalOnChange or Compile. It must not be modified.
+func (u *Unmarshaled) Load() interface{} {
+	return u.value.Load()
+}
+
+// Updates returns a channel receiving every new value. The channel holds
+// only the latest value: values not received before the next change are
+// dropped.
+func (u *Unmarshaled) Updates() <-chan interface{} {
+	ch := make(chan interface{}, 1)
+	u.mu.Lock()
+	u.updates = append(u.updates, ch)
+	u.mu.Unlock()
+	return ch
+}
+
+// Stop stops updating the value. Load keeps returning the last one.
+func (u *Unmarshaled) Stop() {
+	u.v.unmarshalers.mu.Lock()
+	defer u.v.unmarshalers.mu.Unlock()
+	list := u.v.unmarshalers.list
+	for i, other := range list {
+		if other == u {
+			u.v.unmarshalers.list = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// reload decodes the config and delivers the outcome, see decode and
+// deliver.
+func (u *Unmarshaled) reload() {
+	u.decode()
+	u.deliver()
+}
+
+// decode unmarshals the config into a fresh value, if it changed since the
+// current value was, and if successful makes it the current one. The
+// outcome is left for deliver.
+func (u *Unmarshaled) decode() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	gen := u.v.Generation()
+	if gen == u.gen {
+		return
+	}
+	u.gen = gen
+
+	val := reflect.New(u.typ).Interface()
+	err := u.v.Unmarshal(val, u.opts...)
+	if err == nil {
+		u.value.Store(val)
+		u.notify(val)
+	}
+	// replaces an older outcome not delivered yet
+	u.pending = &decoded{val: val, err: err}
+}
+
+// deliver hands the pending outcomes of decode to onUpdate or onError, one
+// at a time and in order. They are called without holding u.mu, so that
+// they may change the configuration: the decodes of these changes are
+// delivered by the goroutine already delivering, once the functions return,
+// rather than concurrently.
+func (u *Unmarshaled) deliver() {
+	u.mu.Lock()
+	if u.delivering {
+		u.mu.Unlock()
+		return
+	}
+	u.delivering = true
+	for u.pending != nil {
+		d := u.pending
+		u.pending = nil
+		onUpdate, onError := u.onUpdate, u.onError
+		u.mu.Unlock()
+
+		if d.err != nil {
+			if onError != nil {
+				onError(d.err)
+			}
+		} else {
+			for _, fn := range onUpdate {
+				fn(d.val)
+			}
+		}
+		u.mu.Lock()
+	}
+	u.delivering = false
+	u.mu.Unlock()
+}
+
+// notify sends val on the channels returned by Updates. u.mu must be held.
+func (u *Unmarshaled) notify(val interface{}) {
+	for _, ch := range u.updates {
+		select {
+		case ch <- val:
+		default:
+			// replace the value not yet received
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- val:
+			default:
+			}
+		}
+	}
+}
+
+// dispatchUnmarshalers updates the values returned by UnmarshalOnChange; the
+// values of Compile are updated by the change events of the reload.
+func (v *Viper) dispatchUnmarshalers() {
+	v.reloadUnmarshalers(false)
+}
+
+// reloadUnmarshalers reloads the values returned by Compile if everyChange
+// is set, and the ones returned by UnmarshalOnChange otherwise. Values are
+// delivered once all are decoded, without holding the dispatch lock, so
+// that the functions they are delivered to may change the configuration.
+func (v *Viper) reloadUnmarshalers(everyChange bool) {
+	v.unmarshalers.mu.Lock()
+	list := v.unmarshalers.list
+	v.unmarshalers.mu.Unlock()
+	if len(list) == 0 {
+		return
+	}
+
+	v.unmarshalers.dispatch.Lock()
+	for _, u := range list {
+		if u.everyChange == everyChange {
+			u.decode()
+		}
+	}
+	v.unmarshalers.dispatch.Unlock()
+	for _, u := range list {
+		if u.everyChange == everyChange {
+			u.deliver()
+		}
+	}
+}
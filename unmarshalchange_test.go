@@ -0,0 +1,69 @@
+package viper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type liveConfig struct {
+	Name    string
+	Timeout time.Duration
+	Tags    []string
+}
+
+func TestUnmarshalOnChange(t *testing.T) {
+	var errs []error
+	v := New()
+	v.SetConfigType("json")
+	v.SetDefault("timeout", "1s")
+	require.NoError(t, v.ReceiveRemoteConfig([]byte(`{"name": "api", "tags": ["a"]}`)))
+
+	var cfg liveConfig
+	live, err := v.UnmarshalOnChange(&cfg, func(err error) { errs = append(errs, err) })
+	require.NoError(t, err)
+	assert.Equal(t, liveConfig{Name: "api", Timeout: time.Second, Tags: []string{"a"}}, cfg)
+	// rawVal stays the caller's
+	assert.False(t, &cfg == live.Load())
+	assert.Equal(t, cfg, *live.Load().(*liveConfig))
+	cfg.Tags[0] = "z"
+	assert.Equal(t, []string{"a"}, live.Load().(*liveConfig).Tags)
+	updates := live.Updates()
+
+	require.NoError(t, v.ReceiveRemoteConfig([]byte(`{"name": "api", "tags": ["a", "b"]}`)))
+	require.NoError(t, v.ReceiveRemoteConfig([]byte(`{"name": "web"}`)))
+	current := live.Load().(*liveConfig)
+	assert.Equal(t, "web", current.Name)
+	// values handed out are never modified
+	assert.Equal(t, []string{"z"}, cfg.Tags)
+	assert.True(t, current == <-updates)
+	select {
+	case <-updates:
+		t.Fatal("stale value not dropped")
+	default:
+	}
+
+	// values that can't be decoded keep the current value
+	v.Set("timeout", "soon")
+	v.dispatchUnmarshalers()
+	require.Len(t, errs, 1)
+	assert.True(t, current == live.Load())
+
+	v.Set("timeout", "2s")
+	live.Stop()
+	v.dispatchUnmarshalers()
+	assert.Equal(t, time.Second, live.Load().(*liveConfig).Timeout)
+}
+
+func TestUnmarshalOnChangeErrors(t *testing.T) {
+	v := New()
+	_, err := v.UnmarshalOnChange(liveConfig{}, nil)
+	assert.Error(t, err)
+
+	v.Set("timeout", "soon")
+	_, err = v.UnmarshalOnChange(&liveConfig{}, nil)
+	assert.Error(t, err)
+	assert.Empty(t, v.unmarshalers.list)
+}
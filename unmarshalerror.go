@@ -0,0 +1,178 @@
+package viper
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// UnmarshalError denotes a value which Unmarshal, UnmarshalKey or
+// UnmarshalExact can't decode, located by its full key and its origin.
+type UnmarshalError struct {
+	// Key is the full key of the value, e.g. "servers.0.timeout".
+	Key string
+	// Source is the layer the value is read from and Detail locates it
+	// within the layer, see Origin. Line is the line of the key in the
+	// config file, if known.
+	Source SourceKind
+	Detail string
+	Line   int
+	err    error
+}
+
+// Error returns the formatted unmarshal error.
+func (e UnmarshalError) Error() string {
+	msg := fmt.Sprintf("key %q: %s", e.Key, e.err.Error())
+	switch {
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.Detail, e.Line, msg)
+	case e.Detail != "":
+		return fmt.Sprintf("%s (%s %s)", msg, e.Source, e.Detail)
+	case e.Source != SourceNone:
+		return fmt.Sprintf("%s (%s)", msg, e.Source)
+	}
+	return msg
+}
+
+// decodedName matches the name of the field mapstructure reports an error
+// for, e.g. 'Servers[0].Timeout'.
+var decodedName = regexp.MustCompile(`'([^']+)'`)
+
+// unmarshalError returns the errors of decoding input, the settings of key
+// or of the whole configuration if key is empty, as UnmarshalErrors: one
+// error is returned as is, several as a MultiError sorted by key.
+func (v *Viper) unmarshalError(err error, key string, input interface{}) error {
+	merr, ok := err.(*mapstructure.Error)
+	if !ok || v == nil {
+		return err
+	}
+
+	var prefix []string
+	if key != "" {
+		prefix = strings.Split(v.normalizeKey(key), v.keyDelim)
+	}
+	v.mu.RLock()
+	src := v.configSource
+	v.mu.RUnlock()
+	var doc configDocument
+	if configType := v.getConfigType(); src != nil {
+		if _, ok := codecFor(configType); !ok {
+			if data, err := decompress(src.data); err == nil {
+				doc = v.documentOf(data, configType)
+			}
+		}
+	}
+
+	errs := make([]error, 0, len(merr.Errors))
+	for _, msg := range merr.Errors {
+		m := decodedName.FindStringSubmatch(msg)
+		if m == nil {
+			errs = append(errs, errors.New(msg))
+			continue
+		}
+		path := append(append([]string(nil), prefix...), v.settingsPath(input, decodedPath(m[1]))...)
+		e := UnmarshalError{Key: strings.Join(path, v.keyDelim), err: errors.New(msg)}
+		e.Source, e.Detail, e.Line = v.locateKey(path, src, doc)
+		errs = append(errs, e)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errorKey(errs[i]) < errorKey(errs[j])
+	})
+	return errorList(errs)
+}
+
+func errorKey(err error) string {
+	if e, ok := err.(UnmarshalError); ok {
+		return e.Key
+	}
+	return ""
+}
+
+// decodedPath splits a field name reported by mapstructure into its
+// elements: "Servers[0].Timeout" is Servers, 0 and Timeout.
+func decodedPath(name string) []string {
+	var path []string
+	for name != "" {
+		switch name[0] {
+		case '.':
+			name = name[1:]
+		case '[':
+			end := strings.IndexByte(name, ']')
+			if end < 0 {
+				return append(path, name[1:])
+			}
+			path = append(path, name[1:end])
+			name = name[end+1:]
+		default:
+			end := strings.IndexAny(name, ".[")
+			if end < 0 {
+				end = len(name)
+			}
+			path = append(path, name[:end])
+			name = name[end:]
+		}
+	}
+	return path
+}
+
+// settingsPath returns the keys of settings the field names of path are
+// decoded from, as mapstructure matches the names case-insensitively.
+// Names which can't be found are normalized.
+func (v *Viper) settingsPath(settings interface{}, path []string) []string {
+	keys := make([]string, len(path))
+	for i, name := range path {
+		keys[i] = v.normalizeKey(name)
+		val := reflect.ValueOf(settings)
+		settings = nil
+		switch val.Kind() {
+		case reflect.Map:
+			if val.Type().Key().Kind() == reflect.String {
+				if item := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key())); item.IsValid() {
+					keys[i], settings = name, item.Interface()
+					continue
+				}
+			}
+			for _, k := range val.MapKeys() {
+				if s := fmt.Sprint(k.Interface()); strings.EqualFold(s, name) {
+					keys[i], settings = s, val.MapIndex(k).Interface()
+					break
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			if n, err := strconv.Atoi(name); err == nil && n >= 0 && n < val.Len() {
+				settings = val.Index(n).Interface()
+			}
+		}
+	}
+	return keys
+}
+
+// locateKey returns the origin of the key at path, see Origin, and its line
+// in the config file src read by ReadInConfig, whose document is doc. Slice
+// items and keys which aren't in the document are located by their closest
+// parent.
+func (v *Viper) locateKey(path []string, src *configSource, doc configDocument) (SourceKind, string, int) {
+	source, detail := v.Origin(strings.Join(path, v.keyDelim))
+	if source != SourceConfig {
+		return source, detail, 0
+	}
+	for i := len(path) - 1; i > 0 && detail == ""; i-- {
+		_, detail = v.Origin(strings.Join(path[:i], v.keyDelim))
+	}
+	if detail == "" || src == nil || doc == nil || filepath.Clean(src.path) != filepath.Clean(detail) {
+		return source, detail, 0
+	}
+	for i := len(path); i > 0; i-- {
+		if line := doc.line(path[:i]); line > 0 {
+			return source, detail, line
+		}
+	}
+	return source, detail, 0
+}
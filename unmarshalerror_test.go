@@ -0,0 +1,101 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unmarshalErrorConfig struct {
+	Server struct {
+		Port    int
+		Timeout time.Duration
+	}
+	Upstreams []struct {
+		Retries int
+	}
+	Limits map[string]int
+}
+
+func TestUnmarshalError(t *testing.T) {
+	v := New()
+	fs := afero.NewMemMapFs()
+	v.SetFs(fs)
+	file := filepath.Join("etc", "app.yaml")
+	require.NoError(t, afero.WriteFile(fs, file, []byte(`server:
+  port: 8080
+  timeout: soon
+upstreams:
+  - retries: 3
+  - retries: many
+limits:
+  Conns: lots
+`), 0644))
+	v.SetConfigFile(file)
+	require.NoError(t, v.ReadInConfig())
+
+	var config unmarshalErrorConfig
+	err := v.Unmarshal(&config)
+	require.IsType(t, MultiError{}, err)
+	errs := err.(MultiError)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, "limits.conns", errs[0].(UnmarshalError).Key)
+	assert.Equal(t, 8, errs[0].(UnmarshalError).Line)
+
+	timeout := errs[1].(UnmarshalError)
+	assert.Equal(t, "server.timeout", timeout.Key)
+	assert.Equal(t, SourceConfig, timeout.Source)
+	assert.Equal(t, file, timeout.Detail)
+	assert.Equal(t, 3, timeout.Line)
+	assert.Contains(t, timeout.Error(), file+`:3: key "server.timeout": error decoding 'Server.Timeout'`)
+
+	// slice items are located by their list
+	retries := errs[2].(UnmarshalError)
+	assert.Equal(t, "upstreams.1.retries", retries.Key)
+	assert.Equal(t, 4, retries.Line)
+
+	var server struct{ Timeout time.Duration }
+	err = v.UnmarshalKey("Server", &server)
+	require.IsType(t, UnmarshalError{}, err)
+	assert.Equal(t, "server.timeout", err.(UnmarshalError).Key)
+	assert.Equal(t, 3, err.(UnmarshalError).Line)
+}
+
+func TestUnmarshalErrorSources(t *testing.T) {
+	os.Setenv("UNMARSHAL_PORT", "eighty")
+	defer os.Unsetenv("UNMARSHAL_PORT")
+
+	v := New()
+	require.NoError(t, v.BindEnv("server.port", "UNMARSHAL_PORT"))
+	var config unmarshalErrorConfig
+	err := v.Unmarshal(&config)
+	assert.EqualError(t, err, `key "server.port": cannot parse 'Server.Port' as int: strconv.ParseInt: parsing "eighty": invalid syntax (env UNMARSHAL_PORT)`)
+
+	v = New()
+	v.Set("server", map[string]interface{}{"timeout": "soon"})
+	err = v.Unmarshal(&config)
+	require.IsType(t, UnmarshalError{}, err)
+	assert.Equal(t, SourceOverride, err.(UnmarshalError).Source)
+	assert.Contains(t, err.Error(), `key "server.timeout": `)
+	assert.Contains(t, err.Error(), ` (override)`)
+
+	// the config is read without a file
+	v = New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("server:\n  port: [1]\n")))
+	err = v.Unmarshal(&config)
+	require.IsType(t, UnmarshalError{}, err)
+	assert.Equal(t, UnmarshalError{Key: "server.port", Source: SourceConfig, err: err.(UnmarshalError).err}, err)
+
+	v = New()
+	v.Set("unused", 1)
+	err = v.UnmarshalExact(&config)
+	assert.EqualError(t, err, "'' has invalid keys: unused")
+}
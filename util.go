@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"unicode"
@@ -33,14 +34,14 @@ func (pe ConfigParseError) Error() string {
 	return fmt.Sprintf("While parsing config: %s", pe.err.Error())
 }
 
-// toCaseInsensitiveValue checks if the value is a  map;
-// if so, create a copy and lower-case the keys recursively.
-func toCaseInsensitiveValue(value interface{}) interface{} {
+// copyMapValue checks if the value is a map; if so, create a copy,
+// lower-casing the keys recursively if lower is set.
+func copyMapValue(value interface{}, lower bool) interface{} {
 	switch v := value.(type) {
 	case map[interface{}]interface{}:
-		value = copyAndInsensitiviseMap(cast.ToStringMap(v))
+		value = copyMap(cast.ToStringMap(v), lower)
 	case map[string]interface{}:
-		value = copyAndInsensitiviseMap(v)
+		value = copyMap(v, lower)
 	}
 
 	return value
@@ -49,15 +50,22 @@ func toCaseInsensitiveValue(value interface{}) interface{} {
 // copyAndInsensitiviseMap behaves like insensitiviseMap, but creates a copy of
 // any map it makes case insensitive.
 func copyAndInsensitiviseMap(m map[string]interface{}) map[string]interface{} {
+	return copyMap(m, true)
+}
+
+func copyMap(m map[string]interface{}, lower bool) map[string]interface{} {
 	nm := make(map[string]interface{})
 
 	for key, val := range m {
-		lkey := strings.ToLower(key)
+		lkey := key
+		if lower {
+			lkey = strings.ToLower(key)
+		}
 		switch v := val.(type) {
 		case map[interface{}]interface{}:
-			nm[lkey] = copyAndInsensitiviseMap(cast.ToStringMap(v))
+			nm[lkey] = copyMap(cast.ToStringMap(v), lower)
 		case map[string]interface{}:
-			nm[lkey] = copyAndInsensitiviseMap(v)
+			nm[lkey] = copyMap(v, lower)
 		default:
 			nm[lkey] = v
 		}
@@ -67,24 +75,33 @@ func copyAndInsensitiviseMap(m map[string]interface{}) map[string]interface{} {
 }
 
 func insensitiviseMap(m map[string]interface{}) {
+	normalizeMap(m, true)
+}
+
+// normalizeMap converts nested maps to map[string]interface{} in place,
+// lower-casing the keys recursively if lower is set.
+func normalizeMap(m map[string]interface{}, lower bool) {
 	for key, val := range m {
 		switch val.(type) {
 		case map[interface{}]interface{}:
 			// nested map: cast and recursively insensitivise
 			val = cast.ToStringMap(val)
-			insensitiviseMap(val.(map[string]interface{}))
+			normalizeMap(val.(map[string]interface{}), lower)
 		case map[string]interface{}:
 			// nested map: recursively insensitivise
-			insensitiviseMap(val.(map[string]interface{}))
+			normalizeMap(val.(map[string]interface{}), lower)
 		}
 
-		lower := strings.ToLower(key)
-		if key != lower {
+		newKey := key
+		if lower {
+			newKey = strings.ToLower(key)
+		}
+		if key != newKey {
 			// remove old key (not lower-cased)
 			delete(m, key)
 		}
 		// update map
-		m[lower] = val
+		m[newKey] = val
 	}
 }
 
@@ -219,3 +236,19 @@ func deepSearch(m map[string]interface{}, path []string) map[string]interface{}
 	}
 	return m
 }
+
+// kindTypes are the builtin numeric types by kind.
+var kindTypes = map[reflect.Kind]reflect.Type{
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
@@ -0,0 +1,153 @@
+package viper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RequiredKeyError denotes a required key without a value.
+type RequiredKeyError string
+
+// Error returns the formatted required key error.
+func (str RequiredKeyError) Error() string {
+	return fmt.Sprintf("required key %q is not set", string(str))
+}
+
+// SetRequired records keys as required: Validate reports each of them
+// without a value from any source, defaults included. SetRequired is
+// case-insensitive for a key.
+func SetRequired(keys ...string) { v.SetRequired(keys...) }
+func (v *Viper) SetRequired(keys ...string) {
+	if v.required == nil {
+		v.required = make(map[string]bool)
+	}
+	for _, key := range keys {
+		v.required[v.realKey(v.normalizeKey(key))] = true
+	}
+}
+
+// SetValidator sets the function validating the value of key, replacing any
+// validator set before. Unlike the constraints of AddConstraint, validators
+// are only run by Validate. SetValidator is case-insensitive for a key.
+func SetValidator(key string, fn func(value interface{}) error) { v.SetValidator(key, fn) }
+func (v *Viper) SetValidator(key string, fn func(value interface{}) error) {
+	if v.validators == nil {
+		v.validators = make(map[string]Constraint)
+	}
+	v.validators[v.realKey(v.normalizeKey(key))] = fn
+}
+
+// Validate checks the current configuration, from all sources, returning
+// a RequiredKeyError for every required key without a value (see SetRequired
+// and BindStruct) and a ConstraintError for every value which can't be cast
+// to the type of the default registered for its key, or which is rejected by
+// a validator or a constraint registered with AddConstraint. Constraints are
+// checked again as values from the environment, flags and config files
+// bypass them. Several violations are reported as a MultiError.
+func Validate() error { return v.Validate() }
+func (v *Viper) Validate() error {
+	var errs []error
+	for _, key := range v.RequiredKeys() {
+		if !v.IsSet(key) {
+			errs = append(errs, RequiredKeyError(key))
+		}
+	}
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		v.mu.RLock()
+		def := v.searchDefaults(strings.Split(key, v.keyDelim))
+		v.mu.RUnlock()
+		if def == nil {
+			continue
+		}
+		value := v.find(key)
+		if err := castLike(value, def); err != nil {
+			errs = append(errs, ConstraintError{Key: key, Value: value, err: err})
+		}
+	}
+
+	checked := make(map[string]bool, len(v.constraints)+len(v.validators))
+	for key := range v.constraints {
+		checked[key] = true
+	}
+	for key := range v.validators {
+		checked[key] = true
+	}
+	for _, key := range sortedKeys(checked) {
+		value := v.find(key)
+		if value == nil {
+			continue
+		}
+		checks := v.constraints[key]
+		if fn, ok := v.validators[key]; ok {
+			checks = append(checks[:len(checks):len(checks)], fn)
+		}
+		for _, check := range checks {
+			if err := check(value); err != nil {
+				errs = append(errs, ConstraintError{Key: key, Value: value, err: err})
+			}
+		}
+	}
+	return errorList(errs)
+}
+
+// castLike returns an error if value can't be cast to the type of def.
+func castLike(value, def interface{}) error {
+	var err error
+	switch def.(type) {
+	case bool:
+		_, err = cast.ToBoolE(value)
+	case string:
+		_, err = cast.ToStringE(value)
+	case int, int8, int16, int32, int64:
+		_, err = cast.ToInt64E(value)
+	case uint, uint8, uint16, uint32, uint64:
+		_, err = cast.ToUint64E(value)
+	case float32, float64:
+		_, err = cast.ToFloat64E(value)
+	case time.Duration:
+		_, err = cast.ToDurationE(value)
+	case time.Time:
+		_, err = cast.ToTimeE(value)
+	case []string:
+		_, err = cast.ToStringSliceE(value)
+	case map[string]interface{}:
+		_, err = cast.ToStringMapE(value)
+	}
+	if err != nil {
+		return fmt.Errorf("can't be cast to %T like the default", def)
+	}
+	return nil
+}
+
+// A ReadOption configures ReadInConfigWithOptions.
+type ReadOption func(*readConfig)
+
+type readConfig struct {
+	validate      bool
+	discardSource bool
+	strict        bool
+}
+
+// readOptions returns the options of the last ReadInConfig.
+func (v *Viper) readOptions() readConfig {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.readConfig
+}
+
+// ValidateOnRead makes ReadInConfigWithOptions, and the re-reads of
+// WatchConfig, Validate the configuration once read. The configuration is
+// loaded regardless: ReadInConfigWithOptions returns the validation errors and
+// WatchConfig logs them.
+func ValidateOnRead() ReadOption {
+	return func(c *readConfig) {
+		c.validate = true
+	}
+}
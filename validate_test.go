@@ -0,0 +1,64 @@
+package viper
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	v := New()
+	v.SetRequired("DB.Host", "db.user")
+	v.SetDefault("db.port", 5432)
+	v.SetDefault("debug", false)
+	v.SetValidator("db.port", func(value interface{}) error {
+		if value == "0" {
+			return errors.New("must not be 0")
+		}
+		return nil
+	})
+	v.AddConstraint("name", MaxLength(5))
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("db:\n  host: localhost\n  port: five\ndebug: maybe\n")))
+
+	os.Setenv("VALIDATE_NAME", "stevens")
+	defer os.Unsetenv("VALIDATE_NAME")
+	require.NoError(t, v.BindEnv("name", "VALIDATE_NAME"))
+
+	err := v.Validate()
+	require.Error(t, err)
+	assert.Equal(t, MultiError{
+		RequiredKeyError("db.user"),
+		ConstraintError{Key: "db.port", Value: "five", err: errors.New("can't be cast to int like the default")},
+		ConstraintError{Key: "debug", Value: "maybe", err: errors.New("can't be cast to bool like the default")},
+		ConstraintError{Key: "name", Value: "stevens", err: errors.New("longer than 5 characters")},
+	}, err)
+
+	v.Set("db.user", "steve")
+	v.Set("db.port", "0")
+	v.Set("debug", "true")
+	os.Setenv("VALIDATE_NAME", "steve")
+	assert.EqualError(t, v.Validate(), `invalid value 0 for key "db.port": must not be 0`)
+
+	v.Set("db.port", 5433)
+	assert.NoError(t, v.Validate())
+}
+
+func TestReadInConfigValidateOnRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("name: steve\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	v.SetRequired("host")
+	require.NoError(t, v.ReadInConfig())
+	assert.Equal(t, RequiredKeyError("host"), v.ReadInConfigWithOptions(ValidateOnRead()))
+	assert.Equal(t, "steve", v.GetString("name"))
+	assert.True(t, v.readConfig.validate)
+}
@@ -0,0 +1,244 @@
+package vault
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DynamicCredentials keeps dynamic secrets, such as database credentials,
+// up to date in a Viper instance. The lease of the secret is renewed before
+// it expires; once it can't be renewed any more (e.g. its max TTL is
+// reached), new credentials are requested and the affected keys rotated.
+//
+// The values of the secret are stored as overrides under the configured
+// prefix, e.g. "db.username" and "db.password" for the prefix "db".
+type DynamicCredentials struct {
+	client *Client
+	path   string
+	v      *viper.Viper
+	prefix string
+
+	onRotate func(data map[string]interface{})
+	onError  func(err error)
+
+	mu     sync.Mutex
+	secret *Secret
+	ttl    time.Duration
+	// when the lease of secret expires
+	expires time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewDynamicCredentials returns DynamicCredentials reading the secret at
+// path (e.g. "database/creds/readonly") into v under prefix.
+func NewDynamicCredentials(client *Client, path string, v *viper.Viper, prefix string) *DynamicCredentials {
+	return &DynamicCredentials{
+		client: client,
+		path:   path,
+		v:      v,
+		prefix: prefix,
+	}
+}
+
+// OnRotate sets a function called with the new secret data every time the
+// credentials are rotated, after the keys have been updated.
+func (d *DynamicCredentials) OnRotate(run func(data map[string]interface{})) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onRotate = run
+}
+
+// OnError sets a function called when renewing or rotating fails.
+// Failed operations are retried with a backoff, before the lease expires.
+func (d *DynamicCredentials) OnError(run func(err error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onError = run
+}
+
+// ErrStarted is returned by Start for DynamicCredentials already started.
+var ErrStarted = errors.New("vault: dynamic credentials already started")
+
+// Start reads the initial credentials into Viper and starts renewing them in
+// the background until Stop is called. Secrets without a lease, i.e. with a
+// lease duration of 0, are read once. Start returns ErrStarted if the
+// credentials are already being renewed.
+func (d *DynamicCredentials) Start() error {
+	d.mu.Lock()
+	if d.stop != nil {
+		d.mu.Unlock()
+		return ErrStarted
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	d.stop, d.done = stop, done
+	d.mu.Unlock()
+
+	secret, err := d.client.Read(d.path)
+	if err != nil {
+		d.mu.Lock()
+		if d.stop == stop {
+			d.stop = nil
+		}
+		d.mu.Unlock()
+		close(done)
+		return err
+	}
+
+	d.rotate(secret)
+	go d.run(stop, done)
+	return nil
+}
+
+// Stop stops renewing the credentials. The current values are left in place.
+func (d *DynamicCredentials) Stop() {
+	d.mu.Lock()
+	stop, done := d.stop, d.done
+	d.stop = nil
+	d.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Secret returns the current secret, including its lease.
+func (d *DynamicCredentials) Secret() *Secret {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.secret
+}
+
+func (d *DynamicCredentials) run(stop, done chan struct{}) {
+	defer close(done)
+
+	var backoff time.Duration
+	for {
+		secret := d.Secret()
+		if secret.TTL() <= 0 {
+			// no lease to renew
+			<-stop
+			return
+		}
+		delay := renewalDelay(secret)
+		if backoff > 0 {
+			delay = d.retryDelay(backoff)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		if err := d.refresh(); err != nil {
+			backoff = nextBackoff(backoff)
+			d.mu.Lock()
+			onError := d.onError
+			d.mu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// refresh renews the lease when possible and rotates the credentials when the
+// lease can't be extended for at least half of its initial duration.
+func (d *DynamicCredentials) refresh() error {
+	d.mu.Lock()
+	current, ttl := d.secret, d.ttl
+	d.mu.Unlock()
+
+	if current.Renewable {
+		renewed, err := d.client.RenewLease(current.LeaseID, ttl)
+		if err == nil && renewed.TTL() >= ttl/2 {
+			// the secret handed out by Secret is never modified
+			next := *current
+			next.LeaseDuration = renewed.LeaseDuration
+			d.mu.Lock()
+			d.secret = &next
+			d.expires = time.Now().Add(next.TTL())
+			d.mu.Unlock()
+			return nil
+		}
+	}
+
+	secret, err := d.client.Read(d.path)
+	if err != nil {
+		return err
+	}
+	d.rotate(secret)
+	return nil
+}
+
+func (d *DynamicCredentials) rotate(secret *Secret) {
+	for key, value := range secret.Data {
+		if d.prefix != "" {
+			key = d.prefix + "." + key
+		}
+		d.v.MarkSecret(key)
+		d.v.Set(key, value)
+	}
+
+	d.mu.Lock()
+	d.secret = secret
+	d.ttl = secret.TTL()
+	d.expires = time.Now().Add(d.ttl)
+	onRotate := d.onRotate
+	d.mu.Unlock()
+
+	if onRotate != nil {
+		onRotate(secret.Data)
+	}
+}
+
+// minRenewalDelay is the shortest time between renewals, so that short
+// leases and failures don't keep Vault busy.
+const minRenewalDelay = time.Second
+
+// renewalDelay returns how long to wait before renewing the lease of secret:
+// two thirds of its duration, leaving time to rotate before it expires.
+func renewalDelay(secret *Secret) time.Duration {
+	delay := secret.TTL() * 2 / 3
+	if delay < minRenewalDelay {
+		delay = minRenewalDelay
+	}
+	return delay
+}
+
+// maxRetryBackoff is the longest time between the retries of a failed
+// renewal.
+const maxRetryBackoff = time.Minute
+
+// nextBackoff returns the backoff of the retry following a failure retried
+// after backoff.
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		return minRenewalDelay
+	}
+	if backoff *= 2; backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// retryDelay returns how long to wait before retrying a failed renewal:
+// backoff, but no more than half the time left on the lease, so that several
+// retries happen before it expires.
+func (d *DynamicCredentials) retryDelay(backoff time.Duration) time.Duration {
+	d.mu.Lock()
+	left := time.Until(d.expires)
+	d.mu.Unlock()
+	if backoff > left/2 {
+		backoff = left / 2
+	}
+	if backoff < minRenewalDelay {
+		backoff = minRenewalDelay
+	}
+	return backoff
+}
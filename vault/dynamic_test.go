@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVault(t *testing.T) (*httptest.Server, *int) {
+	var mu sync.Mutex
+	issued := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/database/creds/readonly":
+			issued++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       fmt.Sprintf("database/creds/readonly/%d", issued),
+				"lease_duration": 1,
+				"renewable":      true,
+				"data": map[string]interface{}{
+					"username": fmt.Sprintf("user-%d", issued),
+					"password": fmt.Sprintf("pass-%d", issued),
+				},
+			})
+		case "/v1/sys/leases/renew":
+			// max TTL reached: the lease can't be extended
+			json.NewEncoder(w).Encode(map[string]interface{}{"lease_duration": 0})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":["no handler for route"]}`))
+		}
+	}))
+	return srv, &issued
+}
+
+func TestDynamicCredentials(t *testing.T) {
+	srv, _ := newTestVault(t)
+	defer srv.Close()
+
+	v := viper.New()
+	creds := NewDynamicCredentials(NewClient(srv.URL, "root"), "database/creds/readonly", v, "db")
+	rotated := make(chan map[string]interface{}, 2)
+	creds.OnRotate(func(data map[string]interface{}) { rotated <- data })
+
+	require.NoError(t, creds.Start())
+	defer creds.Stop()
+	<-rotated
+	assert.Equal(t, "user-1", v.GetString("db.username"))
+	assert.Equal(t, "pass-1", v.GetString("db.password"))
+	assert.True(t, v.IsSecret("db.username"))
+
+	data := <-rotated
+	creds.Stop()
+	assert.Equal(t, "user-2", data["username"])
+	assert.Equal(t, "user-2", v.GetString("db.username"))
+	assert.Equal(t, "database/creds/readonly/2", creds.Secret().LeaseID)
+}
+
+func TestClientError(t *testing.T) {
+	srv, _ := newTestVault(t)
+	defer srv.Close()
+
+	_, err := NewClient(srv.URL, "root").Read("secret/missing")
+	require.Error(t, err)
+	assert.Equal(t, Error{StatusCode: http.StatusNotFound, Errors: []string{"no handler for route"}}, err)
+}
+
+func TestDynamicCredentialsWithoutLease(t *testing.T) {
+	var mu sync.Mutex
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reads++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_duration": 0,
+			"renewable":      false,
+			"data":           map[string]interface{}{"api_key": "key"},
+		})
+	}))
+	defer srv.Close()
+
+	v := viper.New()
+	creds := NewDynamicCredentials(NewClient(srv.URL, "root"), "secret/api", v, "")
+	require.NoError(t, creds.Start())
+	assert.Equal(t, ErrStarted, creds.Start())
+	time.Sleep(200 * time.Millisecond)
+	creds.Stop()
+
+	assert.Equal(t, "key", v.GetString("api_key"))
+	mu.Lock()
+	assert.Equal(t, 1, reads)
+	mu.Unlock()
+
+	// stopped credentials can be started again
+	require.NoError(t, creds.Start())
+	creds.Stop()
+}
+
+func TestDynamicCredentialsRenewal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		duration := 3600
+		if r.URL.Path == "/v1/sys/leases/renew" {
+			duration = 7200
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"lease_id":       "database/creds/readonly/1",
+			"lease_duration": duration,
+			"renewable":      true,
+			"data":           map[string]interface{}{"username": "user"},
+		})
+	}))
+	defer srv.Close()
+
+	creds := NewDynamicCredentials(NewClient(srv.URL, "root"), "database/creds/readonly", viper.New(), "db")
+	require.NoError(t, creds.Start())
+	defer creds.Stop()
+	first := creds.Secret()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = creds.Secret().LeaseDuration
+		}
+	}()
+	require.NoError(t, creds.refresh())
+	<-done
+
+	// secrets handed out are never modified
+	assert.Equal(t, 3600, first.LeaseDuration)
+	assert.Equal(t, 7200, creds.Secret().LeaseDuration)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, []time.Duration{
+		nextBackoff(0), nextBackoff(time.Second), nextBackoff(2 * time.Second),
+	})
+	assert.Equal(t, maxRetryBackoff, nextBackoff(maxRetryBackoff))
+
+	d := &DynamicCredentials{expires: time.Now().Add(10 * time.Second)}
+	assert.Equal(t, 2*time.Second, d.retryDelay(2*time.Second))
+	// retries happen before the lease expires
+	delay := d.retryDelay(time.Minute)
+	assert.True(t, delay <= 5*time.Second && delay > 4*time.Second, delay)
+	d.expires = time.Now().Add(-time.Second)
+	assert.Equal(t, minRenewalDelay, d.retryDelay(time.Minute))
+}
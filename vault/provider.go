@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RefreshInterval is the interval at which WatchRemoteConfigOnChannel
+// re-reads secrets. Secrets leased for a shorter time are re-read after two
+// thirds of their lease duration.
+var RefreshInterval = 5 * time.Minute
+
+// remoteConfigProvider serves Vault secrets as the "vault" remote provider.
+type remoteConfigProvider struct{}
+
+func (rc remoteConfigProvider) Get(rp viper.RemoteProvider) (io.Reader, error) {
+	b, _, err := rc.read(rp)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (rc remoteConfigProvider) Watch(rp viper.RemoteProvider) (io.Reader, error) {
+	return rc.Get(rp)
+}
+
+// IsSecret marks the keys read from Vault as secrets, see viper.MarkSecret.
+func (rc remoteConfigProvider) IsSecret(rp viper.RemoteProvider) bool {
+	return true
+}
+
+func (rc remoteConfigProvider) WatchChannel(rp viper.RemoteProvider) (<-chan *viper.RemoteResponse, chan bool) {
+	quit := make(chan bool)
+	viperResponsCh := make(chan *viper.RemoteResponse)
+	last, ttl, _ := rc.read(rp)
+	go func() {
+		for {
+			select {
+			case <-quit:
+				return
+			case <-time.After(refreshInterval(ttl)):
+			}
+			var (
+				b   []byte
+				err error
+			)
+			b, ttl, err = rc.read(rp)
+			if err == nil {
+				if bytes.Equal(b, last) {
+					continue
+				}
+				last = b
+			}
+			select {
+			case <-quit:
+				return
+			case viperResponsCh <- &viper.RemoteResponse{Value: b, Error: err}:
+			}
+		}
+	}()
+	return viperResponsCh, quit
+}
+
+// read returns the JSON encoded secret data of rp, along with its lease
+// duration.
+func (rc remoteConfigProvider) read(rp viper.RemoteProvider) ([]byte, time.Duration, error) {
+	path, prefix := splitPath(rp.Path())
+	secret, err := NewClient(rp.Endpoint(), "").Read(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data := secret.Data
+	if kv2, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = kv2
+		}
+	}
+	b, err := json.Marshal(mount(data, prefix))
+	return b, secret.TTL(), err
+}
+
+// splitPath splits a provider path into the secret path and the key to mount
+// the secret data under.
+func splitPath(path string) (string, string) {
+	if i := strings.LastIndex(path, "#"); i >= 0 {
+		return path[:i], path[i+1:]
+	}
+	return path, ""
+}
+
+// mount nests data under the dot separated key prefix.
+func mount(data map[string]interface{}, prefix string) map[string]interface{} {
+	if prefix == "" {
+		return data
+	}
+	parts := strings.Split(prefix, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		data = map[string]interface{}{parts[i]: data}
+	}
+	return data
+}
+
+func refreshInterval(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl*2/3 < RefreshInterval {
+		return ttl * 2 / 3
+	}
+	return RefreshInterval
+}
+
+func init() {
+	viper.RegisterRemoteConfigProvider("vault", remoteConfigProvider{})
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKV(t *testing.T) (*httptest.Server, func(password string)) {
+	var mu sync.Mutex
+	password := "hunter2"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v1/secret/data/myapp":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_duration": 0,
+				"data": map[string]interface{}{
+					"data":     map[string]interface{}{"user": "admin", "password": password},
+					"metadata": map[string]interface{}{"version": 3},
+				},
+			})
+		case "/v1/kv/myapp":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_duration": 1,
+				"data":           map[string]interface{}{"password": password},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv, func(p string) {
+		mu.Lock()
+		password = p
+		mu.Unlock()
+	}
+}
+
+func TestRemoteProvider(t *testing.T) {
+	srv, _ := newTestKV(t)
+	defer srv.Close()
+	os.Setenv("VAULT_TOKEN", "root")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	v := viper.New()
+	require.NoError(t, v.AddRemoteProvider("vault", srv.URL, "secret/data/myapp#app.database"))
+	v.SetConfigType("json")
+	require.NoError(t, v.ReadRemoteConfig())
+
+	assert.Equal(t, "admin", v.GetString("app.database.user"))
+	assert.Equal(t, "hunter2", v.GetString("app.database.password"))
+	assert.False(t, v.IsSet("app.database.metadata"))
+	assert.True(t, v.IsSecret("app.database.password"))
+}
+
+func TestRemoteProviderWatchChannel(t *testing.T) {
+	srv, setPassword := newTestKV(t)
+	defer srv.Close()
+	os.Setenv("VAULT_TOKEN", "root")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	rp := testProvider{endpoint: srv.URL, path: "kv/myapp"}
+	respc, quit := remoteConfigProvider{}.WatchChannel(rp)
+	defer close(quit)
+
+	// re-read after two thirds of the lease
+	setPassword("correcthorse")
+	select {
+	case resp := <-respc:
+		require.NoError(t, resp.Error)
+		assert.JSONEq(t, `{"password": "correcthorse"}`, string(resp.Value))
+	case <-time.After(5 * time.Second):
+		t.Fatal("secret not refreshed")
+	}
+}
+
+type testProvider struct {
+	endpoint, path string
+}
+
+func (rp testProvider) Provider() string      { return "vault" }
+func (rp testProvider) Endpoint() string      { return rp.endpoint }
+func (rp testProvider) Path() string          { return rp.path }
+func (rp testProvider) SecretKeyring() string { return "" }
@@ -0,0 +1,143 @@
+// Package vault integrates HashiCorp Vault secrets with Viper.
+//
+// Importing the package registers the "vault" remote provider, serving
+// secrets through the key/value store layer:
+//
+//	import _ "github.com/spf13/viper/vault"
+//
+//	viper.AddRemoteProvider("vault", "https://vault:8200", "secret/data/myapp#database")
+//	viper.SetConfigType("json")
+//	err := viper.ReadRemoteConfig()
+//	password := viper.GetString("database.password")
+//
+// The endpoint is the address of the Vault server, and the path the path of
+// the secret, optionally followed by "#" and the key to mount the secret data
+// under. Both KV version 1 and 2 secrets are supported. An empty endpoint
+// falls back to the VAULT_ADDR environment variable; the token is read from
+// VAULT_TOKEN. The secret data is served as a JSON document, re-read by
+// WatchRemoteConfigOnChannel every RefreshInterval or before its lease
+// expires.
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client is a minimal client for the Vault HTTP API.
+type Client struct {
+	// Address of the Vault server, e.g. "https://vault:8200".
+	Address string
+	// Token used to authenticate requests.
+	Token string
+	// HTTPClient performs the requests; a client timing out after 30
+	// seconds is used when nil.
+	HTTPClient *http.Client
+}
+
+// defaultHTTPClient performs the requests of clients without an HTTPClient.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// NewClient returns a client for the Vault server at address, authenticating
+// with token. Empty values fall back to the VAULT_ADDR and VAULT_TOKEN
+// environment variables.
+func NewClient(address, token string) *Client {
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return &Client{Address: address, Token: token}
+}
+
+// Secret is a secret returned by Vault, along with its lease.
+type Secret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+// TTL returns the lease duration of the secret.
+func (s *Secret) TTL() time.Duration {
+	return time.Duration(s.LeaseDuration) * time.Second
+}
+
+// Error denotes an error response from the Vault server.
+type Error struct {
+	StatusCode int
+	Errors     []string
+}
+
+// Error returns the formatted Vault error.
+func (e Error) Error() string {
+	return fmt.Sprintf("Vault Error %d: %s", e.StatusCode, strings.Join(e.Errors, "; "))
+}
+
+// Read reads the secret at path, e.g. "database/creds/readonly".
+func (c *Client) Read(path string) (*Secret, error) {
+	return c.do(http.MethodGet, path, nil)
+}
+
+// RenewLease extends the lease identified by leaseID by increment.
+func (c *Client) RenewLease(leaseID string, increment time.Duration) (*Secret, error) {
+	body := map[string]interface{}{
+		"lease_id":  leaseID,
+		"increment": int(increment / time.Second),
+	}
+	return c.do(http.MethodPut, "sys/leases/renew", body)
+}
+
+func (c *Client) do(method, path string, body interface{}) (*Secret, error) {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return nil, err
+		}
+	}
+
+	url := strings.TrimSuffix(c.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, &payload)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Vault-Token", c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		verr := Error{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
+			verr.Errors = errBody.Errors
+		}
+		return nil, verr
+	}
+
+	secret := &Secret{}
+	if err := json.NewDecoder(resp.Body).Decode(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
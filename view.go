@@ -0,0 +1,185 @@
+package viper
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// View is a scoped view on the keys of a Viper instance below a prefix.
+// Unlike the detached copy returned by Sub, a View holds no values of its
+// own: every read is delegated to the parent instance, so that a view keeps
+// seeing Set calls, env and flag bindings, aliases and reloads triggered by
+// WatchConfig on its parent.
+type View struct {
+	parent *Viper
+	prefix string
+}
+
+// SubView returns a View on the keys below prefix, e.g. on "clothing" the
+// key "jacket" of the view reads "clothing.jacket" of the instance.
+// SubView is case-insensitive for a key.
+func SubView(prefix string) *View { return v.SubView(prefix) }
+func (v *Viper) SubView(prefix string) *View {
+	if v == nil {
+		// a view on an empty configuration
+		v = New()
+	}
+	return &View{parent: v, prefix: v.normalizeKey(prefix)}
+}
+
+// key returns the key of the parent instance for key.
+func (s *View) key(key string) string {
+	if s.prefix == "" || key == "" {
+		return s.prefix + key
+	}
+	return s.prefix + s.parent.keyDelim + key
+}
+
+// Prefix returns the prefix of the view in its parent instance.
+func (s *View) Prefix() string { return s.prefix }
+
+// Get returns the value associated with the key.
+func (s *View) Get(key string) interface{} { return s.parent.Get(s.key(key)) }
+
+// IsSet checks to see if the key holds a value.
+func (s *View) IsSet(key string) bool { return s.parent.IsSet(s.key(key)) }
+
+// AllKeys returns all keys below the prefix holding a value, relative to the
+// prefix.
+func (s *View) AllKeys() []string {
+	prefix := s.key("")
+	if prefix != "" {
+		prefix += s.parent.keyDelim
+	}
+	keys := []string{}
+	for _, key := range s.parent.AllKeys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return keys
+}
+
+// AllSettings returns all settings below the prefix as a
+// map[string]interface{}.
+func (s *View) AllSettings() map[string]interface{} {
+	m := map[string]interface{}{}
+	for _, k := range s.AllKeys() {
+		value := s.Get(k)
+		if value == nil {
+			continue
+		}
+		path := strings.Split(k, s.parent.keyDelim)
+		deepestMap := deepSearch(m, path[0:len(path)-1])
+		deepestMap[path[len(path)-1]] = value
+	}
+	return m
+}
+
+// SubView returns a View on the keys below prefix, relative to this view.
+func (s *View) SubView(prefix string) *View { return s.parent.SubView(s.key(prefix)) }
+
+// GetString returns the value associated with the key as a string.
+func (s *View) GetString(key string) string { return s.parent.GetString(s.key(key)) }
+
+// GetBool returns the value associated with the key as a boolean.
+func (s *View) GetBool(key string) bool { return s.parent.GetBool(s.key(key)) }
+
+// GetInt returns the value associated with the key as an integer.
+func (s *View) GetInt(key string) int { return s.parent.GetInt(s.key(key)) }
+
+// GetInt32 returns the value associated with the key as an integer.
+func (s *View) GetInt32(key string) int32 { return s.parent.GetInt32(s.key(key)) }
+
+// GetInt64 returns the value associated with the key as an integer.
+func (s *View) GetInt64(key string) int64 { return s.parent.GetInt64(s.key(key)) }
+
+// GetUint returns the value associated with the key as an unsigned integer.
+func (s *View) GetUint(key string) uint { return s.parent.GetUint(s.key(key)) }
+
+// GetUint16 returns the value associated with the key as an unsigned integer.
+func (s *View) GetUint16(key string) uint16 { return s.parent.GetUint16(s.key(key)) }
+
+// GetUint32 returns the value associated with the key as an unsigned integer.
+func (s *View) GetUint32(key string) uint32 { return s.parent.GetUint32(s.key(key)) }
+
+// GetUint64 returns the value associated with the key as an unsigned integer.
+func (s *View) GetUint64(key string) uint64 { return s.parent.GetUint64(s.key(key)) }
+
+// GetFloat64 returns the value associated with the key as a float64.
+func (s *View) GetFloat64(key string) float64 { return s.parent.GetFloat64(s.key(key)) }
+
+// GetTime returns the value associated with the key as time.
+func (s *View) GetTime(key string) time.Time { return s.parent.GetTime(s.key(key)) }
+
+// GetDuration returns the value associated with the key as a duration.
+func (s *View) GetDuration(key string) time.Duration { return s.parent.GetDuration(s.key(key)) }
+
+// GetIntSlice returns the value associated with the key as a slice of int values.
+func (s *View) GetIntSlice(key string) []int { return s.parent.GetIntSlice(s.key(key)) }
+
+// GetFloat64Slice returns the value associated with the key as a slice of float64 values.
+func (s *View) GetFloat64Slice(key string) []float64 { return s.parent.GetFloat64Slice(s.key(key)) }
+
+// GetStringSlice returns the value associated with the key as a slice of strings.
+func (s *View) GetStringSlice(key string) []string { return s.parent.GetStringSlice(s.key(key)) }
+
+// GetBytes returns the value associated with the key as a slice of bytes.
+func (s *View) GetBytes(key string) []byte { return s.parent.GetBytes(s.key(key)) }
+
+// GetStringMap returns the value associated with the key as a map of interfaces.
+func (s *View) GetStringMap(key string) map[string]interface{} {
+	return s.parent.GetStringMap(s.key(key))
+}
+
+// GetStringMapString returns the value associated with the key as a map of strings.
+func (s *View) GetStringMapString(key string) map[string]string {
+	return s.parent.GetStringMapString(s.key(key))
+}
+
+// GetStringMapStringSlice returns the value associated with the key as a map to a slice of strings.
+func (s *View) GetStringMapStringSlice(key string) map[string][]string {
+	return s.parent.GetStringMapStringSlice(s.key(key))
+}
+
+// GetStringToInt64Map returns the value associated with the key as a map of int64 values.
+func (s *View) GetStringToInt64Map(key string) map[string]int64 {
+	return s.parent.GetStringToInt64Map(s.key(key))
+}
+
+// GetStringToBoolMap returns the value associated with the key as a map of booleans.
+func (s *View) GetStringToBoolMap(key string) map[string]bool {
+	return s.parent.GetStringToBoolMap(s.key(key))
+}
+
+// GetSizeInBytes returns the size of the value associated with the given key
+// in bytes.
+func (s *View) GetSizeInBytes(key string) uint { return s.parent.GetSizeInBytes(s.key(key)) }
+
+// UnmarshalKey takes a single key and unmarshals it into a Struct.
+// Nested keys are merged from all configuration layers, as by Unmarshal.
+// Errors are reported like by Viper.Unmarshal, with the keys of the parent
+// instance.
+func (s *View) UnmarshalKey(key string, rawVal interface{}, opts ...DecoderConfigOption) error {
+	value := s.Get(key)
+	if value == nil || reflect.TypeOf(value).Kind() == reflect.Map {
+		value = s.SubView(key).AllSettings()
+	}
+	return s.decode(s.key(key), value, rawVal, opts)
+}
+
+// Unmarshal unmarshals all settings below the prefix into a Struct. Errors
+// are reported like by Viper.Unmarshal, with the keys of the parent instance.
+func (s *View) Unmarshal(rawVal interface{}, opts ...DecoderConfigOption) error {
+	return s.decode(s.prefix, s.AllSettings(), rawVal, opts)
+}
+
+// decode decodes input, the settings of key of the parent instance, into
+// rawVal.
+func (s *View) decode(key string, input, rawVal interface{}, opts []DecoderConfigOption) error {
+	if err := decode(input, s.parent.defaultDecoderConfig(rawVal, opts...)); err != nil {
+		return s.parent.unmarshalError(err, key, input)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package viper
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubView(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+
+	clothing := v.SubView("Clothing")
+	assert.Equal(t, "clothing", clothing.Prefix())
+	assert.Equal(t, "leather", clothing.Get("jacket"))
+	assert.Equal(t, "large", clothing.GetString("pants.size"))
+	assert.Equal(t, "large", clothing.SubView("pants").Get("size"))
+	assert.ElementsMatch(t, []string{"jacket", "trousers", "pants.size"}, clothing.AllKeys())
+
+	// later changes on the parent are visible
+	v.Set("clothing.jacket", "wool")
+	v.RegisterAlias("clothing.coat", "clothing.jacket")
+	assert.Equal(t, "wool", clothing.Get("jacket"))
+	assert.Equal(t, "wool", clothing.Get("coat"))
+
+	v.AutomaticEnv()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	os.Setenv("CLOTHING_HAT", "fedora")
+	defer os.Unsetenv("CLOTHING_HAT")
+	assert.Equal(t, "fedora", clothing.Get("hat"))
+
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("clothing:\n  pants:\n    size: small\n")))
+	assert.Equal(t, "small", clothing.GetString("pants.size"))
+	assert.Equal(t, map[string]interface{}{
+		"jacket": "wool",
+		"coat":   "wool",
+		"pants":  map[string]interface{}{"size": "small"},
+	}, clothing.AllSettings())
+}
+
+func TestSubViewUnmarshal(t *testing.T) {
+	v := New()
+	v.SetDefault("db.host", "localhost")
+	v.SetDefault("db.pool.size", 10)
+	require.NoError(t, v.BindEnv("db.pool.size", "DB_POOL_SIZE"))
+	os.Setenv("DB_POOL_SIZE", "20")
+	defer os.Unsetenv("DB_POOL_SIZE")
+
+	type pool struct{ Size int }
+	var db struct {
+		Host string
+		Pool pool
+	}
+	require.NoError(t, v.SubView("db").Unmarshal(&db))
+	assert.Equal(t, "localhost", db.Host)
+	assert.Equal(t, 20, db.Pool.Size)
+
+	var p pool
+	require.NoError(t, v.SubView("db").UnmarshalKey("pool", &p))
+	assert.Equal(t, 20, p.Size)
+
+	// errors are keyed like in the parent instance
+	v.Set("db.pool.size", "big")
+	err := v.SubView("db").Unmarshal(&db)
+	require.IsType(t, UnmarshalError{}, err)
+	assert.Equal(t, "db.pool.size", err.(UnmarshalError).Key)
+	assert.Equal(t, SourceOverride, err.(UnmarshalError).Source)
+	err = v.SubView("db").UnmarshalKey("pool", &p)
+	require.IsType(t, UnmarshalError{}, err)
+	assert.Equal(t, "db.pool.size", err.(UnmarshalError).Key)
+}
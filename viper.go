@@ -21,14 +21,19 @@ package viper
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -45,7 +50,6 @@ import (
 	"github.com/spf13/cast"
 	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/pflag"
-	"github.com/subosito/gotenv"
 )
 
 // ConfigMarshalError happens when failing to marshal the configuration.
@@ -69,14 +73,55 @@ func init() {
 	v = New()
 }
 
-type remoteConfigFactory interface {
+// RemoteConfigFactory reads and watches configuration from a remote
+// provider.
+type RemoteConfigFactory interface {
 	Get(rp RemoteProvider) (io.Reader, error)
 	Watch(rp RemoteProvider) (io.Reader, error)
 	WatchChannel(rp RemoteProvider) (<-chan *RemoteResponse, chan bool)
 }
 
 // RemoteConfig is optional, see the remote package
-var RemoteConfig remoteConfigFactory
+var RemoteConfig RemoteConfigFactory
+
+// remoteConfigProviders holds the factories registered by name through
+// RegisterRemoteConfigProvider.
+var remoteConfigProviders = map[string]RemoteConfigFactory{}
+
+// RegisterRemoteConfigProvider makes an additional remote provider available
+// to AddRemoteProvider and AddSecureRemoteProvider under the given name.
+// It is meant to be called from the init function of provider packages,
+// such as github.com/spf13/viper/appconfig.
+func RegisterRemoteConfigProvider(name string, factory RemoteConfigFactory) {
+	remoteConfigProviders[name] = factory
+	if !stringInSlice(name, SupportedRemoteProviders) {
+		SupportedRemoteProviders = append(SupportedRemoteProviders, name)
+	}
+}
+
+// ErrProviderNotCompiled is returned when reading from the etcd or consul
+// remote providers while they aren't compiled into the binary: the remote
+// package registers each of them unless built with the viper_noetcd or
+// viper_noconsul tag, so that binaries which don't need them drop their
+// clients.
+var ErrProviderNotCompiled = errors.New("remote provider not compiled in: import _ github.com/spf13/viper/remote and build without its viper_noetcd or viper_noconsul tag")
+
+// remoteConfigFactory returns the factory serving the given provider: the
+// one registered under its name, or the RemoteConfig set by the remote
+// package.
+func remoteConfigFactory(rp RemoteProvider) (RemoteConfigFactory, error) {
+	if factory, ok := remoteConfigProviders[rp.Provider()]; ok {
+		return factory, nil
+	}
+	if RemoteConfig != nil {
+		return RemoteConfig, nil
+	}
+	if rp.Provider() == "etcd" || rp.Provider() == "consul" {
+		jww.ERROR.Printf("remote provider %q is not compiled in", rp.Provider())
+		return nil, ErrProviderNotCompiled
+	}
+	return nil, RemoteConfigError("Enable the remote features by doing a blank import of the viper/remote package: '_ github.com/spf13/viper/remote'")
+}
 
 // UnsupportedConfigError denotes encountering an unsupported
 // configuration filetype.
@@ -115,6 +160,31 @@ func (fnfe ConfigFileNotFoundError) Error() string {
 	return fmt.Sprintf("Config File %q Not Found in %q", fnfe.name, fnfe.locations)
 }
 
+// MultiError aggregates several errors that occurred while processing a
+// batch of operations, e.g. binding many env variables at once.
+type MultiError []error
+
+// Error returns the formatted errors, one per line.
+func (me MultiError) Error() string {
+	msgs := make([]string, len(me))
+	for i, err := range me {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d error(s) occurred:\n%s", len(me), strings.Join(msgs, "\n"))
+}
+
+// errorList returns nil for no errors, the error itself for a single one and
+// a MultiError otherwise.
+func errorList(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return MultiError(errs)
+}
+
 // A DecoderConfigOption can be passed to viper.Unmarshal to configure
 // mapstructure.DecoderConfig options
 type DecoderConfigOption func(*mapstructure.DecoderConfig)
@@ -125,6 +195,8 @@ type DecoderConfigOption func(*mapstructure.DecoderConfig)
 //  mapstructure.ComposeDecodeHookFunc(
 //		mapstructure.StringToTimeDurationHookFunc(),
 //		mapstructure.StringToSliceHookFunc(","),
+//		StringToRateHookFunc(),
+//		DiscriminatedTypeHookFunc(),
 //	)
 func DecodeHook(hook mapstructure.DecodeHookFunc) DecoderConfigOption {
 	return func(c *mapstructure.DecoderConfig) {
@@ -136,6 +208,13 @@ func DecodeHook(hook mapstructure.DecodeHookFunc) DecoderConfigOption {
 // maintains a set of configuration sources, fetches
 // values to populate those, and provides them according
 // to the source's priority.
+//
+// A Viper is safe for concurrent use: Get and the Get____ methods, Set,
+// Unmarshal and the reads and merges of configuration, including the
+// re-reads of WatchConfig, may be called from several goroutines at once.
+// Methods setting Viper up, e.g. SetConfigFile, SetEnvPrefix, AddConstraint
+// or OnChange, should be called beforehand.
+//
 // The priority of the sources is the following:
 // 1. overrides
 // 2. flags
@@ -167,12 +246,21 @@ func DecodeHook(hook mapstructure.DecodeHookFunc) DecoderConfigOption {
 //		"endpoint": "https://localhost"
 //	}
 type Viper struct {
+	// Configuration generation, see Generation. Accessed atomically, keep it
+	// first in the struct for 64-bit alignment.
+	generation uint64
+
 	// Delimiter that separates a list of keys
 	// used to access a nested value in one go
 	keyDelim string
 
 	// A set of paths to look for the config file in
 	configPaths []string
+	// Groups of paths to look for layered config files in, see AddConfigPathGroup
+	configGroups []configPathGroup
+	// Layered config files and drop-in directories, see SetConfigFiles
+	configFiles []string
+	configDirs  []string
 
 	// The filesystem to read config from.
 	fs afero.Fs
@@ -180,15 +268,25 @@ type Viper struct {
 	// A set of remote providers to search for the configuration
 	remoteProviders []*defaultRemoteProvider
 
+	// Leader gating of remote fetches, see SetRemoteLeader.
+	remoteIsLeader  func() bool
+	remoteBroadcast func(payload []byte) error
+
+	// The config file read by ReadInConfig, patched by WriteConfig.
+	configSource *configSource
+
 	// Name of file to look for inside the path
 	configName        string
 	configFile        string
+	configBundle      bool
 	configType        string
 	configPermissions os.FileMode
+	writeOrder        WriteOrder
 	envPrefix         string
 
 	automaticEnvApplied bool
 	envKeyReplacer      *strings.Replacer
+	envKeyMapper        func(string) string
 	allowEmptyEnv       bool
 
 	config         map[string]interface{}
@@ -204,7 +302,92 @@ type Viper struct {
 	// This will only be used if the configuration read is a properties file.
 	properties *properties.Properties
 
+	// Resolved values of keys registered through CacheKey.
+	cache *keyCache
+
+	// Files the configuration was read from, see ConfigFilesUsed.
+	configFilesUsed []FileInfo
+
+	// Config file each config key was read from and remote provider the
+	// key/value store was read from, see Origin.
+	configOrigins   map[string]string
+	kvstoreProvider RemoteProvider
+	// How each default is defined, see DefaultOrigin.
+	defaultOrigins map[string]defaultOrigin
+
+	// Functions run on every loaded configuration map, see OnLoadTransform.
+	loadTransforms []func(map[string]interface{}) error
+
 	onConfigChange func(fsnotify.Event)
+	onChange       func()
+	events         eventState
+	keyWatchers    keyWatchers
+	unmarshalers   unmarshalers
+
+	// Consulted before mutations, see SetAuthorizer.
+	authorizer Authorizer
+	// Keys marked as secrets, see MarkSecret.
+	secrets secrets
+	// Functions recording mutations, see OnAudit.
+	audit auditState
+	// Last reloads of the configuration, see SetHistorySize.
+	history historyState
+
+	// Constraints enforced on stored values, see AddConstraint.
+	constraints map[string][]Constraint
+	// Keys that must be set and validators of values, see Validate.
+	required   map[string]bool
+	validators map[string]Constraint
+	// Keys of the schema checked by StrictRead, see RegisterKeys.
+	knownKeys map[string]bool
+	// Defaults of profiles, see SetDefaultFor.
+	profiles profiles
+	// Descriptions and comments of keys, see SetDefaultWithDoc and
+	// SetComment.
+	annotations map[string]keyAnnotation
+	// Deprecated keys, see DeprecateKey.
+	deprecations deprecations
+	// Layers ignored for keys below reserved prefixes, see ReservePrefix.
+	reserved map[string]map[SourceKind]bool
+	// Options of the last ReadInConfig, applied to WatchConfig re-reads.
+	readConfig readConfig
+
+	// Extensions registered below namespaces, see RegisterExtension.
+	extensions []extension
+	// Instances mounted below key prefixes, see Mount.
+	mounted map[string]*Viper
+
+	// References in values are expanded by Get, see EnableExpansion.
+	expansion bool
+	// GetPath resolves paths against the config file, see
+	// SetPathsRelativeToConfig.
+	relativePaths bool
+	// File references in values are resolved by Get, see
+	// EnableFileReferences.
+	fileReferences bool
+	fileRefs       fileRefs
+	// Parses the cron expressions read by GetCron, see SetCronParser.
+	cronParser CronParser
+
+	// Serializes GetOrSet calls per key.
+	getOrSetLocks keyLocks
+
+	// Guards the configuration layers, aliases, bindings, mounts and the
+	// records of config files. Nested maps of the layers are never modified
+	// in place once stored, so that values returned by Get stay unchanged.
+	mu sync.RWMutex
+
+	// Values resolved by Get, see EnableKeyIndex.
+	keyIndex *keyIndex
+
+	// Keys keep their original casing, see KeysCaseSensitive.
+	caseSensitive bool
+
+	// How INI files are read and written, see IniLoadOptions.
+	iniOptions IniOptions
+
+	// Starts spans around reads of configuration, see SetTracer.
+	tracer Tracer
 }
 
 // New returns an initialized Viper instance.
@@ -222,6 +405,7 @@ func New() *Viper {
 	v.env = make(map[string]string)
 	v.aliases = make(map[string]string)
 	v.typeByDefValue = false
+	v.cache = newKeyCache()
 
 	return v
 }
@@ -231,8 +415,18 @@ func New() *Viper {
 // can use it in their testing as well.
 func Reset() {
 	v = New()
-	SupportedExts = []string{"json", "toml", "yaml", "yml", "properties", "props", "prop", "hcl", "dotenv", "env"}
+	SupportedExts = []string{"json", "toml", "yaml", "yml", "properties", "props", "prop", "hcl", "dotenv", "env", "ini"}
+	SupportedExts = append(SupportedExts, registeredExts(SupportedExts)...)
 	SupportedRemoteProviders = []string{"etcd", "consul"}
+	// keep the providers registered by imported packages
+	names := make([]string, 0, len(remoteConfigProviders))
+	for name := range remoteConfigProviders {
+		if !stringInSlice(name, SupportedRemoteProviders) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	SupportedRemoteProviders = append(SupportedRemoteProviders, names...)
 }
 
 type defaultRemoteProvider struct {
@@ -270,7 +464,7 @@ type RemoteProvider interface {
 }
 
 // SupportedExts are universally supported extensions.
-var SupportedExts = []string{"json", "toml", "yaml", "yml", "properties", "props", "prop", "hcl", "dotenv", "env"}
+var SupportedExts = []string{"json", "toml", "yaml", "yml", "properties", "props", "prop", "hcl", "dotenv", "env", "ini"}
 
 // SupportedRemoteProviders are universally supported remote providers.
 var SupportedRemoteProviders = []string{"etcd", "consul"}
@@ -291,18 +485,13 @@ func (v *Viper) WatchConfig() {
 			log.Fatal(err)
 		}
 		defer watcher.Close()
-		// we have to watch the entire directory to pick up renames/atomic saves in a cross-platform way
-		filename, err := v.getConfigFile()
+		cw, err := v.newConfigWatch()
 		if err != nil {
 			log.Printf("error: %v\n", err)
 			initWG.Done()
 			return
 		}
 
-		configFile := filepath.Clean(filename)
-		configDir, _ := filepath.Split(configFile)
-		realConfigFile, _ := filepath.EvalSymlinks(filename)
-
 		eventsWG := sync.WaitGroup{}
 		eventsWG.Add(1)
 		go func() {
@@ -313,24 +502,40 @@ func (v *Viper) WatchConfig() {
 						eventsWG.Done()
 						return
 					}
-					currentConfigFile, _ := filepath.EvalSymlinks(filename)
-					// we only care about the config file with the following cases:
-					// 1 - if the config file was modified or created
-					// 2 - if the real path to the config file changed (eg: k8s ConfigMap replacement)
-					const writeOrCreateMask = fsnotify.Write | fsnotify.Create
-					if (filepath.Clean(event.Name) == configFile &&
-						event.Op&writeOrCreateMask != 0) ||
-						(currentConfigFile != "" && currentConfigFile != realConfigFile) {
-						realConfigFile = currentConfigFile
-						err := v.ReadInConfig()
+					if cw.changed(event) {
+						span := v.startSpan(SpanReload, map[string]string{
+							"event.name": event.Name,
+							"event.op":   event.Op.String(),
+						})
+						before := v.watchedValues()
+						beforeExtensions := v.extensionSettings()
+						beforeHistory := v.historyBefore()
+						err := v.readInConfig()
+						v.endConfigSpan(span, err, false)
+						v.recordReload(AuditRead, v.configFilesSource(event.Name), beforeHistory, err)
 						if err != nil {
 							log.Printf("error reading config file: %v\n", err)
+						} else {
+							if before != nil {
+								v.dispatchKeyChanges(before, v.watchedValues())
+							}
+							if beforeExtensions != nil {
+								v.dispatchExtensionChanges(beforeExtensions, v.extensionSettings())
+							}
+							v.dispatchUnmarshalers()
+							if v.readOptions().validate {
+								if err := v.Validate(); err != nil {
+									log.Printf("invalid config file: %v\n", err)
+								}
+							}
 						}
-						if v.onConfigChange != nil {
-							v.onConfigChange(event)
+						if cw.layered && cw.refresh(v) == nil {
+							for _, dir := range cw.watchDirs() {
+								watcher.Add(dir)
+							}
 						}
-					} else if filepath.Clean(event.Name) == configFile &&
-						event.Op&fsnotify.Remove&fsnotify.Remove != 0 {
+						v.emitConfigChange(event)
+					} else if cw.removed(event) {
 						eventsWG.Done()
 						return
 					}
@@ -344,7 +549,9 @@ func (v *Viper) WatchConfig() {
 				}
 			}
 		}()
-		watcher.Add(configDir)
+		for _, dir := range cw.watchDirs() {
+			watcher.Add(dir)
+		}
 		initWG.Done()   // done initalizing the watch in this go routine, so the parent routine can move on...
 		eventsWG.Wait() // now, wait for event loop to end in this go-routine...
 	}()
@@ -353,10 +560,16 @@ func (v *Viper) WatchConfig() {
 
 // SetConfigFile explicitly defines the path, name and extension of the config file.
 // Viper will use this and not check any of the config paths.
+// The config file may also be given as a file://, http://, https:// or
+// s3://bucket/key URL, see RegisterConfigURLScheme for other schemes; remote
+// config files are fetched by ReadInConfig and MergeInConfig, but can't be
+// watched or written.
 func SetConfigFile(in string) { v.SetConfigFile(in) }
 func (v *Viper) SetConfigFile(in string) {
 	if in != "" {
-		v.configFile = in
+		v.configFile = localConfigFile(in)
+		v.configFiles = nil
+		v.configBundle = false
 	}
 }
 
@@ -394,16 +607,27 @@ func (v *Viper) AllowEmptyEnv(allowEmptyEnv bool) {
 // key. This allows env vars which have different keys than the config object
 // keys.
 func (v *Viper) getEnv(key string) (string, bool) {
+	return v.lookupEnv(v.envName(key))
+}
+
+// envName returns the name of the environment variable getEnv reads for key.
+func (v *Viper) envName(key string) string {
 	if v.envKeyReplacer != nil {
-		key = v.envKeyReplacer.Replace(key)
+		return v.envKeyReplacer.Replace(key)
 	}
+	return key
+}
 
-	val, ok := os.LookupEnv(key)
+// lookupEnv looks up an environment variable by its exact name, honouring
+// AllowEmptyEnv.
+func (v *Viper) lookupEnv(name string) (string, bool) {
+	val, ok := os.LookupEnv(name)
 
 	return val, ok && (v.allowEmptyEnv || val != "")
 }
 
 // ConfigFileUsed returns the file used to populate the config registry.
+// See ConfigFilesUsed for details on all files that contributed to it.
 func ConfigFileUsed() string            { return v.ConfigFileUsed() }
 func (v *Viper) ConfigFileUsed() string { return v.configFile }
 
@@ -422,7 +646,8 @@ func (v *Viper) AddConfigPath(in string) {
 
 // AddRemoteProvider adds a remote configuration source.
 // Remote Providers are searched in the order they are added.
-// provider is a string value, "etcd" or "consul" are currently supported.
+// provider is a string value, "etcd" or "consul" are currently supported,
+// as well as the providers registered with RegisterRemoteConfigProvider.
 // endpoint is the url.  etcd requires http://ip:port  consul requires ip:port
 // path is the path in the k/v store to retrieve configuration
 // To retrieve a config file called myapp.json from /configs/myapp.json
@@ -451,7 +676,8 @@ func (v *Viper) AddRemoteProvider(provider, endpoint, path string) error {
 
 // AddSecureRemoteProvider adds a remote configuration source.
 // Secure Remote Providers are searched in the order they are added.
-// provider is a string value, "etcd" or "consul" are currently supported.
+// provider is a string value, "etcd" or "consul" are currently supported,
+// as well as the providers registered with RegisterRemoteConfigProvider.
 // endpoint is the url.  etcd requires http://ip:port  consul requires ip:port
 // secretkeyring is the filepath to your openpgp secret keyring.  e.g. /etc/secrets/myring.gpg
 // path is the path in the k/v store to retrieve configuration
@@ -515,8 +741,9 @@ func (v *Viper) searchMap(source map[string]interface{}, path []string) interfac
 			// if the type of `next` is the same as the type being asserted
 			return v.searchMap(next.(map[string]interface{}), path[1:])
 		default:
-			// got a value but nested key expected, return "nil" for not found
-			return nil
+			// got a value but nested key expected: an item of a slice, or
+			// "nil" for not found
+			return v.searchSlice(next, path[1:])
 		}
 	}
 	return nil
@@ -540,7 +767,7 @@ func (v *Viper) searchMapWithPathPrefixes(source map[string]interface{}, path []
 
 	// search for path prefixes, starting from the longest one
 	for i := len(path); i > 0; i-- {
-		prefixKey := strings.ToLower(strings.Join(path[0:i], v.keyDelim))
+		prefixKey := v.normalizeKey(strings.Join(path[0:i], v.keyDelim))
 
 		next, ok := source[prefixKey]
 		if ok {
@@ -559,7 +786,9 @@ func (v *Viper) searchMapWithPathPrefixes(source map[string]interface{}, path []
 				// if the type of `next` is the same as the type being asserted
 				val = v.searchMapWithPathPrefixes(next.(map[string]interface{}), path[i:])
 			default:
-				// got a value but nested key expected, do nothing and look for next prefix
+				// got a value but nested key expected: an item of a slice, or
+				// look for next prefix
+				val = v.searchSlice(next, path[i:])
 			}
 			if val != nil {
 				return val
@@ -589,6 +818,9 @@ func (v *Viper) isPathShadowedInDeepMap(path []string, m map[string]interface{})
 		case map[string]interface{}:
 			continue
 		default:
+			if indexable(parentVal) && !sliceShadows(parentVal, path[i:]) {
+				continue
+			}
 			// parentVal is a regular value which shadows "path"
 			return strings.Join(path[0:i], v.keyDelim)
 		}
@@ -667,19 +899,41 @@ func GetViper() *Viper {
 // override, flag, env, config file, key/value store, default
 //
 // Get returns an interface. For a specific value use one of the Get____ methods.
+//
+// A nil *Viper, e.g. returned by Sub for a missing key, reads as an empty
+// configuration: Get, IsSet, AllKeys, AllSettings, Sub, InConfig, the Get____
+// methods and the Unmarshal methods return zero values rather than panicking.
 func Get(key string) interface{} { return v.Get(key) }
 func (v *Viper) Get(key string) interface{} {
-	lcaseKey := strings.ToLower(key)
-	val := v.find(lcaseKey)
+	if v == nil {
+		return nil
+	}
+	if v.keyIndex != nil {
+		return v.keyIndex.get(v, key)
+	}
+	return v.get(key)
+}
+
+func (v *Viper) get(key string) interface{} {
+	lcaseKey := v.normalizeKey(key)
+	val := v.cachedFind(lcaseKey)
 	if val == nil {
 		return nil
 	}
+	if v.expansion {
+		val = v.expandValue(lcaseKey, val)
+	}
+	if v.fileReferences {
+		val = v.resolveFileRefs(lcaseKey, val)
+	}
 
 	if v.typeByDefValue {
 		// TODO(bep) this branch isn't covered by a single test.
 		valType := val
 		path := strings.Split(lcaseKey, v.keyDelim)
-		defVal := v.searchMap(v.defaults, path)
+		v.mu.RLock()
+		defVal := v.searchDefaults(path)
+		v.mu.RUnlock()
 		if defVal != nil {
 			valType = defVal
 		}
@@ -717,16 +971,21 @@ func (v *Viper) Get(key string) interface{} {
 
 // Sub returns new Viper instance representing a sub tree of this instance.
 // Sub is case-insensitive for a key.
+// The returned instance is a detached copy of the values; see SubView for a
+// view that stays connected to this instance.
+// Sub returns nil if key doesn't hold a map; reads of the nil instance return
+// zero values, see Get.
 func Sub(key string) *Viper { return v.Sub(key) }
 func (v *Viper) Sub(key string) *Viper {
-	subv := New()
 	data := v.Get(key)
 	if data == nil {
 		return nil
 	}
+	subv := New()
+	subv.caseSensitive = v.caseSensitive
 
 	if reflect.TypeOf(data).Kind() == reflect.Map {
-		subv.config = cast.ToStringMap(data)
+		subv.config = copyMap(cast.ToStringMap(data), false)
 		return subv
 	}
 	return nil
@@ -768,6 +1027,12 @@ func (v *Viper) GetUint(key string) uint {
 	return cast.ToUint(v.Get(key))
 }
 
+// GetUint16 returns the value associated with the key as an unsigned integer.
+func GetUint16(key string) uint16 { return v.GetUint16(key) }
+func (v *Viper) GetUint16(key string) uint16 {
+	return cast.ToUint16(v.Get(key))
+}
+
 // GetUint32 returns the value associated with the key as an unsigned integer.
 func GetUint32(key string) uint32 { return v.GetUint32(key) }
 func (v *Viper) GetUint32(key string) uint32 {
@@ -804,12 +1069,32 @@ func (v *Viper) GetIntSlice(key string) []int {
 	return cast.ToIntSlice(v.Get(key))
 }
 
+// GetFloat64Slice returns the value associated with the key as a slice of float64 values.
+func GetFloat64Slice(key string) []float64 { return v.GetFloat64Slice(key) }
+func (v *Viper) GetFloat64Slice(key string) []float64 {
+	res, _ := toFloat64SliceE(v.Get(key))
+	return res
+}
+
 // GetStringSlice returns the value associated with the key as a slice of strings.
 func GetStringSlice(key string) []string { return v.GetStringSlice(key) }
 func (v *Viper) GetStringSlice(key string) []string {
 	return cast.ToStringSlice(v.Get(key))
 }
 
+// GetBytes returns the value associated with the key as a slice of bytes.
+func GetBytes(key string) []byte { return v.GetBytes(key) }
+func (v *Viper) GetBytes(key string) []byte {
+	switch val := v.Get(key).(type) {
+	case nil:
+		return nil
+	case []byte:
+		return val
+	default:
+		return []byte(cast.ToString(val))
+	}
+}
+
 // GetStringMap returns the value associated with the key as a map of interfaces.
 func GetStringMap(key string) map[string]interface{} { return v.GetStringMap(key) }
 func (v *Viper) GetStringMap(key string) map[string]interface{} {
@@ -828,6 +1113,18 @@ func (v *Viper) GetStringMapStringSlice(key string) map[string][]string {
 	return cast.ToStringMapStringSlice(v.Get(key))
 }
 
+// GetStringToInt64Map returns the value associated with the key as a map of int64 values.
+func GetStringToInt64Map(key string) map[string]int64 { return v.GetStringToInt64Map(key) }
+func (v *Viper) GetStringToInt64Map(key string) map[string]int64 {
+	return cast.ToStringMapInt64(v.Get(key))
+}
+
+// GetStringToBoolMap returns the value associated with the key as a map of booleans.
+func GetStringToBoolMap(key string) map[string]bool { return v.GetStringToBoolMap(key) }
+func (v *Viper) GetStringToBoolMap(key string) map[string]bool {
+	return cast.ToStringMapBool(v.Get(key))
+}
+
 // GetSizeInBytes returns the size of the value associated with the given key
 // in bytes.
 func GetSizeInBytes(key string) uint { return v.GetSizeInBytes(key) }
@@ -841,33 +1138,44 @@ func UnmarshalKey(key string, rawVal interface{}, opts ...DecoderConfigOption) e
 	return v.UnmarshalKey(key, rawVal, opts...)
 }
 func (v *Viper) UnmarshalKey(key string, rawVal interface{}, opts ...DecoderConfigOption) error {
-	err := decode(v.Get(key), defaultDecoderConfig(rawVal, opts...))
+	input := v.Get(key)
+	err := decode(input, v.defaultDecoderConfig(rawVal, opts...))
 
 	if err != nil {
-		return err
+		return v.unmarshalError(err, key, input)
 	}
 
 	return nil
 }
 
 // Unmarshal unmarshals the config into a Struct. Make sure that the tags
-// on the fields of the structure are properly set.
+// on the fields of the structure are properly set. Values which can't be
+// decoded are reported as UnmarshalErrors, giving their full key and where
+// they are read from, several of them as a MultiError.
 func Unmarshal(rawVal interface{}, opts ...DecoderConfigOption) error {
 	return v.Unmarshal(rawVal, opts...)
 }
 func (v *Viper) Unmarshal(rawVal interface{}, opts ...DecoderConfigOption) error {
-	err := decode(v.AllSettings(), defaultDecoderConfig(rawVal, opts...))
+	input := v.AllSettings()
+	err := decode(input, v.defaultDecoderConfig(rawVal, opts...))
 
 	if err != nil {
-		return err
+		return v.unmarshalError(err, "", input)
 	}
 
 	return nil
 }
 
 // defaultDecoderConfig returns default mapsstructure.DecoderConfig with suppot
-// of time.Duration values & string slices
-func defaultDecoderConfig(output interface{}, opts ...DecoderConfigOption) *mapstructure.DecoderConfig {
+// of time.Duration values & string slices, matching the discriminator keys of
+// DiscriminatedTypeHookFunc the way the instance matches keys.
+func (v *Viper) defaultDecoderConfig(output interface{}, opts ...DecoderConfigOption) *mapstructure.DecoderConfig {
+	return newDecoderConfig(output, v != nil && v.caseSensitive, opts...)
+}
+
+// newDecoderConfig returns the default decoder config, matching
+// discriminator keys with the same case if caseSensitive is set.
+func newDecoderConfig(output interface{}, caseSensitive bool, opts ...DecoderConfigOption) *mapstructure.DecoderConfig {
 	c := &mapstructure.DecoderConfig{
 		Metadata:         nil,
 		Result:           output,
@@ -875,6 +1183,8 @@ func defaultDecoderConfig(output interface{}, opts ...DecoderConfigOption) *maps
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
 			mapstructure.StringToTimeDurationHookFunc(),
 			mapstructure.StringToSliceHookFunc(","),
+			StringToRateHookFunc(),
+			discriminatedTypeHook(caseSensitive),
 		),
 	}
 	for _, opt := range opts {
@@ -895,13 +1205,14 @@ func decode(input interface{}, config *mapstructure.DecoderConfig) error {
 // UnmarshalExact unmarshals the config into a Struct, erroring if a field is nonexistent
 // in the destination struct.
 func (v *Viper) UnmarshalExact(rawVal interface{}) error {
-	config := defaultDecoderConfig(rawVal)
+	config := v.defaultDecoderConfig(rawVal)
 	config.ErrorUnused = true
 
-	err := decode(v.AllSettings(), config)
+	input := v.AllSettings()
+	err := decode(input, config)
 
 	if err != nil {
-		return err
+		return v.unmarshalError(err, "", input)
 	}
 
 	return nil
@@ -948,7 +1259,9 @@ func (v *Viper) BindFlagValue(key string, flag FlagValue) error {
 	if flag == nil {
 		return fmt.Errorf("flag for %q is nil", key)
 	}
-	v.pflags[strings.ToLower(key)] = flag
+	v.mu.Lock()
+	v.pflags[v.normalizeKey(key)] = flag
+	v.unlockChanged()
 	return nil
 }
 
@@ -963,7 +1276,7 @@ func (v *Viper) BindEnv(input ...string) error {
 		return fmt.Errorf("BindEnv missing key to bind to")
 	}
 
-	key = strings.ToLower(input[0])
+	key = v.normalizeKey(input[0])
 
 	if len(input) == 1 {
 		envkey = v.mergeWithEnvPrefix(key)
@@ -971,143 +1284,278 @@ func (v *Viper) BindEnv(input ...string) error {
 		envkey = input[1]
 	}
 
+	v.mu.Lock()
 	v.env[key] = envkey
+	v.unlockChanged()
 
 	return nil
 }
 
+// BindEnvs binds many Viper keys to ENV variables at once, given a map of
+// keys to env variable names. An empty env variable name binds the key like a
+// single-argument BindEnv call would (uppercased, with the EnvPrefix).
+// Every binding is attempted; failures are returned together as a MultiError.
+func BindEnvs(bindings map[string]string) error { return v.BindEnvs(bindings) }
+func (v *Viper) BindEnvs(bindings map[string]string) error {
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs MultiError
+	for _, key := range keys {
+		var err error
+		if key == "" {
+			err = fmt.Errorf("BindEnvs missing key to bind %q to", bindings[key])
+		} else if bindings[key] == "" {
+			err = v.BindEnv(key)
+		} else {
+			err = v.BindEnv(key, bindings[key])
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // Given a key, find the value.
 // Viper will check in the following order:
 // flag, env, config file, key/value store, default.
 // Viper will check to see if an alias exists first.
 // Note: this assumes a lower-cased key given.
 func (v *Viper) find(lcaseKey string) interface{} {
+	val, _, _ := v.findWithOrigin(lcaseKey)
+	return val
+}
+
+// findOwnWithOrigin is find, additionally reporting the layer the value was
+// found in, ignoring mounted instances. For flags and env variables, detail
+// holds the flag or variable name.
+func (v *Viper) findOwnWithOrigin(lcaseKey string) (interface{}, SourceKind, string) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
 	var (
 		val    interface{}
-		exists bool
 		path   = strings.Split(lcaseKey, v.keyDelim)
 		nested = len(path) > 1
 	)
 
 	// compute the path through the nested maps to the nested value
 	if nested && v.isPathShadowedInDeepMap(path, castMapStringToMapInterface(v.aliases)) != "" {
-		return nil
+		return nil, SourceNone, ""
 	}
 
 	// if the requested key is an alias, then return the proper key
 	lcaseKey = v.realKey(lcaseKey)
 	path = strings.Split(lcaseKey, v.keyDelim)
 	nested = len(path) > 1
+	reserved := v.reservedLayers(path)
 
 	// Set() override first
-	val = v.searchMap(v.override, path)
-	if val != nil {
-		return val
-	}
-	if nested && v.isPathShadowedInDeepMap(path, v.override) != "" {
-		return nil
+	if !reserved[SourceOverride] {
+		val = v.withoutReserved(v.searchMap(v.override, path), path, SourceOverride)
+		if val != nil {
+			return val, SourceOverride, ""
+		}
+		if nested && v.isPathShadowedInDeepMap(path, v.override) != "" {
+			return nil, SourceNone, ""
+		}
 	}
 
 	// PFlag override next
-	flag, exists := v.pflags[lcaseKey]
-	if exists && flag.HasChanged() {
-		switch flag.ValueType() {
-		case "int", "int8", "int16", "int32", "int64":
-			return cast.ToInt(flag.ValueString())
-		case "bool":
-			return cast.ToBool(flag.ValueString())
-		case "stringSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return res
-		case "intSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return cast.ToIntSlice(res)
-		default:
-			return flag.ValueString()
+	if !reserved[SourceFlag] {
+		flag, exists := v.pflags[lcaseKey]
+		if exists && flag.HasChanged() {
+			return flagValue(flag), SourceFlag, flag.Name()
+		}
+		if nested && v.isPathShadowedInFlatMap(path, v.pflags) != "" {
+			return nil, SourceNone, ""
 		}
-	}
-	if nested && v.isPathShadowedInFlatMap(path, v.pflags) != "" {
-		return nil
 	}
 
 	// Env override next
-	if v.automaticEnvApplied {
-		// even if it hasn't been registered, if automaticEnv is used,
-		// check any Get request
-		if val, ok := v.getEnv(v.mergeWithEnvPrefix(lcaseKey)); ok {
-			return val
+	if !reserved[SourceEnv] {
+		if v.automaticEnvApplied && v.envKeyMapper != nil {
+			// the mapper decides which variables populate which keys
+			mapped := v.mappedEnv()
+			if envName, ok := mapped[lcaseKey]; ok {
+				if val, ok := v.lookupEnv(envName); ok {
+					return val, SourceEnv, envName
+				}
+			}
+			if nested && v.isPathShadowedInFlatMap(path, mapped) != "" {
+				return nil, SourceNone, ""
+			}
+		} else if v.automaticEnvApplied {
+			// even if it hasn't been registered, if automaticEnv is used,
+			// check any Get request
+			envName := v.mergeWithEnvPrefix(lcaseKey)
+			if val, ok := v.getEnv(envName); ok {
+				return val, SourceEnv, v.envName(envName)
+			}
+			if nested && v.isPathShadowedInAutoEnv(path) != "" {
+				return nil, SourceNone, ""
+			}
 		}
-		if nested && v.isPathShadowedInAutoEnv(path) != "" {
-			return nil
+		envkey, exists := v.env[lcaseKey]
+		if exists {
+			if val, ok := v.getEnv(envkey); ok {
+				return val, SourceEnv, v.envName(envkey)
+			}
 		}
-	}
-	envkey, exists := v.env[lcaseKey]
-	if exists {
-		if val, ok := v.getEnv(envkey); ok {
-			return val
+		if nested && v.isPathShadowedInFlatMap(path, v.env) != "" {
+			return nil, SourceNone, ""
 		}
 	}
-	if nested && v.isPathShadowedInFlatMap(path, v.env) != "" {
-		return nil
-	}
 
 	// Config file next
-	val = v.searchMapWithPathPrefixes(v.config, path)
-	if val != nil {
-		return val
-	}
-	if nested && v.isPathShadowedInDeepMap(path, v.config) != "" {
-		return nil
+	if !reserved[SourceConfig] {
+		val = v.withoutReserved(v.searchMapWithPathPrefixes(v.config, path), path, SourceConfig)
+		if val != nil {
+			return val, SourceConfig, ""
+		}
+		if nested && v.isPathShadowedInDeepMap(path, v.config) != "" {
+			return nil, SourceNone, ""
+		}
 	}
 
 	// K/V store next
-	val = v.searchMap(v.kvstore, path)
-	if val != nil {
-		return val
+	if !reserved[SourceKVStore] {
+		val = v.withoutReserved(v.searchMap(v.kvstore, path), path, SourceKVStore)
+		if val != nil {
+			return val, SourceKVStore, ""
+		}
+		if nested && v.isPathShadowedInDeepMap(path, v.kvstore) != "" {
+			return nil, SourceNone, ""
+		}
 	}
-	if nested && v.isPathShadowedInDeepMap(path, v.kvstore) != "" {
-		return nil
+
+	// Defaults of the profile next
+	if profile := v.profileDefaults(); profile != nil {
+		val = v.searchMap(profile, path)
+		if val != nil {
+			return val, SourceDefault, ""
+		}
+		if nested && v.isPathShadowedInDeepMap(path, profile) != "" {
+			return nil, SourceNone, ""
+		}
 	}
 
 	// Default next
 	val = v.searchMap(v.defaults, path)
 	if val != nil {
-		return val
+		return val, SourceDefault, ""
 	}
 	if nested && v.isPathShadowedInDeepMap(path, v.defaults) != "" {
-		return nil
+		return nil, SourceNone, ""
 	}
 
 	// last chance: if no other value is returned and a flag does exist for the value,
 	// get the flag's value even if the flag's value has not changed
-	if flag, exists := v.pflags[lcaseKey]; exists {
-		switch flag.ValueType() {
-		case "int", "int8", "int16", "int32", "int64":
-			return cast.ToInt(flag.ValueString())
-		case "bool":
-			return cast.ToBool(flag.ValueString())
-		case "stringSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return res
-		case "intSlice":
-			s := strings.TrimPrefix(flag.ValueString(), "[")
-			s = strings.TrimSuffix(s, "]")
-			res, _ := readAsCSV(s)
-			return cast.ToIntSlice(res)
-		default:
-			return flag.ValueString()
-		}
+	if flag, exists := v.pflags[lcaseKey]; exists && !reserved[SourceFlag] {
+		return flagValue(flag), SourceFlag, flag.Name()
 	}
 	// last item, no need to check shadowing
 
-	return nil
+	return nil, SourceNone, ""
+}
+
+// flagValue converts the string representation of a flag back into a value
+// of the flag's type, so that e.g. an ipSlice flag yields a []net.IP and a
+// bytesHex flag a []byte rather than their printed forms.
+// Unknown types are returned as strings.
+func flagValue(flag FlagValue) interface{} {
+	switch flag.ValueType() {
+	case "int", "int8", "int16", "int32", "int64", "count":
+		return cast.ToInt(flag.ValueString())
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return cast.ToUint(flag.ValueString())
+	case "float32", "float64":
+		return cast.ToFloat64(flag.ValueString())
+	case "bool":
+		return cast.ToBool(flag.ValueString())
+	case "duration":
+		return cast.ToDuration(flag.ValueString())
+	case "stringSlice", "stringArray":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		return res
+	case "intSlice":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		return cast.ToIntSlice(res)
+	case "uintSlice":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		out := make([]uint, len(res))
+		for i, s := range res {
+			out[i] = cast.ToUint(s)
+		}
+		return out
+	case "boolSlice":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		out := make([]bool, len(res))
+		for i, s := range res {
+			out[i] = cast.ToBool(s)
+		}
+		return out
+	case "durationSlice":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		out := make([]time.Duration, len(res))
+		for i, s := range res {
+			out[i] = cast.ToDuration(s)
+		}
+		return out
+	case "ip":
+		return net.ParseIP(strings.TrimSpace(flag.ValueString()))
+	case "ipSlice":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		out := make([]net.IP, len(res))
+		for i, s := range res {
+			out[i] = net.ParseIP(strings.TrimSpace(s))
+		}
+		return out
+	case "ipNet":
+		if _, n, err := net.ParseCIDR(flag.ValueString()); err == nil {
+			return *n
+		}
+		return flag.ValueString()
+	case "bytesHex":
+		if b, err := hex.DecodeString(flag.ValueString()); err == nil {
+			return b
+		}
+		return flag.ValueString()
+	case "bytesBase64":
+		if b, err := base64.StdEncoding.DecodeString(flag.ValueString()); err == nil {
+			return b
+		}
+		return flag.ValueString()
+	case "stringToString", "stringToInt":
+		res, _ := readAsCSV(trimBrackets(flag.ValueString()))
+		out := make(map[string]interface{}, len(res))
+		for _, pair := range res {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			if flag.ValueType() == "stringToInt" {
+				out[kv[0]] = cast.ToInt(kv[1])
+			} else {
+				out[kv[0]] = kv[1]
+			}
+		}
+		return out
+	default:
+		return flag.ValueString()
+	}
+}
+
+func trimBrackets(s string) string {
+	s = strings.TrimPrefix(s, "[")
+	return strings.TrimSuffix(s, "]")
 }
 
 func readAsCSV(val string) ([]string, error) {
@@ -1123,7 +1571,10 @@ func readAsCSV(val string) ([]string, error) {
 // IsSet is case-insensitive for a key.
 func IsSet(key string) bool { return v.IsSet(key) }
 func (v *Viper) IsSet(key string) bool {
-	lcaseKey := strings.ToLower(key)
+	if v == nil {
+		return false
+	}
+	lcaseKey := v.normalizeKey(key)
 	val := v.find(lcaseKey)
 	return val != nil
 }
@@ -1143,15 +1594,60 @@ func (v *Viper) SetEnvKeyReplacer(r *strings.Replacer) {
 	v.envKeyReplacer = r
 }
 
+// SetEnvKeyMapper sets a function translating environment variable names
+// into config keys when AutomaticEnv is on. The mapper is handed every name
+// in the environment (e.g. "APP_DB__HOST") and returns the key it should
+// populate (e.g. "db.host"), or "" to ignore the variable. The returned key is
+// used verbatim: Viper neither lower-cases it nor applies the env prefix or
+// key replacer, so the mapper has full control over nesting, casing and
+// filtering.
+// Passing nil restores the default key to env name translation.
+func SetEnvKeyMapper(mapper func(envName string) string) { v.SetEnvKeyMapper(mapper) }
+func (v *Viper) SetEnvKeyMapper(mapper func(envName string) string) {
+	v.envKeyMapper = mapper
+}
+
+// mappedEnv returns the environment translated by the env key mapper,
+// as a map of config keys to environment variable names.
+func (v *Viper) mappedEnv() map[string]string {
+	m := map[string]string{}
+	if v.envKeyMapper == nil {
+		return m
+	}
+	for _, kv := range os.Environ() {
+		name := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			name = kv[:i]
+		}
+		if key := v.envKeyMapper(name); key != "" {
+			m[v.normalizeKey(key)] = name
+		}
+	}
+	return m
+}
+
+// mappedEnvKeys returns the keys populated through the env key mapper for
+// variables that are actually set.
+func (v *Viper) mappedEnvKeys() map[string]interface{} {
+	m := map[string]interface{}{}
+	for key, envName := range v.mappedEnv() {
+		if _, ok := v.lookupEnv(envName); ok {
+			m[key] = envName
+		}
+	}
+	return m
+}
+
 // RegisterAlias creates an alias that provides another accessor for the same key.
 // This enables one to change a name without breaking the application.
 func RegisterAlias(alias string, key string) { v.RegisterAlias(alias, key) }
 func (v *Viper) RegisterAlias(alias string, key string) {
-	v.registerAlias(alias, strings.ToLower(key))
+	v.registerAlias(alias, v.normalizeKey(key))
 }
 
 func (v *Viper) registerAlias(alias string, key string) {
-	alias = strings.ToLower(alias)
+	alias = v.normalizeKey(alias)
+	v.mu.Lock()
 	if alias != key && alias != v.realKey(key) {
 		_, exists := v.aliases[alias]
 
@@ -1170,16 +1666,23 @@ func (v *Viper) registerAlias(alias string, key string) {
 			if val, ok := v.defaults[alias]; ok {
 				delete(v.defaults, alias)
 				v.defaults[key] = val
+				if o, ok := v.defaultOrigins[alias]; ok {
+					delete(v.defaultOrigins, alias)
+					v.defaultOrigins[key] = o
+				}
 			}
 			if val, ok := v.override[alias]; ok {
 				delete(v.override, alias)
 				v.override[key] = val
 			}
 			v.aliases[alias] = key
+			v.unlockChanged()
+			return
 		}
 	} else {
 		jww.WARN.Println("Creating circular reference alias", alias, key, v.realKey(key))
 	}
+	v.mu.Unlock()
 }
 
 func (v *Viper) realKey(key string) string {
@@ -1194,6 +1697,12 @@ func (v *Viper) realKey(key string) string {
 // InConfig checks to see if the given key (or an alias) is in the config file.
 func InConfig(key string) bool { return v.InConfig(key) }
 func (v *Viper) InConfig(key string) bool {
+	if v == nil {
+		return false
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	// if the requested key is an alias, then return the proper key
 	key = v.realKey(key)
 
@@ -1206,70 +1715,199 @@ func (v *Viper) InConfig(key string) bool {
 // Default only used when no value is provided by the user via flag, config or ENV.
 func SetDefault(key string, value interface{}) { v.SetDefault(key, value) }
 func (v *Viper) SetDefault(key string, value interface{}) {
+	if err := v.setDefault(key, value, callerOrigin()); err != nil {
+		jww.ERROR.Println(err)
+	}
+}
+
+func (v *Viper) setDefault(key string, value interface{}, origin defaultOrigin) error {
 	// If alias passed in, then set the proper default
-	key = v.realKey(strings.ToLower(key))
-	value = toCaseInsensitiveValue(value)
+	key = v.lockedRealKey(v.normalizeKey(key))
+	if err := v.Authorize(ActionSetDefault, key); err != nil {
+		return err
+	}
+	value = v.toCaseInsensitiveValue(value)
 
 	path := strings.Split(key, v.keyDelim)
-	lastKey := strings.ToLower(path[len(path)-1])
-	deepestMap := deepSearch(v.defaults, path[0:len(path)-1])
-
-	// set innermost value
-	deepestMap[lastKey] = value
+	auditing := v.auditing()
+	v.mu.Lock()
+	var old interface{}
+	if auditing {
+		old = v.searchMap(v.defaults, path)
+	}
+	if err := v.setInLayer(v.defaults, path, value, nil, nil); err != nil {
+		v.mu.Unlock()
+		return err
+	}
+	v.recordDefaultOrigin(key, origin)
+	v.unlockChanged()
+	if auditing {
+		v.recordAudit(nil, AuditRecord{Operation: AuditSetDefault, Key: key, OldValue: old, NewValue: value})
+	}
+	return nil
 }
 
 // Set sets the value for the key in the override register.
 // Set is case-insensitive for a key.
 // Will be used instead of values obtained via
 // flags, config file, ENV, default, or key/value store.
+// Values rejected by a constraint or the Authorizer are logged and ignored,
+// see SetE.
 func Set(key string, value interface{}) { v.Set(key, value) }
 func (v *Viper) Set(key string, value interface{}) {
+	if err := v.SetE(key, value); err != nil {
+		jww.ERROR.Println(err)
+	}
+}
+
+// SetE behaves like Set, but returns an error instead of logging it if the
+// value is rejected by a constraint registered through AddConstraint or by
+// the Authorizer, or if key addresses an item out of range of a slice (see
+// SliceIndexError).
+func SetE(key string, value interface{}) error { return v.SetE(key, value) }
+func (v *Viper) SetE(key string, value interface{}) error {
+	return v.setE(key, value, nil)
+}
+
+// setE stores an override, recording actor in its AuditRecord.
+func (v *Viper) setE(key string, value interface{}, actor map[string]string) error {
+	key, err := v.deprecatedKey(v.normalizeKey(key))
+	if err != nil {
+		return err
+	}
 	// If alias passed in, then set the proper override
-	key = v.realKey(strings.ToLower(key))
-	value = toCaseInsensitiveValue(value)
+	key = v.lockedRealKey(key)
+	if err := v.Authorize(ActionSet, key); err != nil {
+		return err
+	}
+	value = v.toCaseInsensitiveValue(value)
+	if err := errorList(v.checkConstraints(key, value)); err != nil {
+		return err
+	}
 
 	path := strings.Split(key, v.keyDelim)
-	lastKey := strings.ToLower(path[len(path)-1])
-	deepestMap := deepSearch(v.override, path[0:len(path)-1])
-
-	// set innermost value
-	deepestMap[lastKey] = value
+	seedPath, seed := v.sliceSeed(path)
+	auditing := v.auditing()
+	v.mu.Lock()
+	var old interface{}
+	if auditing {
+		old = v.searchMap(v.override, path)
+	}
+	if err := v.setInLayer(v.override, path, value, seedPath, seed); err != nil {
+		v.mu.Unlock()
+		return err
+	}
+	v.unlockChanged()
+	if auditing {
+		v.recordAudit(actor, AuditRecord{Operation: AuditSet, Key: key, OldValue: old, NewValue: value})
+	}
+	return nil
 }
 
 // ReadInConfig will discover and load the configuration file from disk
 // and key/value stores, searching in one of the defined paths.
 func ReadInConfig() error { return v.ReadInConfig() }
-func (v *Viper) ReadInConfig() error {
+func (v *Viper) ReadInConfig() error { return v.ReadInConfigWithOptions() }
+
+// ReadInConfigWithOptions is ReadInConfig with options, e.g. ValidateOnRead,
+// which also apply to the re-reads of WatchConfig.
+func ReadInConfigWithOptions(opts ...ReadOption) error { return v.ReadInConfigWithOptions(opts...) }
+func (v *Viper) ReadInConfigWithOptions(opts ...ReadOption) (err error) {
+	span := v.startSpan(SpanReadInConfig, nil)
+	before := v.historyBefore()
+	defer func() {
+		v.endConfigSpan(span, err, false)
+		v.recordReload(AuditRead, v.configFilesSource(v.configFile), before, err)
+	}()
+
+	c := readConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	v.mu.Lock()
+	v.readConfig = c
+	v.mu.Unlock()
+
+	if err := v.readInConfig(); err != nil {
+		return err
+	}
+	if c.validate {
+		return v.Validate()
+	}
+	return nil
+}
+
+func (v *Viper) readInConfig() error {
 	jww.INFO.Println("Attempting to read in config file")
+	if v.layeredConfig() {
+		return v.readLayeredConfig()
+	}
 	filename, err := v.getConfigFile()
 	if err != nil {
 		return err
 	}
+	if v.configBundle {
+		return v.readConfigBundle(filename)
+	}
 
 	if !stringInSlice(v.getConfigType(), SupportedExts) {
 		return UnsupportedConfigError(v.getConfigType())
 	}
 
 	jww.DEBUG.Println("Reading file: ", filename)
-	file, err := afero.ReadFile(v.fs, filename)
+	file, release, err := v.readConfigFileDiscarded(filename, v.getConfigType())
 	if err != nil {
 		return err
 	}
+	defer release()
 
 	config := make(map[string]interface{})
 
-	err = v.unmarshalReader(bytes.NewReader(file), config)
+	err = v.unmarshalBytesAs(file, config, v.getConfigType())
 	if err != nil {
 		return err
 	}
+	if v.readOptions().strict {
+		if err := v.checkStrict(config, filename, file, v.getConfigType()); err != nil {
+			return err
+		}
+	}
+	if err := v.applyLoadTransforms(config); err != nil {
+		return err
+	}
 
+	v.mu.Lock()
+	old := v.config
 	v.config = config
+	v.configSource = &configSource{path: filename, data: file}
+	if v.readConfig.discardSource {
+		v.configSource, v.properties = nil, nil
+	}
+	v.recordConfigFile(filename, v.getConfigType(), file, false)
+	v.recordConfigOrigins(config, filename, false)
+	v.unlockChanged()
+	v.warnShadowedKeys()
+	if v.auditing() {
+		v.auditLayer(AuditRead, filename, old, config)
+	}
 	return nil
 }
 
 // MergeInConfig merges a new configuration with an existing config.
 func MergeInConfig() error { return v.MergeInConfig() }
-func (v *Viper) MergeInConfig() error {
+func (v *Viper) MergeInConfig() error { return v.MergeInConfigWithOptions() }
+
+// MergeInConfigWithOptions is MergeInConfig with the options of
+// MergeConfigMapWithOptions.
+func MergeInConfigWithOptions(opts ...MergeOption) error { return v.MergeInConfigWithOptions(opts...) }
+func (v *Viper) MergeInConfigWithOptions(opts ...MergeOption) (err error) {
+	span := v.startSpan(SpanMergeInConfig, nil)
+	before := v.historyBefore()
+	defer func() {
+		v.endConfigSpan(span, err, true)
+		v.recordReload(AuditMerge, v.configFilesSource(v.configFile), before, err)
+	}()
+
 	jww.INFO.Println("Attempting to merge in config file")
 	filename, err := v.getConfigFile()
 	if err != nil {
@@ -1280,41 +1918,160 @@ func (v *Viper) MergeInConfig() error {
 		return UnsupportedConfigError(v.getConfigType())
 	}
 
-	file, err := afero.ReadFile(v.fs, filename)
+	file, err := v.readConfigFile(filename)
 	if err != nil {
 		return err
 	}
 
-	return v.MergeConfig(bytes.NewReader(file))
+	cfg := make(map[string]interface{})
+	if err := v.unmarshalReader(bytes.NewReader(file), cfg); err != nil {
+		return err
+	}
+	c := newMergeConfig(opts...)
+	c.source, c.data = filename, file
+	conflicts, err := v.mergeConfigMap(cfg, c)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.recordConfigFile(filename, v.getConfigType(), file, true)
+	v.recordConfigOrigins(cfg, filename, true)
+	v.mu.Unlock()
+	return errorList(conflicts)
 }
 
 // ReadConfig will read a configuration file, setting existing keys to nil if the
 // key does not exist in the file.
 func ReadConfig(in io.Reader) error { return v.ReadConfig(in) }
 func (v *Viper) ReadConfig(in io.Reader) error {
-	v.config = make(map[string]interface{})
-	return v.unmarshalReader(in, v.config)
+	config := make(map[string]interface{})
+	if err := v.unmarshalReader(in, config); err != nil {
+		return err
+	}
+	if err := v.applyLoadTransforms(config); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	old := v.config
+	v.config = config
+	v.configSource = nil
+	v.recordConfigOrigins(config, "", false)
+	v.unlockChanged()
+	v.warnShadowedKeys()
+	if v.auditing() {
+		v.auditLayer(AuditRead, "", old, config)
+	}
+	return nil
 }
 
 // MergeConfig merges a new configuration with an existing config.
 func MergeConfig(in io.Reader) error { return v.MergeConfig(in) }
-func (v *Viper) MergeConfig(in io.Reader) error {
+func (v *Viper) MergeConfig(in io.Reader) error { return v.MergeConfigWithOptions(in) }
+
+// MergeConfigWithOptions is MergeConfig with the options of
+// MergeConfigMapWithOptions.
+func MergeConfigWithOptions(in io.Reader, opts ...MergeOption) error {
+	return v.MergeConfigWithOptions(in, opts...)
+}
+func (v *Viper) MergeConfigWithOptions(in io.Reader, opts ...MergeOption) error {
 	cfg := make(map[string]interface{})
 	if err := v.unmarshalReader(in, cfg); err != nil {
 		return err
 	}
-	return v.MergeConfigMap(cfg)
+	return v.MergeConfigMapWithOptions(cfg, opts...)
 }
 
 // MergeConfigMap merges the configuration from the map given with an existing config.
 // Note that the map given may be modified.
+// Nothing is merged if a value is rejected by a constraint, see AddConstraint.
+// Maps are merged recursively and slices replaced; null values are ignored.
+// Values conflicting with the structure of the existing config, e.g. a string
+// merged into a map, are logged and skipped. See MergeConfigMapWithOptions to
+// change these.
 func MergeConfigMap(cfg map[string]interface{}) error { return v.MergeConfigMap(cfg) }
 func (v *Viper) MergeConfigMap(cfg map[string]interface{}) error {
-	if v.config == nil {
-		v.config = make(map[string]interface{})
+	return v.MergeConfigMapWithOptions(cfg)
+}
+
+// MergeConfigMapWithOptions is MergeConfigMap with options: slices are
+// appended if MergeWithArrayAppend is given, null values delete their key if
+// MergeWithNullDelete is given, and conflicting values are returned as errors
+// if MergeAtomic or MergeReportConflicts is given.
+func MergeConfigMapWithOptions(cfg map[string]interface{}, opts ...MergeOption) error {
+	return v.MergeConfigMapWithOptions(cfg, opts...)
+}
+func (v *Viper) MergeConfigMapWithOptions(cfg map[string]interface{}, opts ...MergeOption) error {
+	conflicts, err := v.mergeConfigMap(cfg, newMergeConfig(opts...))
+	if err != nil {
+		return err
+	}
+	return errorList(conflicts)
+}
+
+// mergeConfigMap merges cfg into the config, returning the conflicting
+// values that were skipped. Nothing is merged if an error is returned.
+func (v *Viper) mergeConfigMap(cfg map[string]interface{}, c *mergeConfig) ([]error, error) {
+	v.insensitiviseMap(cfg)
+	c.caseSensitive = v.caseSensitive
+	if c.strict {
+		if err := v.checkStrict(cfg, c.source, c.data, v.getConfigType()); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.applyLoadTransforms(cfg); err != nil {
+		return nil, err
+	}
+	if err := errorList(v.checkConstraints("", cfg)); err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	// merge into a copy, the nested maps of the config may be in use
+	old := v.config
+	config := copyMap(v.config, false)
+	var conflicts []error
+	if c.conflicts != mergeSkipConflicts {
+		conflicts = v.mergeConflicts(cfg, config, "", c)
+		if c.conflicts == mergeAtomic && len(conflicts) > 0 {
+			v.mu.Unlock()
+			return nil, errorList(conflicts)
+		}
+	}
+	mergeMapsWith(cfg, config, nil, c)
+	v.config = config
+	v.recordConfigOrigins(cfg, "", true)
+	v.unlockChanged()
+	v.warnShadowedKeys()
+	if v.auditing() {
+		v.auditLayer(AuditMerge, c.source, old, config)
+	}
+	return conflicts, nil
+}
+
+// OnLoadTransform registers a function that is run on every configuration map
+// loaded by ReadInConfig, ReadConfig, MergeInConfig, MergeConfig,
+// MergeConfigMap and ReadRemoteConfig, before the values become visible
+// through Get. Transforms may modify the map in place (e.g. trim whitespace,
+// expand paths or move deprecated keys) and run in registration order.
+// If a transform returns an error, loading stops, the error is returned and
+// the previous configuration is kept.
+func OnLoadTransform(transform func(map[string]interface{}) error) { v.OnLoadTransform(transform) }
+func (v *Viper) OnLoadTransform(transform func(map[string]interface{}) error) {
+	if transform != nil {
+		v.loadTransforms = append(v.loadTransforms, transform)
+	}
+}
+
+func (v *Viper) applyLoadTransforms(cfg map[string]interface{}) error {
+	if err := v.migrateDeprecated(cfg); err != nil {
+		return err
+	}
+	for _, transform := range v.loadTransforms {
+		if err := transform(cfg); err != nil {
+			return err
+		}
 	}
-	insensitiviseMap(cfg)
-	mergeMaps(cfg, v.config, nil)
 	return nil
 }
 
@@ -1353,6 +2110,12 @@ func (v *Viper) SafeWriteConfigAs(filename string) error {
 func writeConfig(filename string, force bool) error { return v.writeConfig(filename, force) }
 func (v *Viper) writeConfig(filename string, force bool) error {
 	jww.INFO.Println("Attempting to write configuration to file.")
+	if err := v.Authorize(ActionWriteConfig, filename); err != nil {
+		return err
+	}
+	if configURL(filename) != nil {
+		return fmt.Errorf("Can't write config to remote file %s.", filename)
+	}
 	ext := filepath.Ext(filename)
 	if len(ext) <= 1 {
 		return fmt.Errorf("Filename: %s requires valid extension.", filename)
@@ -1361,9 +2124,11 @@ func (v *Viper) writeConfig(filename string, force bool) error {
 	if !stringInSlice(configType, SupportedExts) {
 		return UnsupportedConfigError(configType)
 	}
+	v.mu.Lock()
 	if v.config == nil {
 		v.config = make(map[string]interface{})
 	}
+	v.mu.Unlock()
 	var flags int
 	if force == true {
 		flags = os.O_CREATE | os.O_TRUNC | os.O_WRONLY
@@ -1374,29 +2139,97 @@ func (v *Viper) writeConfig(filename string, force bool) error {
 			return fmt.Errorf("File: %s exists. Use WriteConfig to overwrite.", filename)
 		}
 	}
+	b, err := v.renderConfig(filename, configType)
+	if err != nil {
+		return err
+	}
 	f, err := v.fs.OpenFile(filename, flags, v.configPermissions)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	if err := v.marshalWriter(f, configType); err != nil {
-		return err
+	if _, err := f.Write(b); err != nil {
+		return ConfigMarshalError{err}
 	}
-
 	return f.Sync()
 }
 
+// RenderConfig returns the configuration encoded in format, exactly as
+// WriteConfig would write it to a config file of that format, without
+// writing anything. If the config file of the instance has that format, its
+// layout and comments are preserved like by WriteConfig.
+func RenderConfig(format string) ([]byte, error) { return v.RenderConfig(format) }
+func (v *Viper) RenderConfig(format string) ([]byte, error) {
+	if !stringInSlice(format, SupportedExts) {
+		return nil, UnsupportedConfigError(format)
+	}
+	filename := ""
+	if f, err := v.getConfigFile(); err == nil && filepath.Ext(f) == "."+format {
+		filename = f
+	}
+	return v.renderConfig(filename, format)
+}
+
+// renderConfig encodes the configuration as written to filename, patching
+// the config file it was read from if possible.
+func (v *Viper) renderConfig(filename, configType string) ([]byte, error) {
+	if v.writeOrder == WriteOrderSource {
+		if patched, ok := v.patchedConfigFile(filename, configType); ok {
+			return patched, nil
+		}
+	}
+	var buf bytes.Buffer
+	if err := v.marshalWriter(&buf, configType); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // Unmarshal a Reader into a map.
 // Should probably be an unexported function.
 func unmarshalReader(in io.Reader, c map[string]interface{}) error {
 	return v.unmarshalReader(in, c)
 }
 func (v *Viper) unmarshalReader(in io.Reader, c map[string]interface{}) error {
-	buf := new(bytes.Buffer)
+	return v.unmarshalReaderAs(in, c, v.getConfigType())
+}
+
+// unmarshalReaderAs reads in into c, parsing it as the given config type.
+func (v *Viper) unmarshalReaderAs(in io.Reader, c map[string]interface{}, configType string) error {
+	buf := readBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
 	buf.ReadFrom(in)
+	err := v.unmarshalBytesAs(buf.Bytes(), c, configType)
+	// the builtin formats don't retain the data they parse
+	if _, ok := codecFor(configType); !ok {
+		readBuffers.Put(buf)
+	}
+	return err
+}
 
-	switch strings.ToLower(v.getConfigType()) {
+// unmarshalBytesAs behaves like unmarshalReaderAs, parsing data.
+func (v *Viper) unmarshalBytesAs(data []byte, c map[string]interface{}, configType string) error {
+	data, err := decompress(data)
+	if err != nil {
+		return ConfigParseError{err}
+	}
+	buf := bytes.NewBuffer(data)
+
+	if codec, ok := codecFor(configType); ok {
+		if err := codec.Decode(buf.Bytes(), c); err != nil {
+			return ConfigParseError{err}
+		}
+		v.insensitiviseMap(c)
+		return nil
+	}
+
+	if data, err = toUTF8(data); err != nil {
+		return ConfigParseError{err}
+	}
+	buf = bytes.NewBuffer(data)
+
+	switch strings.ToLower(configType) {
 	case "yaml", "yml":
 		if err := yaml.Unmarshal(buf.Bytes(), &c); err != nil {
 			return ConfigParseError{err}
@@ -1427,7 +2260,7 @@ func (v *Viper) unmarshalReader(in io.Reader, c map[string]interface{}) error {
 		}
 
 	case "dotenv", "env":
-		env, err := gotenv.StrictParse(buf)
+		env, err := parseDotenv(buf.Bytes())
 		if err != nil {
 			return ConfigParseError{err}
 		}
@@ -1435,24 +2268,31 @@ func (v *Viper) unmarshalReader(in io.Reader, c map[string]interface{}) error {
 			c[k] = v
 		}
 
+	case "ini":
+		if err := v.unmarshalIni(buf.Bytes(), c); err != nil {
+			return ConfigParseError{err}
+		}
+
 	case "properties", "props", "prop":
-		v.properties = properties.NewProperties()
-		var err error
-		if v.properties, err = properties.Load(buf.Bytes(), properties.UTF8); err != nil {
+		props, err := properties.Load(buf.Bytes(), properties.UTF8)
+		if err != nil {
 			return ConfigParseError{err}
 		}
-		for _, key := range v.properties.Keys() {
-			value, _ := v.properties.Get(key)
+		v.mu.Lock()
+		v.properties = props
+		v.mu.Unlock()
+		for _, key := range props.Keys() {
+			value, _ := props.Get(key)
 			// recursively build nested maps
 			path := strings.Split(key, ".")
-			lastKey := strings.ToLower(path[len(path)-1])
+			lastKey := v.normalizeKey(path[len(path)-1])
 			deepestMap := deepSearch(c, path[0:len(path)-1])
 			// set innermost value
 			deepestMap[lastKey] = value
 		}
 	}
 
-	insensitiviseMap(c)
+	v.insensitiviseMap(c)
 	return nil
 }
 
@@ -1460,15 +2300,26 @@ func (v *Viper) unmarshalReader(in io.Reader, c map[string]interface{}) error {
 func marshalWriter(f afero.File, configType string) error {
 	return v.marshalWriter(f, configType)
 }
-func (v *Viper) marshalWriter(f afero.File, configType string) error {
-	c := v.AllSettings()
+func (v *Viper) marshalWriter(f io.Writer, configType string) error {
+	c := v.outputSettings()
+	if codec, ok := codecFor(configType); ok {
+		b, err := codec.Encode(c)
+		if err != nil {
+			return ConfigMarshalError{err}
+		}
+		if _, err := f.Write(b); err != nil {
+			return ConfigMarshalError{err}
+		}
+		return nil
+	}
+
 	switch configType {
 	case "json":
 		b, err := json.MarshalIndent(c, "", "  ")
 		if err != nil {
 			return ConfigMarshalError{err}
 		}
-		_, err = f.WriteString(string(b))
+		_, err = io.WriteString(f, string(b))
 		if err != nil {
 			return ConfigMarshalError{err}
 		}
@@ -1485,12 +2336,17 @@ func (v *Viper) marshalWriter(f afero.File, configType string) error {
 		}
 
 	case "prop", "props", "properties":
-		if v.properties == nil {
-			v.properties = properties.NewProperties()
+		// write a copy, the properties read may be written concurrently
+		p := properties.NewProperties()
+		v.mu.RLock()
+		if v.properties != nil && v.writeOrder != WriteOrderSorted {
+			p.Merge(v.properties)
 		}
-		p := v.properties
-		for _, key := range v.AllKeys() {
-			_, _, err := p.Set(key, v.GetString(key))
+		v.mu.RUnlock()
+		keys := v.AllKeys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			_, _, err := p.Set(key, cast.ToString(v.outputValue(key)))
 			if err != nil {
 				return ConfigMarshalError{err}
 			}
@@ -1502,39 +2358,73 @@ func (v *Viper) marshalWriter(f afero.File, configType string) error {
 
 	case "dotenv", "env":
 		lines := []string{}
-		for _, key := range v.AllKeys() {
+		keys := v.AllKeys()
+		sort.Strings(keys)
+		for _, key := range keys {
 			envName := strings.ToUpper(strings.Replace(key, ".", "_", -1))
-			val := v.Get(key)
-			lines = append(lines, fmt.Sprintf("%v=%v", envName, val))
+			val := v.outputValue(key)
+			lines = append(lines, envName+"="+dotenvValue(fmt.Sprint(val)))
 		}
 		s := strings.Join(lines, "\n")
-		if _, err := f.WriteString(s); err != nil {
+		if _, err := io.WriteString(f, s); err != nil {
+			return ConfigMarshalError{err}
+		}
+
+	case "ini":
+		if _, err := f.Write(v.marshalIniWithComments(c, v.writeComments())); err != nil {
 			return ConfigMarshalError{err}
 		}
 
 	case "toml":
+		if comments := v.writeComments(); len(comments) > 0 {
+			lines, err := v.tomlExample(nil, c, comments)
+			if err == nil {
+				_, err = io.WriteString(f, strings.Join(lines, "\n")+"\n")
+				if err != nil {
+					return ConfigMarshalError{err}
+				}
+				return nil
+			}
+			jww.WARN.Println("Unable to write the comments of the TOML config:", err)
+		}
 		t, err := toml.TreeFromMap(c)
 		if err != nil {
 			return ConfigMarshalError{err}
 		}
 		s := t.String()
-		if _, err := f.WriteString(s); err != nil {
+		if _, err := io.WriteString(f, s); err != nil {
 			return ConfigMarshalError{err}
 		}
 
 	case "yaml", "yml":
+		if comments := v.writeComments(); len(comments) > 0 {
+			lines, err := v.yamlExample(nil, c, comments)
+			if err != nil {
+				return err
+			}
+			if _, err = io.WriteString(f, strings.Join(lines, "\n")+"\n"); err != nil {
+				return ConfigMarshalError{err}
+			}
+			return nil
+		}
 		b, err := yaml.Marshal(c)
 		if err != nil {
 			return ConfigMarshalError{err}
 		}
-		if _, err = f.WriteString(string(b)); err != nil {
+		if _, err = io.WriteString(f, string(b)); err != nil {
 			return ConfigMarshalError{err}
 		}
 	}
 	return nil
 }
 
-func keyExists(k string, m map[string]interface{}) string {
+func keyExists(k string, m map[string]interface{}, caseSensitive bool) string {
+	if _, ok := m[k]; ok {
+		return k
+	}
+	if caseSensitive {
+		return ""
+	}
 	lk := strings.ToLower(k)
 	for mk := range m {
 		lmk := strings.ToLower(mk)
@@ -1577,8 +2467,31 @@ func castMapFlagToMapInterface(src map[string]FlagValue) map[string]interface{}
 // `map[string]interface{}` instead.
 func mergeMaps(
 	src, tgt map[string]interface{}, itgt map[interface{}]interface{}) {
+	mergeMapsWith(src, tgt, itgt, &mergeConfig{})
+}
+
+// mergeMaps merges src into tgt, matching keys as the instance does, see
+// KeysCaseSensitive.
+func (v *Viper) mergeMaps(src, tgt map[string]interface{}) {
+	mergeMapsWith(src, tgt, nil, &mergeConfig{caseSensitive: v.caseSensitive})
+}
+
+// mergeMapsWith behaves like mergeMaps, following the given merge options
+// for slices and nil values.
+func mergeMapsWith(
+	src, tgt map[string]interface{}, itgt map[interface{}]interface{}, c *mergeConfig) {
 	for sk, sv := range src {
-		tk := keyExists(sk, tgt)
+		tk := keyExists(sk, tgt, c.caseSensitive)
+		if sv == nil && c.nullDeletes {
+			if tk != "" {
+				jww.TRACE.Printf("deleting key=%s", tk)
+				delete(tgt, tk)
+				if itgt != nil {
+					delete(itgt, tk)
+				}
+			}
+			continue
+		}
 		if tk == "" {
 			jww.TRACE.Printf("tk=\"\", tgt[%s]=%v", sk, sv)
 			tgt[sk] = sv
@@ -1598,6 +2511,15 @@ func mergeMaps(
 			continue
 		}
 
+		if c.arrays == mergeArrayAppend && isSlice(sv) && isSlice(tv) {
+			jww.TRACE.Printf("appending slices key=%s", sk)
+			tgt[tk] = appendSlices(tv, sv)
+			if itgt != nil {
+				itgt[tk] = tgt[tk]
+			}
+			continue
+		}
+
 		svType := reflect.TypeOf(sv)
 		tvType := reflect.TypeOf(tv)
 		if svType != tvType {
@@ -1616,10 +2538,10 @@ func mergeMaps(
 			tsv := sv.(map[interface{}]interface{})
 			ssv := castToMapStringInterface(tsv)
 			stv := castToMapStringInterface(ttv)
-			mergeMaps(ssv, stv, ttv)
+			mergeMapsWith(ssv, stv, ttv, c)
 		case map[string]interface{}:
 			jww.TRACE.Printf("merging maps")
-			mergeMaps(sv.(map[string]interface{}), ttv, nil)
+			mergeMapsWith(sv.(map[string]interface{}), ttv, nil, c)
 		default:
 			jww.TRACE.Printf("setting value")
 			tgt[tk] = sv
@@ -1648,40 +2570,115 @@ func (v *Viper) WatchRemoteConfigOnChannel() error {
 
 // Retrieve the first found remote configuration.
 func (v *Viper) getKeyValueConfig() error {
-	if RemoteConfig == nil {
+	if RemoteConfig == nil && len(remoteConfigProviders) == 0 {
 		return RemoteConfigError("Enable the remote features by doing a blank import of the viper/remote package: '_ github.com/spf13/viper/remote'")
 	}
+	if !v.isRemoteLeader() {
+		// followers receive the configuration through ReceiveRemoteConfig
+		return nil
+	}
 
+	notCompiled := false
 	for _, rp := range v.remoteProviders {
-		val, err := v.getRemoteConfig(rp)
+		payload, err := v.getRemoteConfig(rp)
 		if err != nil {
+			notCompiled = notCompiled || err == ErrProviderNotCompiled
 			continue
 		}
-		v.kvstore = val
-		return nil
+		return v.broadcastRemoteConfig(payload)
+	}
+	if notCompiled {
+		return ErrProviderNotCompiled
 	}
 	return RemoteConfigError("No Files Found")
 }
 
-func (v *Viper) getRemoteConfig(provider RemoteProvider) (map[string]interface{}, error) {
-	reader, err := RemoteConfig.Get(provider)
+func (v *Viper) getRemoteConfig(provider RemoteProvider) (payload []byte, err error) {
+	span := v.startSpan(SpanRemoteFetch, remoteSpanAttributes(provider))
+	before := v.historyBefore()
+	defer func() {
+		span.End(err)
+		v.recordReload(AuditRemote, remoteAuditSource(provider), before, err)
+	}()
+
+	factory, err := remoteConfigFactory(provider)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := factory.Get(provider)
 	if err != nil {
 		return nil, err
 	}
-	err = v.unmarshalReader(reader, v.kvstore)
-	return v.kvstore, err
+	return v.readRemoteConfig(provider, reader)
+}
+
+// readRemoteConfig reads a configuration fetched from a remote provider into
+// the key/value store, returning the raw payload. provider is nil for
+// payloads received from the remote leader.
+func (v *Viper) readRemoteConfig(provider RemoteProvider, in io.Reader) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(in); err != nil {
+		return nil, err
+	}
+	payload := buf.Bytes()
+
+	v.mu.RLock()
+	kvstore := copyMap(v.kvstore, false)
+	v.mu.RUnlock()
+	if err := v.unmarshalReader(bytes.NewReader(payload), kvstore); err != nil {
+		return payload, err
+	}
+	if err := v.applyLoadTransforms(kvstore); err != nil {
+		return payload, err
+	}
+	v.markRemoteSecrets(provider, payload)
+	v.mu.Lock()
+	old := v.kvstore
+	v.kvstore = kvstore
+	v.kvstoreProvider = provider
+	v.unlockChanged()
+	if v.auditing() {
+		v.auditLayer(AuditRemote, remoteAuditSource(provider), old, kvstore)
+	}
+	v.dispatchUnmarshalers()
+	return payload, nil
 }
 
 // Retrieve the first found remote configuration.
 func (v *Viper) watchKeyValueConfigOnChannel() error {
+	if !v.isRemoteLeader() {
+		return nil
+	}
+
 	for _, rp := range v.remoteProviders {
-		respc, _ := RemoteConfig.WatchChannel(rp)
+		factory, err := remoteConfigFactory(rp)
+		if err != nil {
+			return err
+		}
+		respc, _ := factory.WatchChannel(rp)
 		//Todo: Add quit channel
 		go func(rc <-chan *RemoteResponse) {
 			for {
-				b := <-rc
+				b, ok := <-rc
+				if !ok {
+					return
+				}
+				if b.Error != nil {
+					jww.ERROR.Printf("error watching remote config: %v", b.Error)
+					continue
+				}
+				span := v.startSpan(SpanRemoteFetch, remoteSpanAttributes(rp))
+				before := v.historyBefore()
 				reader := bytes.NewReader(b.Value)
-				v.unmarshalReader(reader, v.kvstore)
+				payload, err := v.readRemoteConfig(rp, reader)
+				span.End(err)
+				v.recordReload(AuditRemote, remoteAuditSource(rp), before, err)
+				if err == nil && v.isRemoteLeader() {
+					err = v.broadcastRemoteConfig(payload)
+				}
+				if err != nil {
+					jww.ERROR.Printf("error reading remote config: %v", err)
+				}
 			}
 		}(respc)
 		return nil
@@ -1691,39 +2688,105 @@ func (v *Viper) watchKeyValueConfigOnChannel() error {
 
 // Retrieve the first found remote configuration.
 func (v *Viper) watchKeyValueConfig() error {
+	if !v.isRemoteLeader() {
+		return nil
+	}
+
+	notCompiled := false
 	for _, rp := range v.remoteProviders {
-		val, err := v.watchRemoteConfig(rp)
+		payload, err := v.watchRemoteConfig(rp)
 		if err != nil {
+			notCompiled = notCompiled || err == ErrProviderNotCompiled
 			continue
 		}
-		v.kvstore = val
-		return nil
+		return v.broadcastRemoteConfig(payload)
+	}
+	if notCompiled {
+		return ErrProviderNotCompiled
 	}
 	return RemoteConfigError("No Files Found")
 }
 
-func (v *Viper) watchRemoteConfig(provider RemoteProvider) (map[string]interface{}, error) {
-	reader, err := RemoteConfig.Watch(provider)
+func (v *Viper) watchRemoteConfig(provider RemoteProvider) (payload []byte, err error) {
+	span := v.startSpan(SpanRemoteFetch, remoteSpanAttributes(provider))
+	before := v.historyBefore()
+	defer func() {
+		span.End(err)
+		v.recordReload(AuditRemote, remoteAuditSource(provider), before, err)
+	}()
+
+	factory, err := remoteConfigFactory(provider)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := factory.Watch(provider)
 	if err != nil {
 		return nil, err
 	}
-	err = v.unmarshalReader(reader, v.kvstore)
-	return v.kvstore, err
+	return v.readRemoteConfig(provider, reader)
+}
+
+// SetRemoteLeader gates remote configuration fetches behind a leader, to
+// reduce the load on the key/value store when many instances watch the same
+// configuration. isLeader is consulted on every ReadRemoteConfig,
+// WatchRemoteConfig and WatchRemoteConfigOnChannel call: the leader fetches
+// the configuration and hands the raw payload to broadcast, a user-provided
+// transport sending it to its peers; other instances don't contact the remote
+// providers and are fed the payloads through ReceiveRemoteConfig.
+func SetRemoteLeader(isLeader func() bool, broadcast func(payload []byte) error) {
+	v.SetRemoteLeader(isLeader, broadcast)
+}
+func (v *Viper) SetRemoteLeader(isLeader func() bool, broadcast func(payload []byte) error) {
+	v.remoteIsLeader = isLeader
+	v.remoteBroadcast = broadcast
+}
+
+// ReceiveRemoteConfig reads a remote configuration payload broadcast by the
+// leader (see SetRemoteLeader) into the key/value store, as if it had been
+// fetched from the remote provider.
+func ReceiveRemoteConfig(payload []byte) error { return v.ReceiveRemoteConfig(payload) }
+func (v *Viper) ReceiveRemoteConfig(payload []byte) error {
+	_, err := v.readRemoteConfig(nil, bytes.NewReader(payload))
+	return err
+}
+
+func (v *Viper) isRemoteLeader() bool {
+	return v.remoteIsLeader == nil || v.remoteIsLeader()
+}
+
+func (v *Viper) broadcastRemoteConfig(payload []byte) error {
+	if v.remoteBroadcast == nil {
+		return nil
+	}
+	if err := v.remoteBroadcast(payload); err != nil {
+		return RemoteConfigError(fmt.Sprintf("broadcasting remote config: %v", err))
+	}
+	return nil
 }
 
 // AllKeys returns all keys holding a value, regardless of where they are set.
 // Nested keys are returned with a v.keyDelim (= ".") separator
 func AllKeys() []string { return v.AllKeys() }
 func (v *Viper) AllKeys() []string {
+	if v == nil {
+		return []string{}
+	}
 	m := map[string]bool{}
+	v.mu.RLock()
 	// add all paths, by order of descending priority to ensure correct shadowing
 	m = v.flattenAndMergeMap(m, castMapStringToMapInterface(v.aliases), "")
 	m = v.flattenAndMergeMap(m, v.override, "")
 	m = v.mergeFlatMap(m, castMapFlagToMapInterface(v.pflags))
 	m = v.mergeFlatMap(m, castMapStringToMapInterface(v.env))
+	if v.automaticEnvApplied && v.envKeyMapper != nil {
+		m = v.mergeFlatMap(m, v.mappedEnvKeys())
+	}
 	m = v.flattenAndMergeMap(m, v.config, "")
 	m = v.flattenAndMergeMap(m, v.kvstore, "")
+	m = v.flattenAndMergeMap(m, v.profileDefaults(), "")
 	m = v.flattenAndMergeMap(m, v.defaults, "")
+	v.mu.RUnlock()
+	m = v.mountedKeys(m)
 
 	// convert set of paths to list
 	a := []string{}
@@ -1761,7 +2824,7 @@ func (v *Viper) flattenAndMergeMap(shadow map[string]bool, m map[string]interfac
 			m2 = cast.ToStringMap(val)
 		default:
 			// immediate value
-			shadow[strings.ToLower(fullKey)] = true
+			shadow[v.normalizeKey(fullKey)] = true
 			continue
 		}
 		// recursively merge to shadow map
@@ -1787,7 +2850,7 @@ outer:
 			}
 		}
 		// add key
-		shadow[strings.ToLower(k)] = true
+		shadow[v.normalizeKey(k)] = true
 	}
 	return shadow
 }
@@ -1805,7 +2868,7 @@ func (v *Viper) AllSettings() map[string]interface{} {
 			continue
 		}
 		path := strings.Split(k, v.keyDelim)
-		lastKey := strings.ToLower(path[len(path)-1])
+		lastKey := v.normalizeKey(path[len(path)-1])
 		deepestMap := deepSearch(m, path[0:len(path)-1])
 		// set innermost value
 		deepestMap[lastKey] = value
@@ -1826,6 +2889,8 @@ func (v *Viper) SetConfigName(in string) {
 	if in != "" {
 		v.configName = in
 		v.configFile = ""
+		v.configFiles = nil
+		v.configBundle = false
 	}
 }
 
@@ -1853,8 +2918,13 @@ func (v *Viper) getConfigType() string {
 	if err != nil {
 		return ""
 	}
+	cf = configFilePath(cf)
 
 	ext := filepath.Ext(cf)
+	if len(ext) > 1 && isCompressionExt(ext[1:]) {
+		// e.g. config.yaml.gz
+		ext = filepath.Ext(strings.TrimSuffix(cf, ext))
+	}
 
 	if len(ext) > 1 {
 		return ext[1:]
@@ -1882,6 +2952,13 @@ func (v *Viper) searchInPath(in string) (filename string) {
 			jww.DEBUG.Println("Found: ", filepath.Join(in, v.configName+"."+ext))
 			return filepath.Join(in, v.configName+"."+ext)
 		}
+		for _, c := range compressions {
+			file := filepath.Join(in, v.configName+"."+ext+"."+c.ext)
+			if b, _ := exists(v.fs, file); b {
+				jww.DEBUG.Println("Found: ", file)
+				return file
+			}
+		}
 	}
 
 	return ""
@@ -1905,11 +2982,29 @@ func (v *Viper) findConfigFile() (string, error) {
 // purposes.
 func Debug() { v.Debug() }
 func (v *Viper) Debug() {
-	fmt.Printf("Aliases:\n%#v\n", v.aliases)
-	fmt.Printf("Override:\n%#v\n", v.override)
-	fmt.Printf("PFlags:\n%#v\n", v.pflags)
-	fmt.Printf("Env:\n%#v\n", v.env)
-	fmt.Printf("Key/Value Store:\n%#v\n", v.kvstore)
-	fmt.Printf("Config:\n%#v\n", v.config)
-	fmt.Printf("Defaults:\n%#v\n", v.defaults)
+	redact := v.redactingOutput()
+	var out bytes.Buffer
+	v.mu.RLock()
+	var pflags interface{} = v.pflags
+	override, kvstore, config, defaults := v.override, v.kvstore, v.config, v.defaults
+	profile, profileDefaults := v.profiles.active, v.profileDefaults()
+	if redact {
+		pflags = v.redactFlags()
+		override, kvstore = v.redactMapLocked(override, ""), v.redactMapLocked(kvstore, "")
+		config, defaults = v.redactMapLocked(config, ""), v.redactMapLocked(defaults, "")
+		profileDefaults = v.redactMapLocked(profileDefaults, "")
+	}
+	fmt.Fprintf(&out, "Aliases:\n%#v\n", v.aliases)
+	fmt.Fprintf(&out, "Override:\n%#v\n", override)
+	fmt.Fprintf(&out, "PFlags:\n%#v\n", pflags)
+	fmt.Fprintf(&out, "Env:\n%#v\n", v.env)
+	fmt.Fprintf(&out, "Key/Value Store:\n%#v\n", kvstore)
+	fmt.Fprintf(&out, "Config:\n%#v\n", config)
+	if profile != "" {
+		fmt.Fprintf(&out, "Defaults of profile %q:\n%#v\n", profile, profileDefaults)
+	}
+	fmt.Fprintf(&out, "Defaults:\n%#v\n", defaults)
+	fmt.Fprintf(&out, "Default origins:\n%#v\n", v.defaultOriginStrings())
+	v.mu.RUnlock()
+	fmt.Print(out.String())
 }
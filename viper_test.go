@@ -8,9 +8,11 @@ package viper
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -390,6 +392,118 @@ func TestRemotePrecedence(t *testing.T) {
 	Set("newkey", "remote")
 }
 
+type testRemoteConfigFactory struct {
+	payload []byte
+	gets    int
+	// returned by WatchChannel
+	responses chan *RemoteResponse
+}
+
+func (f *testRemoteConfigFactory) Get(rp RemoteProvider) (io.Reader, error) {
+	f.gets++
+	return bytes.NewReader(f.payload), nil
+}
+
+func (f *testRemoteConfigFactory) Watch(rp RemoteProvider) (io.Reader, error) {
+	return f.Get(rp)
+}
+
+func (f *testRemoteConfigFactory) WatchChannel(rp RemoteProvider) (<-chan *RemoteResponse, chan bool) {
+	return f.responses, nil
+}
+
+// registerTestProvider registers factory as the remote provider name for the
+// duration of the test.
+func registerTestProvider(t *testing.T, name string, factory RemoteConfigFactory) {
+	RegisterRemoteConfigProvider(name, factory)
+	t.Cleanup(func() {
+		delete(remoteConfigProviders, name)
+		for i, provider := range SupportedRemoteProviders {
+			if provider == name {
+				SupportedRemoteProviders = append(SupportedRemoteProviders[:i:i], SupportedRemoteProviders[i+1:]...)
+				break
+			}
+		}
+	})
+}
+
+func TestRemoteProviderNotCompiled(t *testing.T) {
+	registerTestProvider(t, "test-compiled", &testRemoteConfigFactory{payload: remoteExample})
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("etcd", "http://127.0.0.1:4001", "/config"))
+	assert.Equal(t, ErrProviderNotCompiled, v.ReadRemoteConfig())
+	assert.Equal(t, ErrProviderNotCompiled, v.WatchRemoteConfig())
+
+	// the providers compiled in are still read
+	require.NoError(t, v.AddRemoteProvider("test-compiled", "localhost", "/config"))
+	require.NoError(t, v.ReadRemoteConfig())
+	assert.Equal(t, "remote", v.Get("newkey"))
+}
+
+func TestRemoteLeader(t *testing.T) {
+	factory := &testRemoteConfigFactory{payload: remoteExample}
+	registerTestProvider(t, "test-leader", factory)
+
+	var broadcast [][]byte
+	newInstance := func(leader bool) *Viper {
+		v := New()
+		v.SetConfigType("json")
+		require.NoError(t, v.AddRemoteProvider("test-leader", "localhost", "/config"))
+		v.SetRemoteLeader(func() bool { return leader }, func(payload []byte) error {
+			broadcast = append(broadcast, payload)
+			return nil
+		})
+		return v
+	}
+	leader, follower := newInstance(true), newInstance(false)
+
+	require.NoError(t, follower.ReadRemoteConfig())
+	assert.Equal(t, 0, factory.gets)
+	assert.Nil(t, follower.Get("newkey"))
+
+	require.NoError(t, leader.ReadRemoteConfig())
+	assert.Equal(t, 1, factory.gets)
+	assert.Equal(t, "remote", leader.Get("newkey"))
+	require.Len(t, broadcast, 1)
+
+	require.NoError(t, follower.ReceiveRemoteConfig(broadcast[0]))
+	assert.Equal(t, "remote", follower.Get("newkey"))
+	assert.Equal(t, 1, factory.gets)
+}
+
+func TestWatchRemoteConfigOnChannel(t *testing.T) {
+	factory := &testRemoteConfigFactory{responses: make(chan *RemoteResponse)}
+	registerTestProvider(t, "test-watch", factory)
+
+	v := New()
+	v.SetConfigType("json")
+	require.NoError(t, v.AddRemoteProvider("test-watch", "localhost", "/config"))
+	read := make(chan []byte, 3)
+	v.SetRemoteLeader(func() bool { return true }, func(payload []byte) error {
+		read <- payload
+		return nil
+	})
+	require.NoError(t, v.WatchRemoteConfigOnChannel())
+
+	factory.responses <- &RemoteResponse{Value: remoteExample}
+	// errors are logged and keep the configuration
+	factory.responses <- &RemoteResponse{Error: errors.New("connection lost")}
+	factory.responses <- &RemoteResponse{Value: []byte(`{"newkey": "again"}`)}
+	// the watch ends with the channel
+	close(factory.responses)
+
+	assert.Equal(t, remoteExample, <-read)
+	assert.Equal(t, []byte(`{"newkey": "again"}`), <-read)
+	assert.Equal(t, "again", v.GetString("newkey"))
+	select {
+	case payload := <-read:
+		t.Fatalf("unexpected read of %q", payload)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
 func TestEnv(t *testing.T) {
 	initJSON()
 
@@ -410,6 +524,28 @@ func TestEnv(t *testing.T) {
 
 }
 
+func TestBindEnvs(t *testing.T) {
+	initJSON()
+
+	SetEnvPrefix("bulk")
+	err := BindEnvs(map[string]string{
+		"db.host": "DATABASE_HOST",
+		"id":      "",
+	})
+	assert.NoError(t, err)
+
+	os.Setenv("DATABASE_HOST", "db.local")
+	os.Setenv("BULK_ID", "42")
+
+	assert.Equal(t, "db.local", Get("db.host"))
+	assert.Equal(t, "42", Get("id"))
+
+	err = BindEnvs(map[string]string{"": "ORPHAN", "name": "NAME"})
+	if assert.Error(t, err) {
+		assert.Len(t, err.(MultiError), 1)
+	}
+}
+
 func TestEmptyEnv(t *testing.T) {
 	initJSON()
 
@@ -489,6 +625,31 @@ func TestSetEnvKeyReplacer(t *testing.T) {
 	assert.Equal(t, "30s", Get("refresh-interval"))
 }
 
+func TestSetEnvKeyMapper(t *testing.T) {
+	Reset()
+
+	AutomaticEnv()
+	SetEnvKeyMapper(func(envName string) string {
+		if !strings.HasPrefix(envName, "MAPPED_") {
+			return ""
+		}
+		return strings.ToLower(strings.Replace(strings.TrimPrefix(envName, "MAPPED_"), "__", ".", -1))
+	})
+	t.Setenv("MAPPED_DB__HOST", "localhost")
+	t.Setenv("MAPPED_LOG_LEVEL", "debug")
+	t.Setenv("UNMAPPED_VALUE", "ignored")
+
+	assert.Equal(t, "localhost", Get("db.host"))
+	assert.Equal(t, "debug", Get("log_level"))
+	assert.Nil(t, Get("unmapped_value"))
+	assert.Contains(t, AllKeys(), "db.host")
+	assert.NotContains(t, AllKeys(), "unmapped_value")
+
+	// variables added to the environment are mapped right away
+	t.Setenv("MAPPED_LOG_FORMAT", "json")
+	assert.Equal(t, "json", Get("log_format"))
+}
+
 func TestAllKeys(t *testing.T) {
 	initConfigs()
 
@@ -748,6 +909,26 @@ func TestBindPFlagsIntSlice(t *testing.T) {
 	}
 }
 
+func TestBindPFlagsTypedValues(t *testing.T) {
+	v := New() // create independent Viper object
+	flagSet := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flagSet.IPSlice("ips", nil, "test")
+	flagSet.CountP("verbose", "v", "test")
+	flagSet.BytesHex("key", nil, "test")
+	flagSet.UintSlice("ports", nil, "test")
+	flagSet.Duration("timeout", time.Second, "test")
+
+	err := flagSet.Parse([]string{"--ips=10.0.0.1,10.0.0.2", "-vvv", "--key=CAFE", "--ports=80,443"})
+	require.NoError(t, err)
+	require.NoError(t, v.BindPFlags(flagSet))
+
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, v.Get("ips"))
+	assert.Equal(t, 3, v.Get("verbose"))
+	assert.Equal(t, []byte{0xca, 0xfe}, v.GetBytes("key"))
+	assert.Equal(t, []int{80, 443}, v.GetIntSlice("ports"))
+	assert.Equal(t, time.Second, v.Get("timeout"))
+}
+
 func TestBindPFlag(t *testing.T) {
 	var testString = "testing"
 	var testValue = newStringValue(testString, &testString)
@@ -1022,6 +1203,63 @@ func TestSub(t *testing.T) {
 	assert.Equal(t, (*Viper)(nil), subv)
 }
 
+func TestNilViper(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	v.ReadConfig(bytes.NewBuffer(yamlExample))
+
+	subv := v.Sub("missing").Sub("key")
+	assert.Nil(t, subv)
+	assert.Nil(t, subv.Get("size"))
+	assert.Equal(t, "", subv.GetString("size"))
+	assert.Equal(t, 0, subv.GetInt("size"))
+	assert.Nil(t, subv.GetStringSlice("size"))
+	assert.False(t, subv.IsSet("size"))
+	assert.False(t, subv.InConfig("size"))
+	assert.Empty(t, subv.AllKeys())
+	assert.Empty(t, subv.AllSettings())
+
+	var c struct{ Size string }
+	assert.NoError(t, subv.Unmarshal(&c))
+	assert.NoError(t, subv.UnmarshalKey("pants", &c))
+	assert.NoError(t, subv.UnmarshalExact(&c))
+	assert.Equal(t, "", c.Size)
+
+	var r ConfigReader = subv
+	assert.Equal(t, time.Duration(0), r.GetDuration("timeout"))
+
+	_, err := subv.GetIntE("Size")
+	assert.Equal(t, KeyNotSetError("size"), err)
+	assert.Equal(t, JitteredDuration{}, subv.GetDurationJitter("timeout"))
+	assert.Equal(t, Rate{}, subv.GetRate("limit"))
+	_, err = subv.GetWeights("split")
+	assert.Equal(t, KeyNotSetError("split"), err)
+	_, err = subv.GetCron("schedule")
+	assert.Equal(t, KeyNotSetError("schedule"), err)
+	_, err = subv.GetHostPort("addr")
+	assert.Equal(t, KeyNotSetError("addr"), err)
+	_, err = subv.GetDSN("db")
+	assert.IsType(t, DSNError{}, err)
+
+	src, detail := subv.Origin("size")
+	assert.Equal(t, SourceNone, src)
+	assert.Equal(t, "", detail)
+	view := subv.SubView("pants")
+	assert.Nil(t, view.Get("size"))
+	assert.Empty(t, view.AllSettings())
+
+	val, err := subv.GetOrSet("size", func() (interface{}, error) { return "large", nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "large", val)
+
+	assert.Equal(t, uint64(0), subv.Generation())
+	snap := subv.Pin()
+	assert.Equal(t, uint64(0), snap.Generation())
+	assert.Nil(t, snap.Get("size"))
+	assert.False(t, subv.IsSecret("password"))
+	assert.Empty(t, subv.AllSettingsRedacted())
+}
+
 var hclWriteExpected = []byte(`"foos" = {
   "foo" = {
     "key" = 1
@@ -1243,6 +1481,37 @@ func TestWriteConfigYAML(t *testing.T) {
 	assert.Equal(t, yamlWriteExpected, read)
 }
 
+func TestRenderConfig(t *testing.T) {
+	v := New()
+	fs := afero.NewMemMapFs()
+	v.SetFs(fs)
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBuffer(yamlExample)))
+
+	b, err := v.RenderConfig("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, yamlWriteExpected, b)
+	_, err = v.RenderConfig("doc")
+	assert.Equal(t, UnsupportedConfigError("doc"), err)
+
+	// the config file is patched, like by WriteConfig
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("# service\nname: api # the name\nport: 80\n"), 0644))
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.Set("port", 8080)
+	b, err = v.RenderConfig("yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "# service\nname: api # the name\nport: 8080\n", string(b))
+	b, err = v.RenderConfig("json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "api", "port": 8080}`, string(b))
+
+	require.NoError(t, v.WriteConfig())
+	written, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "# service\nname: api # the name\nport: 8080\n", string(written))
+}
+
 var yamlMergeExampleTgt = []byte(`
 hello:
     pop: 37890
@@ -1426,6 +1695,60 @@ func TestMergeConfigMap(t *testing.T) {
 
 }
 
+func TestOnLoadTransform(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	v.OnLoadTransform(func(m map[string]interface{}) error {
+		// migrate an old key name
+		if val, ok := m["colour"]; ok {
+			delete(m, "colour")
+			m["color"] = val
+		}
+		return nil
+	})
+	v.OnLoadTransform(func(m map[string]interface{}) error {
+		for k, val := range m {
+			if s, ok := val.(string); ok {
+				m[k] = strings.TrimSpace(s)
+			}
+		}
+		return nil
+	})
+
+	require.NoError(t, v.ReadConfig(strings.NewReader("colour: \" red \"\nname: steve\n")))
+	assert.Equal(t, "red", v.Get("color"))
+	assert.Nil(t, v.Get("colour"))
+
+	require.NoError(t, v.MergeConfigMap(map[string]interface{}{"Colour": "blue "}))
+	assert.Equal(t, "blue", v.Get("color"))
+
+	v.OnLoadTransform(func(m map[string]interface{}) error {
+		return fmt.Errorf("rejected")
+	})
+	assert.Error(t, v.ReadConfig(strings.NewReader("name: bob\n")))
+	assert.Equal(t, "steve", v.Get("name"))
+}
+
+func TestOnLoadTransformRemote(t *testing.T) {
+	v := New()
+	v.SetConfigType("json")
+	reject := false
+	v.OnLoadTransform(func(m map[string]interface{}) error {
+		if reject {
+			return fmt.Errorf("rejected")
+		}
+		return nil
+	})
+	require.NoError(t, v.ReceiveRemoteConfig([]byte(`{"name": "api"}`)))
+
+	// neither a bad payload nor a rejected one replace the previous values
+	assert.Error(t, v.ReceiveRemoteConfig([]byte(`{"name": `)))
+	assert.Equal(t, "api", v.Get("name"))
+	reject = true
+	assert.Error(t, v.ReceiveRemoteConfig([]byte(`{"name": "web"}`)))
+	assert.Equal(t, "api", v.Get("name"))
+}
+
 func TestUnmarshalingWithAliases(t *testing.T) {
 	v := New()
 	v.SetDefault("ID", 1)
@@ -0,0 +1,22 @@
+//go:build viper_prometheus
+// +build viper_prometheus
+
+package viperprom
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var _ prometheus.Collector = (*Exporter)(nil)
+
+// Describe implements prometheus.Collector. It describes no metrics, making
+// the Exporter an unchecked collector, as gauges may be exported after it is
+// registered.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector, collecting the gauges like a
+// scrape of ServeHTTP.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range e.samples() {
+		desc := prometheus.NewDesc(s.name, s.help, nil, nil)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, s.value)
+	}
+}
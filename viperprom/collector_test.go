@@ -0,0 +1,38 @@
+//go:build viper_prometheus
+// +build viper_prometheus
+
+package viperprom
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporterCollector(t *testing.T) {
+	v := viper.New()
+	v.Set("workers", 8)
+	v.Set("token", 42)
+	v.MarkSecret("token")
+	e := NewExporter(v, "myapp")
+	require.NoError(t, e.Export("token", ""))
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(e))
+	require.NoError(t, reg.Register(prometheus.NewCounter(prometheus.CounterOpts{Name: "myapp_requests_total", Help: "Requests."})))
+	// gauges may be exported once registered
+	require.NoError(t, e.Export("workers", "Number of workers."))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, mf := range families {
+		if g := mf.GetMetric()[0].GetGauge(); g != nil {
+			values[mf.GetName()] = g.GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"myapp_workers": 8}, values)
+}
@@ -0,0 +1,204 @@
+// Package viperprom exports selected configuration values of a Viper
+// instance as Prometheus gauges, e.g. to show the effective limits and
+// timeouts of every instance on a dashboard:
+//
+//	e := viperprom.NewExporter(viper.GetViper(), "myapp")
+//	e.Export("server.max_connections", "Maximum number of client connections.")
+//	e.ExportAs("server.timeout", "myapp_server_timeout_seconds", "Request timeout.")
+//	http.Handle("/metrics", e)
+//
+// Values are read on every scrape, so gauges follow Set calls, reloads of
+// WatchConfig and remote updates as soon as they happen. Numbers and numeric
+// strings are exported as is, booleans as 0 or 1 and durations in seconds.
+// Keys without a value, with a value which isn't numeric, or marked as
+// secrets through viper.MarkSecret are left out of the scrape.
+//
+// Built with the viper_prometheus tag, an Exporter is a
+// prometheus.Collector of the Prometheus client library, to be registered
+// with the registry of an app serving other metrics:
+//
+//	prometheus.MustRegister(e)
+//
+// Otherwise the package doesn't depend on the client library: the exposition
+// is written in the Prometheus text format, and can be appended to other
+// output with WriteTo.
+package viperprom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// ContentType is the content type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Exporter exports configuration values as gauges. It is an http.Handler
+// serving the gauges to Prometheus, and is safe for concurrent use.
+type Exporter struct {
+	v         *viper.Viper
+	namespace string
+
+	mu     sync.Mutex
+	gauges map[string]gauge
+}
+
+type gauge struct {
+	key, help string
+}
+
+// NewExporter returns an exporter of values of v. namespace, if not empty,
+// prefixes the names of the gauges derived from keys by Export.
+func NewExporter(v *viper.Viper, namespace string) *Exporter {
+	return &Exporter{v: v, namespace: namespace, gauges: map[string]gauge{}}
+}
+
+// Export exports the value of key as a gauge named after the namespace and
+// the key, e.g. "myapp_server_max_connections" for "server.max_connections".
+func (e *Exporter) Export(key, help string) error {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(key))
+	if e.namespace != "" {
+		name = e.namespace + "_" + name
+	}
+	return e.ExportAs(key, name, help)
+}
+
+// ExportAs exports the value of key as a gauge named name, replacing any
+// gauge of that name exported before.
+func (e *Exporter) ExportAs(key, name, help string) error {
+	if key == "" {
+		return fmt.Errorf("can't export an empty key")
+	}
+	if !metricName.MatchString(name) {
+		return fmt.Errorf("invalid metric name %q for key %q", name, key)
+	}
+	e.mu.Lock()
+	e.gauges[name] = gauge{key: key, help: help}
+	e.mu.Unlock()
+	return nil
+}
+
+// Gauges returns the current value of every gauge with a numeric value, by
+// name.
+func (e *Exporter) Gauges() map[string]float64 {
+	samples := e.samples()
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.name] = s.value
+	}
+	return values
+}
+
+// WriteTo writes the gauges in the Prometheus text format, ordered by name.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, s := range e.samples() {
+		if s.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", s.name, escapeHelp(s.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", s.name)
+		fmt.Fprintf(bw, "%s %s\n", s.name, strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP serves the gauges in the Prometheus text format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	e.WriteTo(w)
+}
+
+// sample is the value of a gauge at a scrape.
+type sample struct {
+	gauge
+	name  string
+	value float64
+}
+
+// samples returns the gauges whose key has a numeric value and isn't a
+// secret, ordered by name.
+func (e *Exporter) samples() []sample {
+	e.mu.Lock()
+	samples := make([]sample, 0, len(e.gauges))
+	for name, g := range e.gauges {
+		samples = append(samples, sample{gauge: g, name: name})
+	}
+	e.mu.Unlock()
+	sort.Slice(samples, func(i, j int) bool { return samples[i].name < samples[j].name })
+
+	n := 0
+	for _, s := range samples {
+		if e.v.IsSecret(s.key) {
+			continue
+		}
+		if f, ok := gaugeValue(e.v.Get(s.key)); ok {
+			s.value = f
+			samples[n] = s
+			n++
+		}
+	}
+	return samples[:n]
+}
+
+// gaugeValue converts a configuration value to the value of a gauge.
+func gaugeValue(val interface{}) (float64, bool) {
+	switch val := val.(type) {
+	case nil:
+		return 0, false
+	case bool:
+		if val {
+			return 1, true
+		}
+		return 0, true
+	case time.Duration:
+		return val.Seconds(), true
+	case string:
+		s := strings.TrimSpace(val)
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+		if b, err := strconv.ParseBool(s); err == nil {
+			return gaugeValue(b)
+		}
+		if d, err := time.ParseDuration(s); err == nil {
+			return d.Seconds(), true
+		}
+		return 0, false
+	}
+	f, err := cast.ToFloat64E(val)
+	return f, err == nil
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package viperprom
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporter(t *testing.T) {
+	v := viper.New()
+	v.Set("server.max_connections", 100)
+	v.Set("server.timeout", "1m30s")
+	v.Set("server.tls", true)
+	v.Set("server.ratio", "0.25")
+	v.Set("server.name", "api")
+
+	e := NewExporter(v, "myapp")
+	require.NoError(t, e.Export("server.max_connections", "Maximum number of client connections."))
+	require.NoError(t, e.ExportAs("server.timeout", "myapp_server_timeout_seconds", "Request timeout,\nin seconds."))
+	require.NoError(t, e.Export("server.tls", ""))
+	require.NoError(t, e.Export("server.ratio", ""))
+	require.NoError(t, e.Export("server.name", "Not numeric."))
+	require.NoError(t, e.Export("server.missing", "Not set."))
+	assert.Error(t, e.ExportAs("server.tls", "0tls", ""))
+	assert.Error(t, e.Export("", ""))
+
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	assert.Equal(t, `# HELP myapp_server_max_connections Maximum number of client connections.
+# TYPE myapp_server_max_connections gauge
+myapp_server_max_connections 100
+# TYPE myapp_server_ratio gauge
+myapp_server_ratio 0.25
+# HELP myapp_server_timeout_seconds Request timeout,\nin seconds.
+# TYPE myapp_server_timeout_seconds gauge
+myapp_server_timeout_seconds 90
+# TYPE myapp_server_tls gauge
+myapp_server_tls 1
+`, buf.String())
+
+	// gauges follow the configuration
+	v.Set("server.max_connections", 50)
+	v.Set("server.timeout", 2*time.Second)
+	v.Set("server.tls", false)
+	assert.Equal(t, map[string]float64{
+		"myapp_server_max_connections": 50,
+		"myapp_server_ratio":           0.25,
+		"myapp_server_timeout_seconds": 2,
+		"myapp_server_tls":             0,
+	}, e.Gauges())
+}
+
+func TestExporterSecrets(t *testing.T) {
+	v := viper.New()
+	v.Set("db.pool_size", 10)
+	v.Set("db.pin", 1234)
+	v.MarkSecret("db.pin")
+
+	e := NewExporter(v, "")
+	require.NoError(t, e.Export("db.pool_size", ""))
+	require.NoError(t, e.Export("db.pin", ""))
+	assert.Equal(t, map[string]float64{"db_pool_size": 10}, e.Gauges())
+	var buf bytes.Buffer
+	_, err := e.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "1234")
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	v := viper.New()
+	v.Set("workers", 8)
+	e := NewExporter(v, "")
+	require.NoError(t, e.Export("workers", ""))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "# TYPE workers gauge\nworkers 8\n", rec.Body.String())
+}
@@ -0,0 +1,87 @@
+package viper
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/cast"
+)
+
+// WeightsError denotes a subtree which isn't a valid set of weights.
+type WeightsError struct {
+	Key string
+	err error
+}
+
+// Error returns the formatted weights error.
+func (e WeightsError) Error() string {
+	return fmt.Sprintf("invalid weights %q: %s", e.Key, e.err.Error())
+}
+
+// A WeightsOption configures GetWeights.
+type WeightsOption func(*weightsConfig)
+
+type weightsConfig struct {
+	sum float64
+}
+
+// WeightsSumTo makes GetWeights require the weights to add up to total,
+// typically 1 or 100, so that a split where a share was forgotten, or
+// counted twice, is reported rather than silently scaled.
+func WeightsSumTo(total float64) WeightsOption {
+	return func(c *weightsConfig) {
+		c.sum = total
+	}
+}
+
+// GetWeights returns the subtree at key as weights normalized to add up to
+// 1, e.g. to split traffic between releases:
+//
+//	traffic:
+//	  stable: 90
+//	  canary: 10
+//
+// returns map[stable:0.9 canary:0.1]. Weights must be numbers and can't be
+// negative, nor all be 0. GetWeights returns a KeyNotSetError if the key has
+// no value and a WeightsError if the weights are invalid.
+func GetWeights(key string, opts ...WeightsOption) (map[string]float64, error) {
+	return v.GetWeights(key, opts...)
+}
+func (v *Viper) GetWeights(key string, opts ...WeightsOption) (map[string]float64, error) {
+	val, err := v.getE(key)
+	if err != nil {
+		return nil, err
+	}
+	c := weightsConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	m, err := cast.ToStringMapE(val)
+	if err != nil {
+		return nil, WeightsError{Key: key, err: err}
+	}
+
+	weights := make(map[string]float64, len(m))
+	sum := 0.0
+	for _, name := range sortedMapKeys(m) {
+		w, err := cast.ToFloat64E(m[name])
+		if err != nil {
+			return nil, WeightsError{Key: key, err: fmt.Errorf("weight of %q isn't a number: %v", name, m[name])}
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, WeightsError{Key: key, err: fmt.Errorf("invalid weight %v for %q", w, name)}
+		}
+		weights[name] = w
+		sum += w
+	}
+	if sum == 0 {
+		return nil, WeightsError{Key: key, err: fmt.Errorf("weights add up to 0")}
+	}
+	if c.sum != 0 && math.Abs(sum-c.sum) > 1e-9*c.sum {
+		return nil, WeightsError{Key: key, err: fmt.Errorf("weights add up to %v instead of %v", sum, c.sum)}
+	}
+	for name, w := range weights {
+		weights[name] = w / sum
+	}
+	return weights, nil
+}
@@ -0,0 +1,62 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWeights(t *testing.T) {
+	v := New()
+	v.SetConfigType("yaml")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString(`
+traffic:
+  stable: 90
+  canary: 10
+shadow:
+  a: 0.25
+  b: 0.5
+  c: "0.25"
+  d: 0
+`)))
+
+	weights, err := v.GetWeights("traffic")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"stable": 0.9, "canary": 0.1}, weights)
+
+	weights, err = v.GetWeights("traffic", WeightsSumTo(100))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"stable": 0.9, "canary": 0.1}, weights)
+
+	weights, err = v.GetWeights("shadow", WeightsSumTo(1))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"a": 0.25, "b": 0.5, "c": 0.25, "d": 0}, weights)
+
+	_, err = v.GetWeights("traffic", WeightsSumTo(1))
+	assert.EqualError(t, err, `invalid weights "traffic": weights add up to 100 instead of 1`)
+
+	v.Set("traffic", map[string]interface{}{"stable": 90, "canary": 20})
+	_, err = v.GetWeights("traffic", WeightsSumTo(100))
+	assert.EqualError(t, err, `invalid weights "traffic": weights add up to 110 instead of 100`)
+
+	v.Set("traffic", map[string]interface{}{"stable": 90, "canary": -10})
+	_, err = v.GetWeights("traffic")
+	assert.EqualError(t, err, `invalid weights "traffic": invalid weight -10 for "canary"`)
+
+	v.Set("traffic", map[string]interface{}{"stable": 90, "canary": "most"})
+	_, err = v.GetWeights("traffic")
+	assert.EqualError(t, err, `invalid weights "traffic": weight of "canary" isn't a number: most`)
+
+	v.Set("traffic", map[string]interface{}{"stable": 0, "canary": 0})
+	_, err = v.GetWeights("traffic")
+	assert.EqualError(t, err, `invalid weights "traffic": weights add up to 0`)
+
+	v.Set("traffic", "stable")
+	_, err = v.GetWeights("traffic")
+	assert.IsType(t, WeightsError{}, err)
+
+	_, err = v.GetWeights("missing")
+	assert.Equal(t, KeyNotSetError("missing"), err)
+}
@@ -0,0 +1,26 @@
+package viper
+
+// WriteOrder is the order of the keys written by WriteConfig, see
+// SetWriteOrder.
+type WriteOrder int
+
+const (
+	// WriteOrderSource keeps the order, comments and layout of the config
+	// file read when it's written back, for the formats supporting it:
+	// YAML, TOML and properties (new keys are added at the end of their
+	// section). Other files are written with their keys sorted.
+	WriteOrderSource WriteOrder = iota
+	// WriteOrderSorted always writes the keys sorted, so that the output
+	// only depends on the settings, never on the file read.
+	WriteOrderSorted
+)
+
+// SetWriteOrder sets the order of the keys written by WriteConfig,
+// SafeWriteConfig, WriteConfigAs, SafeWriteConfigAs and RenderConfig,
+// WriteOrderSource by default. Both orders are deterministic, so that
+// generated config files produce clean diffs; the output of the formats
+// registered through RegisterConfigType is up to their codec.
+func SetWriteOrder(order WriteOrder) { v.SetWriteOrder(order) }
+func (v *Viper) SetWriteOrder(order WriteOrder) {
+	v.writeOrder = order
+}
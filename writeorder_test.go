@@ -0,0 +1,68 @@
+package viper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOrder(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/etc/app/config.yaml", []byte("# service\nname: app\nport: 8080 # listener\ndb:\n  user: app\n  host: db\n"), 0644))
+
+	v := New()
+	v.SetFs(fs)
+	v.SetConfigFile("/etc/app/config.yaml")
+	require.NoError(t, v.ReadInConfig())
+	v.Set("db.port", 5432)
+
+	require.NoError(t, v.WriteConfig())
+	out, err := afero.ReadFile(fs, "/etc/app/config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "# service\nname: app\nport: 8080 # listener\ndb:\n  user: app\n  host: db\n  port: 5432\n", string(out))
+
+	v.SetWriteOrder(WriteOrderSorted)
+	require.NoError(t, v.WriteConfig())
+	out, err = afero.ReadFile(fs, "/etc/app/config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "db:\n  host: db\n  port: 5432\n  user: app\nname: app\nport: 8080\n", string(out))
+}
+
+func TestWriteOrderDeterministic(t *testing.T) {
+	v := New()
+	for _, key := range []string{"zeta", "alpha", "mid.b", "mid.a", "omega", "beta"} {
+		v.Set(key, key)
+	}
+
+	expected := map[string]string{
+		"env":        "ALPHA=alpha\nBETA=beta\nMID_A=mid.a\nMID_B=mid.b\nOMEGA=omega\nZETA=zeta",
+		"properties": "alpha = alpha\nbeta = beta\nmid.a = mid.a\nmid.b = mid.b\nomega = omega\nzeta = zeta\n",
+		"toml":       "alpha = \"alpha\"\nbeta = \"beta\"\nomega = \"omega\"\nzeta = \"zeta\"\n\n[mid]\n  a = \"mid.a\"\n  b = \"mid.b\"\n",
+	}
+	for format, want := range expected {
+		for i := 0; i < 10; i++ {
+			out, err := v.RenderConfig(format)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(out), format)
+		}
+	}
+}
+
+func TestWriteOrderProperties(t *testing.T) {
+	v := New()
+	v.SetConfigType("properties")
+	require.NoError(t, v.ReadConfig(bytes.NewBufferString("#app\nname = app\nhost = localhost\n")))
+	v.Set("debug", true)
+
+	out, err := v.RenderConfig("properties")
+	require.NoError(t, err)
+	assert.Equal(t, "#app\nname = app\nhost = localhost\ndebug = true\n", string(out))
+
+	v.SetWriteOrder(WriteOrderSorted)
+	out, err = v.RenderConfig("properties")
+	require.NoError(t, err)
+	assert.Equal(t, "debug = true\nhost = localhost\nname = app\n", string(out))
+}